@@ -0,0 +1,58 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/impr0ver/gophKeeper/internal/adminconfig"
+	"github.com/impr0ver/gophKeeper/internal/adminwork"
+	"github.com/impr0ver/gophKeeper/internal/handlers"
+	"github.com/impr0ver/gophKeeper/internal/logger"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	buildVersion = "N/A"
+	buildDate    = "N/A"
+	buildCommit  = "N/A"
+)
+
+func main() {
+	cfg := adminconfig.NewAdminConfig()
+
+	logger.NewLogrusLogger()
+
+	buildInfo()
+
+	if len(cfg.Args) == 0 {
+		fmt.Fprint(os.Stderr, adminwork.Usage)
+		os.Exit(2)
+	}
+
+	conn := handlers.NewAdminConnection(cfg.ServerAddress, cfg.ClientCert, cfg.AdminToken, cfg.LegacyLogin)
+	admin := adminwork.NewAdmin(conn, os.Stdout)
+
+	if cfg.Login != "" {
+		if err := admin.Login(cfg.Login, cfg.Password); err != nil {
+			log.Infoln(err)
+			fmt.Fprintln(os.Stderr, "login error:", err)
+			os.Exit(1)
+		}
+	}
+
+	if err := admin.Run(cfg.Args); err != nil {
+		log.Infoln(err)
+		fmt.Fprintln(os.Stderr, "error:", err)
+		if errors.Is(err, adminwork.ErrUnknownCommand) || errors.Is(err, adminwork.ErrWrongArgs) {
+			fmt.Fprint(os.Stderr, adminwork.Usage)
+		}
+		os.Exit(1)
+	}
+}
+
+func buildInfo() {
+	log.Infof("Build admin version: %s", buildVersion)
+	log.Infof("Build admin date: %s", buildDate)
+	log.Infof("Build admin commit: %s", buildCommit)
+}
@@ -1,10 +1,14 @@
 package main
 
 import (
+	"fmt"
+	"os"
+
 	"github.com/impr0ver/gophKeeper/internal/clientconfig"
 	"github.com/impr0ver/gophKeeper/internal/clientwork"
 	"github.com/impr0ver/gophKeeper/internal/handlers"
 	"github.com/impr0ver/gophKeeper/internal/logger"
+	"github.com/impr0ver/gophKeeper/internal/userdata"
 	log "github.com/sirupsen/logrus"
 
 	_ "github.com/jackc/pgx/v5/stdlib"
@@ -23,10 +27,53 @@ func main() {
 	
 	buildInfo()
 	
-	conn := handlers.NewClientConnection(cfg.ServerAddress, cfg.ClientCert)
+	conn := handlers.NewClientConnection(cfg.ServerAddress, cfg.ClientCert, cfg.LegacyLogin)
 	handlers := handlers.NewClientHandlers(conn)
 
-	termUserInterface := clientwork.NewTUI(handlers, cfg.MaxFileSize)
+	// Export or import of vault archive runs without TUI
+	if cfg.Export != "" || cfg.Import != "" {
+		for _, secret := range []struct {
+			name  string
+			value *string
+		}{
+			{"Password", &cfg.Password},
+			{"AES key", &cfg.AESKey},
+			{"Vault password", &cfg.VaultPassword},
+		} {
+			if *secret.value != "" {
+				continue
+			}
+			value, err := clientwork.ReadSecret(secret.name, os.Stdin, os.Stderr)
+			if err != nil {
+				log.Infoln(err)
+				fmt.Fprintln(os.Stderr, "error:", err)
+				os.Exit(1)
+			}
+			*secret.value = value
+		}
+
+		credentials := userdata.UserCredentials{
+			Login:    cfg.Login,
+			Password: cfg.Password,
+			AESKey:   cfg.AESKey,
+		}
+
+		var err error
+		if cfg.Export != "" {
+			err = clientwork.ExportVault(handlers, credentials, cfg.Export, cfg.VaultPassword, os.Stdout)
+		} else {
+			err = clientwork.ImportVault(handlers, credentials, cfg.Import, cfg.VaultPassword, os.Stdout)
+		}
+		if err != nil {
+			log.Infoln(err)
+			fmt.Fprintln(os.Stderr, "error:", err)
+			os.Exit(1)
+		}
+
+		return
+	}
+
+	termUserInterface := clientwork.NewTUI(handlers, cfg.MaxFileSize, cfg.LegacyLogin)
 
 	//TUI close app via Ctrl+C (via method "app.Stop()" in lib "tview" is not work properly)
 	err := termUserInterface.Run()
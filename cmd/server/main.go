@@ -2,11 +2,18 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
+	"github.com/impr0ver/gophKeeper/internal/certgen"
 	"github.com/impr0ver/gophKeeper/internal/handlers"
+	"github.com/impr0ver/gophKeeper/internal/jwtauth"
 	"github.com/impr0ver/gophKeeper/internal/logger"
 	"github.com/impr0ver/gophKeeper/internal/serverconfig"
 	"github.com/impr0ver/gophKeeper/internal/storage"
@@ -15,6 +22,14 @@ import (
 	_ "github.com/jackc/pgx/v5/stdlib"
 )
 
+// Exit codes of fsck command, like in fsck(8).
+const (
+	fsckConsistent = 0
+	fsckFixed      = 1
+	fsckFound      = 4
+	fsckError      = 8
+)
+
 var (
 	buildVersion = "N/A"
 	buildDate    = "N/A"
@@ -22,29 +37,93 @@ var (
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "cert" {
+		certCommand(os.Args[2:])
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "fsck" {
+		os.Exit(fsckCommand(os.Args[2:]))
+	}
+
 	cfg := serverconfig.NewServerConfig()
 
+	if cfg.PrintConfig {
+		out, err := cfg.Print()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "print config error: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Print(out)
+		return
+	}
+
 	logger.NewLogrusLogger()
 	sLogger := logger.NewSugarLogger()
 
+	if err := logger.SetLogLevel(cfg.LogLevel); err != nil {
+		sLogger.Warnf("wrong log level %q: %v", cfg.LogLevel, err)
+	}
+
 	buildInfo()
 
-	dataBase := storage.NewDBStorage(cfg.DatabaseDSN, cfg.MigrationsURL)
+	dataBase, err := storage.OpenDBStorage(cfg.Storage, cfg.DatabaseDSN, cfg.MigrationsURL)
+	if err != nil {
+		log.Fatalf("DB storage error: %v", err)
+	}
 	dataBase.MigrateUP()
-	files := storage.NewFileStorage(cfg.FilesStore)
+	files, err := newFileStorage(cfg)
+	if err != nil {
+		log.Fatalf("file storage error: %v", err)
+	}
 
 	stor := storage.NewStorage(dataBase, files)
+	stor.Quota = cfg.UserQuota
 
-	jwtAuth := handlers.NewAuthenticatorJWT([]byte(cfg.JWTAuth.SecretJWT), cfg.JWTAuth.ExpirationTime)
-	h := handlers.NewServerHandlers(stor, jwtAuth)
+	passwords, err := cfg.PasswordHash.Params()
+	if err != nil {
+		log.Fatalf("password hash error: %v", err)
+	}
+
+	jwtAuth := jwtauth.NewAuthenticatorJWT([]byte(cfg.JWTAuth.SecretJWT), cfg.JWTAuth.ExpirationTime, dataBase)
+	h := handlers.NewServerHandlers(stor, jwtAuth, passwords, cfg.LegacyLogin)
 	server := handlers.NewServerConn(h, jwtAuth, cfg.ServerCert, cfg.ServerKey, cfg.ServerConsoleLog)
+	scrubber := storage.NewScrubber(dataBase, files)
+	server.Admin = handlers.NewAdminConn(handlers.NewAdminHandlers(dataBase, scrubber), jwtAuth, cfg.AdminToken)
+	server.Limiter = handlers.NewRateLimiter(cfg.RateLimit.RPS, cfg.RateLimit.Burst)
+	server.Idempotency = handlers.NewIdempotency(dataBase, cfg.IdempotencyWindow)
 
 	ctx, cancel := context.WithCancel(context.Background())
 	server.Start(ctx, cfg.ListenAddr)
 
+	if cfg.Fsck.Interval > 0 {
+		action, err := storage.ParseFsckAction(cfg.Fsck.Action)
+		if err != nil {
+			log.Fatalf("storage check error: %v", err)
+		}
+
+		go storage.NewChecker(dataBase, files, cfg.Fsck.Grace).Run(ctx, cfg.Fsck.Interval, action)
+	}
+
+	if cfg.ScrubInterval > 0 {
+		go scrubber.Run(ctx, cfg.ScrubInterval)
+	}
+
+	sighup := make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+
 	sigint := make(chan os.Signal, 1)
 	signal.Notify(sigint, syscall.SIGTERM, syscall.SIGINT, syscall.SIGQUIT)
-	<-sigint
+
+	for waiting := true; waiting; {
+		select {
+		case <-sighup:
+			cfg = reloadConfig(cfg, server, jwtAuth)
+		case <-sigint:
+			waiting = false
+		}
+	}
 
 	cancel()
 	server.Stop()
@@ -53,6 +132,117 @@ func main() {
 	log.Info("gRPC server is gracefully stop!")
 }
 
+// certCommand generates TLS certificates.
+func certCommand(args []string) {
+	err := certgen.Run(args, os.Stdout)
+	if errors.Is(err, certgen.ErrUnknownCommand) {
+		fmt.Print(certgen.Usage)
+	}
+	if err != nil && !errors.Is(err, flag.ErrHelp) {
+		fmt.Fprintf(os.Stderr, "cert error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// fsckCommand checks consistency of DB and file storage, prints JSON report and returns exit code.
+func fsckCommand(args []string) int {
+	cfg, err := serverconfig.ParseServerConfig("fsck", args)
+	if errors.Is(err, flag.ErrHelp) {
+		return fsckConsistent
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "config error: %v\n", err)
+		return fsckError
+	}
+
+	action, err := storage.ParseFsckAction(cfg.Fsck.Action)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "fsck error: %v\n", err)
+		return fsckError
+	}
+
+	files, err := newFileStorage(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "fsck error: %v\n", err)
+		return fsckError
+	}
+
+	dataBase, err := storage.OpenDBStorage(cfg.Storage, cfg.DatabaseDSN, cfg.MigrationsURL)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "fsck error: %v\n", err)
+		return fsckError
+	}
+
+	result, err := storage.NewChecker(dataBase, files, cfg.Fsck.Grace).Check(context.Background(), action)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "fsck error: %v\n", err)
+		return fsckError
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(result); err != nil {
+		fmt.Fprintf(os.Stderr, "fsck error: %v\n", err)
+		return fsckError
+	}
+
+	switch unfixed := len(result.OrphanFiles) + len(result.StaleStaged) + len(result.MissingFiles) - len(result.Fixed); {
+	case result.Consistent():
+		return fsckConsistent
+	case unfixed == 0:
+		return fsckFixed
+	default:
+		return fsckFound
+	}
+}
+
+// newFileStorage returns file storage in blob store backend selected by config.
+func newFileStorage(cfg serverconfig.ServerConfig) (storage.FileStorager, error) {
+	blobs, err := storage.NewBlobStore(cfg.Blob.Backend, cfg.FilesStore, storage.S3Config{
+		Endpoint:  cfg.Blob.S3.Endpoint,
+		Region:    cfg.Blob.S3.Region,
+		Bucket:    cfg.Blob.S3.Bucket,
+		AccessKey: cfg.Blob.S3.AccessKey,
+		SecretKey: cfg.Blob.S3.SecretKey,
+		Prefix:    cfg.Blob.S3.Prefix,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return storage.NewBlobFileStorage(blobs), nil
+}
+
+// reloadConfig applies settings which are safe to change without restart, others are only reported.
+func reloadConfig(cfg serverconfig.ServerConfig, server *handlers.ServerConn, auth interface {
+	SetExpirationTime(time.Duration)
+}) serverconfig.ServerConfig {
+	newCfg, err := cfg.Reload()
+	if err != nil {
+		log.Errorf("reload config error, old config is kept: %v", err)
+		return cfg
+	}
+
+	if err := logger.SetLogLevel(newCfg.LogLevel); err != nil {
+		log.Errorf("wrong log level %q, old level is kept: %v", newCfg.LogLevel, err)
+		newCfg.LogLevel = cfg.LogLevel
+	}
+
+	server.SetConsoleLog(newCfg.ServerConsoleLog)
+	server.Limiter.SetLimits(newCfg.RateLimit.RPS, newCfg.RateLimit.Burst)
+	server.Idempotency.SetWindow(newCfg.IdempotencyWindow)
+	auth.SetExpirationTime(newCfg.JWTAuth.ExpirationTime)
+
+	if cfg.RestartRequired(newCfg) {
+		log.Warn("config reloaded, but some changed settings need server restart, they are kept until restart")
+	}
+
+	log.Infof("config reloaded: log level %s, console log %t, rate limit %v rps (burst %d), token TTL %s, idempotency window %s",
+		newCfg.LogLevel, newCfg.ServerConsoleLog, newCfg.RateLimit.RPS, newCfg.RateLimit.Burst, newCfg.JWTAuth.ExpirationTime, newCfg.IdempotencyWindow)
+
+	return cfg.Reloaded(newCfg)
+}
+
 func buildInfo() {
 	sLogger := logger.NewSugarLogger()
 	sLogger.Infof("Build server version: %s", buildVersion)
@@ -0,0 +1,73 @@
+package adminconfig
+
+import (
+	"flag"
+	"os"
+	"strconv"
+)
+
+// AdminConfig struct for admin tool config.
+type AdminConfig struct {
+	ServerAddress string
+	ClientCert    string
+	AdminToken    string
+	Login         string
+	Password      string
+	LegacyLogin   bool
+	Args          []string
+}
+
+var (
+	defaultServerAddress = "127.0.0.1:9000"
+	defaultClientCert    = "../../cmd/cert/ca-cert.pem"
+	defaultAdminToken    = ""
+	defaultLogin         = ""
+	defaultPassword      = ""
+	defaultLegacyLogin   = false
+)
+
+// NewAdminConfig gets admin tool config, command and its arguments are in Args.
+func NewAdminConfig() AdminConfig {
+	var cfg AdminConfig
+
+	flag.StringVar(&cfg.ServerAddress, "addr", defaultServerAddress, "Server address and port")
+	flag.StringVar(&cfg.ClientCert, "clientcert", defaultClientCert, "Path to client certificat for TLS")
+	flag.StringVar(&cfg.AdminToken, "token", defaultAdminToken, "Admin token of server")
+	flag.StringVar(&cfg.Login, "login", defaultLogin, "Login of admin-role user (instead of admin token)")
+	flag.StringVar(&cfg.Password, "password", defaultPassword, "Password of admin-role user")
+	flag.BoolVar(&cfg.LegacyLogin, "legacylogin", defaultLegacyLogin, "Send password to server, if SRP login fails")
+
+	flag.Parse()
+
+	if v, ok := os.LookupEnv("SERVER_ADDR"); ok {
+		cfg.ServerAddress = v
+	}
+
+	if v, ok := os.LookupEnv("CLIENT_CERT"); ok {
+		cfg.ClientCert = v
+	}
+
+	if v, ok := os.LookupEnv("ADMIN_TOKEN"); ok {
+		cfg.AdminToken = v
+	}
+
+	if v, ok := os.LookupEnv("ADMIN_LOGIN"); ok {
+		cfg.Login = v
+	}
+
+	if v, ok := os.LookupEnv("ADMIN_PASSWORD"); ok {
+		cfg.Password = v
+	}
+
+	if v, ok := os.LookupEnv("LEGACY_LOGIN"); ok {
+		legacy, err := strconv.ParseBool(v)
+		if err != nil {
+			legacy = defaultLegacyLogin
+		}
+		cfg.LegacyLogin = legacy
+	}
+
+	cfg.Args = flag.Args()
+
+	return cfg
+}
@@ -0,0 +1,25 @@
+package adminconfig
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInitConfig(t *testing.T) {
+	os.Setenv("SERVER_ADDR", "127.0.0.1:9001")
+	os.Setenv("ADMIN_TOKEN", "adminSecret")
+	os.Setenv("ADMIN_LOGIN", "admin")
+	cfgTest := NewAdminConfig()
+
+	assert.Equal(t, "127.0.0.1:9001", cfgTest.ServerAddress, "test #SERVER_ADDR")
+	assert.Equal(t, "adminSecret", cfgTest.AdminToken, "test #AdminToken")
+	assert.Equal(t, "admin", cfgTest.Login, "test #Login")
+	assert.Equal(t, "../../cmd/cert/ca-cert.pem", cfgTest.ClientCert, "test #ClientCert")
+	assert.False(t, cfgTest.LegacyLogin, "test #LegacyLogin")
+
+	os.Unsetenv("SERVER_ADDR")
+	os.Unsetenv("ADMIN_TOKEN")
+	os.Unsetenv("ADMIN_LOGIN")
+}
@@ -0,0 +1,186 @@
+package adminwork
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/impr0ver/gophKeeper/internal/handlers"
+	"github.com/impr0ver/gophKeeper/internal/userdata"
+)
+
+// Usage is help message of admin tool.
+const Usage = `Usage: admin [flags] <command> [arguments]
+
+Commands:
+  users                    list users with records count and usage
+  stats                    show server stats
+  disable <login>          disable user account and reject its tokens
+  enable <login>           enable user account
+  logout <login>           force logout of user (invalidate issued tokens)
+  grant <login>            grant admin role
+  revoke <login>           revoke admin role
+  quota <login> <bytes>    set user storage quota
+  resetquota <login>       reset user storage quota to server default
+`
+
+// Admin tool errors.
+var (
+	ErrUnknownCommand = errors.New("unknown command")
+	ErrWrongArgs      = errors.New("wrong command arguments")
+)
+
+// Admin is a command line tool for server administration.
+type Admin struct {
+	conn handlers.AdminConnection
+	out  io.Writer
+}
+
+// NewAdmin returns admin tool which prints results to out.
+func NewAdmin(conn handlers.AdminConnection, out io.Writer) *Admin {
+	return &Admin{
+		conn: conn,
+		out:  out,
+	}
+}
+
+// Login logins admin-role user, not needed with admin token.
+func (a *Admin) Login(login, password string) error {
+	_, err := a.conn.Login(userdata.UserCredentials{
+		Login:    login,
+		Password: password,
+	})
+
+	return err
+}
+
+// Run executes command with arguments.
+func (a *Admin) Run(args []string) error {
+	if len(args) == 0 {
+		return ErrUnknownCommand
+	}
+
+	command, args := args[0], args[1:]
+
+	switch command {
+	case "users":
+		return a.users()
+	case "stats":
+		return a.stats()
+	case "quota":
+		if len(args) != 2 {
+			return ErrWrongArgs
+		}
+
+		quota, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil || quota < 0 {
+			return ErrWrongArgs
+		}
+
+		return a.done(a.conn.SetUserQuota(args[0], quota))
+	}
+
+	action, ok := userCommands[command]
+	if !ok {
+		return ErrUnknownCommand
+	}
+
+	if len(args) != 1 {
+		return ErrWrongArgs
+	}
+
+	return a.done(action(a.conn, args[0]))
+}
+
+// userCommands are commands with single login argument.
+var userCommands = map[string]func(conn handlers.AdminConnection, login string) error{
+	"disable": func(conn handlers.AdminConnection, login string) error {
+		return conn.SetUserDisabled(login, true)
+	},
+	"enable": func(conn handlers.AdminConnection, login string) error {
+		return conn.SetUserDisabled(login, false)
+	},
+	"logout": func(conn handlers.AdminConnection, login string) error {
+		return conn.LogoutUser(login)
+	},
+	"grant": func(conn handlers.AdminConnection, login string) error {
+		return conn.SetUserAdmin(login, true)
+	},
+	"revoke": func(conn handlers.AdminConnection, login string) error {
+		return conn.SetUserAdmin(login, false)
+	},
+	"resetquota": func(conn handlers.AdminConnection, login string) error {
+		return conn.ResetUserQuota(login)
+	},
+}
+
+// done prints result of command without output.
+func (a *Admin) done(err error) error {
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(a.out, "OK")
+
+	return nil
+}
+
+// users prints users table.
+func (a *Admin) users() error {
+	users, err := a.conn.ListUsers()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(a.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tLOGIN\tADMIN\tDISABLED\tRECORDS\tUSAGE\tQUOTA")
+
+	for _, user := range users {
+		quota := "default"
+		if user.Usage.Quota > 0 {
+			quota = strconv.FormatInt(user.Usage.Quota, 10)
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%t\t%t\t%d\t%d\t%s\n",
+			user.ID, user.Login, user.Admin, user.Disabled, user.Usage.Records, user.Usage.Bytes, quota)
+	}
+
+	return w.Flush()
+}
+
+// stats prints server stats.
+func (a *Admin) stats() error {
+	stats, err := a.conn.GetStats()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(a.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Uptime:\t%s\n", stats.Uptime)
+	fmt.Fprintf(w, "Users:\t%d\n", stats.Users)
+	fmt.Fprintf(w, "Disabled users:\t%d\n", stats.DisabledUsers)
+	fmt.Fprintf(w, "Admins:\t%d\n", stats.Admins)
+	fmt.Fprintf(w, "Records:\t%d\n", stats.Records)
+	fmt.Fprintf(w, "Stored bytes:\t%d\n", stats.StoredBytes)
+	if stats.Scrub.Runs > 0 {
+		fmt.Fprintf(w, "Last scrub:\t%s\n", stats.Scrub.LastRun.Format(time.RFC3339))
+		fmt.Fprintf(w, "Scrubbed records:\t%d\n", stats.Scrub.Verified)
+		fmt.Fprintf(w, "Corrupted records:\t%d\n", stats.Scrub.Corrupted)
+	}
+
+	types := make([]userdata.RecordType, 0, len(stats.RecordsByType))
+	for recordType := range stats.RecordsByType {
+		types = append(types, recordType)
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+
+	for _, recordType := range types {
+		fmt.Fprintf(w, "  %s:\t%d\n", recordType, stats.RecordsByType[recordType])
+	}
+
+	return w.Flush()
+}
@@ -0,0 +1,111 @@
+package adminwork
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/impr0ver/gophKeeper/internal/handlers/mocks"
+	"github.com/impr0ver/gophKeeper/internal/storage"
+	"github.com/impr0ver/gophKeeper/internal/userdata"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdmin_Run(t *testing.T) {
+	conn := mocks.NewAdminConnection(t)
+	out := &bytes.Buffer{}
+	admin := NewAdmin(conn, out)
+
+	tc := []struct {
+		name   string
+		mock   func()
+		args   []string
+		want   error
+		output string
+	}{
+		{
+			"Disable user",
+			func() {
+				conn.On("SetUserDisabled", "bob", true).Return(nil).Once()
+			},
+			[]string{"disable", "bob"},
+			nil,
+			"OK\n",
+		},
+		{
+			"Set quota",
+			func() {
+				conn.On("SetUserQuota", "bob", int64(1024)).Return(nil).Once()
+			},
+			[]string{"quota", "bob", "1024"},
+			nil,
+			"OK\n",
+		},
+		{
+			"Logout unknown user",
+			func() {
+				conn.On("LogoutUser", "nobody").Return(storage.ErrUserNotFound).Once()
+			},
+			[]string{"logout", "nobody"},
+			storage.ErrUserNotFound,
+			"",
+		},
+		{
+			"Wrong quota",
+			func() {},
+			[]string{"quota", "bob", "-1"},
+			ErrWrongArgs,
+			"",
+		},
+		{
+			"Missing login",
+			func() {},
+			[]string{"grant"},
+			ErrWrongArgs,
+			"",
+		},
+		{
+			"Unknown command",
+			func() {},
+			[]string{"drop"},
+			ErrUnknownCommand,
+			"",
+		},
+	}
+
+	for _, test := range tc {
+		t.Log(test.name)
+		out.Reset()
+		test.mock()
+		assert.Equal(t, test.want, admin.Run(test.args))
+		assert.Equal(t, test.output, out.String())
+		conn.AssertExpectations(t)
+	}
+}
+
+func TestAdmin_Tables(t *testing.T) {
+	conn := mocks.NewAdminConnection(t)
+	out := &bytes.Buffer{}
+	admin := NewAdmin(conn, out)
+
+	conn.On("ListUsers").Return([]userdata.UserInfo{
+		{ID: "1", Login: "alice", Admin: true, Usage: userdata.Usage{Records: 2, Bytes: 100, Quota: 1000}},
+	}, nil).Once()
+	assert.NoError(t, admin.Run([]string{"users"}))
+	assert.Contains(t, out.String(), "alice")
+	assert.Contains(t, out.String(), "1000")
+
+	out.Reset()
+	conn.On("GetStats").Return(userdata.ServerStats{
+		Users:         1,
+		Records:       2,
+		RecordsByType: map[userdata.RecordType]int64{userdata.TypeText: 2},
+		Uptime:        time.Minute,
+		Scrub:         userdata.ScrubStats{Runs: 1, LastRun: time.Now(), Verified: 1, Corrupted: 1},
+	}, nil).Once()
+	assert.NoError(t, admin.Run([]string{"stats"}))
+	assert.Contains(t, out.String(), "1m0s")
+	assert.Contains(t, out.String(), "Text:")
+	assert.Contains(t, out.String(), "Corrupted records:  1")
+}
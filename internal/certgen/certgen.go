@@ -0,0 +1,220 @@
+// Package certgen creates CA, server and client certificates for TLS connection of client and server.
+package certgen
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"os"
+	"time"
+)
+
+// Certificate generation errors.
+var (
+	ErrNoHosts  = errors.New("server certificate needs at least one host")
+	ErrWrongPEM = errors.New("wrong PEM data")
+	ErrNotCA    = errors.New("certificate is not a CA")
+	ErrWrongKey = errors.New("unsupported private key type")
+)
+
+// Options of new certificate.
+type Options struct {
+	CommonName   string
+	Organization string
+	// Hosts are DNS names and IP addresses for Subject Alternative Name extension.
+	Hosts    []string
+	Validity time.Duration
+}
+
+// KeyPair is certificate with its private key.
+type KeyPair struct {
+	Cert *x509.Certificate
+	Key  crypto.Signer
+}
+
+// NewCA creates self-signed CA certificate.
+func NewCA(opts Options) (*KeyPair, error) {
+	template := newTemplate(opts)
+	template.IsCA = true
+	template.BasicConstraintsValid = true
+	template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature
+
+	return issue(template, nil)
+}
+
+// IssueServer creates server certificate signed by CA, hosts are put into SAN extension.
+func (ca *KeyPair) IssueServer(opts Options) (*KeyPair, error) {
+	if len(opts.Hosts) == 0 {
+		return nil, ErrNoHosts
+	}
+
+	template := newTemplate(opts)
+	template.KeyUsage = x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+
+	for _, host := range opts.Hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+
+	return issue(template, ca)
+}
+
+// IssueClient creates client certificate signed by CA for mutual TLS.
+func (ca *KeyPair) IssueClient(opts Options) (*KeyPair, error) {
+	template := newTemplate(opts)
+	template.KeyUsage = x509.KeyUsageDigitalSignature
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+
+	return issue(template, ca)
+}
+
+// newTemplate fills common certificate fields.
+func newTemplate(opts Options) *x509.Certificate {
+	now := time.Now()
+
+	return &x509.Certificate{
+		Subject: pkix.Name{
+			CommonName:   opts.CommonName,
+			Organization: []string{opts.Organization},
+		},
+		NotBefore: now.Add(-time.Hour),
+		NotAfter:  now.Add(opts.Validity),
+	}
+}
+
+// issue generates key and signs certificate by parent, nil parent makes self-signed certificate.
+func issue(template *x509.Certificate, parent *KeyPair) (*KeyPair, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+	template.SerialNumber = serial
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	signerCert, signerKey := template, crypto.Signer(key)
+	if parent != nil {
+		if !parent.Cert.IsCA {
+			return nil, ErrNotCA
+		}
+		signerCert, signerKey = parent.Cert, parent.Key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signerCert, key.Public(), signerKey)
+	if err != nil {
+		return nil, err
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+
+	return &KeyPair{Cert: cert, Key: key}, nil
+}
+
+// CertPEM returns certificate in PEM format.
+func (kp *KeyPair) CertPEM() []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: kp.Cert.Raw})
+}
+
+// KeyPEM returns private key in PKCS#8 PEM format.
+func (kp *KeyPair) KeyPEM() ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(kp.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// WriteFiles saves certificate and private key, key file is readable only by owner.
+func (kp *KeyPair) WriteFiles(certFile, keyFile string) error {
+	keyPEM, err := kp.KeyPEM()
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(keyFile, keyPEM, 0600); err != nil {
+		return err
+	}
+
+	return os.WriteFile(certFile, kp.CertPEM(), 0644)
+}
+
+// LoadKeyPair reads certificate and private key from PEM files.
+func LoadKeyPair(certFile, keyFile string) (*KeyPair, error) {
+	certPEM, err := os.ReadFile(certFile)
+	if err != nil {
+		return nil, err
+	}
+
+	keyPEM, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	certBlock, _ := pem.Decode(certPEM)
+	if certBlock == nil || certBlock.Type != "CERTIFICATE" {
+		return nil, ErrWrongPEM
+	}
+
+	cert, err := x509.ParseCertificate(certBlock.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	keyBlock, _ := pem.Decode(keyPEM)
+	if keyBlock == nil {
+		return nil, ErrWrongPEM
+	}
+
+	key, err := parsePrivateKey(keyBlock)
+	if err != nil {
+		return nil, err
+	}
+
+	return &KeyPair{Cert: cert, Key: key}, nil
+}
+
+// parsePrivateKey parses PKCS#8, PKCS#1 and EC private keys, so keys made by openssl can be used too.
+func parsePrivateKey(block *pem.Block) (crypto.Signer, error) {
+	var (
+		key interface{}
+		err error
+	)
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, ErrWrongKey
+	}
+
+	return signer, nil
+}
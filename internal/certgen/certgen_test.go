@@ -0,0 +1,76 @@
+package certgen
+
+import (
+	"bytes"
+	"crypto/x509"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIssueServer(t *testing.T) {
+	ca, err := NewCA(Options{CommonName: "test CA", Validity: time.Hour})
+	assert.NoError(t, err)
+	assert.True(t, ca.Cert.IsCA)
+
+	server, err := ca.IssueServer(Options{CommonName: "localhost", Hosts: []string{"localhost", "127.0.0.1"}, Validity: time.Hour})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"localhost"}, server.Cert.DNSNames)
+	assert.True(t, server.Cert.IPAddresses[0].Equal(net.ParseIP("127.0.0.1")))
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.Cert)
+
+	_, err = server.Cert.Verify(x509.VerifyOptions{DNSName: "127.0.0.1", Roots: roots})
+	assert.NoError(t, err)
+
+	_, err = server.Cert.Verify(x509.VerifyOptions{DNSName: "example.com", Roots: roots})
+	assert.Error(t, err)
+
+	_, err = ca.IssueServer(Options{Validity: time.Hour})
+	assert.Equal(t, ErrNoHosts, err)
+
+	_, err = server.IssueClient(Options{Validity: time.Hour})
+	assert.Equal(t, ErrNotCA, err)
+}
+
+func TestIssueClient(t *testing.T) {
+	ca, err := NewCA(Options{CommonName: "test CA", Validity: time.Hour})
+	assert.NoError(t, err)
+
+	client, err := ca.IssueClient(Options{CommonName: "client", Validity: time.Hour})
+	assert.NoError(t, err)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.Cert)
+
+	_, err = client.Cert.Verify(x509.VerifyOptions{Roots: roots, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}})
+	assert.NoError(t, err)
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	out := &bytes.Buffer{}
+
+	assert.NoError(t, Run([]string{"all", "-dir", dir, "-hosts", "localhost,127.0.0.1", "-days", "30"}, out))
+	assert.NoError(t, Run([]string{"client", "-dir", dir, "-name", "alice"}, out))
+
+	ca, err := LoadKeyPair(filepath.Join(dir, "ca-cert.pem"), filepath.Join(dir, "ca-key.pem"))
+	assert.NoError(t, err)
+
+	server, err := LoadKeyPair(filepath.Join(dir, "server-cert.pem"), filepath.Join(dir, "server-key.pem"))
+	assert.NoError(t, err)
+	assert.Equal(t, "localhost", server.Cert.Subject.CommonName)
+	assert.NoError(t, server.Cert.CheckSignatureFrom(ca.Cert))
+	assert.WithinDuration(t, time.Now().Add(30*24*time.Hour), server.Cert.NotAfter, time.Minute)
+
+	client, err := LoadKeyPair(filepath.Join(dir, "alice-cert.pem"), filepath.Join(dir, "alice-key.pem"))
+	assert.NoError(t, err)
+	assert.Equal(t, "alice", client.Cert.Subject.CommonName)
+
+	assert.Equal(t, ErrUnknownCommand, Run([]string{"root"}, out))
+	assert.Error(t, Run([]string{"server", "-dir", t.TempDir()}, out), "no CA in dir")
+}
@@ -0,0 +1,144 @@
+package certgen
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Usage is help message of cert command.
+const Usage = `Usage: server cert <command> [flags]
+
+Commands:
+  ca        create CA private key and self-signed certificate
+  server    create server certificate signed by CA, hosts are put into SAN
+  client    create client certificate signed by CA for mutual TLS
+  all       create CA and server certificate
+
+Run "server cert <command> -h" to see flags of command.
+`
+
+// ErrUnknownCommand is returned for unknown cert command.
+var ErrUnknownCommand = errors.New("unknown cert command")
+
+const day = 24 * time.Hour
+
+// Run executes cert command with arguments, generated files are reported to out.
+func Run(args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return ErrUnknownCommand
+	}
+
+	command, args := args[0], args[1:]
+
+	fs := flag.NewFlagSet("cert "+command, flag.ContinueOnError)
+	fs.SetOutput(out)
+
+	dir := fs.String("dir", ".", "Directory for generated files")
+	days := fs.Int("days", 365, "Certificate validity in days")
+	org := fs.String("org", "gophKeeper", "Organization name in certificate subject")
+	caCert := fs.String("cacert", "ca-cert.pem", "CA certificate file name in dir")
+	caKey := fs.String("cakey", "ca-key.pem", "CA private key file name in dir")
+
+	var (
+		hosts string
+		name  string
+	)
+
+	switch command {
+	case "ca":
+	case "server", "all":
+		fs.StringVar(&hosts, "hosts", "localhost,127.0.0.1,0.0.0.0", "Comma separated DNS names and IPs of server")
+	case "client":
+		fs.StringVar(&name, "name", "client", "Client name, used as common name and files prefix")
+	default:
+		return ErrUnknownCommand
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	opts := Options{Organization: *org, Validity: time.Duration(*days) * day}
+	caCertFile, caKeyFile := filepath.Join(*dir, *caCert), filepath.Join(*dir, *caKey)
+
+	switch command {
+	case "ca":
+		return createCA(opts, caCertFile, caKeyFile, out)
+	case "all":
+		if err := createCA(opts, caCertFile, caKeyFile, out); err != nil {
+			return err
+		}
+	}
+
+	ca, err := LoadKeyPair(caCertFile, caKeyFile)
+	if err != nil {
+		return fmt.Errorf("load CA: %w", err)
+	}
+
+	var (
+		kp       *KeyPair
+		filename string
+	)
+
+	if command == "client" {
+		opts.CommonName = name
+		filename = name
+		kp, err = ca.IssueClient(opts)
+	} else {
+		opts.Hosts = splitHosts(hosts)
+		opts.CommonName = "gophKeeper server"
+		if len(opts.Hosts) > 0 {
+			opts.CommonName = opts.Hosts[0]
+		}
+		filename = "server"
+		kp, err = ca.IssueServer(opts)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return writeKeyPair(kp, filepath.Join(*dir, filename+"-cert.pem"), filepath.Join(*dir, filename+"-key.pem"), out)
+}
+
+// createCA creates and saves CA key pair.
+func createCA(opts Options, certFile, keyFile string, out io.Writer) error {
+	opts.CommonName = opts.Organization + " CA"
+
+	ca, err := NewCA(opts)
+	if err != nil {
+		return err
+	}
+
+	return writeKeyPair(ca, certFile, keyFile, out)
+}
+
+// writeKeyPair saves key pair and reports file names.
+func writeKeyPair(kp *KeyPair, certFile, keyFile string, out io.Writer) error {
+	if err := kp.WriteFiles(certFile, keyFile); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "%s: %s, %s (valid until %s)\n",
+		kp.Cert.Subject.CommonName, certFile, keyFile, kp.Cert.NotAfter.Format(time.DateOnly))
+
+	return nil
+}
+
+// splitHosts splits comma separated hosts list.
+func splitHosts(hosts string) []string {
+	var result []string
+
+	for _, host := range strings.Split(hosts, ",") {
+		if host = strings.TrimSpace(host); host != "" {
+			result = append(result, host)
+		}
+	}
+
+	return result
+}
@@ -17,12 +17,22 @@ type ClientConfig struct {
 	ServerAddress string
 	ClientCert    string
 	MaxFileSize   int64
+	LegacyLogin   bool
+	// Export and Import are paths of vault archive, client exports or imports records without TUI.
+	// Secrets aren't read from flags, they are visible in process list, only env or prompt sets them.
+	Export        string
+	Import        string
+	Login         string
+	Password      string
+	AESKey        string
+	VaultPassword string
 }
 
 var (
 	defaultServerAddress = "127.0.0.1:9000"
 	defaultClientCert    = "../../cmd/cert/ca-cert.pem"
 	defaultMaxFileSize   = int64(8 * MB)
+	defaultLegacyLogin   = false
 )
 
 func NewClientConfig() ClientConfig {
@@ -31,6 +41,11 @@ func NewClientConfig() ClientConfig {
 	flag.StringVar(&cfg.ServerAddress, "addr", defaultServerAddress, "Server address and port")
 	flag.StringVar(&cfg.ClientCert, "clientcert", defaultClientCert, "Path to client certificat for TLS")
 	flag.Int64Var(&cfg.MaxFileSize, "maxsize", defaultMaxFileSize, "Max size of send file (type file) in MB")
+	flag.BoolVar(&cfg.LegacyLogin, "legacylogin", defaultLegacyLogin, "Send password to server, if SRP login fails (once for users registered before SRP login)")
+
+	flag.StringVar(&cfg.Export, "export", "", "Export records to vault archive and exit")
+	flag.StringVar(&cfg.Import, "import", "", "Import records from vault archive and exit")
+	flag.StringVar(&cfg.Login, "login", "", "Login of user for export or import")
 
 	flag.Parse()
 
@@ -50,5 +65,29 @@ func NewClientConfig() ClientConfig {
 		cfg.MaxFileSize = int64Var * MB
 	}
 
+	if v, ok := os.LookupEnv("LEGACY_LOGIN"); ok {
+		boolVar, err := strconv.ParseBool(v)
+		if err != nil {
+			boolVar = defaultLegacyLogin
+		}
+		cfg.LegacyLogin = boolVar
+	}
+
+	if v, ok := os.LookupEnv("USER_LOGIN"); ok {
+		cfg.Login = v
+	}
+
+	if v, ok := os.LookupEnv("USER_PASSWORD"); ok {
+		cfg.Password = v
+	}
+
+	if v, ok := os.LookupEnv("AES_KEY"); ok {
+		cfg.AESKey = v
+	}
+
+	if v, ok := os.LookupEnv("VAULT_PASSWORD"); ok {
+		cfg.VaultPassword = v
+	}
+
 	return cfg
 }
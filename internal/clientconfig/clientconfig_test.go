@@ -1,6 +1,7 @@
 package clientconfig
 
 import (
+	"flag"
 	"os"
 	"testing"
 
@@ -10,12 +11,20 @@ import (
 func TestInitConfig(t *testing.T) {
 	os.Setenv("SERVER_ADDR", "127.0.0.1:9000")
 	os.Setenv("FILE_MAXSIZE", "10")
+	os.Setenv("VAULT_PASSWORD", "vaultSecret")
 	cfgTest := NewClientConfig()
 	assert.Equal(t, "127.0.0.1:9000", cfgTest.ServerAddress, "test #SERVER_ADDR")
 	os.Unsetenv("SERVER_ADDR")
 
 	assert.Equal(t, int64(10*MB), cfgTest.MaxFileSize, "test #MaxFileSize")
 	assert.Equal(t, "../../cmd/cert/ca-cert.pem", cfgTest.ClientCert, "test #ClientCert")
+	assert.False(t, cfgTest.LegacyLogin, "test #LegacyLogin")
+	assert.Empty(t, cfgTest.Export, "test #Export")
+	assert.Equal(t, "vaultSecret", cfgTest.VaultPassword, "test #VaultPassword")
+	os.Unsetenv("VAULT_PASSWORD")
+	assert.Nil(t, flag.Lookup("password"), "test #password flag")
+	assert.Nil(t, flag.Lookup("aeskey"), "test #aeskey flag")
+	assert.Nil(t, flag.Lookup("vaultpassword"), "test #vaultpassword flag")
 
 	assert.Equal(t, int64(10*MB), int64(10485760), "test #MaxFileSize2")
 	os.Unsetenv("FILE_MAXSIZE")
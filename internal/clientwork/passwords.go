@@ -0,0 +1,231 @@
+package clientwork
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/impr0ver/gophKeeper/internal/csvimport"
+	"github.com/impr0ver/gophKeeper/internal/storage"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	log "github.com/sirupsen/logrus"
+)
+
+// importBatchSize is number of logins created with one request, server accepts up to 500 records.
+const importBatchSize = 100
+
+// passwordsImportPage asks path of CSV file with passwords exported from browser.
+func (app *TUI) passwordsImportPage(message string) {
+	var path string
+
+	form := tview.NewForm()
+
+	form.SetBorder(true)
+	form.SetBorderColor(tcell.ColorDarkGrey)
+	form.SetFieldTextColor(tcell.ColorBlack)
+	form.SetFieldBackgroundColor(tcell.ColorWhite)
+	form.SetButtonBackgroundColor(tcell.ColorGray)
+	form.SetLabelColor(tcell.ColorLightGreen)
+
+	form.AddInputField("CSV file", "", 35, nil, func(text string) {
+		path = text
+	})
+
+	form.AddButton("Preview", func() {
+		file, err := os.Open(path)
+		if err != nil {
+			log.Infoln(err)
+
+			app.passwordsImportPage("[red]Can't open CSV file.[white]")
+			return
+		}
+		defer file.Close()
+
+		format, rows, err := csvimport.Parse(file)
+		if errors.Is(err, csvimport.ErrFormat) {
+			app.passwordsImportPage("[red]Unknown CSV format, export passwords from Chrome, Firefox or Safari.[white]")
+			return
+		}
+		if err != nil {
+			log.Infoln(err)
+
+			app.passwordsImportPage("[red]Can't read CSV file.[white]")
+			return
+		}
+
+		app.passwordsPreviewPage(rows, fmt.Sprintf("%s export, %d logins.", format, len(rows)))
+	})
+
+	frame := tview.NewFrame(form).SetBorders(0, 0, 0, 1, 4, 4).
+		AddText(
+			"Passwords exported to CSV from Chrome, Firefox or Safari are imported as login records.",
+			false,
+			tview.AlignLeft,
+			tcell.ColorWhite,
+		).
+		AddText(
+			"Enter - choose option / ESC - return to the menu",
+			false,
+			tview.AlignLeft,
+			tcell.ColorWhite,
+		).
+		AddText(
+			message,
+			false,
+			tview.AlignRight,
+			tcell.ColorWhite,
+		)
+
+	frame.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyESC {
+			app.recordsInfoPage("Returned to menu.")
+		}
+		return event
+	})
+
+	app.pages.AddPage("passwordsImport", frame, true, true)
+	app.pages.SwitchToPage("passwordsImport")
+}
+
+// passwordsPreviewPage shows logins of CSV file, selected ones are uploaded. Rows with errors can't be selected.
+func (app *TUI) passwordsPreviewPage(rows []csvimport.Row, message string) {
+	selected := make([]bool, len(rows))
+	for i, row := range rows {
+		selected[i] = row.Err == nil
+	}
+
+	table := tview.NewTable().SetSelectable(true, false).SetFixed(1, 0)
+	table.SetBorder(true)
+	table.SetBorderColor(tcell.ColorDarkGrey)
+
+	for col, title := range []string{"", "Line", "Site", "Username", "Status"} {
+		table.SetCell(0, col, tview.NewTableCell(title).SetTextColor(tcell.ColorLightGreen).SetSelectable(false))
+	}
+
+	// setRow shows selection and status of row
+	setRow := func(i int) {
+		row := rows[i]
+
+		mark, status, color := "[ ]", "skipped", tcell.ColorGrey
+		switch {
+		case row.Err != nil:
+			mark, status, color = "[-]", row.Err.Error(), tcell.ColorRed
+		case selected[i]:
+			mark, status, color = "[x]", "import", tcell.ColorWhite
+		}
+
+		cells := []string{mark, strconv.Itoa(row.Line), row.Name(), row.Username, status}
+		for col, text := range cells {
+			table.SetCell(i+1, col, tview.NewTableCell(tview.Escape(strings.ReplaceAll(text, "\n", " "))).SetTextColor(color))
+		}
+	}
+
+	for i := range rows {
+		setRow(i)
+	}
+
+	// count returns number of selected rows
+	count := func() int {
+		n := 0
+		for _, ok := range selected {
+			if ok {
+				n++
+			}
+		}
+
+		return n
+	}
+
+	frame := tview.NewFrame(table).SetBorders(0, 0, 0, 1, 4, 4)
+	frame.
+		AddText(
+			"↑ or ↓ - switch logins / Enter - select login / Ctrl+A - select all or none",
+			false,
+			tview.AlignLeft,
+			tcell.ColorWhite,
+		).
+		AddText(
+			"Ctrl+U - upload selected logins / ESC - return to the menu",
+			false,
+			tview.AlignLeft,
+			tcell.ColorWhite,
+		).
+		AddText(
+			message,
+			false,
+			tview.AlignRight,
+			tcell.ColorWhite,
+		)
+
+	table.SetSelectedFunc(func(row, _ int) {
+		if i := row - 1; i >= 0 && i < len(rows) && rows[i].Err == nil {
+			selected[i] = !selected[i]
+			setRow(i)
+		}
+	})
+
+	frame.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyESC:
+			app.recordsInfoPage("Returned to menu.")
+		case tcell.KeyCtrlA:
+			all := count() == 0
+			for i, row := range rows {
+				selected[i] = all && row.Err == nil
+				setRow(i)
+			}
+		case tcell.KeyCtrlU:
+			chosen := make([]csvimport.Row, 0, count())
+			for i, row := range rows {
+				if selected[i] {
+					chosen = append(chosen, row)
+				}
+			}
+			if len(chosen) == 0 {
+				app.passwordsPreviewPage(rows, "[red]No logins are selected.[white]")
+				return nil
+			}
+
+			app.uploadPasswords(chosen)
+			return nil
+		}
+		return event
+	})
+
+	app.pages.AddPage("passwordsPreview", frame, true, true)
+	app.pages.SwitchToPage("passwordsPreview")
+}
+
+// uploadPasswords uploads logins by batches, logins which aren't created are shown again with errors.
+func (app *TUI) uploadPasswords(rows []csvimport.Row) {
+	count, failed, err := csvimport.Upload(app.client, rows, importBatchSize)
+
+	if errors.Is(err, storage.ErrUnauthenticated) {
+		log.Infoln(storage.ErrUnauthenticated)
+
+		app.authPage("[red]Session expired. Please login again.[white]")
+		return
+	}
+	if err != nil {
+		log.Infoln(err)
+
+		message := errorMessage(err)
+		if count > 0 {
+			message = fmt.Sprintf("[red]Import stopped, %d logins are imported.[white]", count)
+		}
+
+		app.recordsInfoPage(message)
+		return
+	}
+
+	if len(failed) > 0 {
+		app.passwordsPreviewPage(failed, fmt.Sprintf("[yellow]Imported %d logins, %d failed.[white]", count, len(failed)))
+		return
+	}
+
+	app.recordsInfoPage(fmt.Sprintf("[green]Imported %d logins.[white]", count))
+}
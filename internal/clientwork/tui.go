@@ -9,6 +9,9 @@ import (
 	"image/jpeg"
 	"path"
 	"regexp"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/impr0ver/gophKeeper/internal/handlers"
 	"github.com/impr0ver/gophKeeper/internal/storage"
@@ -30,10 +33,12 @@ type TUI struct {
 	pages       *tview.Pages
 	client      handlers.ClientHandlers
 	maxFileSize int64
+	legacyLogin bool
 }
 
 // NewTUI gets new terminal user interface for client.
-func NewTUI(client handlers.ClientHandlers, fileSize int64) *TUI {
+// Without legacyLogin failed login suggests legacy login to users registered before SRP login.
+func NewTUI(client handlers.ClientHandlers, fileSize int64, legacyLogin bool) *TUI {
 	application := tview.NewApplication()
 	pages := tview.NewPages()
 
@@ -48,6 +53,7 @@ func NewTUI(client handlers.ClientHandlers, fileSize int64) *TUI {
 		client:      client,
 		pages:       pages,
 		maxFileSize: fileSize,
+		legacyLogin: legacyLogin,
 	}
 
 	tui.authPage("Please set login & password for continue =>>>")
@@ -98,6 +104,11 @@ func (app *TUI) authPage(message string) {
 		if errors.Is(err, storage.ErrWrongCredentials) {
 			log.Infoln(storage.ErrWrongCredentials)
 
+			if !app.legacyLogin {
+				app.authPage("[red]Wrong credentials. If you registered before SRP login, restart client with -legacylogin once.[white]")
+				return
+			}
+
 			app.authPage("[red]Wrong credentials. Please try again.[white]")
 			return
 		}
@@ -110,7 +121,7 @@ func (app *TUI) authPage(message string) {
 		if errors.Is(err, storage.ErrUnknown) || err != nil {
 			log.Infoln(storage.ErrUnknown)
 
-			app.authPage("[red]Something is wrong. ;([white]")
+			app.authPage(errorMessage(err))
 			return
 		}
 
@@ -135,7 +146,7 @@ func (app *TUI) authPage(message string) {
 		if errors.Is(err, storage.ErrUnknown) || err != nil {
 			log.Infoln(storage.ErrUnknown)
 
-			app.authPage("[red]Something is wrong. ;([white]")
+			app.authPage(errorMessage(err))
 			return
 		}
 
@@ -181,7 +192,7 @@ func (app *TUI) recordsInfoPage(message string) {
 	if errors.Is(err, storage.ErrUnknown) || err != nil {
 		log.Infoln(storage.ErrUnknown)
 
-		message = "[red]Something is wrong. ;([white]"
+		message = errorMessage(err)
 		return
 	}
 
@@ -204,7 +215,12 @@ func (app *TUI) recordsInfoPage(message string) {
 			record.Metadata = "no metadata"
 		}
 
-		list.AddItem(record.ID, "Type: "+record.Type.String()+" | Metadata: "+record.Metadata+" | AES key hint: "+record.KeyHint, '⏺', f)
+		list.AddItem(record.ID, "Type: "+record.Type.String()+" | Metadata: "+record.Metadata+" | AES key: "+keyName(record.KeyHint)+" | Updated: "+formatTime(record.UpdatedAt), '⏺', f)
+	}
+
+	// Records in old format are found only when they are decrypted
+	if legacy := app.client.LegacyRecords(); legacy > 0 && message == "" {
+		message = fmt.Sprintf("[yellow]%d records in old format, press Ctrl+E to re-encrypt.[white]", legacy)
 	}
 
 	listFrame := tview.NewFrame(list).SetBorders(0, 0, 0, 1, 4, 4).
@@ -221,7 +237,13 @@ func (app *TUI) recordsInfoPage(message string) {
 			tcell.ColorWhite,
 		).
 		AddText(
-			"Ctrl+K - change AES key",
+			"Ctrl+K - change, add to keyring or rotate AES key / Ctrl+E - re-encrypt old records",
+			false,
+			tview.AlignLeft,
+			tcell.ColorWhite,
+		).
+		AddText(
+			"Ctrl+B - export or import encrypted backup / Ctrl+P - import passwords from browser CSV",
 			false,
 			tview.AlignLeft,
 			tcell.ColorWhite,
@@ -248,6 +270,15 @@ func (app *TUI) recordsInfoPage(message string) {
 		if event.Key() == tcell.KeyCtrlK {
 			app.setNewAESKey("Change AES Key")
 		}
+		if event.Key() == tcell.KeyCtrlE {
+			app.reencryptRecords()
+		}
+		if event.Key() == tcell.KeyCtrlB {
+			app.backupPage("")
+		}
+		if event.Key() == tcell.KeyCtrlP {
+			app.passwordsImportPage("")
+		}
 		if event.Key() == tcell.KeyESC {
 			app.authPage("Logget out")
 		}
@@ -258,9 +289,29 @@ func (app *TUI) recordsInfoPage(message string) {
 	app.pages.SwitchToPage("records")
 }
 
-// setNewAESKey set new AES key for decrypt records
+// reencryptRecords re-encrypts records in old format with key derived from AES key.
+func (app *TUI) reencryptRecords() {
+	count, err := app.client.ReencryptRecords()
+
+	if errors.Is(err, storage.ErrUnauthenticated) {
+		log.Infoln(storage.ErrUnauthenticated)
+
+		app.authPage("[red]Session expired. Please login again.[white]")
+		return
+	}
+	if err != nil {
+		log.Infoln(err)
+
+		app.recordsInfoPage(errorMessage(err))
+		return
+	}
+
+	app.recordsInfoPage(fmt.Sprintf("[green]Re-encrypted %d records.[white]", count))
+}
+
+// setNewAESKey set new AES key for new records, adds key to keyring for decrypt records or rotates all records to it
 func (app *TUI) setNewAESKey(message string) {
-	var newAESKey string
+	var newAESKey, label string
 
 	form := tview.NewForm()
 
@@ -274,25 +325,62 @@ func (app *TUI) setNewAESKey(message string) {
 	form.AddPasswordField("AES Key", "", 35, '*', func(masterKey string) {
 		newAESKey = masterKey
 	})
+	form.AddInputField("Label", "", 35, nil, func(text string) {
+		label = text
+	})
 
 	form.AddButton("Set AES key", func() {
-		err := app.client.SetAESKey(newAESKey)
+		err := app.client.SetAESKey(newAESKey, label)
 		if errors.Is(err, handlers.ErrEmptyField) {
 			log.Infoln(handlers.ErrEmptyField)
 
 			app.authPage("[red]AES field is empty.[white]")
 			return
 		}
+		if errors.Is(err, handlers.ErrInvalidKeyLabel) {
+			log.Infoln(handlers.ErrInvalidKeyLabel)
+
+			app.setNewAESKey("[red]Label is too long.[white]")
+			return
+		}
 		if errors.Is(err, storage.ErrUnknown) || err != nil {
 			log.Infoln(storage.ErrUnknown)
 
-			app.authPage("[red]Something is wrong. ;([white]")
+			app.authPage(errorMessage(err))
 			return
 		}
 
 		app.recordsInfoPage("[green]AES key change successfully![white]")
 	})
 
+	form.AddButton("Add to keyring", func() {
+		err := app.client.AddAESKey(newAESKey, label)
+		if errors.Is(err, handlers.ErrEmptyField) {
+			log.Infoln(handlers.ErrEmptyField)
+
+			app.setNewAESKey("[red]AES field is empty.[white]")
+			return
+		}
+		if errors.Is(err, handlers.ErrInvalidKeyLabel) {
+			log.Infoln(handlers.ErrInvalidKeyLabel)
+
+			app.setNewAESKey("[red]Label is too long.[white]")
+			return
+		}
+		if err != nil {
+			log.Infoln(err)
+
+			app.setNewAESKey(errorMessage(err))
+			return
+		}
+
+		app.recordsInfoPage("[green]AES key added to keyring![white]")
+	})
+
+	form.AddButton("Rotate key", func() {
+		app.rotateKey(newAESKey, label)
+	})
+
 	frame := tview.NewFrame(form).SetBorders(0, 0, 0, 1, 4, 4).
 		AddText(
 			"Enter - choose option / ESC - return to the menu",
@@ -318,6 +406,85 @@ func (app *TUI) setNewAESKey(message string) {
 	})
 }
 
+// rotateKey re-encrypts all records with new AES key and shows progress, rotation is resumed with the same key if it fails.
+func (app *TUI) rotateKey(newAESKey string, label string) {
+	if newAESKey == "" {
+		app.setNewAESKey("[red]AES field is empty.[white]")
+		return
+	}
+	if !userdata.ValidKeyLabel(label) {
+		app.setNewAESKey("[red]Label is too long.[white]")
+		return
+	}
+
+	progress := tview.NewTextView().SetDynamicColors(true).SetTextAlign(tview.AlignCenter)
+	progress.SetBorder(true)
+	progress.SetBorderColor(tcell.ColorDarkGrey)
+	progress.SetText("Loading records...")
+
+	frame := tview.NewFrame(progress).SetBorders(0, 0, 0, 1, 4, 4).
+		AddText(
+			"Rotating AES key, please wait. Records are re-encrypted on server in batches.",
+			false,
+			tview.AlignLeft,
+			tcell.ColorWhite,
+		)
+
+	app.pages.AddPage("rotatekey", frame, true, true)
+	app.pages.SwitchToPage("rotatekey")
+
+	go func() {
+		count, err := app.client.RotateKey(newAESKey, label, func(done int, total int) {
+			app.QueueUpdateDraw(func() {
+				progress.SetText(fmt.Sprintf("%s\n\n%d / %d records", progressBar(done, total), done, total))
+			})
+		})
+
+		app.QueueUpdateDraw(func() {
+			app.pages.RemovePage("rotatekey")
+
+			if errors.Is(err, storage.ErrUnauthenticated) {
+				log.Infoln(storage.ErrUnauthenticated)
+
+				app.authPage("[red]Session expired. Please login again and rotate key again to resume.[white]")
+				return
+			}
+			if err != nil {
+				log.Infoln(err)
+
+				app.recordsInfoPage(rotateErrorMessage(err, count))
+				return
+			}
+
+			app.recordsInfoPage(fmt.Sprintf("[green]AES key rotated, re-encrypted %d records.[white]", count))
+		})
+	}()
+}
+
+// progressBar returns text bar of done part of total.
+func progressBar(done int, total int) string {
+	const width = 40
+
+	filled := width
+	if total > 0 {
+		filled = done * width / total
+	}
+
+	return "[green]" + strings.Repeat("█", filled) + "[grey]" + strings.Repeat("░", width-filled) + "[white]"
+}
+
+// rotateErrorMessage returns message about failed key rotation, which is resumed by rotating with the same key.
+func rotateErrorMessage(err error, count int) string {
+	switch {
+	case errors.Is(err, handlers.ErrKeyNotInKeyring):
+		return "[red]Some records are encrypted with unknown key, add it to keyring and rotate again.[white]"
+	case errors.Is(err, storage.ErrVersionConflict):
+		return fmt.Sprintf("[red]Records were changed during rotation, %d are re-encrypted. Rotate again to resume.[white]", count)
+	default:
+		return fmt.Sprintf("[red]Rotation stopped, %d records are re-encrypted. Rotate again with the same key to resume.[white]", count)
+	}
+}
+
 // recordPage - record page (decrypted record data, copy or delete record).
 func (app *TUI) recordPage(recordID string, message string) {
 	record, err := app.client.GetRecord(recordID)
@@ -353,7 +520,7 @@ func (app *TUI) recordPage(recordID string, message string) {
 			SetDisabled(true)).
 		SetBorders(0, 0, 0, 1, 4, 4).
 		AddText(
-			record.Metadata+" | "+record.Type.String(),
+			record.Metadata+" | "+record.Type.String()+" | Version "+strconv.FormatInt(record.Version, 10)+", updated "+formatTime(record.UpdatedAt),
 			true,
 			tview.AlignCenter,
 			tcell.ColorLightGreen,
@@ -405,7 +572,7 @@ func (app *TUI) recordPage(recordID string, message string) {
 			if errors.Is(err, storage.ErrUnknown) || err != nil {
 				log.Infoln(storage.ErrUnknown)
 
-				app.recordPage(recordID, "[red]Something is wrong. ;([white]")
+				app.recordPage(recordID, errorMessage(err))
 				return event
 			}
 
@@ -438,7 +605,7 @@ func (app *TUI) createTextRecord() {
 		record.Metadata = text
 	})
 	form.AddButton("OK", func() {
-		err := app.client.CreateRecord(record)
+		meta, err := app.client.CreateRecord(record)
 
 		if errors.Is(err, storage.ErrUnauthenticated) {
 			log.Infoln(storage.ErrUnauthenticated)
@@ -446,18 +613,18 @@ func (app *TUI) createTextRecord() {
 			app.authPage("[red]Session expired. Please login again.[white]")
 			return
 		}
-		if errors.Is(err, storage.ErrUnknown) {
-			log.Infoln(storage.ErrUnknown)
-
-			app.recordsInfoPage("[red]Something is wrong. ;([white]")
-			return
-		}
 		if errors.Is(err, handlers.ErrWrongAESKey) {
 			app.authPage("[red]Wrong AES key. Please login again.[white]")
 			return
 		}
+		if err != nil {
+			log.Infoln(err)
 
-		app.recordsInfoPage("[green]Created record successfully.[white]")
+			app.recordsInfoPage(errorMessage(err))
+			return
+		}
+
+		app.recordsInfoPage(createdMessage(meta))
 	})
 
 	frame := tview.NewFrame(form).SetBorders(0, 0, 0, 1, 4, 4).
@@ -507,22 +674,24 @@ func (app *TUI) createCredentialsRecord() {
 	})
 	form.AddButton("OK", func() {
 		record.Data, _ = loginAndPassword.Bytes()
-		err := app.client.CreateRecord(record)
+		meta, err := app.client.CreateRecord(record)
 
 		if errors.Is(err, storage.ErrUnauthenticated) {
 			app.authPage("[red]Session expired. Please login again.[white]")
 			return
 		}
-		if errors.Is(err, storage.ErrUnknown) {
-			app.recordsInfoPage("[red]Something is wrong. ;([white]")
-			return
-		}
 		if errors.Is(err, handlers.ErrWrongAESKey) {
 			app.authPage("[red]Wrong AES key. Please login again.[white]")
 			return
 		}
+		if err != nil {
+			log.Infoln(err)
 
-		app.recordsInfoPage("[green]Created record successfully.[white]")
+			app.recordsInfoPage(errorMessage(err))
+			return
+		}
+
+		app.recordsInfoPage(createdMessage(meta))
 	})
 
 	frame := tview.NewFrame(form).SetBorders(0, 0, 0, 1, 4, 4).
@@ -608,22 +777,24 @@ func (app *TUI) createCardRecord() {
 		}
 
 		record.Data, _ = creditCard.Bytes()
-		err = app.client.CreateRecord(record)
+		meta, err := app.client.CreateRecord(record)
 
 		if errors.Is(err, storage.ErrUnauthenticated) {
 			app.authPage("[red]Session expired. Please login again.[white]")
 			return
 		}
-		if errors.Is(err, storage.ErrUnknown) {
-			app.recordsInfoPage("[red]Something is wrong. ;([white]")
-			return
-		}
 		if errors.Is(err, handlers.ErrWrongAESKey) {
 			app.authPage("[red]Wrong AES key. Please login again.[white]")
 			return
 		}
+		if err != nil {
+			log.Infoln(err)
+
+			app.recordsInfoPage(errorMessage(err))
+			return
+		}
 
-		app.recordsInfoPage("[green]Created record successfully.[white]")
+		app.recordsInfoPage(createdMessage(meta))
 	})
 
 	frame := tview.NewFrame(form).SetBorders(0, 0, 0, 1, 4, 4).
@@ -699,22 +870,24 @@ func (app *TUI) createFileRecord() {
 		}
 
 		record.Data = data
-		err = app.client.CreateRecord(record)
+		meta, err := app.client.CreateRecord(record)
 
 		if errors.Is(err, storage.ErrUnauthenticated) {
 			app.authPage("[red]Session expired. Please login again.[white]")
 			return
 		}
-		if errors.Is(err, storage.ErrUnknown) {
-			app.recordsInfoPage("[red]Something is wrong. ;([white]")
-			return
-		}
 		if errors.Is(err, handlers.ErrWrongAESKey) {
 			app.authPage("[red]Wrong AES key. Please login again.[white]")
 			return
 		}
+		if err != nil {
+			log.Infoln(err)
 
-		app.recordsInfoPage("[green]Created record successfully.[white]")
+			app.recordsInfoPage(errorMessage(err))
+			return
+		}
+
+		app.recordsInfoPage(createdMessage(meta))
 	})
 
 	frame := tview.NewFrame(form).SetBorders(0, 0, 0, 1, 4, 4).
@@ -805,3 +978,55 @@ func (app *TUI) createRecordPage(message string) {
 	app.pages.AddPage("create", frame, true, true)
 	app.pages.SwitchToPage("create")
 }
+
+// timeLayout is layout of record times shown to user.
+const timeLayout = "2006-01-02 15:04"
+
+// formatTime returns local time for user, unknown time is shown as dash.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+
+	return t.Local().Format(timeLayout)
+}
+
+// keyName returns label and fingerprint of AES key from key hint of record.
+func keyName(hint string) string {
+	fingerprint, label, ok := userdata.ParseKeyHint(hint)
+	if !ok {
+		return "unknown"
+	}
+
+	if label == "" {
+		return fingerprint
+	}
+
+	return label + " (" + fingerprint + ")"
+}
+
+// createdMessage returns message about created record with its ID.
+func createdMessage(meta userdata.RecordMeta) string {
+	return fmt.Sprintf("[green]Created record %s at %s.[white]", meta.ID, formatTime(meta.CreatedAt))
+}
+
+// errorMessage returns message about error for user, details sent by server are used if they are.
+func errorMessage(err error) string {
+	var statusErr *handlers.StatusError
+	errors.As(err, &statusErr)
+
+	switch {
+	case statusErr != nil && len(statusErr.Fields) > 0:
+		return fmt.Sprintf("[red]Field %s: %s.[white]", statusErr.Fields[0].Field, statusErr.Fields[0].Description)
+	case statusErr != nil && statusErr.RetryDelay > 0:
+		return fmt.Sprintf("[red]Too many requests, try again in %.1f s.[white]", statusErr.RetryDelay.Seconds())
+	case errors.Is(err, storage.ErrQuotaExceeded):
+		return "[red]Storage quota exceeded, delete some records.[white]"
+	case errors.Is(err, storage.ErrUserDisabled):
+		return "[red]User is disabled, contact administrator.[white]"
+	case errors.Is(err, handlers.ErrUnavailable):
+		return "[red]Server is unavailable, try again later.[white]"
+	default:
+		return "[red]Something is wrong. ;([white]"
+	}
+}
@@ -0,0 +1,233 @@
+package clientwork
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/impr0ver/gophKeeper/internal/handlers"
+	"github.com/impr0ver/gophKeeper/internal/storage"
+	"github.com/impr0ver/gophKeeper/internal/userdata"
+	"github.com/impr0ver/gophKeeper/internal/vault"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/term"
+)
+
+// ErrVaultPassword is returned if password of vault archive isn't set.
+var ErrVaultPassword = errors.New("password of vault archive is empty")
+
+// ReadSecret reads secret from terminal without echo, secret is empty if input isn't terminal.
+func ReadSecret(name string, in *os.File, out io.Writer) (string, error) {
+	if !term.IsTerminal(int(in.Fd())) {
+		return "", nil
+	}
+
+	fmt.Fprintf(out, "%s: ", name)
+	secret, err := term.ReadPassword(int(in.Fd()))
+	fmt.Fprintln(out)
+
+	return string(secret), err
+}
+
+// ExportVault exports all records of user to vault archive at path, archive is written only if export succeeds.
+func ExportVault(client handlers.ClientHandlers, credentials userdata.UserCredentials, path string, password string, out io.Writer) error {
+	if password == "" {
+		return ErrVaultPassword
+	}
+
+	if err := client.Login(credentials); err != nil {
+		return err
+	}
+
+	var archive bytes.Buffer
+	count, err := client.ExportVault(&archive, password, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(path, archive.Bytes(), 0o600); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "Exported %d records to %s\n", count, path)
+
+	return nil
+}
+
+// ImportVault imports records of vault archive at path to account of user.
+func ImportVault(client handlers.ClientHandlers, credentials userdata.UserCredentials, path string, password string, out io.Writer) error {
+	if password == "" {
+		return ErrVaultPassword
+	}
+
+	archive, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+
+	if err := client.Login(credentials); err != nil {
+		return err
+	}
+
+	imported, skipped, err := client.ImportVault(archive, password, nil)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "Imported %d records, skipped %d existing records\n", imported, skipped)
+
+	return nil
+}
+
+// backupPage exports records to vault archive or imports them from it.
+func (app *TUI) backupPage(message string) {
+	var path, password string
+
+	form := tview.NewForm()
+
+	form.SetBorder(true)
+	form.SetBorderColor(tcell.ColorDarkGrey)
+	form.SetFieldTextColor(tcell.ColorBlack)
+	form.SetFieldBackgroundColor(tcell.ColorWhite)
+	form.SetButtonBackgroundColor(tcell.ColorGray)
+	form.SetLabelColor(tcell.ColorLightGreen)
+
+	form.AddInputField("Archive path", "", 35, nil, func(text string) {
+		path = text
+	})
+	form.AddPasswordField("Archive password", "", 35, '*', func(text string) {
+		password = text
+	})
+
+	form.AddButton("Export", func() {
+		if path == "" || password == "" {
+			app.backupPage("[red]Some fields are empty.[white]")
+			return
+		}
+
+		app.vaultProgress("Exporting records, please wait. Records are downloaded in batches.", func(progress func(done int, total int)) string {
+			var archive bytes.Buffer
+			count, err := app.client.ExportVault(&archive, password, progress)
+			if err == nil {
+				err = os.WriteFile(path, archive.Bytes(), 0o600)
+			}
+			if err != nil {
+				log.Infoln(err)
+
+				return vaultErrorMessage(err)
+			}
+
+			return fmt.Sprintf("[green]Exported %d records to %s.[white]", count, path)
+		})
+	})
+
+	form.AddButton("Import", func() {
+		if path == "" || password == "" {
+			app.backupPage("[red]Some fields are empty.[white]")
+			return
+		}
+
+		app.vaultProgress("Importing records, please wait. Records are uploaded in batches.", func(progress func(done int, total int)) string {
+			archive, err := os.Open(path)
+			if err != nil {
+				log.Infoln(err)
+
+				return "[red]Can't open vault archive.[white]"
+			}
+			defer archive.Close()
+
+			imported, skipped, err := app.client.ImportVault(archive, password, progress)
+			if err != nil {
+				log.Infoln(err)
+
+				return vaultErrorMessage(err)
+			}
+
+			return fmt.Sprintf("[green]Imported %d records, skipped %d existing.[white]", imported, skipped)
+		})
+	})
+
+	frame := tview.NewFrame(form).SetBorders(0, 0, 0, 1, 4, 4).
+		AddText(
+			"Archive has all records and files, it's encrypted with its own password.",
+			false,
+			tview.AlignLeft,
+			tcell.ColorWhite,
+		).
+		AddText(
+			"Enter - choose option / ESC - return to the menu",
+			false,
+			tview.AlignLeft,
+			tcell.ColorWhite,
+		).
+		AddText(
+			message,
+			false,
+			tview.AlignRight,
+			tcell.ColorWhite,
+		)
+
+	app.pages.AddPage("backup", frame, true, true)
+	app.pages.SwitchToPage("backup")
+
+	frame.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyESC {
+			app.recordsInfoPage("Returned to menu.")
+		}
+		return event
+	})
+}
+
+// vaultProgress runs export or import in background and shows its progress, then shows records with result message.
+func (app *TUI) vaultProgress(title string, work func(progress func(done int, total int)) string) {
+	progress := tview.NewTextView().SetDynamicColors(true).SetTextAlign(tview.AlignCenter)
+	progress.SetBorder(true)
+	progress.SetBorderColor(tcell.ColorDarkGrey)
+	progress.SetText("Loading records...")
+
+	frame := tview.NewFrame(progress).SetBorders(0, 0, 0, 1, 4, 4).
+		AddText(
+			title,
+			false,
+			tview.AlignLeft,
+			tcell.ColorWhite,
+		)
+
+	app.pages.AddPage("vault", frame, true, true)
+	app.pages.SwitchToPage("vault")
+
+	go func() {
+		message := work(func(done int, total int) {
+			app.QueueUpdateDraw(func() {
+				progress.SetText(fmt.Sprintf("%s\n\n%d / %d records", progressBar(done, total), done, total))
+			})
+		})
+
+		app.QueueUpdateDraw(func() {
+			app.pages.RemovePage("vault")
+			app.recordsInfoPage(message)
+		})
+	}()
+}
+
+// vaultErrorMessage returns message about failed export or import.
+func vaultErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, vault.ErrPassword):
+		return "[red]Wrong password of vault archive or it's damaged.[white]"
+	case errors.Is(err, vault.ErrFormat), errors.Is(err, vault.ErrVersion), errors.Is(err, vault.ErrDamaged):
+		return "[red]" + err.Error() + ".[white]"
+	case errors.Is(err, storage.ErrUnauthenticated):
+		return "[red]Session expired. Please login again.[white]"
+	case errors.Is(err, handlers.ErrKeyNotInKeyring):
+		return "[red]Some records are encrypted with unknown key, add it to keyring and export again.[white]"
+	default:
+		return errorMessage(err)
+	}
+}
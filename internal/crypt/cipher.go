@@ -0,0 +1,280 @@
+package crypt
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/zenazn/pkcs7pad"
+	"golang.org/x/crypto/argon2"
+)
+
+// Encrypted data has header with key derivation parameters:
+// magic "GKE" | version | memory uint32 | iterations uint32 | parallelism uint8 | salt length uint8 | salt | data.
+// Data without header is legacy, its key is MD5 of secret.
+const (
+	headerMagic = "GKE"
+	// formatV1 is AES-256-CBC with key derived by Argon2id, data is IV and cipher text.
+	formatV1 byte = 1
+	// formatV2 is AES-256-GCM with key derived by Argon2id, data is nonce and sealed text.
+	// Header and associated data are authenticated with data.
+	formatV2 byte = 2
+	// formatKey is data key of record wrapped by AES-256-GCM with key encryption key, header is the same as in format 2.
+	formatKey byte = 3
+	// formatEnvelope is AES-256-GCM with random data key, header is only magic and version.
+	formatEnvelope byte = 4
+	headerSize          = len(headerMagic) + 1 + 4 + 4 + 1 + 1
+	keySize             = 32
+	// maxKeyMemory, maxKeyIterations and maxKeyParallelism limit cost of key derivation (memory in KiB),
+	// so malformed header can't exhaust memory or hang client.
+	maxKeyMemory      = 1024 * 1024
+	maxKeyIterations  = 64
+	maxKeyParallelism = 64
+	// fingerprintSize is size of key fingerprint in bytes.
+	fingerprintSize = 8
+)
+
+// Decryption errors.
+var (
+	// ErrDecrypt is returned when data isn't decrypted: key is wrong or data is changed.
+	ErrDecrypt = errors.New("failed to decrypt data")
+	// ErrMalformed is returned when data can't be cipher text of any format.
+	ErrMalformed = errors.New("malformed cipher text")
+)
+
+// DefaultKeyParams are parameters of key derivation of new data.
+var DefaultKeyParams = DefaultPasswordParams
+
+// DeriveKey derives AES-256 key from secret and salt with Argon2id.
+func DeriveKey(secret string, salt []byte, params PasswordParams) []byte {
+	return argon2.IDKey([]byte(secret), salt, params.Iterations, params.Memory, params.Parallelism, keySize)
+}
+
+// Cipher encrypts data with key derived from secret and user salt.
+// Derived keys are cached, because derivation is slow by design.
+type Cipher struct {
+	secret string
+	salt   []byte
+	params PasswordParams
+
+	mu   sync.Mutex
+	keys map[string][]byte
+}
+
+// NewCipher returns cipher of secret, new data is encrypted with key derived with salt and params.
+func NewCipher(secret string, salt []byte, params PasswordParams) *Cipher {
+	return &Cipher{
+		secret: secret,
+		salt:   salt,
+		params: params,
+		keys:   make(map[string][]byte),
+	}
+}
+
+// key returns cached key derived with salt and params.
+func (c *Cipher) key(salt []byte, params PasswordParams) []byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	id := fmt.Sprintf("%d,%d,%d,%x", params.Memory, params.Iterations, params.Parallelism, salt)
+	if key, ok := c.keys[id]; ok {
+		return key
+	}
+
+	key := DeriveKey(c.secret, salt, params)
+	c.keys[id] = key
+
+	return key
+}
+
+// Fingerprint returns identifier of derived key: HMAC-SHA256 of fixed label with key, it's hex of 8 bytes.
+// Key can't be got from fingerprint, and checking guessed secret takes key derivation.
+func (c *Cipher) Fingerprint() string {
+	mac := hmac.New(sha256.New, c.key(c.salt, c.params))
+	mac.Write([]byte("gophkeeper-key-fingerprint"))
+
+	return hex.EncodeToString(mac.Sum(nil)[:fingerprintSize])
+}
+
+// Encrypt encrypts data with derived key by AES-256-GCM, header with derivation parameters is prepended.
+// Associated data isn't saved, but the same one is required to decrypt data.
+func (c *Cipher) Encrypt(plainText, associatedData []byte) ([]byte, error) {
+	return c.seal(formatV2, plainText, associatedData)
+}
+
+// seal encrypts data of format version with derived key or, for data keys, with key encryption key.
+func (c *Cipher) seal(version byte, plainText, associatedData []byte) ([]byte, error) {
+	if !c.params.Valid() || len(c.salt) > 255 {
+		return nil, fmt.Errorf("invalid key derivation parameters %+v", c.params)
+	}
+
+	header := make([]byte, 0, headerSize+len(c.salt))
+	header = append(header, headerMagic...)
+	header = append(header, version)
+	header = binary.BigEndian.AppendUint32(header, c.params.Memory)
+	header = binary.BigEndian.AppendUint32(header, c.params.Iterations)
+	header = append(header, c.params.Parallelism, byte(len(c.salt)))
+	header = append(header, c.salt...)
+
+	key := c.key(c.salt, c.params)
+	if version == formatKey {
+		key = keyEncryptionKey(key)
+	}
+
+	return sealGCM(key, header, plainText, associatedData)
+}
+
+// Decrypt decrypts data with key derived with parameters from its header.
+// Data of older formats is decrypted too, then legacy is true: data without header with MD5 key
+// and AES-256-CBC data, associated data isn't checked for them.
+func (c *Cipher) Decrypt(data, associatedData []byte) (plainText []byte, legacy bool, err error) {
+	if IsEnvelope(data) {
+		return nil, false, ErrMalformed
+	}
+
+	version, salt, params, cipherText, ok := parseHeader(data)
+	if !ok {
+		plainText, err = AES256CBCDecode(data, c.secret)
+
+		return plainText, true, err
+	}
+
+	switch version {
+	case formatV1:
+		plainText, err = cbcDecrypt(c.key(salt, params), cipherText)
+
+		return plainText, true, err
+	case formatKey:
+		return nil, false, ErrMalformed
+	}
+
+	plainText, err = openGCM(c.key(salt, params), data[:len(data)-len(cipherText)], cipherText, associatedData)
+
+	return plainText, false, err
+}
+
+// sealGCM encrypts data by AES-256-GCM with random nonce, header is prepended and authenticated with associated data.
+func sealGCM(key, header, plainText, associatedData []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	data := append(bytes.Clone(header), nonce...)
+
+	return aead.Seal(data, nonce, plainText, append(bytes.Clone(header), associatedData...)), nil
+}
+
+// openGCM decrypts nonce and sealed text encrypted by sealGCM with header.
+func openGCM(key, header, cipherText, associatedData []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(cipherText) < aead.NonceSize()+aead.Overhead() {
+		return nil, ErrMalformed
+	}
+
+	nonce, sealed := cipherText[:aead.NonceSize()], cipherText[aead.NonceSize():]
+
+	plainText, err := aead.Open(nil, nonce, sealed, append(bytes.Clone(header), associatedData...))
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+
+	return plainText, nil
+}
+
+// parseHeader splits data to format version, key derivation parameters and cipher text,
+// ok is false if data has no valid header.
+func parseHeader(data []byte) (version byte, salt []byte, params PasswordParams, cipherText []byte, ok bool) {
+	if len(data) < headerSize || string(data[:len(headerMagic)]) != headerMagic {
+		return 0, nil, params, nil, false
+	}
+
+	version = data[len(headerMagic)]
+	if version != formatV1 && version != formatV2 && version != formatKey {
+		return 0, nil, params, nil, false
+	}
+
+	fields := data[len(headerMagic)+1:]
+	params = PasswordParams{
+		Memory:      binary.BigEndian.Uint32(fields[0:4]),
+		Iterations:  binary.BigEndian.Uint32(fields[4:8]),
+		Parallelism: fields[8],
+	}
+	saltSize := int(fields[9])
+
+	if !params.Bounded() || len(data) < headerSize+saltSize {
+		return 0, nil, params, nil, false
+	}
+
+	return version, data[headerSize : headerSize+saltSize], params, data[headerSize+saltSize:], true
+}
+
+// newGCM returns AES-256-GCM with standard nonce size.
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// cbcEncrypt encrypts padded data with AES-CBC, random IV is prepended. New data isn't encrypted so since format 2.
+func cbcEncrypt(key []byte, plainText []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	padded := pkcs7pad.Pad(plainText, aes.BlockSize)
+
+	cipherText := make([]byte, aes.BlockSize+len(padded))
+	iv := cipherText[:aes.BlockSize]
+	if _, err := io.ReadFull(rand.Reader, iv); err != nil {
+		return nil, err
+	}
+
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(cipherText[aes.BlockSize:], padded)
+
+	return cipherText, nil
+}
+
+// cbcDecrypt decrypts data encrypted by cbcEncrypt.
+func cbcDecrypt(key []byte, cipherText []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(cipherText) < 2*aes.BlockSize || len(cipherText)%aes.BlockSize != 0 {
+		return nil, ErrMalformed
+	}
+
+	plainText := make([]byte, len(cipherText)-aes.BlockSize)
+	cipher.NewCBCDecrypter(block, cipherText[:aes.BlockSize]).CryptBlocks(plainText, cipherText[aes.BlockSize:])
+
+	plainText, err = pkcs7pad.Unpad(plainText)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+
+	return plainText, nil
+}
@@ -0,0 +1,117 @@
+package crypt
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCipher(t *testing.T) {
+	params := PasswordParams{Memory: 64, Iterations: 1, Parallelism: 1}
+	salt := []byte("0123456789abcdef")
+	c := NewCipher("masterkey", salt, params)
+	ad := []byte("record")
+
+	for _, plainText := range [][]byte{nil, []byte("1"), bytes.Repeat([]byte("MySecretText"), 100)} {
+		encrypted, err := c.Encrypt(plainText, ad)
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("GKE\x02\x00\x00\x00\x40\x00\x00\x00\x01\x01\x10"), encrypted[:headerSize])
+		assert.Equal(t, salt, encrypted[headerSize:headerSize+len(salt)])
+
+		decrypted, legacy, err := c.Decrypt(encrypted, ad)
+		assert.NoError(t, err)
+		assert.False(t, legacy)
+		assert.Equal(t, string(plainText), string(decrypted))
+
+		// Parameters of data are taken from header
+		decrypted, _, err = NewCipher("masterkey", []byte("other"), DefaultKeyParams).Decrypt(encrypted, ad)
+		assert.NoError(t, err)
+		assert.Equal(t, string(plainText), string(decrypted))
+
+		_, _, err = NewCipher("otherkey", salt, params).Decrypt(encrypted, ad)
+		assert.Equal(t, ErrDecrypt, err)
+
+		_, _, err = c.Decrypt(encrypted, []byte("other record"))
+		assert.Equal(t, ErrDecrypt, err)
+	}
+
+	// Changed data and header aren't decrypted
+	encrypted, err := c.Encrypt([]byte("hello!"), ad)
+	assert.NoError(t, err)
+	for _, i := range []int{headerSize, headerSize + len(salt), len(encrypted) - 1} {
+		changed := bytes.Clone(encrypted)
+		changed[i] ^= 1
+		_, _, err = c.Decrypt(changed, ad)
+		assert.Equal(t, ErrDecrypt, err, "byte %d is changed", i)
+	}
+
+	_, _, err = c.Decrypt(encrypted[:headerSize+len(salt)+8], ad)
+	assert.Equal(t, ErrMalformed, err)
+
+	// Legacy data with MD5 key
+	encrypted, err = AES256CBCEncode([]byte("hello!"), "masterkey")
+	assert.NoError(t, err)
+	decrypted, legacy, err := c.Decrypt(encrypted, ad)
+	assert.NoError(t, err)
+	assert.True(t, legacy)
+	assert.Equal(t, []byte("hello!"), decrypted)
+
+	// CBC data with derived key
+	cipherText, err := cbcEncrypt(c.key(salt, params), []byte("hello!"))
+	assert.NoError(t, err)
+	header := []byte("GKE\x01\x00\x00\x00\x40\x00\x00\x00\x01\x01\x10")
+	decrypted, legacy, err = c.Decrypt(append(append(header, salt...), cipherText...), nil)
+	assert.NoError(t, err)
+	assert.True(t, legacy)
+	assert.Equal(t, []byte("hello!"), decrypted)
+
+	_, _, err = c.Decrypt([]byte("short"), ad)
+	assert.Equal(t, ErrMalformed, err)
+
+	_, err = NewCipher("masterkey", salt, PasswordParams{}).Encrypt([]byte("hello!"), ad)
+	assert.Error(t, err)
+}
+
+func TestCipher_Fingerprint(t *testing.T) {
+	params := PasswordParams{Memory: 64, Iterations: 1, Parallelism: 1}
+	salt := []byte("0123456789abcdef")
+
+	fingerprint := NewCipher("masterkey", salt, params).Fingerprint()
+	assert.Len(t, fingerprint, 2*fingerprintSize)
+	assert.Equal(t, fingerprint, NewCipher("masterkey", salt, params).Fingerprint())
+	assert.NotContains(t, fingerprint, "master")
+
+	assert.NotEqual(t, fingerprint, NewCipher("otherkey", salt, params).Fingerprint())
+	assert.NotEqual(t, fingerprint, NewCipher("masterkey", []byte("other salt"), params).Fingerprint())
+}
+
+func TestParseHeader(t *testing.T) {
+	tc := []struct {
+		name string
+		data string
+		ok   bool
+	}{
+		{"Valid header", "GKE\x01\x00\x00\x00\x40\x00\x00\x00\x01\x01\x02saltdata", true},
+		{"Format 2", "GKE\x02\x00\x00\x00\x40\x00\x00\x00\x01\x01\x02saltdata", true},
+		{"Wrapped data key", "GKE\x03\x00\x00\x00\x40\x00\x00\x00\x01\x01\x02saltdata", true},
+		{"Other version", "GKE\x05\x00\x00\x00\x40\x00\x00\x00\x01\x01\x02saltdata", false},
+		{"Short header", "GKE\x01\x00\x00\x00\x40", false},
+		{"Invalid parameters", "GKE\x01\x00\x00\x00\x40\x00\x00\x00\x00\x01\x02saltdata", false},
+		{"Too much memory", "GKE\x01\xff\x00\x00\x00\x00\x00\x00\x01\x01\x02saltdata", false},
+		{"Too many iterations", "GKE\x01\x00\x00\x00\x40\xff\xff\xff\xff\x01\x02saltdata", false},
+		{"Too much parallelism", "GKE\x01\x00\x00\x40\x00\x00\x00\x00\x01\xff\x02saltdata", false},
+		{"Short salt", "GKE\x01\x00\x00\x00\x40\x00\x00\x00\x01\x01\xffsalt", false},
+	}
+
+	for _, test := range tc {
+		t.Log(test.name)
+		_, salt, params, cipherText, ok := parseHeader([]byte(test.data))
+		assert.Equal(t, test.ok, ok)
+		if ok {
+			assert.Equal(t, []byte("sa"), salt)
+			assert.Equal(t, PasswordParams{Memory: 64, Iterations: 1, Parallelism: 1}, params)
+			assert.Equal(t, []byte("ltdata"), cipherText)
+		}
+	}
+}
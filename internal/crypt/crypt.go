@@ -71,30 +71,28 @@ func AES256CBCEncode(plainText []byte, key string) ([]byte, error) {
 	return cipherText, nil
 }
 
-// AES256CBCDecode decrypt cipher text string into plain text string
+// AES256CBCDecode decrypt cipher text string into plain text string.
+// ErrMalformed is returned if cipher text has wrong size, ErrDecrypt if its padding is wrong.
 func AES256CBCDecode(cipherText []byte, key string) ([]byte, error) {
 	bKey := getMD5Hash(key)
 
 	block, err := aes.NewCipher(bKey)
 	if err != nil {
-		log.Panic(err)
+		return nil, err
 	}
 
-	if len(cipherText) < aes.BlockSize {
-		log.Panic("cipherText too short")
-	}
-	iv := cipherText[:aes.BlockSize]
-	cipherText = cipherText[aes.BlockSize:]
-	if len(cipherText)%aes.BlockSize != 0 {
-		log.Panic("cipherText is not a multiple of the block size")
+	if len(cipherText) < 2*aes.BlockSize || len(cipherText)%aes.BlockSize != 0 {
+		return nil, ErrMalformed
 	}
 
-	mode := cipher.NewCBCDecrypter(block, iv)
-	mode.CryptBlocks(cipherText, cipherText)
+	// Cipher text isn't changed in place, it may be data of record
+	plainText := make([]byte, len(cipherText)-aes.BlockSize)
+	mode := cipher.NewCBCDecrypter(block, cipherText[:aes.BlockSize])
+	mode.CryptBlocks(plainText, cipherText[aes.BlockSize:])
 
-	cipherText, err = pkcs7pad.Unpad(cipherText)
+	plainText, err = pkcs7pad.Unpad(plainText)
 	if err != nil {
-		return nil, fmt.Errorf("error in Unpad function: %w", err)
+		return nil, ErrDecrypt
 	}
-	return cipherText, nil
+	return plainText, nil
 }
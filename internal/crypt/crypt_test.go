@@ -44,10 +44,17 @@ func TestAES_second(t *testing.T) {
 			
 			_, err = AES256CBCDecode(encrypted, key2)
 			if err != nil {
-				assert.Equal(t, "error in Unpad function: pkcs7pad: bad padding", err.Error())
+				assert.Equal(t, ErrDecrypt, err)
 			}
 		}
 	})
+
+	t.Run("Malformed cipher text", func(t *testing.T) {
+		for _, cipherText := range [][]byte{nil, make([]byte, 16), make([]byte, 40)} {
+			_, err := AES256CBCDecode(cipherText, "mytestkey")
+			assert.Equal(t, ErrMalformed, err)
+		}
+	})
 }
 
 func Test_GenerateRandom(t *testing.T) {
@@ -0,0 +1,77 @@
+package crypt
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+)
+
+// Envelope encryption: data of record is encrypted with its own random data key, and data key is wrapped
+// with key encryption key derived from AES key. Key rotation rewraps only data keys, data isn't changed.
+
+// DataKeySize is size of data key in bytes.
+const DataKeySize = keySize
+
+// envelopeHeader is header of data encrypted with data key.
+var envelopeHeader = []byte{headerMagic[0], headerMagic[1], headerMagic[2], formatEnvelope}
+
+// keyEncryptionKey returns key encryption key for derived key, so data keys aren't wrapped with key of data.
+func keyEncryptionKey(key []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("gophkeeper-key-encryption-key"))
+
+	return mac.Sum(nil)
+}
+
+// NewDataKey returns random data key.
+func NewDataKey() ([]byte, error) {
+	return GenerateRand(DataKeySize)
+}
+
+// WrapKey encrypts data key with key encryption key, header with derivation parameters is prepended.
+// Associated data isn't saved, but the same one is required to unwrap key.
+func (c *Cipher) WrapKey(dataKey, associatedData []byte) ([]byte, error) {
+	return c.seal(formatKey, dataKey, associatedData)
+}
+
+// UnwrapKey decrypts data key wrapped by WrapKey with key derived with parameters from its header.
+func (c *Cipher) UnwrapKey(wrapped, associatedData []byte) ([]byte, error) {
+	version, salt, params, cipherText, ok := parseHeader(wrapped)
+	if !ok || version != formatKey {
+		return nil, ErrMalformed
+	}
+
+	header := wrapped[:len(wrapped)-len(cipherText)]
+	dataKey, err := openGCM(keyEncryptionKey(c.key(salt, params)), header, cipherText, associatedData)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(dataKey) != DataKeySize {
+		return nil, ErrMalformed
+	}
+
+	return dataKey, nil
+}
+
+// IsEnvelope checks if data is encrypted with data key.
+func IsEnvelope(data []byte) bool {
+	return len(data) >= len(envelopeHeader) && string(data[:len(envelopeHeader)]) == string(envelopeHeader)
+}
+
+// SealData encrypts data with data key by AES-256-GCM.
+func SealData(dataKey, plainText, associatedData []byte) ([]byte, error) {
+	if len(dataKey) != DataKeySize {
+		return nil, ErrMalformed
+	}
+
+	return sealGCM(dataKey, envelopeHeader, plainText, associatedData)
+}
+
+// OpenData decrypts data encrypted by SealData.
+func OpenData(dataKey, data, associatedData []byte) ([]byte, error) {
+	if !IsEnvelope(data) || len(dataKey) != DataKeySize {
+		return nil, ErrMalformed
+	}
+
+	return openGCM(dataKey, envelopeHeader, data[len(envelopeHeader):], associatedData)
+}
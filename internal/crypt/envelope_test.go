@@ -0,0 +1,68 @@
+package crypt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnvelope(t *testing.T) {
+	params := PasswordParams{Memory: 64, Iterations: 1, Parallelism: 1}
+	salt := []byte("0123456789abcdef")
+	ad := []byte("record")
+	c := NewCipher("masterkey", salt, params)
+
+	dataKey, err := NewDataKey()
+	assert.NoError(t, err)
+	assert.Len(t, dataKey, DataKeySize)
+
+	// Data is encrypted with data key
+	data, err := SealData(dataKey, []byte("hello!"), ad)
+	assert.NoError(t, err)
+	assert.True(t, IsEnvelope(data))
+
+	plainText, err := OpenData(dataKey, data, ad)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("hello!"), plainText)
+
+	_, err = OpenData(dataKey, data, []byte("other record"))
+	assert.Equal(t, ErrDecrypt, err)
+	_, _, err = c.Decrypt(data, ad)
+	assert.Equal(t, ErrMalformed, err, "data key is required")
+
+	// Data key is wrapped with key encryption key, not with key of data
+	wrapped, err := c.WrapKey(dataKey, ad)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(wrapped), string(dataKey))
+
+	unwrapped, err := c.UnwrapKey(wrapped, ad)
+	assert.NoError(t, err)
+	assert.Equal(t, dataKey, unwrapped)
+
+	_, _, err = c.Decrypt(wrapped, ad)
+	assert.Equal(t, ErrMalformed, err)
+	_, err = c.UnwrapKey(wrapped, []byte("other record"))
+	assert.Equal(t, ErrDecrypt, err)
+	_, err = NewCipher("otherkey", salt, params).UnwrapKey(wrapped, ad)
+	assert.Equal(t, ErrDecrypt, err)
+
+	encrypted, err := c.Encrypt(dataKey, ad)
+	assert.NoError(t, err)
+	_, err = c.UnwrapKey(encrypted, ad)
+	assert.Equal(t, ErrMalformed, err)
+
+	// Rewrapped key opens the same data
+	other := NewCipher("otherkey", salt, params)
+	rewrapped, err := other.WrapKey(unwrapped, ad)
+	assert.NoError(t, err)
+	unwrapped, err = other.UnwrapKey(rewrapped, ad)
+	assert.NoError(t, err)
+	plainText, err = OpenData(unwrapped, data, ad)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("hello!"), plainText)
+
+	_, err = SealData([]byte("short"), []byte("hello!"), ad)
+	assert.Equal(t, ErrMalformed, err)
+	_, err = OpenData(dataKey, []byte("GKE"), ad)
+	assert.Equal(t, ErrMalformed, err)
+}
@@ -0,0 +1,144 @@
+package crypt
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/impr0ver/gophKeeper/internal/userdata"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// Errors of password verification.
+var (
+	ErrWrongPassword = errors.New("wrong password")
+	ErrPasswordHash  = errors.New("unknown password hash format")
+)
+
+// Argon2id hash parameters.
+const (
+	argon2Prefix  = "$argon2id$"
+	passwordSalt  = 16
+	passwordKey   = 32
+	legacyHashLen = 64
+)
+
+// PasswordParams are cost parameters of Argon2id password hash.
+type PasswordParams struct {
+	// Memory in KiB.
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+}
+
+// DefaultPasswordParams are recommended by RFC 9106 for memory-constrained environments.
+var DefaultPasswordParams = PasswordParams{Memory: 64 * 1024, Iterations: 3, Parallelism: 4}
+
+// Valid checks if parameters may be used for hashing.
+func (p PasswordParams) Valid() bool {
+	return p.Iterations > 0 && p.Parallelism > 0 && p.Memory >= 8*uint32(p.Parallelism)
+}
+
+// Bounded checks if parameters are valid and their cost is limited, parameters of untrusted data must be bounded.
+func (p PasswordParams) Bounded() bool {
+	return p.Valid() && p.Memory <= maxKeyMemory && p.Iterations <= maxKeyIterations && p.Parallelism <= maxKeyParallelism
+}
+
+// HashPassword makes salted Argon2id hash of password encoded with its parameters:
+// $argon2id$v=19$m=65536,t=3,p=4$salt$hash.
+func HashPassword(password string, params PasswordParams) (string, error) {
+	if !params.Valid() {
+		return "", fmt.Errorf("invalid password hash parameters %+v", params)
+	}
+
+	salt, err := GenerateRand(passwordSalt)
+	if err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, passwordKey)
+
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", argon2Prefix, argon2.Version,
+		params.Memory, params.Iterations, params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// VerifyPassword checks password of credentials against saved SRP-6a verifier or hash.
+// Argon2id and legacy unsalted SHA-256 hashes are accepted too. If saved one isn't SRP verifier or has other parameters,
+// new SRP verifier of password is returned to replace saved one, otherwise rehash is empty.
+func VerifyPassword(credentials userdata.UserCredentials, hash string, params PasswordParams) (rehash string, err error) {
+	if IsSRPVerifier(hash) {
+		saved, err := verifySRPPassword(credentials, hash)
+		if err != nil {
+			return "", err
+		}
+
+		if saved != params {
+			return NewSRPVerifier(credentials, params)
+		}
+
+		return "", nil
+	}
+
+	if !strings.HasPrefix(hash, argon2Prefix) {
+		if len(hash) != legacyHashLen {
+			return "", ErrPasswordHash
+		}
+
+		if subtle.ConstantTimeCompare([]byte(hash), []byte(PasswordHash(credentials))) != 1 {
+			return "", ErrWrongPassword
+		}
+
+		return NewSRPVerifier(credentials, params)
+	}
+
+	saved, salt, key, err := parsePasswordHash(hash)
+	if err != nil {
+		return "", err
+	}
+
+	other := argon2.IDKey([]byte(credentials.Password), salt, saved.Iterations, saved.Memory, saved.Parallelism, uint32(len(key)))
+	if subtle.ConstantTimeCompare(key, other) != 1 {
+		return "", ErrWrongPassword
+	}
+
+	return NewSRPVerifier(credentials, params)
+}
+
+// parsePasswordHash decodes parameters, salt and key of Argon2id hash.
+func parsePasswordHash(hash string) (PasswordParams, []byte, []byte, error) {
+	var (
+		params  PasswordParams
+		version int
+	)
+
+	parts := strings.Split(strings.TrimPrefix(hash, argon2Prefix), "$")
+	if len(parts) != 4 {
+		return params, nil, nil, ErrPasswordHash
+	}
+
+	if _, err := fmt.Sscanf(parts[0], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, ErrPasswordHash
+	}
+
+	if _, err := fmt.Sscanf(parts[1], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil || !params.Valid() {
+		return params, nil, nil, ErrPasswordHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return params, nil, nil, ErrPasswordHash
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, ErrPasswordHash
+	}
+
+	return params, salt, key, nil
+}
@@ -0,0 +1,66 @@
+package crypt
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/impr0ver/gophKeeper/internal/userdata"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPasswordHash(t *testing.T) {
+	params := PasswordParams{Memory: 64, Iterations: 1, Parallelism: 1}
+	credentials := userdata.UserCredentials{Login: "user", Password: "password"}
+
+	hash, err := HashPassword(credentials.Password, params)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(hash, "$argon2id$v=19$m=64,t=1,p=1$"))
+
+	other, err := HashPassword(credentials.Password, params)
+	assert.NoError(t, err)
+	assert.NotEqual(t, hash, other, "hash is salted")
+
+	_, err = HashPassword(credentials.Password, PasswordParams{})
+	assert.Error(t, err)
+
+	verifier, err := NewSRPVerifier(credentials, params)
+	assert.NoError(t, err)
+
+	// Saved hashes are replaced by SRP verifiers
+	tc := []struct {
+		name        string
+		credentials userdata.UserCredentials
+		hash        string
+		params      PasswordParams
+		rehash      bool
+		err         error
+	}{
+		{"Valid password", credentials, verifier, params, false, nil},
+		{"Wrong password", userdata.UserCredentials{Login: "user", Password: "wrong"}, verifier, params, false, ErrWrongPassword},
+		{"Parameters of verifier are changed", credentials, verifier, PasswordParams{Memory: 128, Iterations: 1, Parallelism: 1}, true, nil},
+		{"Argon2id hash", credentials, hash, params, true, nil},
+		{"Broken verifier", credentials, "$srp6a$m=64,t=1,p=1$salt", params, false, ErrPasswordHash},
+		{"Wrong password with Argon2id hash", userdata.UserCredentials{Login: "user", Password: "wrong"}, hash, params, false, ErrWrongPassword},
+		{"Legacy hash", credentials, PasswordHash(credentials), params, true, nil},
+		{"Wrong password with legacy hash", userdata.UserCredentials{Login: "user", Password: "wrong"}, PasswordHash(credentials), params, false, ErrWrongPassword},
+		{"Unknown hash", credentials, "password", params, false, ErrPasswordHash},
+		{"Broken hash", credentials, "$argon2id$v=19$m=64,t=1,p=1$salt", params, false, ErrPasswordHash},
+		{"Other version", credentials, strings.Replace(hash, "v=19", "v=16", 1), params, false, ErrPasswordHash},
+	}
+
+	for _, test := range tc {
+		t.Log(test.name)
+		rehash, err := VerifyPassword(test.credentials, test.hash, test.params)
+		assert.Equal(t, test.err, err)
+		if !test.rehash {
+			assert.Empty(t, rehash)
+			continue
+		}
+
+		assert.True(t, IsSRPVerifier(rehash))
+		rehash, err = VerifyPassword(test.credentials, rehash, test.params)
+		assert.NoError(t, err)
+		assert.Empty(t, rehash)
+	}
+}
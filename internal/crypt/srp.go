@@ -0,0 +1,348 @@
+package crypt
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/impr0ver/gophKeeper/internal/userdata"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// SRP-6a errors.
+var (
+	ErrSRPPublic = errors.New("invalid SRP public value")
+	ErrSRPServer = errors.New("server proof is wrong")
+)
+
+// SRP-6a parameters: 2048-bit group of RFC 5054 with SHA-256.
+// Private key x is Argon2id of login and password, so verifier is as hard to brute force as password hash.
+const (
+	srpPrefix  = "$srp6a$"
+	srpSalt    = 16
+	srpKey     = 32
+	srpPrivate = 32
+	srpGroup   = "AC6BDB41324A9A9BF166DE5E1389582FAF72B6651987EE07FC3192943DB56050A37329CBB4A099ED8193E0757767A13DD52312AB4B03310DCD7F48A9DA04FD50E8083969EDB767B0CF6095179A163AB3661A05FBD5FAAAE82918A9962F0B93B855F97993EC975EEAA80D740ADBF4FF747359D041D5C33EA71D281E446B14773BCA97B43A23FB801676BD207A436C6481F1D2B9078717461A5B9D32E688F87748544523B524B0D57D5EA77A2775D2ECFA032CFBDBF52FB3786160279004E57AE6AF874E7303CE53299CCC041C7BC308D82A5698F3A8D0C38271AE35F8E9DBFBB694B5C803D89F7AE435DE236D525F54759B65E372FCD68EF20FA7111F9E4AFF73"
+)
+
+var (
+	srpN, _ = new(big.Int).SetString(srpGroup, 16)
+	srpG    = big.NewInt(2)
+	srpK    = new(big.Int).SetBytes(srpHash(srpN.Bytes(), srpPad(srpG)))
+)
+
+// SRPChallenge is sent by server in reply to public value of client.
+type SRPChallenge struct {
+	Salt   []byte
+	Params PasswordParams
+	// B is public value of server.
+	B []byte
+}
+
+// srpHash returns SHA-256 of concatenated parts.
+func srpHash(parts ...[]byte) []byte {
+	h := sha256.New()
+	for _, part := range parts {
+		h.Write(part)
+	}
+
+	return h.Sum(nil)
+}
+
+// srpPad returns number padded to length of group modulus.
+func srpPad(x *big.Int) []byte {
+	return x.FillBytes(make([]byte, (srpN.BitLen()+7)/8))
+}
+
+// srpPrivateKey derives private key x of user from login and password.
+func srpPrivateKey(credentials userdata.UserCredentials, salt []byte, params PasswordParams) *big.Int {
+	key := argon2.IDKey([]byte(credentials.Login+":"+credentials.Password), salt, params.Iterations, params.Memory, params.Parallelism, srpKey)
+
+	return new(big.Int).SetBytes(key)
+}
+
+// srpRandom returns random private value.
+func srpRandom() (*big.Int, error) {
+	buf, err := GenerateRand(srpPrivate)
+	if err != nil {
+		return nil, err
+	}
+
+	return new(big.Int).SetBytes(buf), nil
+}
+
+// srpPublic parses public value of other side, it must be in range (0, N).
+func srpPublic(value []byte) (*big.Int, error) {
+	public := new(big.Int).SetBytes(value)
+	if public.Sign() == 0 || public.Cmp(srpN) >= 0 {
+		return nil, ErrSRPPublic
+	}
+
+	return public, nil
+}
+
+// srpScramble returns scrambling parameter u of public values, it must not be zero.
+func srpScramble(a, b *big.Int) (*big.Int, error) {
+	u := new(big.Int).SetBytes(srpHash(srpPad(a), srpPad(b)))
+	if u.Sign() == 0 {
+		return nil, ErrSRPPublic
+	}
+
+	return u, nil
+}
+
+// srpProofs returns proof of client and proof of server for shared secret.
+func srpProofs(a, b, secret *big.Int) (clientProof []byte, serverProof []byte) {
+	key := srpHash(srpPad(secret))
+	clientProof = srpHash(srpPad(a), srpPad(b), key)
+
+	return clientProof, srpHash(srpPad(a), clientProof, key)
+}
+
+// NewSRPVerifier makes SRP-6a verifier of password with random salt encoded with its parameters:
+// $srp6a$m=65536,t=3,p=4$salt$verifier. Server saves verifier and can't get password from it.
+func NewSRPVerifier(credentials userdata.UserCredentials, params PasswordParams) (string, error) {
+	if !params.Valid() {
+		return "", fmt.Errorf("invalid password hash parameters %+v", params)
+	}
+
+	salt, err := GenerateRand(srpSalt)
+	if err != nil {
+		return "", err
+	}
+
+	x := srpPrivateKey(credentials, salt, params)
+
+	return encodeSRPVerifier(params, salt, new(big.Int).Exp(srpG, x, srpN)), nil
+}
+
+// DummySRPVerifier makes verifier with salt, which matches no password.
+// Server answers with it for unknown logins, so they can't be told from known ones.
+func DummySRPVerifier(salt []byte, params PasswordParams) (string, error) {
+	x, err := srpRandom()
+	if err != nil {
+		return "", err
+	}
+
+	return encodeSRPVerifier(params, salt, new(big.Int).Exp(srpG, x, srpN)), nil
+}
+
+// IsSRPVerifier checks if saved hash is SRP-6a verifier.
+func IsSRPVerifier(hash string) bool {
+	return strings.HasPrefix(hash, srpPrefix)
+}
+
+// encodeSRPVerifier encodes verifier with its salt and parameters.
+func encodeSRPVerifier(params PasswordParams, salt []byte, verifier *big.Int) string {
+	return fmt.Sprintf("%sm=%d,t=%d,p=%d$%s$%s", srpPrefix,
+		params.Memory, params.Iterations, params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(verifier.Bytes()),
+	)
+}
+
+// parseSRPVerifier decodes parameters, salt and verifier.
+func parseSRPVerifier(hash string) (PasswordParams, []byte, *big.Int, error) {
+	var params PasswordParams
+
+	parts := strings.Split(strings.TrimPrefix(hash, srpPrefix), "$")
+	if !IsSRPVerifier(hash) || len(parts) != 3 {
+		return params, nil, nil, ErrPasswordHash
+	}
+
+	if _, err := fmt.Sscanf(parts[0], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil || !params.Valid() {
+		return params, nil, nil, ErrPasswordHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return params, nil, nil, ErrPasswordHash
+	}
+
+	data, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return params, nil, nil, ErrPasswordHash
+	}
+
+	verifier, err := srpPublic(data)
+	if err != nil {
+		return params, nil, nil, ErrPasswordHash
+	}
+
+	return params, salt, verifier, nil
+}
+
+// ValidSRPVerifier checks verifier sent by client on registration. Server checks password against verifier
+// on legacy login, so cost of its parameters mustn't exceed limit, which is cost of server own hashes.
+func ValidSRPVerifier(hash string, limit PasswordParams) bool {
+	params, _, _, err := parseSRPVerifier(hash)
+
+	return err == nil && params.Bounded() &&
+		params.Memory <= limit.Memory && params.Iterations <= limit.Iterations && params.Parallelism <= limit.Parallelism
+}
+
+// verifySRPPassword checks password against SRP-6a verifier.
+func verifySRPPassword(credentials userdata.UserCredentials, hash string) (PasswordParams, error) {
+	params, salt, verifier, err := parseSRPVerifier(hash)
+	if err != nil {
+		return params, err
+	}
+
+	// Verifiers saved before their parameters were checked may be too expensive
+	if !params.Bounded() {
+		return params, ErrPasswordHash
+	}
+
+	other := new(big.Int).Exp(srpG, srpPrivateKey(credentials, salt, params), srpN)
+	if subtle.ConstantTimeCompare(srpPad(verifier), srpPad(other)) != 1 {
+		return params, ErrWrongPassword
+	}
+
+	return params, nil
+}
+
+// SRPServer is server side of one SRP-6a login.
+type SRPServer struct {
+	salt     []byte
+	params   PasswordParams
+	verifier *big.Int
+	a        *big.Int
+	b        *big.Int
+	public   *big.Int
+}
+
+// NewSRPServer starts login of user with saved verifier, a is public value of client.
+func NewSRPServer(hash string, a []byte) (*SRPServer, error) {
+	params, salt, verifier, err := parseSRPVerifier(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	clientPublic, err := srpPublic(a)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := srpRandom()
+	if err != nil {
+		return nil, err
+	}
+
+	// B = k*v + g^b
+	public := new(big.Int).Mul(srpK, verifier)
+	public.Add(public, new(big.Int).Exp(srpG, b, srpN))
+	public.Mod(public, srpN)
+
+	return &SRPServer{
+		salt:     salt,
+		params:   params,
+		verifier: verifier,
+		a:        clientPublic,
+		b:        b,
+		public:   public,
+	}, nil
+}
+
+// Challenge returns salt, parameters and public value of server for client.
+func (s *SRPServer) Challenge() SRPChallenge {
+	return SRPChallenge{
+		Salt:   s.salt,
+		Params: s.params,
+		B:      srpPad(s.public),
+	}
+}
+
+// Verify checks proof of client and returns proof of server, ErrWrongPassword is returned if password of client is wrong.
+func (s *SRPServer) Verify(clientProof []byte) ([]byte, error) {
+	u, err := srpScramble(s.a, s.public)
+	if err != nil {
+		return nil, err
+	}
+
+	// S = (A * v^u)^b
+	secret := new(big.Int).Exp(s.verifier, u, srpN)
+	secret.Mul(secret, s.a)
+	secret.Exp(secret, s.b, srpN)
+
+	expected, serverProof := srpProofs(s.a, s.public, secret)
+	if subtle.ConstantTimeCompare(expected, clientProof) != 1 {
+		return nil, ErrWrongPassword
+	}
+
+	return serverProof, nil
+}
+
+// SRPClient is client side of one SRP-6a login.
+type SRPClient struct {
+	a           *big.Int
+	public      *big.Int
+	serverProof []byte
+}
+
+// NewSRPClient starts login with random private value.
+func NewSRPClient() (*SRPClient, error) {
+	a, err := srpRandom()
+	if err != nil {
+		return nil, err
+	}
+
+	return &SRPClient{
+		a:      a,
+		public: new(big.Int).Exp(srpG, a, srpN),
+	}, nil
+}
+
+// Public returns public value A, which is sent to server.
+func (c *SRPClient) Public() []byte {
+	return srpPad(c.public)
+}
+
+// Proof returns proof of password for challenge of server, password itself isn't sent.
+func (c *SRPClient) Proof(credentials userdata.UserCredentials, challenge SRPChallenge) ([]byte, error) {
+	// Parameters are limited, so server can't exhaust memory of client
+	if !challenge.Params.Bounded() {
+		return nil, ErrSRPPublic
+	}
+
+	serverPublic, err := srpPublic(challenge.B)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := srpScramble(c.public, serverPublic)
+	if err != nil {
+		return nil, err
+	}
+
+	x := srpPrivateKey(credentials, challenge.Salt, challenge.Params)
+
+	// S = (B - k*g^x)^(a + u*x)
+	base := new(big.Int).Exp(srpG, x, srpN)
+	base.Mul(base, srpK)
+	base.Sub(serverPublic, base)
+	base.Mod(base, srpN)
+
+	exp := new(big.Int).Mul(u, x)
+	exp.Add(exp, c.a)
+
+	secret := new(big.Int).Exp(base, exp, srpN)
+
+	clientProof, serverProof := srpProofs(c.public, serverPublic, secret)
+	c.serverProof = serverProof
+
+	return clientProof, nil
+}
+
+// VerifyServer checks proof of server, so client knows that server has verifier of user.
+func (c *SRPClient) VerifyServer(serverProof []byte) error {
+	if c.serverProof == nil || subtle.ConstantTimeCompare(c.serverProof, serverProof) != 1 {
+		return ErrSRPServer
+	}
+
+	return nil
+}
@@ -0,0 +1,91 @@
+package crypt
+
+import (
+	"math"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/impr0ver/gophKeeper/internal/userdata"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSRP(t *testing.T) {
+	params := PasswordParams{Memory: 64, Iterations: 1, Parallelism: 1}
+	credentials := userdata.UserCredentials{Login: "user", Password: "password"}
+
+	verifier, err := NewSRPVerifier(credentials, params)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(verifier, "$srp6a$m=64,t=1,p=1$"))
+	assert.True(t, ValidSRPVerifier(verifier, params))
+	assert.True(t, ValidSRPVerifier(verifier, DefaultPasswordParams))
+	assert.False(t, ValidSRPVerifier(verifier, PasswordParams{Memory: 32, Iterations: 1, Parallelism: 1}), "more memory than limit")
+
+	dummy, err := DummySRPVerifier([]byte("salt"), params)
+	assert.NoError(t, err)
+
+	tc := []struct {
+		name        string
+		credentials userdata.UserCredentials
+		verifier    string
+		err         error
+	}{
+		{"Valid password", credentials, verifier, nil},
+		{"Wrong password", userdata.UserCredentials{Login: "user", Password: "wrong"}, verifier, ErrWrongPassword},
+		{"Other login", userdata.UserCredentials{Login: "other", Password: "password"}, verifier, ErrWrongPassword},
+		{"Dummy verifier", credentials, dummy, ErrWrongPassword},
+	}
+
+	for _, test := range tc {
+		t.Log(test.name)
+		client, err := NewSRPClient()
+		assert.NoError(t, err)
+
+		server, err := NewSRPServer(test.verifier, client.Public())
+		assert.NoError(t, err)
+
+		proof, err := client.Proof(test.credentials, server.Challenge())
+		assert.NoError(t, err)
+
+		serverProof, err := server.Verify(proof)
+		assert.Equal(t, test.err, err)
+		if err == nil {
+			assert.NoError(t, client.VerifyServer(serverProof))
+			assert.Equal(t, ErrSRPServer, client.VerifyServer(proof))
+		}
+	}
+
+	// Public values must be in range (0, N)
+	for _, public := range [][]byte{nil, {0}, srpN.Bytes(), new(big.Int).Add(srpN, big.NewInt(1)).Bytes()} {
+		_, err := NewSRPServer(verifier, public)
+		assert.Equal(t, ErrSRPPublic, err)
+
+		client, err := NewSRPClient()
+		assert.NoError(t, err)
+		_, err = client.Proof(credentials, SRPChallenge{Params: params, B: public})
+		assert.Equal(t, ErrSRPPublic, err)
+	}
+
+	client, err := NewSRPClient()
+	assert.NoError(t, err)
+	_, err = client.Proof(credentials, SRPChallenge{Params: PasswordParams{Memory: maxKeyMemory + 1, Iterations: 1, Parallelism: 1}, B: []byte{1}})
+	assert.Equal(t, ErrSRPPublic, err, "too much memory")
+	_, err = client.Proof(credentials, SRPChallenge{Params: PasswordParams{Memory: 64, Iterations: maxKeyIterations + 1, Parallelism: 1}, B: []byte{1}})
+	assert.Equal(t, ErrSRPPublic, err, "too many iterations")
+	assert.Equal(t, ErrSRPServer, client.VerifyServer(nil))
+
+	_, err = NewSRPServer("$argon2id$v=19$m=64,t=1,p=1$salt$hash", client.Public())
+	assert.Equal(t, ErrPasswordHash, err)
+
+	for _, hash := range []string{"$srp6a$m=64,t=1,p=1$salt", "$srp6a$m=0,t=1,p=1$c2FsdA$AQ", "$srp6a$m=64,t=1,p=1$c2FsdA$AA"} {
+		assert.False(t, ValidSRPVerifier(hash, params), hash)
+	}
+
+	// Costly parameters are rejected on registration and login
+	limit := PasswordParams{Memory: maxKeyMemory, Iterations: math.MaxUint32, Parallelism: math.MaxUint8}
+	costly := strings.Replace(verifier, "t=1,", "t=1000000,", 1)
+	assert.False(t, ValidSRPVerifier(costly, limit))
+	_, err = VerifyPassword(credentials, costly, params)
+	assert.Equal(t, ErrPasswordHash, err)
+}
@@ -0,0 +1,225 @@
+package csvimport
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/impr0ver/gophKeeper/internal/userdata"
+)
+
+// Format is browser, which exported passwords to CSV.
+type Format string
+
+// Supported CSV formats.
+const (
+	FormatChrome  Format = "Chrome"
+	FormatFirefox Format = "Firefox"
+	FormatSafari  Format = "Safari"
+)
+
+// Import errors.
+var (
+	ErrFormat        = errors.New("unknown CSV format, export passwords from Chrome, Firefox or Safari")
+	ErrEmptyPassword = errors.New("password is empty")
+	ErrFieldCount    = errors.New("wrong number of fields")
+)
+
+// Row is login of CSV file, it isn't imported if Err is set.
+type Row struct {
+	// Line is line number of row in file.
+	Line     int
+	Title    string
+	URL      string
+	Username string
+	Password string
+	Notes    string
+	Err      error
+}
+
+// columns are indexes of known columns in header, -1 if column is missing.
+type columns struct {
+	title    int
+	url      int
+	username int
+	password int
+	notes    int
+}
+
+// detect finds format of CSV file and its columns by header.
+// Chrome: name,url,username,password,note. Firefox: url,username,password,httpRealm,formActionOrigin,guid,...
+// Safari: Title,URL,Username,Password,Notes,OTPAuth.
+func detect(header []string) (Format, columns, error) {
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if _, ok := index[name]; !ok {
+			index[name] = i
+		}
+	}
+
+	column := func(names ...string) int {
+		for _, name := range names {
+			if i, ok := index[name]; ok {
+				return i
+			}
+		}
+
+		return -1
+	}
+
+	cols := columns{
+		title:    column("name", "title"),
+		url:      column("url"),
+		username: column("username"),
+		password: column("password"),
+		notes:    column("note", "notes"),
+	}
+	if cols.url < 0 || cols.username < 0 || cols.password < 0 {
+		return "", cols, ErrFormat
+	}
+
+	switch {
+	case column("guid", "httprealm") >= 0:
+		return FormatFirefox, cols, nil
+	case column("title") >= 0:
+		return FormatSafari, cols, nil
+	case column("name") >= 0:
+		return FormatChrome, cols, nil
+	default:
+		return "", cols, ErrFormat
+	}
+}
+
+// Parse reads CSV export of browser passwords. Rows, which can't be imported, have error,
+// ErrFormat is returned if file isn't export of known browser.
+func Parse(r io.Reader) (Format, []Row, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return "", nil, ErrFormat
+	}
+
+	format, cols, err := detect(header)
+	if err != nil {
+		return "", nil, err
+	}
+
+	field := func(record []string, i int) string {
+		if i < 0 || i >= len(record) {
+			return ""
+		}
+
+		return record[i]
+	}
+
+	rows := make([]Row, 0)
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			rows = append(rows, Row{Line: parseErr.StartLine, Err: parseErr.Err})
+			continue
+		}
+		if err != nil {
+			return format, rows, err
+		}
+
+		line, _ := reader.FieldPos(0)
+		row := Row{
+			Line:     line,
+			Title:    strings.TrimSpace(field(record, cols.title)),
+			URL:      strings.TrimSpace(field(record, cols.url)),
+			Username: field(record, cols.username),
+			Password: field(record, cols.password),
+			Notes:    strings.TrimSpace(field(record, cols.notes)),
+		}
+
+		switch {
+		case len(record) != len(header):
+			row.Err = ErrFieldCount
+		case row.Password == "":
+			row.Err = ErrEmptyPassword
+		}
+
+		rows = append(rows, row)
+	}
+
+	return format, rows, nil
+}
+
+// Name returns title of row, URL is used if title is empty.
+func (r Row) Name() string {
+	if r.Title != "" {
+		return r.Title
+	}
+
+	return r.URL
+}
+
+// Record returns login and password record of row, title, URL and notes are its metadata.
+func (r Row) Record() userdata.Record {
+	parts := make([]string, 0, 3)
+	for _, part := range []string{r.Title, r.URL, r.Notes} {
+		if part != "" && (len(parts) == 0 || parts[len(parts)-1] != part) {
+			parts = append(parts, part)
+		}
+	}
+
+	loginAndPassword := userdata.LoginAndPassword{Login: r.Username, Password: r.Password}
+	data, _ := loginAndPassword.Bytes()
+
+	return userdata.Record{
+		Type:     userdata.TypeLoginAndPassword,
+		Metadata: strings.Join(parts, " | "),
+		Data:     data,
+	}
+}
+
+// RecordsCreator creates records with one request, it's implemented by client handlers.
+type RecordsCreator interface {
+	CreateRecords(records []userdata.Record) ([]userdata.RecordResult, error)
+}
+
+// Upload creates records of rows by batches and returns number of created records and rows,
+// which aren't created, with errors. Upload stops if request of batch fails.
+func Upload(creator RecordsCreator, rows []Row, batchSize int) (int, []Row, error) {
+	count := 0
+	failed := make([]Row, 0)
+	for len(rows) > 0 {
+		batch := rows
+		if len(batch) > batchSize {
+			batch = batch[:batchSize]
+		}
+		rows = rows[len(batch):]
+
+		records := make([]userdata.Record, 0, len(batch))
+		for _, row := range batch {
+			records = append(records, row.Record())
+		}
+
+		results, err := creator.CreateRecords(records)
+		if err != nil {
+			return count, failed, err
+		}
+
+		for i, result := range results {
+			if result.Err != nil && i < len(batch) {
+				row := batch[i]
+				row.Err = result.Err
+				failed = append(failed, row)
+				continue
+			}
+			count++
+		}
+	}
+
+	return count, failed, nil
+}
@@ -0,0 +1,149 @@
+package csvimport
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/impr0ver/gophKeeper/internal/storage"
+	"github.com/impr0ver/gophKeeper/internal/userdata"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tc := []struct {
+		name   string
+		csv    string
+		format Format
+		rows   []Row
+		err    error
+	}{
+		{
+			"Chrome",
+			"name,url,username,password,note\n" +
+				"example.com,https://example.com/,alice,secret,work\n" +
+				"empty.com,https://empty.com/,bob,,\n",
+			FormatChrome,
+			[]Row{
+				{Line: 2, Title: "example.com", URL: "https://example.com/", Username: "alice", Password: "secret", Notes: "work"},
+				{Line: 3, Title: "empty.com", URL: "https://empty.com/", Username: "bob", Err: ErrEmptyPassword},
+			},
+			nil,
+		},
+		{
+			"Firefox",
+			"\"url\",\"username\",\"password\",\"httpRealm\",\"formActionOrigin\",\"guid\",\"timeCreated\",\"timeLastUsed\",\"timePasswordChanged\"\n" +
+				"\"https://example.com\",\"alice\",\"pa,ss\",,\"https://example.com\",\"{1}\",\"1\",\"1\",\"1\"\n" +
+				"\"https://short.com\",\"bob\"\n",
+			FormatFirefox,
+			[]Row{
+				{Line: 2, URL: "https://example.com", Username: "alice", Password: "pa,ss"},
+				{Line: 3, URL: "https://short.com", Username: "bob", Err: ErrFieldCount},
+			},
+			nil,
+		},
+		{
+			"Safari with BOM",
+			"\ufeffTitle,URL,Username,Password,Notes,OTPAuth\n" +
+				"Example,https://example.com/,alice,secret,\"multi\nline\",\n",
+			FormatSafari,
+			[]Row{
+				{Line: 2, Title: "Example", URL: "https://example.com/", Username: "alice", Password: "secret", Notes: "multi\nline"},
+			},
+			nil,
+		},
+		{
+			"Unknown format",
+			"login,password\nalice,secret\n",
+			"",
+			nil,
+			ErrFormat,
+		},
+		{
+			"Empty file",
+			"",
+			"",
+			nil,
+			ErrFormat,
+		},
+	}
+
+	for _, test := range tc {
+		t.Log(test.name)
+		format, rows, err := Parse(strings.NewReader(test.csv))
+		assert.Equal(t, test.err, err)
+		assert.Equal(t, test.format, format)
+		assert.Equal(t, test.rows, rows)
+	}
+
+	// Row with broken quotes has error, next rows are parsed
+	_, rows, err := Parse(strings.NewReader("name,url,username,password\na,b\"c,d,e\nx,https://x.com,y,z\n"))
+	assert.NoError(t, err)
+	if assert.Len(t, rows, 2) {
+		assert.Error(t, rows[0].Err)
+		assert.NoError(t, rows[1].Err)
+		assert.Equal(t, "z", rows[1].Password)
+	}
+}
+
+func TestRow_Record(t *testing.T) {
+	row := Row{Title: "example.com", URL: "https://example.com/", Username: "alice", Password: "secret", Notes: "work"}
+	record := row.Record()
+	assert.Equal(t, userdata.TypeLoginAndPassword, record.Type)
+	assert.Equal(t, "example.com | https://example.com/ | work", record.Metadata)
+	assert.Equal(t, []byte("alice:secret"), record.Data)
+	assert.Equal(t, "example.com", row.Name())
+
+	row = Row{URL: "https://example.com", Username: "alice", Password: "secret"}
+	assert.Equal(t, "https://example.com", row.Record().Metadata)
+	assert.Equal(t, "https://example.com", row.Name())
+}
+
+// testCreator creates records in memory, records with empty metadata fail.
+type testCreator struct {
+	batches [][]userdata.Record
+	err     error
+}
+
+func (c *testCreator) CreateRecords(records []userdata.Record) ([]userdata.RecordResult, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	c.batches = append(c.batches, records)
+	results := make([]userdata.RecordResult, 0, len(records))
+	for _, record := range records {
+		result := userdata.RecordResult{ID: record.ID}
+		if record.Metadata == "" {
+			result.Err = storage.ErrQuotaExceeded
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+func TestUpload(t *testing.T) {
+	rows := []Row{
+		{Line: 2, URL: "https://a.com", Username: "a", Password: "1"},
+		{Line: 3, Username: "b", Password: "2"},
+		{Line: 4, URL: "https://c.com", Username: "c", Password: "3"},
+	}
+
+	creator := &testCreator{}
+	count, failed, err := Upload(creator, rows, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.Len(t, creator.batches, 2)
+	if assert.Len(t, failed, 1) {
+		assert.Equal(t, 3, failed[0].Line)
+		assert.Equal(t, storage.ErrQuotaExceeded, failed[0].Err)
+	}
+	assert.NoError(t, rows[1].Err)
+
+	errUnavailable := errors.New("unavailable")
+	count, _, err = Upload(&testCreator{err: errUnavailable}, rows, 2)
+	assert.Equal(t, errUnavailable, err)
+	assert.Equal(t, 0, count)
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/impr0ver/gophKeeper/internal/storage"
+	"github.com/impr0ver/gophKeeper/internal/userdata"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// admin struct for server administration handlers.
+type admin struct {
+	Storage storage.AdminStorager
+	Scrub   ScrubReporter
+	started time.Time
+}
+
+// newAdminHandlers returns administration handlers based on storage.
+func newAdminHandlers(storage storage.AdminStorager, scrub ScrubReporter) *admin {
+	return &admin{
+		Storage: storage,
+		Scrub:   scrub,
+		started: time.Now(),
+	}
+}
+
+// ListUsers gets all users with usage.
+func (a *admin) ListUsers(ctx context.Context) ([]userdata.UserInfo, error) {
+	return a.Storage.ListUsers(ctx)
+}
+
+// GetStats gets server stats.
+func (a *admin) GetStats(ctx context.Context) (userdata.ServerStats, error) {
+	stats, err := a.Storage.GetStats(ctx)
+	if err != nil {
+		return stats, err
+	}
+
+	stats.Uptime = time.Since(a.started)
+	if a.Scrub != nil {
+		stats.Scrub = a.Scrub.Stats()
+	}
+
+	return stats, nil
+}
+
+// IsAdmin checks if user has admin role.
+func (a *admin) IsAdmin(ctx context.Context, userID userdata.UserID) (bool, error) {
+	return a.Storage.IsAdmin(ctx, userID)
+}
+
+// SetUserAdmin grants or revokes admin role.
+func (a *admin) SetUserAdmin(ctx context.Context, login string, isAdmin bool) error {
+	if login == "" {
+		return emptyField("login")
+	}
+
+	log.Infof("admin: set admin role of %s to %t", login, isAdmin)
+
+	return a.Storage.SetUserAdmin(ctx, login, isAdmin)
+}
+
+// SetUserDisabled disables or enables user, disabled user is logged out.
+func (a *admin) SetUserDisabled(ctx context.Context, login string, disabled bool) error {
+	if login == "" {
+		return emptyField("login")
+	}
+
+	log.Infof("admin: set disabled of %s to %t", login, disabled)
+
+	return a.Storage.SetUserDisabled(ctx, login, disabled)
+}
+
+// SetUserQuota sets user quota in bytes, zero resets quota to server default.
+func (a *admin) SetUserQuota(ctx context.Context, login string, quota int64) error {
+	if login == "" {
+		return emptyField("login")
+	}
+
+	log.Infof("admin: set quota of %s to %d", login, quota)
+
+	return a.Storage.SetUserQuota(ctx, login, quota)
+}
+
+// LogoutUser invalidates all issued tokens of user.
+func (a *admin) LogoutUser(ctx context.Context, login string) error {
+	if login == "" {
+		return emptyField("login")
+	}
+
+	log.Infof("admin: logout %s", login)
+
+	return a.Storage.RevokeTokens(ctx, login)
+}
@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/impr0ver/gophKeeper/internal/logger"
+	pb "github.com/impr0ver/gophKeeper/internal/rpc"
+	"github.com/impr0ver/gophKeeper/internal/userdata"
+
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// AdminConnGRPC get connection with server admin service via gRPC.
+type AdminConnGRPC struct {
+	pb.AdminClient
+	gokeeper   pb.GokeeperClient
+	adminToken string
+	authToken  userdata.AuthToken
+	Mu         *sync.Mutex
+	// LegacyLogin allows to send password to server, if SRP login fails.
+	LegacyLogin bool
+}
+
+// newAdminClientConn connects to server admin service.
+func newAdminClientConn(serverAddress, clientCert, adminToken string) *AdminConnGRPC {
+	var sLogger = logger.NewSugarLogger()
+	tlsCredentials, err := clientLoadTLSCredentials(clientCert)
+	if err != nil {
+		log.Infof("cannot load TLS credentials: %v\n", err)
+		sLogger.Fatalf("cannot load TLS credentials: %v\n", err)
+	}
+
+	conn, err := grpc.NewClient("passthrough:///"+serverAddress,
+		grpc.WithTransportCredentials(tlsCredentials),
+		grpc.WithDefaultServiceConfig(retryServiceConfig(pb.Admin_ServiceDesc.ServiceName, pb.Gokeeper_ServiceDesc.ServiceName)))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return newAdminConn(conn, adminToken)
+}
+
+// newAdminConn returns admin client over gRPC connection.
+func newAdminConn(conn grpc.ClientConnInterface, adminToken string) *AdminConnGRPC {
+	return &AdminConnGRPC{
+		AdminClient: pb.NewAdminClient(conn),
+		gokeeper:    pb.NewGokeeperClient(conn),
+		adminToken:  adminToken,
+		Mu:          &sync.Mutex{},
+	}
+}
+
+// adminContext returns context with admin credentials.
+func (c *AdminConnGRPC) adminContext() context.Context {
+	c.Mu.Lock()
+	defer c.Mu.Unlock()
+
+	ctx := context.Background()
+	if c.adminToken != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "adminToken", c.adminToken)
+	}
+	if c.authToken != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authToken", string(c.authToken))
+	}
+
+	return ctx
+}
+
+// Login logins admin-role user by login and password with SRP-6a, token is used in next requests.
+func (c *AdminConnGRPC) Login(credentials userdata.UserCredentials) (string, error) {
+	token, err := loginSRP(c.gokeeper, credentials, c.LegacyLogin)
+	if err != nil {
+		return "", err
+	}
+
+	c.Mu.Lock()
+	defer c.Mu.Unlock()
+
+	c.authToken = userdata.AuthToken(token)
+
+	return token, nil
+}
+
+// ListUsers gets all users.
+func (c *AdminConnGRPC) ListUsers() ([]userdata.UserInfo, error) {
+	gotUsers, err := c.AdminClient.ListUsers(c.adminContext(), &emptypb.Empty{})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+
+	users := make([]userdata.UserInfo, 0, len(gotUsers.Users))
+
+	for _, user := range gotUsers.Users {
+		users = append(users, userdata.UserInfo{
+			ID:       userdata.UserID(user.Id),
+			Login:    user.Login,
+			Admin:    user.Admin,
+			Disabled: user.Disabled,
+			Usage: userdata.Usage{
+				Records: user.Records,
+				Bytes:   user.Usage,
+				Quota:   user.Quota,
+			},
+		})
+	}
+
+	return users, nil
+}
+
+// GetStats gets server stats.
+func (c *AdminConnGRPC) GetStats() (userdata.ServerStats, error) {
+	gotStats, err := c.AdminClient.GetStats(c.adminContext(), &emptypb.Empty{})
+	if err != nil {
+		return userdata.ServerStats{}, fromStatus(err)
+	}
+
+	stats := userdata.ServerStats{
+		Users:         gotStats.Users,
+		DisabledUsers: gotStats.DisabledUsers,
+		Admins:        gotStats.Admins,
+		Records:       gotStats.Records,
+		StoredBytes:   gotStats.StoredBytes,
+		RecordsByType: make(map[userdata.RecordType]int64, len(gotStats.RecordsByType)),
+		Uptime:        time.Duration(gotStats.UptimeSeconds) * time.Second,
+		Scrub: userdata.ScrubStats{
+			Runs:      gotStats.ScrubRuns,
+			LastRun:   fromTimestamp(gotStats.LastScrub),
+			Verified:  gotStats.ScrubbedRecords,
+			Corrupted: gotStats.CorruptedRecords,
+		},
+	}
+
+	for _, count := range gotStats.RecordsByType {
+		stats.RecordsByType[userdata.RecordType(count.Type)] = count.Count
+	}
+
+	return stats, nil
+}
+
+// SetUserAdmin grants or revokes admin role.
+func (c *AdminConnGRPC) SetUserAdmin(login string, admin bool) error {
+	_, err := c.AdminClient.SetUserAdmin(c.adminContext(), &pb.AdminUserFlag{Login: login, Value: admin})
+	return fromStatus(err)
+}
+
+// SetUserDisabled disables or enables user.
+func (c *AdminConnGRPC) SetUserDisabled(login string, disabled bool) error {
+	_, err := c.AdminClient.SetUserDisabled(c.adminContext(), &pb.AdminUserFlag{Login: login, Value: disabled})
+	return fromStatus(err)
+}
+
+// SetUserQuota sets user quota in bytes.
+func (c *AdminConnGRPC) SetUserQuota(login string, quota int64) error {
+	_, err := c.AdminClient.SetUserQuota(c.adminContext(), &pb.AdminUserQuota{Login: login, Quota: quota})
+	return fromStatus(err)
+}
+
+// ResetUserQuota resets user quota to server default.
+func (c *AdminConnGRPC) ResetUserQuota(login string) error {
+	_, err := c.AdminClient.ResetUserQuota(c.adminContext(), &pb.AdminUser{Login: login})
+	return fromStatus(err)
+}
+
+// LogoutUser invalidates all tokens of user.
+func (c *AdminConnGRPC) LogoutUser(login string) error {
+	_, err := c.AdminClient.LogoutUser(c.adminContext(), &pb.AdminUser{Login: login})
+	return fromStatus(err)
+}
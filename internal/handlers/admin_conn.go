@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"strings"
+
+	pb "github.com/impr0ver/gophKeeper/internal/rpc"
+	"github.com/impr0ver/gophKeeper/internal/storage"
+	"github.com/impr0ver/gophKeeper/internal/userdata"
+
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// adminServicePrefix is prefix of all admin service methods.
+const adminServicePrefix = "/rpc.Admin/"
+
+// AdminConn serves administration endpoints.
+type AdminConn struct {
+	pb.UnimplementedAdminServer
+	Handlers      AdminHandlers
+	Authenticator Authenticator
+	AdminToken    string
+}
+
+// NewAdminConn returns new admin service. Empty admin token disables token access, then only admin-role users are allowed.
+func NewAdminConn(handlers AdminHandlers, authenticator Authenticator, adminToken string) *AdminConn {
+	return &AdminConn{
+		Handlers:      handlers,
+		Authenticator: authenticator,
+		AdminToken:    adminToken,
+	}
+}
+
+// VerifyAdmin returns interceptor which checks admin token or admin role of user for admin service methods.
+func (a *AdminConn) VerifyAdmin() Interceptor {
+	return &adminInterceptor{admin: a}
+}
+
+// adminInterceptor checks admin access for admin service methods, other methods pass as is.
+type adminInterceptor struct {
+	admin *AdminConn
+}
+
+// Unary checks admin access of unary request.
+func (i *adminInterceptor) Unary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := i.admin.checkAccess(ctx, info.FullMethod); err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// Stream checks admin access of stream.
+func (i *adminInterceptor) Stream() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := i.admin.checkAccess(ss.Context(), info.FullMethod); err != nil {
+			return err
+		}
+
+		return handler(srv, ss)
+	}
+}
+
+// checkAccess checks admin token or admin role of user for admin service methods.
+func (a *AdminConn) checkAccess(ctx context.Context, method string) error {
+	if !strings.HasPrefix(method, adminServicePrefix) {
+		return nil
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	if a.AdminToken != "" && len(md.Get("adminToken")) > 0 &&
+		subtle.ConstantTimeCompare([]byte(md.Get("adminToken")[0]), []byte(a.AdminToken)) == 1 {
+		return nil
+	}
+
+	if len(md.Get("authToken")) > 0 {
+		userID, err := a.Authenticator.ValidateToken(userdata.AuthToken(md.Get("authToken")[0]))
+		if err != nil {
+			log.Warnf("%s :: %v", "admin validate token error", err)
+
+			return toStatus(storage.ErrUnauthenticated)
+		}
+
+		isAdmin, err := a.Handlers.IsAdmin(ctx, userID)
+		if err != nil && !errors.Is(err, storage.ErrUserNotFound) {
+			return toStatus(err)
+		}
+
+		if isAdmin {
+			return nil
+		}
+	}
+
+	log.Warnf("admin access denied to %s", method)
+
+	return toStatus(ErrPermissionDenied)
+}
+
+// ListUsers process list users endpoint.
+func (a *AdminConn) ListUsers(ctx context.Context, _ *emptypb.Empty) (*pb.UsersList, error) {
+	users, err := a.Handlers.ListUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	usersList := make([]*pb.UserInfo, 0, len(users))
+
+	for _, user := range users {
+		usersList = append(usersList, &pb.UserInfo{
+			Id:       string(user.ID),
+			Login:    user.Login,
+			Admin:    user.Admin,
+			Disabled: user.Disabled,
+			Records:  user.Usage.Records,
+			Usage:    user.Usage.Bytes,
+			Quota:    user.Usage.Quota,
+		})
+	}
+
+	return &pb.UsersList{Users: usersList}, nil
+}
+
+// GetStats process server stats endpoint.
+func (a *AdminConn) GetStats(ctx context.Context, _ *emptypb.Empty) (*pb.ServerStats, error) {
+	stats, err := a.Handlers.GetStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	byType := make([]*pb.TypeCount, 0, len(stats.RecordsByType))
+	for recordType, count := range stats.RecordsByType {
+		byType = append(byType, &pb.TypeCount{Type: pb.MessageType(recordType), Count: count})
+	}
+
+	return &pb.ServerStats{
+		Users:            stats.Users,
+		DisabledUsers:    stats.DisabledUsers,
+		Admins:           stats.Admins,
+		Records:          stats.Records,
+		StoredBytes:      stats.StoredBytes,
+		RecordsByType:    byType,
+		UptimeSeconds:    int64(stats.Uptime.Seconds()),
+		ScrubRuns:        stats.Scrub.Runs,
+		LastScrub:        toTimestamp(stats.Scrub.LastRun),
+		ScrubbedRecords:  stats.Scrub.Verified,
+		CorruptedRecords: stats.Scrub.Corrupted,
+	}, nil
+}
+
+// SetUserAdmin process grant or revoke admin role endpoint.
+func (a *AdminConn) SetUserAdmin(ctx context.Context, flag *pb.AdminUserFlag) (*emptypb.Empty, error) {
+	if err := a.Handlers.SetUserAdmin(ctx, flag.Login, flag.Value); err != nil {
+		return nil, err
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// SetUserDisabled process disable or enable user endpoint.
+func (a *AdminConn) SetUserDisabled(ctx context.Context, flag *pb.AdminUserFlag) (*emptypb.Empty, error) {
+	if err := a.Handlers.SetUserDisabled(ctx, flag.Login, flag.Value); err != nil {
+		return nil, err
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// SetUserQuota process set user quota endpoint.
+func (a *AdminConn) SetUserQuota(ctx context.Context, quota *pb.AdminUserQuota) (*emptypb.Empty, error) {
+	if err := a.Handlers.SetUserQuota(ctx, quota.Login, quota.Quota); err != nil {
+		return nil, err
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// ResetUserQuota process reset user quota to server default endpoint.
+func (a *AdminConn) ResetUserQuota(ctx context.Context, user *pb.AdminUser) (*emptypb.Empty, error) {
+	if err := a.Handlers.SetUserQuota(ctx, user.Login, 0); err != nil {
+		return nil, err
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// LogoutUser process force logout user endpoint.
+func (a *AdminConn) LogoutUser(ctx context.Context, user *pb.AdminUser) (*emptypb.Empty, error) {
+	if err := a.Handlers.LogoutUser(ctx, user.Login); err != nil {
+		return nil, err
+	}
+
+	return &emptypb.Empty{}, nil
+}
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/impr0ver/gophKeeper/internal/handlers/mocks"
+	"github.com/impr0ver/gophKeeper/internal/serverconfig"
+	"github.com/impr0ver/gophKeeper/internal/storage"
+	"github.com/impr0ver/gophKeeper/internal/userdata"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAdminConn(t *testing.T) {
+	var serverCfg = serverconfig.ServerConfig{}
+	serverCfg.ServerCert = "../../cmd/cert/server-cert.pem"
+	serverCfg.ServerKey = "../../cmd/cert/server-key.pem"
+	serverCfg.ListenAddr = "127.0.0.1:9000"
+
+	auth := mocks.NewAuthenticator(t)
+	handlers := mocks.NewServerHandlers(t)
+	adminHandlers := mocks.NewAdminHandlers(t)
+
+	server := NewServerConn(handlers, auth, serverCfg.ServerCert, serverCfg.ServerKey, serverCfg.ServerConsoleLog)
+	server.Admin = NewAdminConn(adminHandlers, auth, "secret")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	server.Start(ctx, serverCfg.ListenAddr)
+
+	tokenClient := newAdminClientConn(serverCfg.ListenAddr, "../../cmd/cert/ca-cert.pem", "secret")
+	wrongTokenClient := newAdminClientConn(serverCfg.ListenAddr, "../../cmd/cert/ca-cert.pem", "wrong")
+	userClient := newAdminClientConn(serverCfg.ListenAddr, "../../cmd/cert/ca-cert.pem", "")
+
+	tc := []struct {
+		name  string
+		mock  func()
+		valid func()
+	}{
+		{
+			"List users with admin token",
+			func() {
+				adminHandlers.On("ListUsers", mock.Anything).Return([]userdata.UserInfo{
+					{ID: "1", Login: "alice", Admin: true, Usage: userdata.Usage{Records: 1, Bytes: 10}},
+				}, nil).Once()
+			},
+			func() {
+				users, err := tokenClient.ListUsers()
+				assert.NoError(t, err)
+				assert.Equal(t, []userdata.UserInfo{
+					{ID: "1", Login: "alice", Admin: true, Usage: userdata.Usage{Records: 1, Bytes: 10}},
+				}, users)
+			},
+		},
+		{
+			"Get stats with wrong admin token",
+			func() {},
+			func() {
+				_, err := wrongTokenClient.GetStats()
+				assert.ErrorIs(t, err, ErrPermissionDenied)
+			},
+		},
+		{
+			"Login and disable user as admin-role user",
+			func() {
+				mockSRPLogin(t, handlers, userdata.UserCredentials{
+					Login:    "admin",
+					Password: "password",
+				}, "token")
+				auth.On("ValidateToken", userdata.AuthToken("token")).Return(userdata.UserID("1"), nil).Twice()
+				adminHandlers.On("IsAdmin", mock.Anything, userdata.UserID("1")).Return(true, nil).Once()
+				adminHandlers.On("SetUserDisabled", mock.Anything, "bob", true).Return(nil).Once()
+			},
+			func() {
+				token, err := userClient.Login(userdata.UserCredentials{
+					Login:    "admin",
+					Password: "password",
+				})
+				assert.NoError(t, err)
+				assert.Equal(t, "token", token)
+
+				assert.NoError(t, userClient.SetUserDisabled("bob", true))
+			},
+		},
+		{
+			"Logout unknown user as regular user",
+			func() {
+				auth.On("ValidateToken", userdata.AuthToken("token")).Return(userdata.UserID("1"), nil).Twice()
+				adminHandlers.On("IsAdmin", mock.Anything, userdata.UserID("1")).Return(false, nil).Once()
+			},
+			func() {
+				assert.ErrorIs(t, userClient.LogoutUser("bob"), ErrPermissionDenied)
+			},
+		},
+		{
+			"Reset quota of unknown user",
+			func() {
+				adminHandlers.On("SetUserQuota", mock.Anything, "nobody", int64(0)).Return(storage.ErrUserNotFound).Once()
+			},
+			func() {
+				assert.ErrorIs(t, tokenClient.ResetUserQuota("nobody"), storage.ErrUserNotFound)
+			},
+		},
+	}
+
+	for _, test := range tc {
+		t.Log(test.name)
+		test.mock()
+		test.valid()
+		handlers.AssertExpectations(t)
+		adminHandlers.AssertExpectations(t)
+	}
+
+	cancel()
+	server.Stop()
+}
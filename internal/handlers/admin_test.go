@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/impr0ver/gophKeeper/internal/storage"
+	storMocks "github.com/impr0ver/gophKeeper/internal/storage/mocks"
+	"github.com/impr0ver/gophKeeper/internal/userdata"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewAdminHandlers(t *testing.T) {
+	store := storMocks.NewAdminStorager(t)
+	handlers := NewAdminHandlers(store, nil)
+
+	assert.NotEmpty(t, handlers)
+}
+
+func TestAdmin_GetStats(t *testing.T) {
+	store := storMocks.NewAdminStorager(t)
+	scrub := storage.NewScrubber(storMocks.NewScrubStorager(t), nil)
+	handlers := NewAdminHandlers(store, scrub)
+
+	store.On("GetStats", context.Background()).Return(userdata.ServerStats{Users: 2}, nil).Once()
+
+	stats, err := handlers.GetStats(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), stats.Users)
+	assert.NotZero(t, stats.Uptime)
+	assert.Equal(t, userdata.ScrubStats{}, stats.Scrub, "scrub isn't done yet")
+}
+
+func TestAdmin_SetUser(t *testing.T) {
+	store := storMocks.NewAdminStorager(t)
+	handlers := NewAdminHandlers(store, nil)
+
+	tc := []struct {
+		name string
+		mock func()
+		call func() error
+		want error
+	}{
+		{
+			"Grant admin role",
+			func() {
+				store.On("SetUserAdmin", context.Background(), "alice", true).Return(nil).Once()
+			},
+			func() error { return handlers.SetUserAdmin(context.Background(), "alice", true) },
+			nil,
+		},
+		{
+			"Disable unknown user",
+			func() {
+				store.On("SetUserDisabled", context.Background(), "nobody", true).Return(storage.ErrUserNotFound).Once()
+			},
+			func() error { return handlers.SetUserDisabled(context.Background(), "nobody", true) },
+			storage.ErrUserNotFound,
+		},
+		{
+			"Set quota",
+			func() {
+				store.On("SetUserQuota", context.Background(), "alice", int64(1024)).Return(nil).Once()
+			},
+			func() error { return handlers.SetUserQuota(context.Background(), "alice", 1024) },
+			nil,
+		},
+		{
+			"Logout user",
+			func() {
+				store.On("RevokeTokens", context.Background(), "alice").Return(nil).Once()
+			},
+			func() error { return handlers.LogoutUser(context.Background(), "alice") },
+			nil,
+		},
+		{
+			"Empty login",
+			func() {},
+			func() error { return handlers.LogoutUser(context.Background(), "") },
+			emptyField("login"),
+		},
+	}
+
+	for _, test := range tc {
+		t.Log(test.name)
+		test.mock()
+		assert.Equal(t, test.want, test.call())
+		store.AssertExpectations(t)
+	}
+}
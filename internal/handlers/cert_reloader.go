@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// certCheckInterval is interval of checking server certificate files for changes.
+const certCheckInterval = 5 * time.Second
+
+// CertReloader keeps server certificate and reloads it when certificate or key file is changed.
+type CertReloader struct {
+	certFile string
+	keyFile  string
+
+	mu       sync.RWMutex
+	cert     *tls.Certificate
+	modTimes [2]time.Time
+}
+
+// NewCertReloader loads certificate and key files.
+func NewCertReloader(certFile, keyFile string) (*CertReloader, error) {
+	r := &CertReloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+	}
+
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Reload reads certificate and key files, on error the previous certificate is kept.
+func (r *CertReloader) Reload() error {
+	modTimes, err := r.fileModTimes()
+	if err != nil {
+		return err
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return err
+	}
+
+	if cert.Leaf == nil {
+		if cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0]); err != nil {
+			return err
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.cert = &cert
+	r.modTimes = modTimes
+
+	return nil
+}
+
+// GetCertificate returns current certificate, it's used in tls.Config.
+func (r *CertReloader) GetCertificate(_ *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.cert, nil
+}
+
+// Changed reports whether certificate or key file was modified after last load.
+func (r *CertReloader) Changed() bool {
+	modTimes, err := r.fileModTimes()
+	if err != nil {
+		return false
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return modTimes != r.modTimes
+}
+
+// Watch checks files with interval and reloads certificate when they are changed, until ctx is done.
+func (r *CertReloader) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !r.Changed() {
+				continue
+			}
+
+			if err := r.Reload(); err != nil {
+				// Files may be in the middle of rotation, next tick will try again
+				log.Warnf("reload server certificate error: %v", err)
+				continue
+			}
+
+			log.Infof("server certificate %s is reloaded", r.certFile)
+		}
+	}
+}
+
+// fileModTimes gets modification times of certificate and key files.
+func (r *CertReloader) fileModTimes() ([2]time.Time, error) {
+	var modTimes [2]time.Time
+
+	for i, file := range []string{r.certFile, r.keyFile} {
+		info, err := os.Stat(file)
+		if err != nil {
+			return modTimes, err
+		}
+		modTimes[i] = info.ModTime()
+	}
+
+	return modTimes, nil
+}
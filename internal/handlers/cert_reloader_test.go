@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/impr0ver/gophKeeper/internal/certgen"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeServerCert issues server certificate for host and saves it to files.
+func writeServerCert(t *testing.T, ca *certgen.KeyPair, host, certFile, keyFile string) {
+	server, err := ca.IssueServer(certgen.Options{CommonName: host, Hosts: []string{host}, Validity: time.Hour})
+	assert.NoError(t, err)
+	assert.NoError(t, server.WriteFiles(certFile, keyFile))
+}
+
+func TestCertReloader(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "server-cert.pem"), filepath.Join(dir, "server-key.pem")
+
+	ca, err := certgen.NewCA(certgen.Options{CommonName: "test CA", Validity: time.Hour})
+	assert.NoError(t, err)
+
+	_, err = NewCertReloader(certFile, keyFile)
+	assert.Error(t, err, "no files")
+
+	writeServerCert(t, ca, "old.test", certFile, keyFile)
+
+	reloader, err := NewCertReloader(certFile, keyFile)
+	assert.NoError(t, err)
+	assert.False(t, reloader.Changed())
+
+	cert, err := reloader.GetCertificate(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "old.test", cert.Leaf.Subject.CommonName)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go reloader.Watch(ctx, 10*time.Millisecond)
+
+	// Broken key file keeps previous certificate
+	assert.NoError(t, os.WriteFile(keyFile, []byte("broken"), 0600))
+	later := time.Now().Add(time.Second)
+	assert.NoError(t, os.Chtimes(keyFile, later, later))
+	time.Sleep(50 * time.Millisecond)
+
+	cert, _ = reloader.GetCertificate(nil)
+	assert.Equal(t, "old.test", cert.Leaf.Subject.CommonName)
+
+	writeServerCert(t, ca, "new.test", certFile, keyFile)
+	later = later.Add(time.Second)
+	assert.NoError(t, os.Chtimes(certFile, later, later))
+	assert.NoError(t, os.Chtimes(keyFile, later, later))
+
+	assert.Eventually(t, func() bool {
+		cert, _ := reloader.GetCertificate(nil)
+		return cert.Leaf.Subject.CommonName == "new.test"
+	}, time.Second, 10*time.Millisecond)
+}
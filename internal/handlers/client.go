@@ -1,13 +1,17 @@
 package handlers
 
 import (
+	"encoding/binary"
+	"errors"
+	"io"
 	"os"
+	"strings"
 	"sync"
 
 	"github.com/impr0ver/gophKeeper/internal/crypt"
-	"github.com/impr0ver/gophKeeper/internal/masker"
 	"github.com/impr0ver/gophKeeper/internal/storage"
 	"github.com/impr0ver/gophKeeper/internal/userdata"
+	"github.com/impr0ver/gophKeeper/internal/vault"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -16,16 +20,79 @@ import (
 type client struct {
 	conn      ClientConnection
 	authToken userdata.AuthToken
+	login     string
 	AESKey    string
-	Mu        *sync.Mutex
+	// KeyParams are parameters of derivation of encryption key from AES key.
+	KeyParams crypt.PasswordParams
+	keySalt   []byte
+	cipher    *crypt.Cipher
+	// keyHint is fingerprint and label of AES key, it's saved with new records.
+	keyHint string
+	// keyring are AES keys of session by fingerprint, record is decrypted with key of its hint.
+	keyring map[string]keyringKey
+	// legacy are IDs of decrypted records in legacy format.
+	legacy map[string]struct{}
+	Mu     *sync.Mutex
+}
+
+// keyringKey is AES key of keyring.
+type keyringKey struct {
+	label  string
+	cipher *crypt.Cipher
 }
 
 // newClientHandlers returns new client handlers with mutex.
 func newClientHandlers(connection ClientConnection) *client {
 	return &client{
-		conn: connection,
-		Mu:   &sync.Mutex{},
+		conn:      connection,
+		KeyParams: crypt.DefaultKeyParams,
+		keyring:   make(map[string]keyringKey),
+		legacy:    make(map[string]struct{}),
+		Mu:        &sync.Mutex{},
+	}
+}
+
+// setKey sets AES key, encryption key is derived from it with salt of user. Key is added to keyring.
+func (c *client) setKey(aesKey string, label string) {
+	c.AESKey = aesKey
+	c.cipher = c.addKey(aesKey, label)
+	c.keyHint = userdata.NewKeyHint(c.cipher.Fingerprint(), label)
+}
+
+// addKey adds AES key to keyring and returns its cipher.
+func (c *client) addKey(aesKey string, label string) *crypt.Cipher {
+	cipher := crypt.NewCipher(aesKey, c.keySalt, c.KeyParams)
+	fingerprint := cipher.Fingerprint()
+
+	// Cipher of known key is kept, it has cached derived keys
+	if key, ok := c.keyring[fingerprint]; ok {
+		cipher = key.cipher
 	}
+	c.keyring[fingerprint] = keyringKey{label: label, cipher: cipher}
+
+	return cipher
+}
+
+// startSession saves token and key of logged in user, salt of key is got from server.
+func (c *client) startSession(token userdata.AuthToken, credentials userdata.UserCredentials) error {
+	salt, err := c.conn.GetKeySalt(token)
+	if err != nil {
+		log.Warnf("%s :: %v", "get key salt error", err)
+
+		return err
+	}
+
+	c.Mu.Lock()
+	defer c.Mu.Unlock()
+
+	c.authToken = token
+	c.login = credentials.Login
+	c.keySalt = salt
+	c.keyring = make(map[string]keyringKey)
+	c.legacy = make(map[string]struct{})
+	c.setKey(credentials.AESKey, "")
+
+	return nil
 }
 
 // Login logins user by creds.
@@ -40,24 +107,40 @@ func (c *client) Login(credentials userdata.UserCredentials) error {
 		return err
 	}
 
+	return c.startSession(userdata.AuthToken(authToken), credentials)
+}
+
+// SetAESKey reset the new AES key with label, new records are encrypted with it.
+// Previous key stays in keyring, so its records are still decrypted.
+func (c *client) SetAESKey(newAESKey string, label string) error {
+	if newAESKey == "" || len(newAESKey) == 0 {
+		return ErrEmptyField
+	}
+
+	if !userdata.ValidKeyLabel(label) {
+		return ErrInvalidKeyLabel
+	}
+
 	c.Mu.Lock()
 	defer c.Mu.Unlock()
-
-	c.authToken = userdata.AuthToken(authToken)
-	c.AESKey = credentials.AESKey
+	c.setKey(newAESKey, label)
 
 	return nil
 }
 
-// SetAESKey reset the new AES key
-func (c *client) SetAESKey(newAESKey string) error {
-	if newAESKey == "" || len(newAESKey) == 0 {
+// AddAESKey adds AES key with label to keyring, records encrypted with it are decrypted automatically.
+func (c *client) AddAESKey(aesKey string, label string) error {
+	if aesKey == "" {
 		return ErrEmptyField
 	}
 
+	if !userdata.ValidKeyLabel(label) {
+		return ErrInvalidKeyLabel
+	}
+
 	c.Mu.Lock()
 	defer c.Mu.Unlock()
-	c.AESKey = newAESKey
+	c.addKey(aesKey, label)
 
 	return nil
 }
@@ -74,13 +157,7 @@ func (c *client) Register(credentials userdata.UserCredentials) error {
 		return err
 	}
 
-	c.Mu.Lock()
-	defer c.Mu.Unlock()
-
-	c.authToken = userdata.AuthToken(authToken)
-	c.AESKey = credentials.AESKey
-
-	return nil
+	return c.startSession(userdata.AuthToken(authToken), credentials)
 }
 
 // GetRecordsInfo gets all records.
@@ -103,10 +180,102 @@ func (c *client) GetRecord(recordID string) (userdata.Record, error) {
 		return record, errGetRecord
 	}
 
-	decrypted, err := crypt.AES256CBCDecode(record.Data, string(c.AESKey))
+	return c.decryptRecord(record)
+}
+
+// associatedData returns data of record, which is authenticated with its cipherdata: record ID, type and owner.
+// So server can't swap data of records or users undetected.
+func (c *client) associatedData(record userdata.Record) []byte {
+	fields := []string{"gophkeeper-record", strings.ToLower(record.ID), record.Type.String(), c.login}
+
+	data := make([]byte, 0, 64)
+	for _, field := range fields {
+		data = binary.BigEndian.AppendUint32(data, uint32(len(field)))
+		data = append(data, field...)
+	}
+
+	return data
+}
+
+// ciphers returns ciphers to decrypt record: key of keyring with fingerprint of record,
+// otherwise current key and then other keys of keyring.
+func (c *client) ciphers(record userdata.Record) []*crypt.Cipher {
+	if fingerprint, _, ok := userdata.ParseKeyHint(record.KeyHint); ok {
+		if key, found := c.keyring[fingerprint]; found {
+			return []*crypt.Cipher{key.cipher}
+		}
+	}
+
+	ciphers := make([]*crypt.Cipher, 0, len(c.keyring)+1)
+	ciphers = append(ciphers, c.cipher)
+	for _, key := range c.keyring {
+		if key.cipher != c.cipher {
+			ciphers = append(ciphers, key.cipher)
+		}
+	}
+
+	return ciphers
+}
+
+// dataKey unwraps data key of record with matching key of keyring.
+func (c *client) dataKey(record userdata.Record) ([]byte, error) {
+	var err error
+	for _, cipher := range c.ciphers(record) {
+		var dataKey []byte
+		dataKey, err = cipher.UnwrapKey(record.DataKey, c.associatedData(record))
+		if err == nil {
+			return dataKey, nil
+		}
+	}
+
+	return nil, err
+}
+
+// decryptData decrypts cipherdata of record with its data key or matching key of keyring,
+// records in legacy format are remembered.
+func (c *client) decryptData(record userdata.Record) ([]byte, bool, error) {
+	var (
+		decrypted []byte
+		legacy    bool
+		err       error
+	)
+
+	if len(record.DataKey) > 0 {
+		dataKey, err := c.dataKey(record)
+		if err == nil {
+			decrypted, err = crypt.OpenData(dataKey, record.Data, c.associatedData(record))
+		}
+		if err != nil {
+			log.Infoln(err)
+			return nil, false, storage.ErrUnknown
+		}
+
+		return decrypted, false, nil
+	}
+
+	for _, cipher := range c.ciphers(record) {
+		decrypted, legacy, err = cipher.Decrypt(record.Data, c.associatedData(record))
+		if err == nil {
+			break
+		}
+	}
 	if err != nil {
 		log.Infoln(err)
-		return record, storage.ErrUnknown
+		return nil, false, storage.ErrUnknown
+	}
+
+	if legacy {
+		c.legacy[record.ID] = struct{}{}
+	}
+
+	return decrypted, legacy, nil
+}
+
+// decryptRecord decrypts cipherdata of record, file data is saved to file.
+func (c *client) decryptRecord(record userdata.Record) (userdata.Record, error) {
+	decrypted, _, err := c.decryptData(record)
+	if err != nil {
+		return record, err
 	}
 
 	record.Data = decrypted
@@ -140,21 +309,522 @@ func (c *client) DeleteRecord(recordID string) error {
 	return c.conn.DeleteRecord(c.authToken, recordID)
 }
 
-// CreateRecord creates new record and crypt plaindata.
-func (c *client) CreateRecord(record userdata.Record) error {
+// CreateRecord creates new record and crypt plaindata, returns meta of created record.
+func (c *client) CreateRecord(record userdata.Record) (userdata.RecordMeta, error) {
 	c.Mu.Lock()
 	defer c.Mu.Unlock()
 
-	encrypted, err := crypt.AES256CBCEncode(record.Data, string(c.AESKey))
+	record, err := c.encryptRecord(record)
+	if err != nil {
+		return userdata.RecordMeta{}, err
+	}
+
+	return c.conn.CreateRecord(c.authToken, record)
+}
+
+// encryptRecord crypts plaindata of record and adds key hint: fingerprint and label of AES key.
+// New ID is chosen for record, because cipherdata is bound to it.
+func (c *client) encryptRecord(record userdata.Record) (userdata.Record, error) {
+	id, err := storage.NewRecordID()
+	if err != nil {
+		log.Infoln(err)
+		return record, storage.ErrUnknown
+	}
+	record.ID = id
+
+	return c.sealRecord(record, record.Data)
+}
+
+// sealRecord crypts plaindata of record with new random data key, which is wrapped with current key.
+func (c *client) sealRecord(record userdata.Record, plainText []byte) (userdata.Record, error) {
+	dataKey, err := crypt.NewDataKey()
+	if err != nil {
+		log.Infoln(err)
+		return record, storage.ErrUnknown
+	}
+
+	encrypted, err := crypt.SealData(dataKey, plainText, c.associatedData(record))
+	if err != nil {
+		log.Infoln(err)
+		return record, storage.ErrUnknown
+	}
+
+	wrapped, err := c.cipher.WrapKey(dataKey, c.associatedData(record))
 	if err != nil {
 		log.Infoln(err)
-		return storage.ErrUnknown
+		return record, storage.ErrUnknown
 	}
+
 	record.Data = encrypted
+	record.DataKey = wrapped
+	record.KeyHint = c.keyHint
 
-	//Add AES-key hint for facilities
-	record.KeyHint = c.AESKey
-	record.KeyHint = masker.Masker(record.KeyHint)
+	return record, nil
+}
 
-	return c.conn.CreateRecord(c.authToken, record)
+// CreateRecords crypts plaindata of records and creates them with one request.
+func (c *client) CreateRecords(records []userdata.Record) ([]userdata.RecordResult, error) {
+	c.Mu.Lock()
+	defer c.Mu.Unlock()
+
+	encrypted := make([]userdata.Record, 0, len(records))
+	for _, record := range records {
+		record, err := c.encryptRecord(record)
+		if err != nil {
+			return nil, err
+		}
+
+		encrypted = append(encrypted, record)
+	}
+
+	return c.conn.CreateRecords(c.authToken, encrypted)
+}
+
+// GetRecords gets records by IDs with one request and decrypts them, result has error if record isn't decrypted.
+func (c *client) GetRecords(recordIDs []string) ([]userdata.RecordResult, error) {
+	c.Mu.Lock()
+	defer c.Mu.Unlock()
+
+	results, err := c.conn.GetRecords(c.authToken, recordIDs)
+	if err != nil {
+		log.Infoln(err)
+
+		return nil, err
+	}
+
+	for i, result := range results {
+		if result.Err != nil {
+			continue
+		}
+
+		results[i].Record, results[i].Err = c.decryptRecord(result.Record)
+	}
+
+	return results, nil
+}
+
+// DeleteRecords deletes records by IDs with one request.
+func (c *client) DeleteRecords(recordIDs []string) ([]userdata.RecordResult, error) {
+	c.Mu.Lock()
+	defer c.Mu.Unlock()
+
+	return c.conn.DeleteRecords(c.authToken, recordIDs)
+}
+
+// LegacyRecords returns number of records in legacy format decrypted since login.
+func (c *client) LegacyRecords() int {
+	c.Mu.Lock()
+	defer c.Mu.Unlock()
+
+	return len(c.legacy)
+}
+
+// ReencryptRecords encrypts all records in legacy format with derived key and returns their number.
+// Records are replaced by new ones, so their IDs are changed.
+func (c *client) ReencryptRecords() (int, error) {
+	c.Mu.Lock()
+	defer c.Mu.Unlock()
+
+	records, err := c.conn.GetRecordsInfo(c.authToken)
+	if err != nil {
+		return 0, err
+	}
+
+	ids := make([]string, 0, len(records))
+	for _, record := range records {
+		ids = append(ids, record.ID)
+	}
+
+	count := 0
+	for len(ids) > 0 {
+		batch := ids
+		if len(batch) > maxBatchSize {
+			batch = batch[:maxBatchSize]
+		}
+		ids = ids[len(batch):]
+
+		replaced, err := c.reencryptBatch(batch)
+		count += replaced
+		if err != nil {
+			return count, err
+		}
+	}
+
+	return count, nil
+}
+
+// reencryptBatch replaces records in legacy format from batch and returns number of replaced records.
+func (c *client) reencryptBatch(recordIDs []string) (int, error) {
+	results, err := c.conn.GetRecords(c.authToken, recordIDs)
+	if err != nil {
+		return 0, err
+	}
+
+	oldIDs := make([]string, 0, len(results))
+	records := make([]userdata.Record, 0, len(results))
+	for _, result := range results {
+		if result.Err != nil {
+			continue
+		}
+
+		decrypted, legacy, err := c.decryptData(result.Record)
+		if err != nil || !legacy {
+			continue
+		}
+
+		result.Record.Data = decrypted
+		record, err := c.encryptRecord(result.Record)
+		if err != nil {
+			return 0, err
+		}
+
+		oldIDs = append(oldIDs, result.ID)
+		records = append(records, record)
+	}
+
+	if len(records) == 0 {
+		return 0, nil
+	}
+
+	created, err := c.conn.CreateRecords(c.authToken, records)
+	if err != nil {
+		return 0, err
+	}
+
+	// Old record is deleted only if its copy is created
+	deleteIDs := make([]string, 0, len(created))
+	for i, result := range created {
+		if result.Err == nil && i < len(oldIDs) {
+			deleteIDs = append(deleteIDs, oldIDs[i])
+		}
+	}
+
+	if len(deleteIDs) == 0 {
+		return 0, nil
+	}
+
+	deleted, err := c.conn.DeleteRecords(c.authToken, deleteIDs)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, result := range deleted {
+		delete(c.legacy, result.ID)
+		if result.Err == nil {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// RotateKey makes new AES key with label current and rotates all records to it, returns number of rotated records.
+// Previous keys stay in keyring, so records are decrypted with them.
+// Data keys of records are rewrapped with new key without downloading data, older records without data key
+// are re-encrypted with new data key. Records are processed by batches with the same IDs, progress is called
+// after each batch with number of processed records.
+// Records encrypted with new key are skipped, so rotation is resumed by calling it again with the same key.
+func (c *client) RotateKey(newAESKey string, label string, progress func(done int, total int)) (int, error) {
+	if newAESKey == "" {
+		return 0, ErrEmptyField
+	}
+
+	if !userdata.ValidKeyLabel(label) {
+		return 0, ErrInvalidKeyLabel
+	}
+
+	c.Mu.Lock()
+	defer c.Mu.Unlock()
+	c.setKey(newAESKey, label)
+
+	records, err := c.conn.GetRecordsInfo(c.authToken)
+	if err != nil {
+		return 0, err
+	}
+
+	fingerprint := c.cipher.Fingerprint()
+	rewrap := make([]userdata.Record, 0, len(records))
+	ids := make([]string, 0, len(records))
+	for _, record := range records {
+		if hinted, _, ok := userdata.ParseKeyHint(record.KeyHint); ok && hinted == fingerprint {
+			continue
+		}
+
+		if len(record.DataKey) > 0 {
+			rewrap = append(rewrap, record)
+		} else {
+			ids = append(ids, record.ID)
+		}
+	}
+
+	total := len(rewrap) + len(ids)
+	if progress != nil {
+		progress(0, total)
+	}
+
+	count := 0
+	for len(rewrap) > 0 {
+		batch := rewrap
+		if len(batch) > maxBatchSize {
+			batch = batch[:maxBatchSize]
+		}
+		rewrap = rewrap[len(batch):]
+
+		rewrapped, err := c.rewrapBatch(batch)
+		count += rewrapped
+		if err != nil {
+			return count, err
+		}
+
+		if progress != nil {
+			progress(total-len(rewrap)-len(ids), total)
+		}
+	}
+
+	for len(ids) > 0 {
+		batch := ids
+		if len(batch) > maxBatchSize {
+			batch = batch[:maxBatchSize]
+		}
+		ids = ids[len(batch):]
+
+		rotated, err := c.rotateBatch(batch)
+		count += rotated
+		if err != nil {
+			return count, err
+		}
+
+		if progress != nil {
+			progress(total-len(ids), total)
+		}
+	}
+
+	return count, nil
+}
+
+// rewrapBatch wraps data keys of records with current key and replaces them with one request.
+func (c *client) rewrapBatch(records []userdata.Record) (int, error) {
+	rewrapped := make([]userdata.Record, 0, len(records))
+	for _, record := range records {
+		dataKey, err := c.dataKey(record)
+		if err != nil {
+			return 0, ErrKeyNotInKeyring
+		}
+
+		record.DataKey, err = c.cipher.WrapKey(dataKey, c.associatedData(record))
+		if err != nil {
+			log.Infoln(err)
+			return 0, storage.ErrUnknown
+		}
+		record.KeyHint = c.keyHint
+
+		rewrapped = append(rewrapped, record)
+	}
+
+	if _, err := c.conn.RewrapRecords(c.authToken, rewrapped); err != nil {
+		return 0, err
+	}
+
+	return len(rewrapped), nil
+}
+
+// rotateBatch re-encrypts records of batch with new data keys wrapped with current key and replaces them with one request.
+// Records deleted since listing are skipped.
+func (c *client) rotateBatch(recordIDs []string) (int, error) {
+	results, err := c.conn.GetRecords(c.authToken, recordIDs)
+	if err != nil {
+		return 0, err
+	}
+
+	records := make([]userdata.Record, 0, len(results))
+	for _, result := range results {
+		if errors.Is(result.Err, storage.ErrNotFound) {
+			continue
+		}
+		if result.Err != nil {
+			return 0, result.Err
+		}
+
+		decrypted, _, err := c.decryptData(result.Record)
+		if err != nil {
+			return 0, ErrKeyNotInKeyring
+		}
+
+		record, err := c.sealRecord(result.Record, decrypted)
+		if err != nil {
+			return 0, err
+		}
+
+		records = append(records, record)
+	}
+
+	if len(records) == 0 {
+		return 0, nil
+	}
+
+	if _, err := c.conn.ReplaceRecords(c.authToken, records); err != nil {
+		return 0, err
+	}
+
+	for _, record := range records {
+		delete(c.legacy, record.ID)
+	}
+
+	return len(records), nil
+}
+
+// ExportVault writes all records with plain data to vault archive encrypted with password, returns number of exported records.
+// Records are downloaded by batches, progress is called after each batch with number of downloaded records.
+func (c *client) ExportVault(w io.Writer, password string, progress func(done int, total int)) (int, error) {
+	if password == "" {
+		return 0, ErrEmptyField
+	}
+
+	c.Mu.Lock()
+	defer c.Mu.Unlock()
+
+	infos, err := c.conn.GetRecordsInfo(c.authToken)
+	if err != nil {
+		return 0, err
+	}
+
+	ids := make([]string, 0, len(infos))
+	for _, info := range infos {
+		ids = append(ids, info.ID)
+	}
+
+	total := len(ids)
+	if progress != nil {
+		progress(0, total)
+	}
+
+	records := make([]userdata.Record, 0, total)
+	for len(ids) > 0 {
+		batch := ids
+		if len(batch) > maxBatchSize {
+			batch = batch[:maxBatchSize]
+		}
+		ids = ids[len(batch):]
+
+		results, err := c.conn.GetRecords(c.authToken, batch)
+		if err != nil {
+			return 0, err
+		}
+
+		for _, result := range results {
+			// Record deleted since listing isn't exported
+			if errors.Is(result.Err, storage.ErrNotFound) {
+				continue
+			}
+			if result.Err != nil {
+				return 0, result.Err
+			}
+
+			decrypted, _, err := c.decryptData(result.Record)
+			if err != nil {
+				return 0, ErrKeyNotInKeyring
+			}
+
+			records = append(records, userdata.Record{
+				ID:       result.Record.ID,
+				Type:     result.Record.Type,
+				Metadata: result.Record.Metadata,
+				Data:     decrypted,
+			})
+		}
+
+		if progress != nil {
+			progress(total-len(ids), total)
+		}
+	}
+
+	if err := vault.Write(w, password, c.KeyParams, c.login, records); err != nil {
+		log.Warnf("%s :: %v", "write vault archive error", err)
+
+		return 0, storage.ErrUnknown
+	}
+
+	return len(records), nil
+}
+
+// ImportVault creates records of vault archive encrypted with password, returns numbers of imported and skipped records.
+// Records keep their IDs in account of archive, in other account IDs are derived from login and archive IDs,
+// so records which exist already are skipped and import is resumed by calling it again.
+// Records are uploaded by batches, progress is called after each batch with number of processed records.
+func (c *client) ImportVault(r io.Reader, password string, progress func(done int, total int)) (int, int, error) {
+	if password == "" {
+		return 0, 0, ErrEmptyField
+	}
+
+	manifest, records, err := vault.Read(r, password)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	c.Mu.Lock()
+	defer c.Mu.Unlock()
+
+	infos, err := c.conn.GetRecordsInfo(c.authToken)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	existing := make(map[string]struct{}, len(infos))
+	for _, info := range infos {
+		existing[strings.ToLower(info.ID)] = struct{}{}
+	}
+
+	skipped := 0
+	imported := make([]userdata.Record, 0, len(records))
+	for _, record := range records {
+		// Record IDs are unique on server, so copies in other account get their own IDs
+		if manifest.Login != c.login || !storage.ValidRecordID(record.ID) {
+			record.ID = storage.DeriveRecordID(c.login, strings.ToLower(record.ID))
+		}
+
+		if _, ok := existing[strings.ToLower(record.ID)]; ok {
+			skipped++
+			continue
+		}
+
+		sealed, err := c.sealRecord(record, record.Data)
+		if err != nil {
+			return 0, skipped, err
+		}
+
+		imported = append(imported, sealed)
+	}
+
+	total := len(imported)
+	if progress != nil {
+		progress(0, total)
+	}
+
+	count := 0
+	for len(imported) > 0 {
+		batch := imported
+		if len(batch) > maxBatchSize {
+			batch = batch[:maxBatchSize]
+		}
+		imported = imported[len(batch):]
+
+		results, err := c.conn.CreateRecords(c.authToken, batch)
+		if err != nil {
+			return count, skipped, err
+		}
+
+		for _, result := range results {
+			if result.Err != nil {
+				return count, skipped, result.Err
+			}
+			count++
+		}
+
+		if progress != nil {
+			progress(total-len(imported), total)
+		}
+	}
+
+	return count, skipped, nil
 }
@@ -2,29 +2,37 @@ package handlers
 
 import (
 	"context"
+	"crypto/rand"
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"math"
 	"os"
+	"strings"
 
+	"github.com/impr0ver/gophKeeper/internal/crypt"
 	"github.com/impr0ver/gophKeeper/internal/logger"
 	pb "github.com/impr0ver/gophKeeper/internal/rpc"
-
 	"github.com/impr0ver/gophKeeper/internal/storage"
 	"github.com/impr0ver/gophKeeper/internal/userdata"
 
 	log "github.com/sirupsen/logrus"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/metadata"
-	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/emptypb"
 )
 
 // ClientConnGPRC get connection with server via gRPC.
 type ClientConnGPRC struct {
 	pb.GokeeperClient
+	// Passwords are parameters of SRP verifier made on registration.
+	Passwords crypt.PasswordParams
+	// LegacyLogin allows to send password to server, if SRP login fails.
+	// Users registered before SRP login need it once, server replaces their password hash by verifier.
+	LegacyLogin bool
 }
 
 // ClientLoadTLSCredentials read and load client certificate.
@@ -48,6 +56,36 @@ func clientLoadTLSCredentials(clientCert string) (credentials.TransportCredentia
 	return credentials.NewTLS(config), nil
 }
 
+// retryPolicy is retry policy of client, retries are safe because mutating requests have idempotency keys.
+const retryPolicy = `{
+	"maxAttempts": 4,
+	"initialBackoff": "0.2s",
+	"maxBackoff": "2s",
+	"backoffMultiplier": 2,
+	"retryableStatusCodes": ["UNAVAILABLE", "ABORTED"]
+}`
+
+// retryServiceConfig returns gRPC service config with retry policy for services.
+func retryServiceConfig(services ...string) string {
+	names := make([]string, 0, len(services))
+	for _, service := range services {
+		names = append(names, fmt.Sprintf(`{"service": %q}`, service))
+	}
+
+	return fmt.Sprintf(`{"methodConfig": [{"name": [%s], "retryPolicy": %s}]}`, strings.Join(names, ", "), retryPolicy)
+}
+
+// withIdempotencyKey adds new idempotency key to request, so server replays response on retries.
+func withIdempotencyKey(ctx context.Context) context.Context {
+	key := make([]byte, 16)
+	if _, err := rand.Read(key); err != nil {
+		log.Warnf("generate idempotency key error: %v", err)
+		return ctx
+	}
+
+	return metadata.AppendToOutgoingContext(ctx, idempotencyKeyMetadata, hex.EncodeToString(key))
+}
+
 // NewClientConnection connects to server.
 func newClientConn(serverAddress, clientCert string) *ClientConnGPRC {
 	var sLogger = logger.NewSugarLogger()
@@ -57,84 +95,144 @@ func newClientConn(serverAddress, clientCert string) *ClientConnGPRC {
 		sLogger.Fatalf("cannot load TLS credentials: %v\n", err)
 	}
 
-	conn, err := grpc.NewClient("passthrough:///"+serverAddress, grpc.WithTransportCredentials(tlsCredentials))
+	conn, err := grpc.NewClient("passthrough:///"+serverAddress,
+		grpc.WithTransportCredentials(tlsCredentials),
+		grpc.WithDefaultServiceConfig(retryServiceConfig(pb.Gokeeper_ServiceDesc.ServiceName)))
 	if err != nil {
 		log.Fatal(err)
 	}
 
 	return &ClientConnGPRC{
 		GokeeperClient: pb.NewGokeeperClient(conn),
+		Passwords:      crypt.DefaultPasswordParams,
 	}
 }
 
-// Login logins user by login and password.
-func (c *ClientConnGPRC) Login(credentials userdata.UserCredentials) (string, error) {
-	session, err := c.GokeeperClient.Login(context.Background(), &pb.UserCreds{
+// loginSRP logins user with SRP-6a, password isn't sent to server.
+// If legacy is set, password is sent to server after failed SRP login.
+func loginSRP(gokeeper pb.GokeeperClient, credentials userdata.UserCredentials, legacy bool) (string, error) {
+	token, err := srpLogin(gokeeper, credentials)
+	if err == nil || !legacy || !errors.Is(err, storage.ErrWrongCredentials) {
+		return token, err
+	}
+
+	session, errLegacy := gokeeper.Login(context.Background(), &pb.UserCreds{
 		Login:    credentials.Login,
 		Password: credentials.Password,
 	})
+	if errLegacy != nil {
+		log.Warnf("%s :: %v", "legacy login error", errLegacy)
+
+		if errLegacy = fromStatus(errLegacy); errors.Is(errLegacy, ErrLegacyLogin) {
+			return "", err
+		}
+
+		return "", errLegacy
+	}
+
+	return session.Token, nil
+}
 
-	switch status.Code(err) {
-	case codes.Unauthenticated:
-		return "", storage.ErrWrongCredentials
-	case codes.Internal:
-		return "", storage.ErrUnknown
-	case codes.InvalidArgument:
-		return "", ErrEmptyField
+// srpLogin makes SRP-6a login and checks that server knows verifier of user.
+func srpLogin(gokeeper pb.GokeeperClient, credentials userdata.UserCredentials) (string, error) {
+	client, err := crypt.NewSRPClient()
+	if err != nil {
+		return "", err
 	}
 
+	challenge, err := gokeeper.StartLoginSRP(context.Background(), &pb.SRPStart{
+		Login: credentials.Login,
+		A:     client.Public(),
+	})
 	if err != nil {
 		log.Warnf("%s :: %v", "login error", err)
 
+		return "", fromStatus(err)
+	}
+
+	if challenge.Parallelism > math.MaxUint8 {
+		return "", crypt.ErrSRPPublic
+	}
+
+	proof, err := client.Proof(credentials, crypt.SRPChallenge{
+		Salt: challenge.Salt,
+		Params: crypt.PasswordParams{
+			Memory:      challenge.Memory,
+			Iterations:  challenge.Iterations,
+			Parallelism: uint8(challenge.Parallelism),
+		},
+		B: challenge.B,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	session, err := gokeeper.FinishLoginSRP(context.Background(), &pb.SRPProof{
+		SessionId: challenge.SessionId,
+		Proof:     proof,
+	})
+	if err != nil {
+		log.Warnf("%s :: %v", "login error", err)
+
+		return "", fromStatus(err)
+	}
+
+	if err := client.VerifyServer(session.Proof); err != nil {
 		return "", err
 	}
 
 	return session.Token, nil
 }
 
-// Register register user by login and password.
+// Login logins user by login and password with SRP-6a.
+func (c *ClientConnGPRC) Login(credentials userdata.UserCredentials) (string, error) {
+	return loginSRP(c.GokeeperClient, credentials, c.LegacyLogin)
+}
+
+// Register register user by login and password, only SRP verifier of password is sent to server.
 func (c *ClientConnGPRC) Register(credentials userdata.UserCredentials) (string, error) {
-	session, err := c.GokeeperClient.Register(context.Background(), &pb.UserCreds{
+	verifier, err := crypt.NewSRPVerifier(credentials, c.Passwords)
+	if err != nil {
+		return "", err
+	}
+
+	// Response has auth token, so it's not sent with idempotency key, which makes server save response
+	session, err := c.GokeeperClient.RegisterSRP(context.Background(), &pb.SRPRegistration{
 		Login:    credentials.Login,
-		Password: credentials.Password,
+		Verifier: verifier,
 	})
+	if err != nil {
+		log.Warnf("%s :: %v", "register error", err)
 
-	code := status.Code(err)
-
-	switch code {
-	case codes.AlreadyExists:
-		return "", storage.ErrLoginExists
-	case codes.Internal:
-		return "", storage.ErrUnknown
-	case codes.InvalidArgument:
-		return "", ErrEmptyField
+		return "", fromStatus(err)
 	}
 
 	return session.Token, nil
 }
 
+// GetKeySalt gets salt of user encryption key.
+func (c *ClientConnGPRC) GetKeySalt(token userdata.AuthToken) ([]byte, error) {
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authToken", string(token))
+	salt, err := c.GokeeperClient.GetKeySalt(ctx, &emptypb.Empty{})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+
+	return salt.Salt, nil
+}
+
 // GetRecordsInfo gets all records.
 func (c *ClientConnGPRC) GetRecordsInfo(token userdata.AuthToken) ([]userdata.Record, error) {
 	ctx := metadata.AppendToOutgoingContext(context.Background(), "authToken", string(token))
 	gotRecords, err := c.GokeeperClient.GetRecordsInfo(ctx, &emptypb.Empty{})
-	code := status.Code(err)
-
-	switch code {
-	case codes.Internal:
-		return nil, storage.ErrUnknown
-	case codes.Unauthenticated:
-		return nil, storage.ErrUnauthenticated
+	if err != nil {
+		return nil, fromStatus(err)
 	}
 
 	records := make([]userdata.Record, 0, len(gotRecords.Records))
 
 	for _, record := range gotRecords.Records {
-		records = append(records, userdata.Record{
-			ID:       record.Id,
-			Metadata: record.Metadata,
-			KeyHint:  record.Keyhint,
-			Type:     userdata.RecordType(record.Type),
-		})
+		records = append(records, fromPBRecord(record))
 	}
 
 	return records, nil
@@ -146,64 +244,150 @@ func (c *ClientConnGPRC) GetRecord(token userdata.AuthToken, recordID string) (u
 	gotRecord, err := c.GokeeperClient.GetRecord(ctx, &pb.RecordID{
 		Id: recordID,
 	})
-	record := userdata.Record{}
-	code := status.Code(err)
-
-	switch code {
-	case codes.Internal:
-		return record, storage.ErrUnknown
-	case codes.Unauthenticated:
-		return record, storage.ErrUnauthenticated
-	case codes.NotFound:
-		return record, storage.ErrNotFound
+	if err != nil {
+		return userdata.Record{}, fromStatus(err)
 	}
 
-	record = userdata.Record{
-		ID:       gotRecord.Id,
-		Metadata: gotRecord.Metadata,
-		KeyHint:  gotRecord.Keyhint,
-		Type:     userdata.RecordType(gotRecord.Type),
-		Data:     gotRecord.StoredData,
-	}
-	return record, nil
+	return fromPBRecord(gotRecord), nil
 }
 
 // DeleteRecord deletes record by ID.
 func (c *ClientConnGPRC) DeleteRecord(token userdata.AuthToken, recordID string) error {
 	ctx := metadata.AppendToOutgoingContext(context.Background(), "authToken", string(token))
-	_, err := c.GokeeperClient.DeleteRecord(ctx, &pb.RecordID{
+	_, err := c.GokeeperClient.DeleteRecord(withIdempotencyKey(ctx), &pb.RecordID{
 		Id: recordID,
 	})
-	code := status.Code(err)
 
-	switch code {
-	case codes.Internal:
-		return storage.ErrUnknown
-	case codes.Unauthenticated:
-		return storage.ErrUnauthenticated
-	case codes.NotFound:
-		return storage.ErrNotFound
-	}
-
-	return nil
+	return fromStatus(err)
 }
 
-// CreateRecord creates record and saves on server side.
-func (c *ClientConnGPRC) CreateRecord(token userdata.AuthToken, record userdata.Record) error {
+// CreateRecord creates record and saves on server side, returns ID, version and timestamps of record.
+func (c *ClientConnGPRC) CreateRecord(token userdata.AuthToken, record userdata.Record) (userdata.RecordMeta, error) {
 	ctx := metadata.AppendToOutgoingContext(context.Background(), "authToken", string(token))
-	_, err := c.GokeeperClient.CreateRecord(ctx, &pb.Record{
+	meta, err := c.GokeeperClient.CreateRecord(withIdempotencyKey(ctx), &pb.Record{
+		Id:         record.ID,
 		Type:       pb.MessageType(record.Type),
 		Keyhint:    record.KeyHint,
+		DataKey:    record.DataKey,
 		Metadata:   record.Metadata,
 		StoredData: record.Data,
 	})
+	if err != nil {
+		return userdata.RecordMeta{}, fromStatus(err)
+	}
+
+	return fromPBRecordMeta(meta), nil
+}
+
+// CreateRecords creates records on server side with one request.
+func (c *ClientConnGPRC) CreateRecords(token userdata.AuthToken, records []userdata.Record) ([]userdata.RecordResult, error) {
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authToken", string(token))
+
+	pbRecords := make([]*pb.Record, 0, len(records))
+	for _, record := range records {
+		pbRecords = append(pbRecords, &pb.Record{
+			Id:         record.ID,
+			Type:       pb.MessageType(record.Type),
+			Keyhint:    record.KeyHint,
+			DataKey:    record.DataKey,
+			Metadata:   record.Metadata,
+			StoredData: record.Data,
+		})
+	}
+
+	results, err := c.GokeeperClient.CreateRecords(withIdempotencyKey(ctx), &pb.RecordsList{Records: pbRecords})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+
+	return fromRecordResults(results), nil
+}
+
+// GetRecords gets records from server by IDs with one request.
+func (c *ClientConnGPRC) GetRecords(token userdata.AuthToken, recordIDs []string) ([]userdata.RecordResult, error) {
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authToken", string(token))
+	results, err := c.GokeeperClient.GetRecords(ctx, &pb.RecordIDs{Ids: recordIDs})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+
+	return fromRecordResults(results), nil
+}
+
+// DeleteRecords deletes records by IDs with one request.
+func (c *ClientConnGPRC) DeleteRecords(token userdata.AuthToken, recordIDs []string) ([]userdata.RecordResult, error) {
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authToken", string(token))
+	results, err := c.GokeeperClient.DeleteRecords(withIdempotencyKey(ctx), &pb.RecordIDs{Ids: recordIDs})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+
+	return fromRecordResults(results), nil
+}
+
+// ReplaceRecords replaces data of records with versions on server side with one request.
+func (c *ClientConnGPRC) ReplaceRecords(token userdata.AuthToken, records []userdata.Record) ([]userdata.RecordResult, error) {
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authToken", string(token))
+
+	pbRecords := make([]*pb.Record, 0, len(records))
+	for _, record := range records {
+		pbRecords = append(pbRecords, &pb.Record{
+			Id:         record.ID,
+			Type:       pb.MessageType(record.Type),
+			Keyhint:    record.KeyHint,
+			DataKey:    record.DataKey,
+			StoredData: record.Data,
+			Version:    record.Version,
+		})
+	}
+
+	results, err := c.GokeeperClient.ReplaceRecords(withIdempotencyKey(ctx), &pb.RecordsList{Records: pbRecords})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+
+	return fromRecordResults(results), nil
+}
+
+// RewrapRecords replaces data keys of records with versions on server side with one request, data isn't sent.
+func (c *ClientConnGPRC) RewrapRecords(token userdata.AuthToken, records []userdata.Record) ([]userdata.RecordResult, error) {
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authToken", string(token))
+
+	pbRecords := make([]*pb.Record, 0, len(records))
+	for _, record := range records {
+		pbRecords = append(pbRecords, &pb.Record{
+			Id:      record.ID,
+			Type:    pb.MessageType(record.Type),
+			Keyhint: record.KeyHint,
+			DataKey: record.DataKey,
+			Version: record.Version,
+		})
+	}
+
+	results, err := c.GokeeperClient.RewrapRecords(withIdempotencyKey(ctx), &pb.RecordsList{Records: pbRecords})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+
+	return fromRecordResults(results), nil
+}
+
+// fromRecordResults converts batch response from server to results.
+func fromRecordResults(results *pb.RecordResults) []userdata.RecordResult {
+	records := make([]userdata.RecordResult, 0, len(results.Results))
+
+	for _, result := range results.Results {
+		record := userdata.RecordResult{
+			ID:  result.Id,
+			Err: fromReason(result.ErrorReason, result.ErrorMessage),
+		}
+
+		if result.Record != nil {
+			record.Record = fromPBRecord(result.Record)
+		}
 
-	switch status.Code(err) {
-	case codes.Internal:
-		return storage.ErrUnknown
-	case codes.Unauthenticated:
-		return storage.ErrUnauthenticated
+		records = append(records, record)
 	}
 
-	return nil
+	return records
 }
@@ -1,16 +1,30 @@
 package handlers
 
 import (
+	"bytes"
 	"testing"
 
+	"github.com/impr0ver/gophKeeper/internal/crypt"
 	"github.com/impr0ver/gophKeeper/internal/handlers/mocks"
 	"github.com/impr0ver/gophKeeper/internal/storage"
 	"github.com/impr0ver/gophKeeper/internal/userdata"
+	"github.com/impr0ver/gophKeeper/internal/vault"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
 
+// testKeyParams make key derivation fast in tests.
+var testKeyParams = crypt.PasswordParams{Memory: 64, Iterations: 1, Parallelism: 1}
+
+// newTestClient returns client handlers with fast key derivation.
+func newTestClient(conn ClientConnection) *client {
+	handlers := newClientHandlers(conn)
+	handlers.KeyParams = testKeyParams
+
+	return handlers
+}
+
 func TestNewClientHandlers(t *testing.T) {
 	conn := mocks.NewClientConnection(t)
 	handlers := newClientHandlers(conn)
@@ -19,8 +33,8 @@ func TestNewClientHandlers(t *testing.T) {
 
 func TestClient_Register(t *testing.T) {
 	conn := mocks.NewClientConnection(t)
-	handlers := newClientHandlers(conn)
-	handlers.AESKey = "hello"
+	handlers := newTestClient(conn)
+	handlers.setKey("hello", "")
 
 	tc := []struct {
 		name  string
@@ -35,6 +49,7 @@ func TestClient_Register(t *testing.T) {
 					Password: "Password",
 					AESKey:   "hello",
 				}).Return("token", nil).Once()
+				conn.On("GetKeySalt", userdata.AuthToken("token")).Return([]byte("salt"), nil).Once()
 			},
 			func() {
 				err := handlers.Register(userdata.UserCredentials{
@@ -49,6 +64,24 @@ func TestClient_Register(t *testing.T) {
 					"hello",
 					handlers.AESKey,
 				)
+				assert.Equal(t, []byte("salt"), handlers.keySalt)
+			},
+		},
+		{
+			"Register without key salt",
+			func() {
+				conn.On("Register", mock.Anything).Return("token", nil).Once()
+				conn.On("GetKeySalt", userdata.AuthToken("token")).Return(nil, storage.ErrUnauthenticated).Once()
+			},
+			func() {
+				handlers.authToken = ""
+				err := handlers.Register(userdata.UserCredentials{
+					Login:    "Login",
+					Password: "Password",
+					AESKey:   "hello",
+				})
+				assert.Equal(t, storage.ErrUnauthenticated, err)
+				assert.Empty(t, handlers.authToken)
 			},
 		},
 		{
@@ -77,7 +110,7 @@ func TestClient_Register(t *testing.T) {
 
 func TestClient_Login(t *testing.T) {
 	conn := mocks.NewClientConnection(t)
-	handlers := newClientHandlers(conn)
+	handlers := newTestClient(conn)
 
 	tc := []struct {
 		name  string
@@ -92,6 +125,7 @@ func TestClient_Login(t *testing.T) {
 					Password: "Password",
 					AESKey:   "hello",
 				}).Return("token", nil).Once()
+				conn.On("GetKeySalt", userdata.AuthToken("token")).Return([]byte("salt"), nil).Once()
 			},
 			func() {
 				err := handlers.Login(userdata.UserCredentials{
@@ -106,6 +140,24 @@ func TestClient_Login(t *testing.T) {
 					"hello",
 					handlers.AESKey,
 				)
+				assert.Equal(t, []byte("salt"), handlers.keySalt)
+			},
+		},
+		{
+			"Login without key salt",
+			func() {
+				conn.On("Login", mock.Anything).Return("token", nil).Once()
+				conn.On("GetKeySalt", userdata.AuthToken("token")).Return(nil, storage.ErrUnauthenticated).Once()
+			},
+			func() {
+				handlers.authToken = ""
+				err := handlers.Login(userdata.UserCredentials{
+					Login:    "Login",
+					Password: "Password",
+					AESKey:   "hello",
+				})
+				assert.Equal(t, storage.ErrUnauthenticated, err)
+				assert.Empty(t, handlers.authToken)
 			},
 		},
 		{
@@ -134,7 +186,7 @@ func TestClient_Login(t *testing.T) {
 
 func TestClient_GetRecordsInfo(t *testing.T) {
 	conn := mocks.NewClientConnection(t)
-	handlers := newClientHandlers(conn)
+	handlers := newTestClient(conn)
 	handlers.authToken = "token"
 
 	tc := []struct {
@@ -184,9 +236,9 @@ func TestClient_GetRecordsInfo(t *testing.T) {
 
 func TestClient_GetRecord(t *testing.T) {
 	conn := mocks.NewClientConnection(t)
-	handlers := newClientHandlers(conn)
+	handlers := newTestClient(conn)
 	handlers.authToken = "token"
-	handlers.AESKey = "hello"
+	handlers.setKey("hello", "")
 
 	tc := []struct {
 		name  string
@@ -229,9 +281,9 @@ func TestClient_GetRecord(t *testing.T) {
 
 func TestClient_DeleteRecord(t *testing.T) {
 	conn := mocks.NewClientConnection(t)
-	handlers := newClientHandlers(conn)
+	handlers := newTestClient(conn)
 	handlers.authToken = "token"
-	handlers.AESKey = "hello"
+	handlers.setKey("hello", "")
 
 	tc := []struct {
 		name  string
@@ -270,9 +322,9 @@ func TestClient_DeleteRecord(t *testing.T) {
 
 func TestClient_CreateRecord(t *testing.T) {
 	conn := mocks.NewClientConnection(t)
-	handlers := newClientHandlers(conn)
+	handlers := newTestClient(conn)
 	handlers.authToken = "token"
-	handlers.AESKey = "masterkey"
+	handlers.setKey("masterkey", "")
 
 	tc := []struct {
 		name  string
@@ -286,13 +338,14 @@ func TestClient_CreateRecord(t *testing.T) {
 					"CreateRecord",
 					userdata.AuthToken("token"),
 					mock.AnythingOfType("userdata.Record"),
-				).Return(nil).Once()
+				).Return(userdata.RecordMeta{ID: "1", Version: 1}, nil).Once()
 			},
 			func() {
-				err := handlers.CreateRecord(userdata.Record{
+				meta, err := handlers.CreateRecord(userdata.Record{
 					Data: []byte("hello!"),
 				})
 				assert.NoError(t, err)
+				assert.Equal(t, userdata.RecordMeta{ID: "1", Version: 1}, meta)
 			},
 		},
 		{
@@ -302,10 +355,10 @@ func TestClient_CreateRecord(t *testing.T) {
 					"CreateRecord",
 					userdata.AuthToken("token"),
 					mock.AnythingOfType("userdata.Record"),
-				).Return(storage.ErrUnauthenticated).Once()
+				).Return(userdata.RecordMeta{}, storage.ErrUnauthenticated).Once()
 			},
 			func() {
-				err := handlers.CreateRecord(userdata.Record{
+				_, err := handlers.CreateRecord(userdata.Record{
 					Data: []byte("hello!"),
 				})
 				assert.Equal(t, storage.ErrUnauthenticated, err)
@@ -318,10 +371,10 @@ func TestClient_CreateRecord(t *testing.T) {
 					"CreateRecord",
 					userdata.AuthToken("token"),
 					mock.AnythingOfType("userdata.Record"),
-				).Return(storage.ErrUnknown).Once()
+				).Return(userdata.RecordMeta{}, storage.ErrUnknown).Once()
 			},
 			func() {
-				err := handlers.CreateRecord(userdata.Record{
+				_, err := handlers.CreateRecord(userdata.Record{
 					Data: []byte("hello!"),
 				})
 				assert.Equal(t, storage.ErrUnknown, err)
@@ -336,3 +389,361 @@ func TestClient_CreateRecord(t *testing.T) {
 		conn.AssertExpectations(t)
 	}
 }
+
+func TestClient_BatchRecords(t *testing.T) {
+	conn := mocks.NewClientConnection(t)
+	handlers := newTestClient(conn)
+	handlers.authToken = "token"
+	handlers.setKey("masterkey", "")
+
+	encrypted, err := crypt.AES256CBCEncode([]byte("hello!"), "masterkey")
+	assert.NoError(t, err)
+
+	var created []userdata.Record
+	conn.On("CreateRecords", userdata.AuthToken("token"), mock.MatchedBy(func(records []userdata.Record) bool {
+		return len(records) == 2 && string(records[0].Data) != "hello!" && records[0].KeyHint != "" &&
+			storage.ValidRecordID(records[0].ID) && records[0].ID != records[1].ID
+	})).Run(func(args mock.Arguments) {
+		created = args.Get(1).([]userdata.Record)
+	}).Return([]userdata.RecordResult{{ID: "1"}, {ID: "2"}}, nil).Once()
+
+	results, err := handlers.CreateRecords([]userdata.Record{{Data: []byte("hello!")}, {Data: []byte("world!")}})
+	assert.NoError(t, err)
+	assert.Len(t, results, 2)
+
+	// Data of record is bound to its ID, so server can't swap data of records
+	swapped := created[0]
+	swapped.ID = created[1].ID
+
+	conn.On("GetRecords", userdata.AuthToken("token"), []string{"1", "2", "3", "4", "5"}).Return([]userdata.RecordResult{
+		{ID: "1", Record: userdata.Record{ID: "1", Type: userdata.TypeText, Data: encrypted}},
+		{ID: "2", Record: userdata.Record{ID: "2", Type: userdata.TypeText, Data: make([]byte, 32)}},
+		{ID: "3", Err: storage.ErrNotFound},
+		{ID: "4", Record: created[1]},
+		{ID: "5", Record: swapped},
+	}, nil).Once()
+
+	results, err = handlers.GetRecords([]string{"1", "2", "3", "4", "5"})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("hello!"), results[0].Record.Data)
+	assert.Equal(t, storage.ErrUnknown, results[1].Err, "not decrypted record")
+	assert.Equal(t, storage.ErrNotFound, results[2].Err)
+	assert.Equal(t, []byte("world!"), results[3].Record.Data)
+	assert.Equal(t, storage.ErrUnknown, results[4].Err, "swapped record")
+
+	conn.On("DeleteRecords", userdata.AuthToken("token"), []string{"1"}).Return(nil, storage.ErrUnauthenticated).Once()
+
+	_, err = handlers.DeleteRecords([]string{"1"})
+	assert.Equal(t, storage.ErrUnauthenticated, err)
+}
+
+func TestClient_ReencryptRecords(t *testing.T) {
+	conn := mocks.NewClientConnection(t)
+	handlers := newTestClient(conn)
+	handlers.authToken = "token"
+	handlers.setKey("masterkey", "")
+
+	legacy, err := crypt.AES256CBCEncode([]byte("hello!"), "masterkey")
+	assert.NoError(t, err)
+	current, err := handlers.cipher.Encrypt([]byte("world!"), handlers.associatedData(userdata.Record{ID: "2", Type: userdata.TypeText}))
+	assert.NoError(t, err)
+
+	conn.On("GetRecordsInfo", userdata.AuthToken("token")).
+		Return([]userdata.Record{{ID: "1"}, {ID: "2"}, {ID: "3"}}, nil).Once()
+	conn.On("GetRecords", userdata.AuthToken("token"), []string{"1", "2", "3"}).Return([]userdata.RecordResult{
+		{ID: "1", Record: userdata.Record{ID: "1", Type: userdata.TypeText, Metadata: "legacy", Data: legacy}},
+		{ID: "2", Record: userdata.Record{ID: "2", Type: userdata.TypeText, Data: current}},
+		{ID: "3", Err: storage.ErrNotFound},
+	}, nil).Once()
+	conn.On("CreateRecords", userdata.AuthToken("token"), mock.MatchedBy(func(records []userdata.Record) bool {
+		if len(records) != 1 || records[0].Metadata != "legacy" || records[0].ID == "1" {
+			return false
+		}
+
+		decrypted, isLegacy, err := handlers.decryptData(records[0])
+
+		return err == nil && !isLegacy && len(records[0].DataKey) > 0 && string(decrypted) == "hello!"
+	})).Return([]userdata.RecordResult{{ID: "4"}}, nil).Once()
+	conn.On("DeleteRecords", userdata.AuthToken("token"), []string{"1"}).
+		Return([]userdata.RecordResult{{ID: "1"}}, nil).Once()
+
+	count, err := handlers.ReencryptRecords()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.Equal(t, 0, handlers.LegacyRecords())
+
+	conn.On("GetRecordsInfo", userdata.AuthToken("token")).Return(nil, storage.ErrUnauthenticated).Once()
+
+	_, err = handlers.ReencryptRecords()
+	assert.Equal(t, storage.ErrUnauthenticated, err)
+}
+
+func TestClient_Keyring(t *testing.T) {
+	conn := mocks.NewClientConnection(t)
+	handlers := newTestClient(conn)
+	handlers.authToken = "token"
+	handlers.setKey("firstkey", "home")
+
+	first, err := handlers.encryptRecord(userdata.Record{Type: userdata.TypeText, Data: []byte("first")})
+	assert.NoError(t, err)
+	fingerprint, label, ok := userdata.ParseKeyHint(first.KeyHint)
+	assert.True(t, ok)
+	assert.Equal(t, "home", label)
+	assert.NotContains(t, first.KeyHint, "first")
+
+	// New key is used for new records, previous one stays in keyring
+	assert.Equal(t, ErrEmptyField, handlers.SetAESKey("", "work"))
+	assert.Equal(t, ErrInvalidKeyLabel, handlers.SetAESKey("secondkey", "new\nline"))
+	assert.NoError(t, handlers.SetAESKey("secondkey", "work"))
+
+	second, err := handlers.encryptRecord(userdata.Record{Type: userdata.TypeText, Data: []byte("second")})
+	assert.NoError(t, err)
+	other, label, ok := userdata.ParseKeyHint(second.KeyHint)
+	assert.True(t, ok)
+	assert.Equal(t, "work", label)
+	assert.NotEqual(t, fingerprint, other)
+
+	scrubbed := first
+	scrubbed.KeyHint = ""
+
+	conn.On("GetRecords", userdata.AuthToken("token"), []string{first.ID, second.ID, scrubbed.ID}).
+		Return([]userdata.RecordResult{{ID: first.ID, Record: first}, {ID: second.ID, Record: second}, {ID: scrubbed.ID, Record: scrubbed}}, nil).Twice()
+
+	results, err := handlers.GetRecords([]string{first.ID, second.ID, scrubbed.ID})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("first"), results[0].Record.Data)
+	assert.Equal(t, []byte("second"), results[1].Record.Data)
+	assert.Equal(t, []byte("first"), results[2].Record.Data, "record without fingerprint is decrypted with other key of keyring")
+
+	// Keyring of other session has only key of login, old key is added to it
+	session := newTestClient(conn)
+	session.authToken = "token"
+	session.setKey("secondkey", "")
+
+	results, err = session.GetRecords([]string{first.ID, second.ID, scrubbed.ID})
+	assert.NoError(t, err)
+	assert.Equal(t, storage.ErrUnknown, results[0].Err)
+	assert.Equal(t, []byte("second"), results[1].Record.Data)
+	assert.Equal(t, storage.ErrUnknown, results[2].Err)
+
+	assert.Equal(t, ErrEmptyField, session.AddAESKey("", "home"))
+	assert.NoError(t, session.AddAESKey("firstkey", "home"))
+	conn.On("GetRecords", userdata.AuthToken("token"), []string{first.ID, scrubbed.ID}).
+		Return([]userdata.RecordResult{{ID: first.ID, Record: first}, {ID: scrubbed.ID, Record: scrubbed}}, nil).Once()
+
+	results, err = session.GetRecords([]string{first.ID, scrubbed.ID})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("first"), results[0].Record.Data)
+	assert.Equal(t, []byte("first"), results[1].Record.Data)
+
+	// New records are encrypted with key of login, not with added one
+	record, err := session.encryptRecord(userdata.Record{Type: userdata.TypeText, Data: []byte("third")})
+	assert.NoError(t, err)
+	assert.Equal(t, second.KeyHint[:len("fp:")+userdata.FingerprintSize], record.KeyHint[:len("fp:")+userdata.FingerprintSize])
+}
+
+func TestClient_RotateKey(t *testing.T) {
+	conn := mocks.NewClientConnection(t)
+	handlers := newTestClient(conn)
+	handlers.authToken = "token"
+	handlers.setKey("oldkey", "old")
+
+	// info returns record as it's listed by server, without data
+	info := func(record userdata.Record) userdata.Record {
+		return userdata.Record{ID: record.ID, Type: record.Type, KeyHint: record.KeyHint, DataKey: record.DataKey, Version: record.Version}
+	}
+
+	first, err := handlers.encryptRecord(userdata.Record{Type: userdata.TypeText, Data: []byte("first")})
+	assert.NoError(t, err)
+	first.Version = 1
+
+	// Record without data key is encrypted with key itself
+	second := userdata.Record{ID: "2", Type: userdata.TypeText, KeyHint: handlers.keyHint, Version: 3}
+	second.Data, err = handlers.cipher.Encrypt([]byte("second"), handlers.associatedData(second))
+	assert.NoError(t, err)
+
+	_, err = handlers.RotateKey("", "new", nil)
+	assert.Equal(t, ErrEmptyField, err)
+	_, err = handlers.RotateKey("newkey", "new\nline", nil)
+	assert.Equal(t, ErrInvalidKeyLabel, err)
+
+	// Rotation fails, new key is current anyway
+	conn.On("GetRecordsInfo", userdata.AuthToken("token")).Return([]userdata.Record{info(first), info(second)}, nil).Once()
+	conn.On("RewrapRecords", userdata.AuthToken("token"), mock.Anything).Return(nil, ErrUnavailable).Once()
+
+	_, err = handlers.RotateKey("newkey", "new", nil)
+	assert.Equal(t, ErrUnavailable, err)
+	_, label, _ := userdata.ParseKeyHint(handlers.keyHint)
+	assert.Equal(t, "new", label)
+
+	// Data keys are rewrapped without data, records without data key get it
+	var rewrapped, replaced []userdata.Record
+	conn.On("GetRecordsInfo", userdata.AuthToken("token")).Return([]userdata.Record{info(first), info(second)}, nil).Once()
+	conn.On("RewrapRecords", userdata.AuthToken("token"), mock.Anything).
+		Return(func(_ userdata.AuthToken, records []userdata.Record) ([]userdata.RecordResult, error) {
+			rewrapped = records
+
+			return []userdata.RecordResult{{ID: first.ID}}, nil
+		}).Once()
+	conn.On("GetRecords", userdata.AuthToken("token"), []string{second.ID}).
+		Return([]userdata.RecordResult{{ID: second.ID, Record: second}}, nil).Once()
+	conn.On("ReplaceRecords", userdata.AuthToken("token"), mock.Anything).
+		Return(func(_ userdata.AuthToken, records []userdata.Record) ([]userdata.RecordResult, error) {
+			replaced = records
+
+			return []userdata.RecordResult{{ID: second.ID}}, nil
+		}).Once()
+
+	var progress [][2]int
+	count, err := handlers.RotateKey("newkey", "new", func(done int, total int) {
+		progress = append(progress, [2]int{done, total})
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.Equal(t, [][2]int{{0, 2}, {1, 2}, {2, 2}}, progress)
+
+	// Records keep IDs and versions and are decrypted only with new key
+	session := newTestClient(conn)
+	session.login = handlers.login
+	session.setKey("newkey", "")
+
+	if assert.Len(t, rewrapped, 1) {
+		assert.Equal(t, first.ID, rewrapped[0].ID)
+		assert.Equal(t, first.Version, rewrapped[0].Version)
+		assert.Equal(t, handlers.keyHint, rewrapped[0].KeyHint)
+		assert.Empty(t, rewrapped[0].Data)
+
+		record := rewrapped[0]
+		record.Data = first.Data
+		decrypted, _, err := session.decryptData(record)
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("first"), decrypted)
+	}
+
+	if assert.Len(t, replaced, 1) {
+		assert.Equal(t, second.ID, replaced[0].ID)
+		assert.Equal(t, second.Version, replaced[0].Version)
+		assert.Equal(t, handlers.keyHint, replaced[0].KeyHint)
+		assert.NotEmpty(t, replaced[0].DataKey)
+
+		decrypted, _, err := session.decryptData(replaced[0])
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("second"), decrypted)
+	}
+
+	// Rotation is resumed, records with new key are skipped
+	rotated := info(first)
+	rotated.KeyHint = handlers.keyHint
+	conn.On("GetRecordsInfo", userdata.AuthToken("token")).Return([]userdata.Record{rotated}, nil).Once()
+
+	count, err = handlers.RotateKey("newkey", "new", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, count)
+
+	// Record of key, which isn't in keyring, stops rotation
+	other := newTestClient(conn)
+	other.setKey("otherkey", "")
+	foreign, err := other.encryptRecord(userdata.Record{Type: userdata.TypeText, Data: []byte("foreign")})
+	assert.NoError(t, err)
+
+	conn.On("GetRecordsInfo", userdata.AuthToken("token")).Return([]userdata.Record{info(foreign)}, nil).Once()
+
+	_, err = handlers.RotateKey("newkey", "new", nil)
+	assert.Equal(t, ErrKeyNotInKeyring, err)
+}
+
+func TestClient_Vault(t *testing.T) {
+	conn := mocks.NewClientConnection(t)
+	handlers := newTestClient(conn)
+	handlers.authToken = "token"
+	handlers.login = "user"
+	handlers.setKey("aeskey", "")
+
+	text, err := handlers.encryptRecord(userdata.Record{Type: userdata.TypeText, Metadata: "note", Data: []byte("text")})
+	assert.NoError(t, err)
+	file, err := handlers.encryptRecord(userdata.Record{Type: userdata.TypeFile, Metadata: "file.txt", Data: []byte("file")})
+	assert.NoError(t, err)
+
+	_, err = handlers.ExportVault(&bytes.Buffer{}, "", nil)
+	assert.Equal(t, ErrEmptyField, err)
+
+	// Record deleted since listing isn't exported
+	conn.On("GetRecordsInfo", userdata.AuthToken("token")).
+		Return([]userdata.Record{{ID: text.ID}, {ID: file.ID}, {ID: "deleted"}}, nil).Once()
+	conn.On("GetRecords", userdata.AuthToken("token"), []string{text.ID, file.ID, "deleted"}).
+		Return([]userdata.RecordResult{
+			{ID: text.ID, Record: text},
+			{ID: file.ID, Record: file},
+			{ID: "deleted", Err: storage.ErrNotFound},
+		}, nil).Once()
+
+	var archive bytes.Buffer
+	var progress [][2]int
+	count, err := handlers.ExportVault(&archive, "password", func(done int, total int) {
+		progress = append(progress, [2]int{done, total})
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.Equal(t, [][2]int{{0, 3}, {3, 3}}, progress)
+
+	// Archive can't be read with other password
+	_, _, err = handlers.ImportVault(bytes.NewReader(archive.Bytes()), "other", nil)
+	assert.Equal(t, vault.ErrPassword, err)
+
+	// Import to the same account skips existing records
+	conn.On("GetRecordsInfo", userdata.AuthToken("token")).Return([]userdata.Record{{ID: text.ID}}, nil).Once()
+
+	var created []userdata.Record
+	conn.On("CreateRecords", userdata.AuthToken("token"), mock.Anything).
+		Return(func(_ userdata.AuthToken, records []userdata.Record) ([]userdata.RecordResult, error) {
+			created = records
+
+			return []userdata.RecordResult{{ID: records[0].ID}}, nil
+		}).Once()
+
+	imported, skipped, err := handlers.ImportVault(bytes.NewReader(archive.Bytes()), "password", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, imported)
+	assert.Equal(t, 1, skipped)
+	if assert.Len(t, created, 1) {
+		assert.Equal(t, file.ID, created[0].ID)
+		assert.Equal(t, "file.txt", created[0].Metadata)
+
+		decrypted, _, err := handlers.decryptData(created[0])
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("file"), decrypted)
+	}
+
+	// Import to other account derives IDs, so records aren't imported twice
+	other := newTestClient(conn)
+	other.authToken = "other"
+	other.login = "other"
+	other.setKey("otherkey", "")
+
+	conn.On("GetRecordsInfo", userdata.AuthToken("other")).Return(nil, nil).Once()
+	conn.On("CreateRecords", userdata.AuthToken("other"), mock.Anything).
+		Return(func(_ userdata.AuthToken, records []userdata.Record) ([]userdata.RecordResult, error) {
+			created = records
+
+			return []userdata.RecordResult{{ID: records[0].ID}, {ID: records[1].ID}}, nil
+		}).Once()
+
+	imported, skipped, err = other.ImportVault(bytes.NewReader(archive.Bytes()), "password", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, imported)
+	assert.Equal(t, 0, skipped)
+	if assert.Len(t, created, 2) {
+		assert.Equal(t, storage.DeriveRecordID("other", text.ID), created[0].ID)
+
+		decrypted, _, err := other.decryptData(created[0])
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("text"), decrypted)
+	}
+
+	conn.On("GetRecordsInfo", userdata.AuthToken("other")).Return(created, nil).Once()
+
+	imported, skipped, err = other.ImportVault(bytes.NewReader(archive.Bytes()), "password", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, imported)
+	assert.Equal(t, 2, skipped)
+}
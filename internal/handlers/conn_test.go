@@ -2,9 +2,13 @@ package handlers
 
 import (
 	"context"
+	"errors"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/impr0ver/gophKeeper/internal/clientconfig"
+	"github.com/impr0ver/gophKeeper/internal/crypt"
 	"github.com/impr0ver/gophKeeper/internal/handlers/mocks"
 	"github.com/impr0ver/gophKeeper/internal/serverconfig"
 	"github.com/impr0ver/gophKeeper/internal/storage"
@@ -14,6 +18,31 @@ import (
 	"github.com/stretchr/testify/mock"
 )
 
+// mockSRPLogin makes handlers answer SRP login of user with token, password of user is in credentials.
+func mockSRPLogin(t *testing.T, handlers *mocks.ServerHandlers, credentials userdata.UserCredentials, token userdata.AuthToken) {
+	verifier, err := crypt.NewSRPVerifier(credentials, testKeyParams)
+	assert.NoError(t, err)
+
+	var server *crypt.SRPServer
+
+	handlers.On("StartLoginSRP", credentials.Login, mock.Anything).Return(func(_ string, a []byte) (string, crypt.SRPChallenge, error) {
+		server, err = crypt.NewSRPServer(verifier, a)
+		if err != nil {
+			return "", crypt.SRPChallenge{}, storage.ErrWrongCredentials
+		}
+
+		return "session", server.Challenge(), nil
+	}).Once()
+	handlers.On("FinishLoginSRP", "session", mock.Anything).Return(func(_ string, proof []byte) (userdata.AuthToken, []byte, error) {
+		serverProof, err := server.Verify(proof)
+		if err != nil {
+			return "", nil, storage.ErrWrongCredentials
+		}
+
+		return token, serverProof, nil
+	}).Once()
+}
+
 func TestCreateUser(t *testing.T) {
 	var serverCfg = serverconfig.ServerConfig{}
 	serverCfg.ServerCert = "../../cmd/cert/server-cert.pem"
@@ -33,6 +62,12 @@ func TestCreateUser(t *testing.T) {
 	server.Start(ctx, serverCfg.ListenAddr)
 	
 	client := newClientConn(clientCfg.ServerAddress, clientCfg.ClientCert)
+	client.Passwords = testKeyParams
+
+	// Password mustn't be sent to server, only its verifier
+	verifier := mock.MatchedBy(func(verifier string) bool {
+		return crypt.ValidSRPVerifier(verifier, testKeyParams) && !strings.Contains(verifier, "Password")
+	})
 
 	tc := []struct {
 		name  string
@@ -42,10 +77,7 @@ func TestCreateUser(t *testing.T) {
 		{
 			"Create user",
 			func() {
-				handlers.On("CreateUser", userdata.UserCredentials{
-					Login:    "Login",
-					Password: "Password",
-				}).Return(userdata.AuthToken("token"), nil).Once()
+				handlers.On("CreateUserSRP", "Login", verifier).Return(userdata.AuthToken("token"), nil).Once()
 			},
 			func() {
 				token, err := client.Register(userdata.UserCredentials{
@@ -59,34 +91,28 @@ func TestCreateUser(t *testing.T) {
 		{
 			"Create user, but error",
 			func() {
-				handlers.On("CreateUser", userdata.UserCredentials{
-					Login:    "Login",
-					Password: "Password",
-				}).Return(userdata.AuthToken("token"), storage.ErrLoginExists).Once()
+				handlers.On("CreateUserSRP", "Login", verifier).Return(userdata.AuthToken("token"), storage.ErrLoginExists).Once()
 			},
 			func() {
 				token, err := client.Register(userdata.UserCredentials{
 					Login:    "Login",
 					Password: "Password",
 				})
-				assert.Equal(t, storage.ErrLoginExists, err)
+				assert.ErrorIs(t, err, storage.ErrLoginExists)
 				assert.Empty(t, token)
 			},
 		},
 		{
 			"Create user, but unknown error",
 			func() {
-				handlers.On("CreateUser", userdata.UserCredentials{
-					Login:    "Login",
-					Password: "Password",
-				}).Return(userdata.AuthToken("token"), storage.ErrUnknown).Once()
+				handlers.On("CreateUserSRP", "Login", verifier).Return(userdata.AuthToken("token"), storage.ErrUnknown).Once()
 			},
 			func() {
 				token, err := client.Register(userdata.UserCredentials{
 					Login:    "Login",
 					Password: "Password",
 				})
-				assert.Equal(t, storage.ErrUnknown, err)
+				assert.ErrorIs(t, err, storage.ErrUnknown)
 				assert.Empty(t, token)
 			},
 		},
@@ -122,6 +148,15 @@ func TestLoginUser(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	server.Start(ctx, serverCfg.ListenAddr)
 
+	credentials := userdata.UserCredentials{
+		Login:    "Login",
+		Password: "Password",
+	}
+	other := userdata.UserCredentials{
+		Login:    "Login",
+		Password: "Other",
+	}
+
 	tc := []struct {
 		name  string
 		mock  func()
@@ -130,51 +165,63 @@ func TestLoginUser(t *testing.T) {
 		{
 			"Login user",
 			func() {
-				handlers.On("LoginUser", userdata.UserCredentials{
-					Login:    "Login",
-					Password: "Password",
-				}).Return(userdata.AuthToken("token"), nil).Once()
+				mockSRPLogin(t, handlers, credentials, "token")
 			},
 			func() {
-				token, err := client.Login(userdata.UserCredentials{
-					Login:    "Login",
-					Password: "Password",
-				})
+				token, err := client.Login(credentials)
 				assert.NoError(t, err)
 				assert.Equal(t, "token", token)
 			},
 		},
 		{
-			"Login user, but error",
+			"Login user, but wrong password",
 			func() {
-				handlers.On("LoginUser", userdata.UserCredentials{
-					Login:    "Login",
-					Password: "Password",
-				}).Return(userdata.AuthToken("token"), storage.ErrWrongCredentials).Once()
+				mockSRPLogin(t, handlers, other, "token")
 			},
 			func() {
-				token, err := client.Login(userdata.UserCredentials{
-					Login:    "Login",
-					Password: "Password",
-				})
-				assert.Equal(t, storage.ErrWrongCredentials, err)
+				token, err := client.Login(credentials)
+				assert.ErrorIs(t, err, storage.ErrWrongCredentials)
 				assert.Empty(t, token)
 			},
 		},
 		{
-			"Create user, but unknown error",
+			"Login user, but unknown error",
 			func() {
-				handlers.On("LoginUser", userdata.UserCredentials{
-					Login:    "Login",
-					Password: "Password",
-				}).Return(userdata.AuthToken("token"), storage.ErrUnknown).Once()
+				handlers.On("StartLoginSRP", "Login", mock.Anything).Return("", crypt.SRPChallenge{}, storage.ErrUnknown).Once()
 			},
 			func() {
-				token, err := client.Login(userdata.UserCredentials{
-					Login:    "Login",
-					Password: "Password",
-				})
-				assert.Equal(t, storage.ErrUnknown, err)
+				token, err := client.Login(credentials)
+				assert.ErrorIs(t, err, storage.ErrUnknown)
+				assert.Empty(t, token)
+			},
+		},
+		{
+			"Login user with legacy login",
+			func() {
+				mockSRPLogin(t, handlers, other, "token")
+				handlers.On("LoginUser", credentials).Return(userdata.AuthToken("legacyToken"), nil).Once()
+			},
+			func() {
+				client.LegacyLogin = true
+				defer func() { client.LegacyLogin = false }()
+
+				token, err := client.Login(credentials)
+				assert.NoError(t, err)
+				assert.Equal(t, "legacyToken", token)
+			},
+		},
+		{
+			"Login user with legacy login, but it's disabled",
+			func() {
+				mockSRPLogin(t, handlers, other, "token")
+				handlers.On("LoginUser", credentials).Return(userdata.AuthToken(""), ErrLegacyLogin).Once()
+			},
+			func() {
+				client.LegacyLogin = true
+				defer func() { client.LegacyLogin = false }()
+
+				token, err := client.Login(credentials)
+				assert.ErrorIs(t, err, storage.ErrWrongCredentials)
 				assert.Empty(t, token)
 			},
 		},
@@ -209,6 +256,9 @@ func TestGetRecordsInfo(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	server.Start(ctx, serverCfg.ListenAddr)
 
+	created := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	updated := created.Add(time.Hour)
+
 	tc := []struct {
 		name  string
 		mock  func()
@@ -217,37 +267,42 @@ func TestGetRecordsInfo(t *testing.T) {
 		{
 			"Get all records",
 			func() {
-				handlers.On("GetRecordsInfo", mock.AnythingOfType("*context.valueCtx")).
-					Return([]userdata.Record{}, nil).Once()
+				handlers.On("GetRecordsInfo", mock.AnythingOfType("*context.valueCtx"), userdata.UserID("userID")).
+					Return([]userdata.Record{{ID: "recordID", Version: 2, CreatedAt: created, UpdatedAt: updated}}, nil).Once()
 				auth.On("ValidateToken", userdata.AuthToken("token")).Return(userdata.UserID("userID"), nil).Once()
 			},
 			func() {
-				_, err := client.GetRecordsInfo("token")
+				records, err := client.GetRecordsInfo("token")
 				assert.NoError(t, err)
+				if assert.Len(t, records, 1) {
+					assert.Equal(t, int64(2), records[0].Version)
+					assert.True(t, created.Equal(records[0].CreatedAt))
+					assert.True(t, updated.Equal(records[0].UpdatedAt))
+				}
 			},
 		},
 		{
 			"Get all records, but error",
 			func() {
-				handlers.On("GetRecordsInfo", mock.AnythingOfType("*context.valueCtx")).
+				handlers.On("GetRecordsInfo", mock.AnythingOfType("*context.valueCtx"), userdata.UserID("userID")).
 					Return([]userdata.Record{}, storage.ErrUnauthenticated).Once()
 				auth.On("ValidateToken", userdata.AuthToken("token")).Return(userdata.UserID("userID"), nil).Once()
 			},
 			func() {
 				_, err := client.GetRecordsInfo("token")
-				assert.Equal(t, storage.ErrUnauthenticated, err)
+				assert.ErrorIs(t, err, storage.ErrUnauthenticated)
 			},
 		},
 		{
 			"Get all records, but unknown error",
 			func() {
-				handlers.On("GetRecordsInfo", mock.AnythingOfType("*context.valueCtx")).
+				handlers.On("GetRecordsInfo", mock.AnythingOfType("*context.valueCtx"), userdata.UserID("userID")).
 					Return([]userdata.Record{}, storage.ErrUnknown).Once()
 				auth.On("ValidateToken", userdata.AuthToken("token")).Return(userdata.UserID("userID"), nil).Once()
 			},
 			func() {
 				_, err := client.GetRecordsInfo("token")
-				assert.Equal(t, storage.ErrUnknown, err)
+				assert.ErrorIs(t, err, storage.ErrUnknown)
 			},
 		},
 	}
@@ -289,7 +344,7 @@ func TestGetRecord(t *testing.T) {
 		{
 			"Get record",
 			func() {
-				handlers.On("GetRecord", mock.AnythingOfType("*context.valueCtx"), "recordID").
+				handlers.On("GetRecord", mock.AnythingOfType("*context.valueCtx"), userdata.UserID("userID"), "recordID").
 					Return(userdata.Record{}, nil).Once()
 				auth.On("ValidateToken", userdata.AuthToken("token")).Return(userdata.UserID("userID"), nil).Once()
 			},
@@ -301,25 +356,25 @@ func TestGetRecord(t *testing.T) {
 		{
 			"Get record, but wrong ID.",
 			func() {
-				handlers.On("GetRecord", mock.AnythingOfType("*context.valueCtx"), "recordID").
+				handlers.On("GetRecord", mock.AnythingOfType("*context.valueCtx"), userdata.UserID("userID"), "recordID").
 					Return(userdata.Record{}, storage.ErrNotFound).Once()
 				auth.On("ValidateToken", userdata.AuthToken("token")).Return(userdata.UserID("userID"), nil).Once()
 			},
 			func() {
 				_, err := client.GetRecord("token", "recordID")
-				assert.Equal(t, storage.ErrNotFound, err)
+				assert.ErrorIs(t, err, storage.ErrNotFound)
 			},
 		},
 		{
 			"Get record, but unknown error.",
 			func() {
-				handlers.On("GetRecord", mock.AnythingOfType("*context.valueCtx"), "recordID").
+				handlers.On("GetRecord", mock.AnythingOfType("*context.valueCtx"), userdata.UserID("userID"), "recordID").
 					Return(userdata.Record{}, storage.ErrUnknown).Once()
 				auth.On("ValidateToken", userdata.AuthToken("token")).Return(userdata.UserID("userID"), nil).Once()
 			},
 			func() {
 				_, err := client.GetRecord("token", "recordID")
-				assert.Equal(t, storage.ErrUnknown, err)
+				assert.ErrorIs(t, err, storage.ErrUnknown)
 			},
 		},
 	}
@@ -353,6 +408,9 @@ func TestCreateRecord(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	server.Start(ctx, serverCfg.ListenAddr)
 
+	created := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	meta := userdata.RecordMeta{ID: "recordID", Version: 1, CreatedAt: created, UpdatedAt: created}
+
 	tc := []struct {
 		name  string
 		mock  func()
@@ -364,13 +422,18 @@ func TestCreateRecord(t *testing.T) {
 				handlers.On(
 					"CreateRecord",
 					mock.AnythingOfType("*context.valueCtx"),
+					userdata.UserID("userID"),
 					userdata.Record{},
-				).Return(nil).Once()
+				).Return(meta, nil).Once()
 				auth.On("ValidateToken", userdata.AuthToken("token")).Return(userdata.UserID("userID"), nil).Once()
 			},
 			func() {
-				err := client.CreateRecord("token", userdata.Record{})
+				created, err := client.CreateRecord("token", userdata.Record{})
 				assert.NoError(t, err)
+				assert.Equal(t, meta.ID, created.ID)
+				assert.Equal(t, meta.Version, created.Version)
+				assert.True(t, meta.CreatedAt.Equal(created.CreatedAt))
+				assert.True(t, meta.UpdatedAt.Equal(created.UpdatedAt))
 			},
 		},
 		{
@@ -379,13 +442,14 @@ func TestCreateRecord(t *testing.T) {
 				handlers.On(
 					"CreateRecord",
 					mock.AnythingOfType("*context.valueCtx"),
+					userdata.UserID("userID"),
 					userdata.Record{},
-				).Return(storage.ErrUnknown).Once()
+				).Return(userdata.RecordMeta{}, storage.ErrUnknown).Once()
 				auth.On("ValidateToken", userdata.AuthToken("token")).Return(userdata.UserID("userID"), nil).Once()
 			},
 			func() {
-				err := client.CreateRecord("token", userdata.Record{})
-				assert.Equal(t, storage.ErrUnknown, err)
+				_, err := client.CreateRecord("token", userdata.Record{})
+				assert.ErrorIs(t, err, storage.ErrUnknown)
 			},
 		},
 	}
@@ -430,6 +494,7 @@ func TestDeleteRecord(t *testing.T) {
 				handlers.On(
 					"DeleteRecord",
 					mock.AnythingOfType("*context.valueCtx"),
+					userdata.UserID("userID"),
 					"recordID",
 				).Return(nil).Once()
 				auth.On("ValidateToken", userdata.AuthToken("token")).Return(userdata.UserID("userID"), nil).Once()
@@ -445,13 +510,14 @@ func TestDeleteRecord(t *testing.T) {
 				handlers.On(
 					"DeleteRecord",
 					mock.AnythingOfType("*context.valueCtx"),
+					userdata.UserID("userID"),
 					"recordID",
 				).Return(storage.ErrNotFound).Once()
 				auth.On("ValidateToken", userdata.AuthToken("token")).Return(userdata.UserID("userID"), nil).Once()
 			},
 			func() {
 				err := client.DeleteRecord("token", "recordID")
-				assert.Equal(t, storage.ErrNotFound, err)
+				assert.ErrorIs(t, err, storage.ErrNotFound)
 			},
 		},
 		{
@@ -460,13 +526,143 @@ func TestDeleteRecord(t *testing.T) {
 				handlers.On(
 					"DeleteRecord",
 					mock.AnythingOfType("*context.valueCtx"),
+					userdata.UserID("userID"),
 					"recordID",
 				).Return(storage.ErrUnknown).Once()
 				auth.On("ValidateToken", userdata.AuthToken("token")).Return(userdata.UserID("userID"), nil).Once()
 			},
 			func() {
 				err := client.DeleteRecord("token", "recordID")
-				assert.Equal(t, storage.ErrUnknown, err)
+				assert.ErrorIs(t, err, storage.ErrUnknown)
+			},
+		},
+	}
+
+	for _, test := range tc {
+		t.Log(test.name)
+		test.mock()
+		test.valid()
+		handlers.AssertExpectations(t)
+	}
+
+	cancel()
+	server.Stop()
+}
+
+func TestBatchRecords(t *testing.T) {
+	var serverCfg = serverconfig.ServerConfig{}
+	serverCfg.ServerCert = "../../cmd/cert/server-cert.pem"
+	serverCfg.ServerKey = "../../cmd/cert/server-key.pem"
+	serverCfg.ListenAddr = "127.0.0.1:9000"
+
+	var clientCfg = clientconfig.ClientConfig{}
+	clientCfg.ServerAddress = "127.0.0.1:9000"
+	clientCfg.ClientCert = "../../cmd/cert/ca-cert.pem"
+
+	auth := mocks.NewAuthenticator(t)
+	client := newClientConn(clientCfg.ServerAddress, clientCfg.ClientCert)
+	handlers := mocks.NewServerHandlers(t)
+
+	server := NewServerConn(handlers, auth, serverCfg.ServerCert, serverCfg.ServerKey, serverCfg.ServerConsoleLog)
+	ctx, cancel := context.WithCancel(context.Background())
+	server.Start(ctx, serverCfg.ListenAddr)
+
+	tc := []struct {
+		name  string
+		mock  func()
+		valid func()
+	}{
+		{
+			"Create records, one is over quota.",
+			func() {
+				handlers.On(
+					"CreateRecords",
+					mock.AnythingOfType("*context.valueCtx"),
+					userdata.UserID("userID"),
+					[]userdata.Record{{Metadata: "first"}, {Metadata: "second"}},
+				).Return([]userdata.RecordResult{{ID: "1"}, {Err: storage.ErrQuotaExceeded}}, nil).Once()
+				auth.On("ValidateToken", userdata.AuthToken("token")).Return(userdata.UserID("userID"), nil).Once()
+			},
+			func() {
+				results, err := client.CreateRecords("token", []userdata.Record{{Metadata: "first"}, {Metadata: "second"}})
+				assert.NoError(t, err)
+				assert.Len(t, results, 2)
+				assert.Equal(t, "1", results[0].ID)
+				assert.NoError(t, results[0].Err)
+				assert.ErrorIs(t, results[1].Err, storage.ErrQuotaExceeded)
+			},
+		},
+		{
+			"Get records, one is not found and one has internal error.",
+			func() {
+				handlers.On(
+					"GetRecords",
+					mock.AnythingOfType("*context.valueCtx"),
+					userdata.UserID("userID"),
+					[]string{"1", "2", "3"},
+				).Return([]userdata.RecordResult{
+					{ID: "1", Record: userdata.Record{ID: "1", Metadata: "meta", Type: userdata.TypeText, Data: []byte("data")}},
+					{ID: "2", Err: storage.ErrNotFound},
+					{ID: "3", Err: errors.New("disk is broken")},
+				}, nil).Once()
+				auth.On("ValidateToken", userdata.AuthToken("token")).Return(userdata.UserID("userID"), nil).Once()
+			},
+			func() {
+				results, err := client.GetRecords("token", []string{"1", "2", "3"})
+				assert.NoError(t, err)
+				assert.Equal(t, userdata.Record{ID: "1", Metadata: "meta", Type: userdata.TypeText, Data: []byte("data")}, results[0].Record)
+				assert.ErrorIs(t, results[1].Err, storage.ErrNotFound)
+				assert.ErrorIs(t, results[2].Err, storage.ErrUnknown)
+				assert.NotContains(t, results[2].Err.Error(), "disk", "internal error is hidden")
+			},
+		},
+		{
+			"Delete records, but batch is too large.",
+			func() {
+				handlers.On(
+					"DeleteRecords",
+					mock.AnythingOfType("*context.valueCtx"),
+					userdata.UserID("userID"),
+					[]string{"1"},
+				).Return(nil, &FieldError{Field: "ids", Err: ErrBatchTooLarge}).Once()
+				auth.On("ValidateToken", userdata.AuthToken("token")).Return(userdata.UserID("userID"), nil).Once()
+			},
+			func() {
+				_, err := client.DeleteRecords("token", []string{"1"})
+				assert.ErrorIs(t, err, ErrBatchTooLarge)
+			},
+		},
+		{
+			"Replace records, but one was changed.",
+			func() {
+				handlers.On(
+					"ReplaceRecords",
+					mock.AnythingOfType("*context.valueCtx"),
+					userdata.UserID("userID"),
+					[]userdata.Record{{ID: "1", KeyHint: "hint", Type: userdata.TypeText, Data: []byte("data"), Version: 2}},
+				).Return(nil, storage.ErrVersionConflict).Once()
+				auth.On("ValidateToken", userdata.AuthToken("token")).Return(userdata.UserID("userID"), nil).Once()
+			},
+			func() {
+				_, err := client.ReplaceRecords("token", []userdata.Record{{ID: "1", KeyHint: "hint", Type: userdata.TypeText, Metadata: "meta", Data: []byte("data"), Version: 2}})
+				assert.ErrorIs(t, err, storage.ErrVersionConflict)
+			},
+		},
+		{
+			"Rewrap records, data isn't sent.",
+			func() {
+				handlers.On(
+					"RewrapRecords",
+					mock.AnythingOfType("*context.valueCtx"),
+					userdata.UserID("userID"),
+					[]userdata.Record{{ID: "1", KeyHint: "hint", DataKey: []byte("key"), Type: userdata.TypeFile, Version: 2}},
+				).Return([]userdata.RecordResult{{ID: "1", Record: userdata.Record{ID: "1", Version: 3}}}, nil).Once()
+				auth.On("ValidateToken", userdata.AuthToken("token")).Return(userdata.UserID("userID"), nil).Once()
+			},
+			func() {
+				results, err := client.RewrapRecords("token", []userdata.Record{{ID: "1", KeyHint: "hint", DataKey: []byte("key"), Type: userdata.TypeFile, Data: []byte("data"), Version: 2}})
+				assert.NoError(t, err)
+				assert.Equal(t, int64(3), results[0].Record.Version)
 			},
 		},
 	}
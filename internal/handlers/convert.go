@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"time"
+
+	pb "github.com/impr0ver/gophKeeper/internal/rpc"
+	"github.com/impr0ver/gophKeeper/internal/userdata"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// toPBRecord converts record to message, ID of owner is never sent.
+func toPBRecord(record userdata.Record) *pb.Record {
+	return &pb.Record{
+		Id:         record.ID,
+		Type:       pb.MessageType(record.Type),
+		Keyhint:    record.KeyHint,
+		DataKey:    record.DataKey,
+		Metadata:   record.Metadata,
+		StoredData: record.Data,
+		Version:    record.Version,
+		CreatedAt:  toTimestamp(record.CreatedAt),
+		UpdatedAt:  toTimestamp(record.UpdatedAt),
+	}
+}
+
+// fromPBRecord converts message to record.
+func fromPBRecord(record *pb.Record) userdata.Record {
+	return userdata.Record{
+		ID:        record.Id,
+		Metadata:  record.Metadata,
+		KeyHint:   record.Keyhint,
+		DataKey:   record.DataKey,
+		Type:      userdata.RecordType(record.Type),
+		Data:      record.StoredData,
+		Version:   record.Version,
+		CreatedAt: fromTimestamp(record.CreatedAt),
+		UpdatedAt: fromTimestamp(record.UpdatedAt),
+	}
+}
+
+// toPBRecordMeta converts record meta to message.
+func toPBRecordMeta(meta userdata.RecordMeta) *pb.RecordMeta {
+	return &pb.RecordMeta{
+		Id:        meta.ID,
+		Version:   meta.Version,
+		CreatedAt: toTimestamp(meta.CreatedAt),
+		UpdatedAt: toTimestamp(meta.UpdatedAt),
+	}
+}
+
+// fromPBRecordMeta converts message to record meta.
+func fromPBRecordMeta(meta *pb.RecordMeta) userdata.RecordMeta {
+	return userdata.RecordMeta{
+		ID:        meta.Id,
+		Version:   meta.Version,
+		CreatedAt: fromTimestamp(meta.CreatedAt),
+		UpdatedAt: fromTimestamp(meta.UpdatedAt),
+	}
+}
+
+// toTimestamp converts time to message, zero time isn't sent.
+func toTimestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+
+	return timestamppb.New(t)
+}
+
+// fromTimestamp converts message to local time, missing timestamp is zero time.
+func fromTimestamp(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+
+	return ts.AsTime().Local()
+}
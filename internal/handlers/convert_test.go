@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/impr0ver/gophKeeper/internal/userdata"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvertRecord(t *testing.T) {
+	created := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	record := userdata.Record{
+		ID:        "1",
+		Type:      userdata.TypeText,
+		KeyHint:   "hint",
+		Metadata:  "text",
+		Data:      []byte{1, 2},
+		Version:   3,
+		CreatedAt: created,
+		UpdatedAt: created.Add(time.Minute),
+	}
+
+	converted := fromPBRecord(toPBRecord(record))
+	assert.Equal(t, record.Data, converted.Data)
+	assert.Equal(t, int64(3), converted.Version)
+	assert.True(t, record.CreatedAt.Equal(converted.CreatedAt))
+	assert.True(t, record.UpdatedAt.Equal(converted.UpdatedAt))
+
+	meta := fromPBRecordMeta(toPBRecordMeta(record.Meta()))
+	assert.Equal(t, "1", meta.ID)
+	assert.True(t, record.UpdatedAt.Equal(meta.UpdatedAt))
+
+	assert.Nil(t, toTimestamp(time.Time{}), "zero time is not sent")
+	assert.True(t, fromTimestamp(nil).IsZero())
+}
@@ -1,9 +1,69 @@
 package handlers
 
-import "errors"
+import (
+	"errors"
+	"time"
+)
 
 // Handlers errors.
 var (
 	ErrEmptyField  = errors.New("field is empty")
 	ErrWrongAESKey = errors.New("wrong AES key")
+
+	ErrInvalidKeyLabel = errors.New("label of AES key is too long or has control characters")
+	ErrKeyNotInKeyring = errors.New("record can't be decrypted with keys of keyring")
+
+	ErrLegacyLogin     = errors.New("login with password is disabled, use SRP login")
+	ErrInvalidVerifier = errors.New("invalid SRP verifier")
+
+	ErrPermissionDenied = errors.New("admin access required")
+	ErrReservedMetadata = errors.New("metadata key is reserved")
+	ErrRateLimited      = errors.New("rate limit exceeded")
+	ErrUnavailable      = errors.New("server is unavailable")
+
+	ErrBatchTooLarge   = errors.New("too many records in batch")
+	ErrInvalidRecordID = errors.New("record ID must be UUID")
+	ErrInvalidDataKey  = errors.New("data key of record is too large")
+
+	ErrIdempotencyKeyLong   = errors.New("idempotency key is too long")
+	ErrIdempotencyKeyReused = errors.New("idempotency key is used by other request")
+	ErrRequestInProgress    = errors.New("request with idempotency key is in progress")
+	ErrNotProtoResponse     = errors.New("response isn't protobuf message")
 )
+
+// FieldError is error of request field, it's sent to client as bad request violation.
+type FieldError struct {
+	Field string
+	Err   error
+}
+
+// Error returns field name with error.
+func (e *FieldError) Error() string {
+	return e.Field + ": " + e.Err.Error()
+}
+
+// Unwrap returns error of field.
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// emptyField returns error of empty request field.
+func emptyField(field string) error {
+	return &FieldError{Field: field, Err: ErrEmptyField}
+}
+
+// RetryError is error of request which may be retried after delay.
+type RetryError struct {
+	Delay time.Duration
+	Err   error
+}
+
+// Error returns error with retry delay.
+func (e *RetryError) Error() string {
+	return e.Err.Error() + ", retry in " + e.Delay.String()
+}
+
+// Unwrap returns error of request.
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
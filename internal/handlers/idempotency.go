@@ -0,0 +1,233 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	pb "github.com/impr0ver/gophKeeper/internal/rpc"
+	"github.com/impr0ver/gophKeeper/internal/storage"
+	"github.com/impr0ver/gophKeeper/internal/userdata"
+
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// idempotencyKeyMetadata is metadata key of idempotency key sent by client.
+const idempotencyKeyMetadata = "idempotencyKey"
+
+// maxIdempotencyKeyLen is max length of idempotency key.
+const maxIdempotencyKeyLen = 128
+
+// inProgressRetryDelay is delay sent to client, when request with the same key is not done yet.
+const inProgressRetryDelay = time.Second
+
+// idempotencyCleanupInterval is interval of deleting expired idempotency keys.
+const idempotencyCleanupInterval = time.Hour
+
+// mutatingMethods are RPCs, which responses are saved for idempotency keys. Responses of reads may be large
+// and responses of auth RPCs have credentials, so they are never saved.
+var mutatingMethods = map[string]bool{
+	pb.Gokeeper_CreateRecord_FullMethodName:   true,
+	pb.Gokeeper_CreateRecords_FullMethodName:  true,
+	pb.Gokeeper_DeleteRecord_FullMethodName:   true,
+	pb.Gokeeper_DeleteRecords_FullMethodName:  true,
+	pb.Gokeeper_ReplaceRecords_FullMethodName: true,
+	pb.Gokeeper_RewrapRecords_FullMethodName:  true,
+}
+
+// Idempotency saves responses of mutating requests with idempotency key and replays them on retries in window.
+type Idempotency struct {
+	storage storage.IdempotencyStorager
+	window  atomic.Int64
+	methods map[string]bool
+}
+
+// NewIdempotency returns idempotency interceptor.
+func NewIdempotency(storage storage.IdempotencyStorager, window time.Duration) *Idempotency {
+	i := &Idempotency{storage: storage, methods: mutatingMethods}
+	i.SetWindow(window)
+
+	return i
+}
+
+// SetWindow changes time while saved responses are replayed.
+func (i *Idempotency) SetWindow(window time.Duration) {
+	i.window.Store(int64(window))
+}
+
+// Window returns time while saved responses are replayed.
+func (i *Idempotency) Window() time.Duration {
+	return time.Duration(i.window.Load())
+}
+
+// Unary replays saved response, if request has idempotency key which was used before.
+// Keys of other than mutating requests are ignored.
+func (i *Idempotency) Unary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		key, ok := idempotencyKey(ctx)
+		if !ok || !i.methods[info.FullMethod] {
+			return handler(ctx, req)
+		}
+		if len(key) > maxIdempotencyKeyLen {
+			return nil, toStatus(&FieldError{Field: idempotencyKeyMetadata, Err: ErrIdempotencyKeyLong})
+		}
+
+		message, ok := req.(proto.Message)
+		if !ok {
+			return handler(ctx, req)
+		}
+
+		request, err := newIdempotentRequest(ctx, key, info.FullMethod, message)
+		if err != nil {
+			return nil, toStatus(err)
+		}
+
+		saved, reserved, err := i.storage.ReserveIdempotencyKey(ctx, request, i.Window())
+		if errors.Is(err, storage.ErrNotFound) {
+			// Other request with the key was released right now
+			return nil, toStatus(&RetryError{Delay: inProgressRetryDelay, Err: ErrRequestInProgress})
+		}
+		if err != nil {
+			return nil, toStatus(err)
+		}
+		if !reserved {
+			return replay(request, saved)
+		}
+
+		resp, err := handler(ctx, req)
+		if err != nil {
+			// Failed request may be done again with the same key, client may be gone already
+			if releaseErr := i.storage.ReleaseIdempotencyKey(context.Background(), request); releaseErr != nil {
+				log.Warnf("release idempotency key error: %v", releaseErr)
+			}
+
+			return nil, err
+		}
+
+		if err := i.complete(request, resp); err != nil {
+			log.Warnf("save idempotent response error: %v", err)
+
+			// Otherwise retries get "in progress" until key expires
+			if releaseErr := i.storage.ReleaseIdempotencyKey(context.Background(), request); releaseErr != nil {
+				log.Warnf("release idempotency key error: %v", releaseErr)
+			}
+		}
+
+		return resp, nil
+	}
+}
+
+// Stream doesn't support idempotency keys, streams are passed as is.
+func (i *Idempotency) Stream() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, ss)
+	}
+}
+
+// Cleanup deletes expired requests every interval until ctx is done.
+func (i *Idempotency) Cleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := i.storage.DeleteExpiredIdempotencyKeys(ctx, time.Now().Add(-i.Window()))
+			if err != nil {
+				log.Warnf("delete expired idempotency keys error: %v", err)
+				continue
+			}
+			if deleted > 0 {
+				log.Infof("%d expired idempotency keys are deleted", deleted)
+			}
+		}
+	}
+}
+
+// complete saves response of reserved request.
+func (i *Idempotency) complete(request userdata.IdempotentRequest, resp interface{}) error {
+	message, ok := resp.(proto.Message)
+	if !ok {
+		return ErrNotProtoResponse
+	}
+
+	response, err := anypb.New(message)
+	if err != nil {
+		return err
+	}
+
+	request.Response, err = proto.Marshal(response)
+	if err != nil {
+		return err
+	}
+
+	return i.storage.CompleteIdempotencyKey(context.Background(), request)
+}
+
+// replay returns saved response, if saved request is the same as new one.
+func replay(request, saved userdata.IdempotentRequest) (interface{}, error) {
+	if saved.Method != request.Method || saved.RequestHash != request.RequestHash {
+		return nil, toStatus(&FieldError{Field: idempotencyKeyMetadata, Err: ErrIdempotencyKeyReused})
+	}
+
+	if saved.Response == nil {
+		return nil, toStatus(&RetryError{Delay: inProgressRetryDelay, Err: ErrRequestInProgress})
+	}
+
+	var response anypb.Any
+	if err := proto.Unmarshal(saved.Response, &response); err != nil {
+		return nil, toStatus(err)
+	}
+
+	resp, err := response.UnmarshalNew()
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return resp, nil
+}
+
+// newIdempotentRequest returns request scoped by user, it's hash covers method and request message.
+func newIdempotentRequest(ctx context.Context, key, method string, req proto.Message) (userdata.IdempotentRequest, error) {
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(req)
+	if err != nil {
+		return userdata.IdempotentRequest{}, err
+	}
+
+	hash := sha256.New()
+	hash.Write([]byte(method))
+	hash.Write(data)
+
+	userID, _ := UserIDFromContext(ctx)
+
+	return userdata.IdempotentRequest{
+		Scope:       string(userID),
+		Key:         key,
+		Method:      method,
+		RequestHash: hex.EncodeToString(hash.Sum(nil)),
+	}, nil
+}
+
+// idempotencyKey gets idempotency key from request metadata.
+func idempotencyKey(ctx context.Context) (string, bool) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", false
+	}
+
+	values := md.Get(idempotencyKeyMetadata)
+	if len(values) == 0 || values[0] == "" {
+		return "", false
+	}
+
+	return values[0], true
+}
@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	pb "github.com/impr0ver/gophKeeper/internal/rpc"
+	storagemocks "github.com/impr0ver/gophKeeper/internal/storage/mocks"
+	"github.com/impr0ver/gophKeeper/internal/userdata"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// savedResponse returns response saved by idempotency interceptor.
+func savedResponse(t *testing.T, value string) []byte {
+	response, err := anypb.New(wrapperspb.String(value))
+	assert.NoError(t, err)
+
+	data, err := proto.Marshal(response)
+	assert.NoError(t, err)
+
+	return data
+}
+
+// whoami calls Whoami method of test service with idempotency key.
+func whoami(conn *grpc.ClientConn, key, value string) (string, error) {
+	ctx := metadata.AppendToOutgoingContext(context.Background(), idempotencyKeyMetadata, key)
+
+	out := new(wrapperspb.StringValue)
+	err := conn.Invoke(ctx, "/test.Identity/Whoami", wrapperspb.String(value), out)
+
+	return out.Value, err
+}
+
+func TestIdempotency(t *testing.T) {
+	storage := storagemocks.NewIdempotencyStorager(t)
+	idempotency := NewIdempotency(storage, time.Hour)
+	idempotency.methods = map[string]bool{"/test.Identity/Whoami": true}
+	conn := startBufconnServerWithOptions(t,
+		[]grpc.DialOption{grpc.WithDefaultServiceConfig(retryServiceConfig(identityServiceDesc.ServiceName))},
+		NewErrorInterceptor(), idempotency)
+
+	byKey := func(key string) interface{} {
+		return mock.MatchedBy(func(request userdata.IdempotentRequest) bool {
+			return request.Key == key && request.Method == "/test.Identity/Whoami"
+		})
+	}
+	var hash string
+
+	tc := []struct {
+		name  string
+		mock  func()
+		valid func()
+	}{
+		{
+			"Request without key is not saved",
+			func() {},
+			func() {
+				out := new(wrapperspb.StringValue)
+				err := conn.Invoke(context.Background(), "/test.Identity/Whoami", wrapperspb.String("value"), out)
+				assert.NoError(t, err)
+			},
+		},
+		{
+			"New key, response is saved",
+			func() {
+				storage.On("ReserveIdempotencyKey", mock.Anything, byKey("new"), time.Hour).
+					Return(func(_ context.Context, request userdata.IdempotentRequest, _ time.Duration) (userdata.IdempotentRequest, bool, error) {
+						hash = request.RequestHash
+						return request, true, nil
+					}).Once()
+				storage.On("CompleteIdempotencyKey", mock.Anything, mock.MatchedBy(func(request userdata.IdempotentRequest) bool {
+					return request.Key == "new" && len(request.Response) > 0
+				})).Return(nil).Once()
+			},
+			func() {
+				_, err := whoami(conn, "new", "value")
+				assert.NoError(t, err)
+			},
+		},
+		{
+			"Used key, saved response is replayed",
+			func() {
+				storage.On("ReserveIdempotencyKey", mock.Anything, byKey("used"), time.Hour).
+					Return(func(_ context.Context, request userdata.IdempotentRequest, _ time.Duration) (userdata.IdempotentRequest, bool, error) {
+						request.RequestHash = hash
+						request.Response = savedResponse(t, "saved")
+						return request, false, nil
+					}).Once()
+			},
+			func() {
+				out, err := whoami(conn, "used", "value")
+				assert.NoError(t, err)
+				assert.Equal(t, "saved", out, "handler is not called again")
+			},
+		},
+		{
+			"Used key with other request",
+			func() {
+				storage.On("ReserveIdempotencyKey", mock.Anything, byKey("reused"), time.Hour).
+					Return(func(_ context.Context, request userdata.IdempotentRequest, _ time.Duration) (userdata.IdempotentRequest, bool, error) {
+						request.RequestHash = hash
+						request.Response = savedResponse(t, "saved")
+						return request, false, nil
+					}).Once()
+			},
+			func() {
+				_, err := whoami(conn, "reused", "other value")
+				assert.Equal(t, codes.InvalidArgument, status.Code(err))
+				assert.ErrorIs(t, fromStatus(err), ErrIdempotencyKeyReused)
+			},
+		},
+		{
+			"Request in progress is retried by client",
+			func() {
+				storage.On("ReserveIdempotencyKey", mock.Anything, byKey("progress"), time.Hour).
+					Return(func(_ context.Context, request userdata.IdempotentRequest, _ time.Duration) (userdata.IdempotentRequest, bool, error) {
+						request.RequestHash = hash
+						return request, false, nil
+					}).Once()
+				storage.On("ReserveIdempotencyKey", mock.Anything, byKey("progress"), time.Hour).
+					Return(func(_ context.Context, request userdata.IdempotentRequest, _ time.Duration) (userdata.IdempotentRequest, bool, error) {
+						request.RequestHash = hash
+						request.Response = savedResponse(t, "done")
+						return request, false, nil
+					}).Once()
+			},
+			func() {
+				out, err := whoami(conn, "progress", "value")
+				assert.NoError(t, err)
+				assert.Equal(t, "done", out)
+			},
+		},
+		{
+			"Failed request releases key",
+			func() {
+				storage.On("ReserveIdempotencyKey", mock.Anything, byKey("fail"), time.Hour).
+					Return(func(_ context.Context, request userdata.IdempotentRequest, _ time.Duration) (userdata.IdempotentRequest, bool, error) {
+						return request, true, nil
+					}).Once()
+				storage.On("ReleaseIdempotencyKey", mock.Anything, byKey("fail")).Return(nil).Once()
+			},
+			func() {
+				_, err := whoami(conn, "fail", "fail")
+				assert.Equal(t, codes.NotFound, status.Code(err))
+			},
+		},
+		{
+			"Saving response fails, key is released",
+			func() {
+				storage.On("ReserveIdempotencyKey", mock.Anything, byKey("unsaved"), time.Hour).
+					Return(func(_ context.Context, request userdata.IdempotentRequest, _ time.Duration) (userdata.IdempotentRequest, bool, error) {
+						return request, true, nil
+					}).Once()
+				storage.On("CompleteIdempotencyKey", mock.Anything, byKey("unsaved")).Return(errors.New("connection refused")).Once()
+				storage.On("ReleaseIdempotencyKey", mock.Anything, byKey("unsaved")).Return(nil).Once()
+			},
+			func() {
+				_, err := whoami(conn, "unsaved", "value")
+				assert.NoError(t, err)
+			},
+		},
+		{
+			"Storage error",
+			func() {
+				storage.On("ReserveIdempotencyKey", mock.Anything, byKey("error"), time.Hour).
+					Return(userdata.IdempotentRequest{}, false, errors.New("connection refused")).Once()
+			},
+			func() {
+				_, err := whoami(conn, "error", "value")
+				assert.Equal(t, codes.Internal, status.Code(err))
+			},
+		},
+	}
+
+	for _, test := range tc {
+		t.Log(test.name)
+		test.mock()
+		test.valid()
+	}
+
+	_, err := whoami(conn, strings.Repeat("k", maxIdempotencyKeyLen+1), "value")
+	assert.Equal(t, codes.InvalidArgument, status.Code(err), "too long key")
+}
+
+func TestIdempotency_Cleanup(t *testing.T) {
+	storage := storagemocks.NewIdempotencyStorager(t)
+	idempotency := NewIdempotency(storage, time.Hour)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	storage.On("DeleteExpiredIdempotencyKeys", mock.Anything, mock.MatchedBy(func(before time.Time) bool {
+		return time.Until(before) < -59*time.Minute
+	})).Return(int64(1), nil).Run(func(mock.Arguments) { cancel() }).Once()
+
+	idempotency.Cleanup(ctx, time.Millisecond)
+}
+
+func TestIdempotency_Methods(t *testing.T) {
+	storage := storagemocks.NewIdempotencyStorager(t)
+	interceptor := NewIdempotency(storage, time.Hour).Unary()
+
+	// Only responses of mutating requests are saved, storage isn't called for others
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(idempotencyKeyMetadata, "key"))
+	handler := func(context.Context, interface{}) (interface{}, error) {
+		return &pb.Token{Token: "token"}, nil
+	}
+
+	for _, method := range []string{
+		pb.Gokeeper_Login_FullMethodName,
+		pb.Gokeeper_RegisterSRP_FullMethodName,
+		pb.Gokeeper_StartLoginSRP_FullMethodName,
+		pb.Gokeeper_FinishLoginSRP_FullMethodName,
+		pb.Gokeeper_GetRecord_FullMethodName,
+		pb.Gokeeper_GetRecords_FullMethodName,
+		pb.Admin_SetUserQuota_FullMethodName,
+	} {
+		t.Log(method)
+		resp, err := interceptor(ctx, &pb.SRPRegistration{Login: "user"}, &grpc.UnaryServerInfo{FullMethod: method}, handler)
+		assert.NoError(t, err)
+		assert.Equal(t, "token", resp.(*pb.Token).Token)
+	}
+
+	storage.On("ReserveIdempotencyKey", mock.Anything, mock.Anything, time.Hour).Return(userdata.IdempotentRequest{}, false, errors.New("connection refused")).Once()
+	_, err := interceptor(ctx, &pb.RecordID{Id: "1"}, &grpc.UnaryServerInfo{FullMethod: pb.Gokeeper_DeleteRecord_FullMethodName}, handler)
+	assert.Error(t, err, "mutating request is reserved")
+}
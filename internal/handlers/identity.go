@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/impr0ver/gophKeeper/internal/userdata"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// identityKey is context key of authenticated user, only auth interceptor sets it.
+type identityKey struct{}
+
+// reservedMetadataKeys are keys which clients must not send, they were used for server-side identity.
+var reservedMetadataKeys = []string{"userid"}
+
+// withUserID returns context with authenticated user.
+func withUserID(ctx context.Context, userID userdata.UserID) context.Context {
+	return context.WithValue(ctx, identityKey{}, userID)
+}
+
+// UserIDFromContext gets user authenticated by auth interceptor.
+func UserIDFromContext(ctx context.Context) (userdata.UserID, bool) {
+	userID, ok := ctx.Value(identityKey{}).(userdata.UserID)
+
+	return userID, ok && userID != ""
+}
+
+// reservedMetadataKey returns first reserved key found in incoming metadata, keys are case-insensitive.
+func reservedMetadataKey(md metadata.MD) (string, bool) {
+	for _, key := range reservedMetadataKeys {
+		if len(md.Get(key)) > 0 {
+			return key, true
+		}
+	}
+
+	return "", false
+}
@@ -2,63 +2,236 @@ package handlers
 
 import (
 	"context"
+	"time"
 
 	"github.com/impr0ver/gophKeeper/internal/logger"
+	pb "github.com/impr0ver/gophKeeper/internal/rpc"
+	"github.com/impr0ver/gophKeeper/internal/storage"
 	"github.com/impr0ver/gophKeeper/internal/userdata"
 
 	log "github.com/sirupsen/logrus"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 )
 
-// LoggingInterceptor logging some data on interceptor.
-func (s *ServerConn) LoggingInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
-	var sLogger = logger.NewSugarLogger()
+// Interceptor is server middleware which works for both unary and streaming RPCs.
+type Interceptor interface {
+	Unary() grpc.UnaryServerInterceptor
+	Stream() grpc.StreamServerInterceptor
+}
+
+// ChainInterceptors returns server options with unary and stream chains, interceptors are called in the given order.
+func ChainInterceptors(interceptors ...Interceptor) []grpc.ServerOption {
+	unary := make([]grpc.UnaryServerInterceptor, 0, len(interceptors))
+	stream := make([]grpc.StreamServerInterceptor, 0, len(interceptors))
 
-	if s.ServerConsoleLog {
-		sLogger.Infof("FullMethod: %s, Received request: %v", info.FullMethod, req)
+	for _, interceptor := range interceptors {
+		unary = append(unary, interceptor.Unary())
+		stream = append(stream, interceptor.Stream())
 	}
 
-	resp, err := handler(ctx, req)
-	return resp, err
+	return []grpc.ServerOption{grpc.ChainUnaryInterceptor(unary...), grpc.ChainStreamInterceptor(stream...)}
+}
+
+// wrappedStream is server stream with replaced context.
+type wrappedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context returns context of stream with values added by interceptors.
+func (w *wrappedStream) Context() context.Context {
+	return w.ctx
+}
+
+// WrapServerStream returns server stream which Context() returns ctx.
+func WrapServerStream(ss grpc.ServerStream, ctx context.Context) grpc.ServerStream {
+	return &wrappedStream{ServerStream: ss, ctx: ctx}
+}
+
+// credentialMethods are RPCs with passwords or verifiers in requests and auth tokens in responses,
+// their messages are never logged or saved.
+var credentialMethods = map[string]bool{
+	pb.Gokeeper_Login_FullMethodName:          true,
+	pb.Gokeeper_Register_FullMethodName:       true,
+	pb.Gokeeper_RegisterSRP_FullMethodName:    true,
+	pb.Gokeeper_FinishLoginSRP_FullMethodName: true,
+}
+
+// loggingInterceptor logs requests on console, if enabled, and failed calls in log.
+type loggingInterceptor struct {
+	consoleLog func() bool
+}
+
+// NewLoggingInterceptor returns logging interceptor, consoleLog is checked on each call, so it may be changed on the fly.
+func NewLoggingInterceptor(consoleLog func() bool) Interceptor {
+	return &loggingInterceptor{consoleLog: consoleLog}
+}
+
+// Unary logs unary request.
+func (l *loggingInterceptor) Unary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if l.consoleLog() {
+			logger.NewSugarLogger().Infof("FullMethod: %s, Received request: %v", info.FullMethod, loggedRequest(info.FullMethod, req))
+		}
+
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		l.logResult(info.FullMethod, start, err)
+
+		return resp, err
+	}
 }
 
-// VerifyAuth check authentication token on interceptor.
-func (s *ServerConn) VerifyAuth() grpc.UnaryServerInterceptor {
-	return func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
-		var sLogger = logger.NewSugarLogger()
-		var token userdata.AuthToken
+// loggedRequest returns request to log, requests with credentials are redacted.
+func loggedRequest(method string, req interface{}) interface{} {
+	if credentialMethods[method] {
+		return "[redacted]"
+	}
 
-		md, ok := metadata.FromIncomingContext(ctx)
+	return req
+}
 
-		if s.ServerConsoleLog {
-			sLogger.Info("Hooked MD on interceptor: ", md.String())
+// Stream logs stream start and each received message.
+func (l *loggingInterceptor) Stream() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if l.consoleLog() {
+			logger.NewSugarLogger().Infof("FullMethod: %s, Stream started", info.FullMethod)
 		}
 
-		if ok && len(md.Get("authToken")) > 0 {
-			token = userdata.AuthToken(md.Get("authToken")[0])
+		start := time.Now()
+		err := handler(srv, &loggingStream{ServerStream: ss, interceptor: l, method: info.FullMethod})
+		l.logResult(info.FullMethod, start, err)
+
+		return err
+	}
+}
+
+// logResult logs failed call.
+func (l *loggingInterceptor) logResult(method string, start time.Time, err error) {
+	if err == nil {
+		return
+	}
 
-			if s.ServerConsoleLog {
-				sLogger.Info("Hooked token: ", token)
-			}
+	log.Infof("%s failed with %s in %s", method, status.Code(err), time.Since(start))
+	if l.consoleLog() {
+		logger.NewSugarLogger().Infof("FullMethod: %s, Failed: %v", method, err)
+	}
+}
+
+// loggingStream logs received messages of stream.
+type loggingStream struct {
+	grpc.ServerStream
+	interceptor *loggingInterceptor
+	method      string
+}
+
+// RecvMsg receives message and logs it.
+func (s *loggingStream) RecvMsg(m interface{}) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil && s.interceptor.consoleLog() {
+		logger.NewSugarLogger().Infof("FullMethod: %s, Received stream message: %v", s.method, m)
+	}
+
+	return err
+}
 
-			userIDValid, err := s.Authenticator.ValidateToken(userdata.AuthToken(token))
-			if err != nil {
-				log.Warnf("%s :: %v", "interceptor validate token error", err)
-				if s.ServerConsoleLog {
-					sLogger.Infof("%s :: %v", "interceptor validate token error", err)
-				}
+// authInterceptor checks authentication token and puts authenticated user into context.
+type authInterceptor struct {
+	authenticator Authenticator
+	consoleLog    func() bool
+}
 
-				return nil, status.Errorf(codes.Unauthenticated, "validate token error :: %v", err)
-			}
+// NewAuthInterceptor returns authentication interceptor.
+func NewAuthInterceptor(authenticator Authenticator, consoleLog func() bool) Interceptor {
+	return &authInterceptor{authenticator: authenticator, consoleLog: consoleLog}
+}
 
-			// Add validated userID in context
-			md.Append("userID", string(userIDValid))
-			ctx = metadata.NewIncomingContext(ctx, md)
+// Unary checks authentication token of unary request.
+func (a *authInterceptor) Unary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := a.authenticate(ctx)
+		if err != nil {
+			return nil, err
 		}
 
 		return handler(ctx, req)
 	}
 }
+
+// Stream checks authentication token of stream, handler gets stream with authenticated context.
+func (a *authInterceptor) Stream() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := a.authenticate(ss.Context())
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, WrapServerStream(ss, ctx))
+	}
+}
+
+// authenticate validates token from metadata and puts userID into context, request without token passes as is.
+// Requests with reserved metadata keys are rejected, so client can't pretend to be other user.
+func (a *authInterceptor) authenticate(ctx context.Context) (context.Context, error) {
+	var sLogger = logger.NewSugarLogger()
+
+	md, ok := metadata.FromIncomingContext(ctx)
+
+	if a.consoleLog() {
+		sLogger.Info("Hooked MD on interceptor: ", md.String())
+	}
+
+	if key, reserved := reservedMetadataKey(md); reserved {
+		log.Warnf("request with reserved metadata key %s is rejected", key)
+
+		return ctx, toStatus(&FieldError{Field: key, Err: ErrReservedMetadata})
+	}
+
+	if !ok || len(md.Get("authToken")) == 0 {
+		return ctx, nil
+	}
+
+	token := userdata.AuthToken(md.Get("authToken")[0])
+
+	if a.consoleLog() {
+		sLogger.Info("Hooked token: ", token)
+	}
+
+	userIDValid, err := a.authenticator.ValidateToken(token)
+	if err != nil {
+		log.Warnf("%s :: %v", "interceptor validate token error", err)
+		if a.consoleLog() {
+			sLogger.Infof("%s :: %v", "interceptor validate token error", err)
+		}
+
+		return ctx, toStatus(storage.ErrUnauthenticated)
+	}
+
+	return withUserID(ctx, userIDValid), nil
+}
+
+// errorInterceptor translates errors of handlers which are not gRPC statuses.
+type errorInterceptor struct{}
+
+// NewErrorInterceptor returns error translation interceptor.
+func NewErrorInterceptor() Interceptor {
+	return &errorInterceptor{}
+}
+
+// Unary translates error of unary handler.
+func (e *errorInterceptor) Unary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		resp, err := handler(ctx, req)
+
+		return resp, toStatus(err)
+	}
+}
+
+// Stream translates error of stream handler.
+func (e *errorInterceptor) Stream() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return toStatus(handler(srv, ss))
+	}
+}
@@ -0,0 +1,243 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/impr0ver/gophKeeper/internal/handlers/mocks"
+	pb "github.com/impr0ver/gophKeeper/internal/rpc"
+	"github.com/impr0ver/gophKeeper/internal/storage"
+	"github.com/impr0ver/gophKeeper/internal/userdata"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// identityService is test service which returns identity of caller.
+type identityService interface{}
+
+// identityUserID gets authenticated user from context for test service.
+func identityUserID(ctx context.Context) string {
+	userID, _ := UserIDFromContext(ctx)
+
+	return string(userID)
+}
+
+// identityServiceDesc has unary and bidi streaming methods, stream answers with identity to every message.
+var identityServiceDesc = grpc.ServiceDesc{
+	ServiceName: "test.Identity",
+	HandlerType: (*identityService)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Whoami",
+			Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+				in := new(wrapperspb.StringValue)
+				if err := dec(in); err != nil {
+					return nil, err
+				}
+
+				handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+					if req.(*wrapperspb.StringValue).Value == "fail" {
+						return nil, storage.ErrNotFound
+					}
+
+					return wrapperspb.String(identityUserID(ctx)), nil
+				}
+
+				return interceptor(ctx, in, &grpc.UnaryServerInfo{Server: srv, FullMethod: "/test.Identity/Whoami"}, handler)
+			},
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Echo",
+			ServerStreams: true,
+			ClientStreams: true,
+			Handler: func(_ interface{}, stream grpc.ServerStream) error {
+				for {
+					in := new(wrapperspb.StringValue)
+					err := stream.RecvMsg(in)
+					if errors.Is(err, io.EOF) {
+						return nil
+					}
+					if err != nil {
+						return err
+					}
+
+					if in.Value == "fail" {
+						return errors.New("some internal error")
+					}
+
+					if err := stream.SendMsg(wrapperspb.String(in.Value + ":" + identityUserID(stream.Context()))); err != nil {
+						return err
+					}
+				}
+			},
+		},
+	},
+}
+
+// startBufconnServer starts server with interceptors chain on in-memory listener.
+func startBufconnServer(t *testing.T, interceptors ...Interceptor) *grpc.ClientConn {
+	return startBufconnServerWithOptions(t, nil, interceptors...)
+}
+
+// startBufconnServerWithOptions starts server on in-memory listener, client is created with dial options.
+func startBufconnServerWithOptions(t *testing.T, options []grpc.DialOption, interceptors ...Interceptor) *grpc.ClientConn {
+	listener := bufconn.Listen(1024 * 1024)
+
+	server := grpc.NewServer(ChainInterceptors(interceptors...)...)
+	server.RegisterService(&identityServiceDesc, struct{}{})
+
+	go func() {
+		_ = server.Serve(listener)
+	}()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet", append([]grpc.DialOption{
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials())}, options...)...)
+	assert.NoError(t, err)
+
+	t.Cleanup(func() {
+		conn.Close()
+		server.Stop()
+	})
+
+	return conn
+}
+
+// echo sends messages to Echo stream and gets answers.
+func echo(ctx context.Context, conn *grpc.ClientConn, messages ...string) ([]string, error) {
+	stream, err := conn.NewStream(ctx, &identityServiceDesc.Streams[0], "/test.Identity/Echo")
+	if err != nil {
+		return nil, err
+	}
+
+	var answers []string
+
+	for _, message := range messages {
+		if err := stream.SendMsg(wrapperspb.String(message)); err != nil {
+			break
+		}
+
+		answer := new(wrapperspb.StringValue)
+		if err := stream.RecvMsg(answer); err != nil {
+			return answers, err
+		}
+		answers = append(answers, answer.Value)
+	}
+
+	if err := stream.CloseSend(); err != nil {
+		return answers, err
+	}
+
+	if err := stream.RecvMsg(new(wrapperspb.StringValue)); !errors.Is(err, io.EOF) {
+		return answers, err
+	}
+
+	return answers, nil
+}
+
+func TestAuthInterceptor(t *testing.T) {
+	auth := mocks.NewAuthenticator(t)
+	consoleLog := func() bool { return true }
+
+	conn := startBufconnServer(t,
+		NewLoggingInterceptor(consoleLog),
+		NewErrorInterceptor(),
+		NewAuthInterceptor(auth, consoleLog),
+	)
+
+	auth.On("ValidateToken", userdata.AuthToken("good")).Return(userdata.UserID("user1"), nil)
+	auth.On("ValidateToken", userdata.AuthToken("bad")).Return(userdata.UserID(""), storage.ErrUnauthenticated)
+
+	good := metadata.AppendToOutgoingContext(context.Background(), "authToken", "good")
+	bad := metadata.AppendToOutgoingContext(context.Background(), "authToken", "bad")
+
+	t.Log("Unary call with valid token")
+	identity := new(wrapperspb.StringValue)
+	err := conn.Invoke(good, "/test.Identity/Whoami", wrapperspb.String(""), identity)
+	assert.NoError(t, err)
+	assert.Equal(t, "user1", identity.Value)
+
+	t.Log("Unary call with invalid token")
+	err = conn.Invoke(bad, "/test.Identity/Whoami", wrapperspb.String(""), identity)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	t.Log("Stream with valid token")
+	answers, err := echo(good, conn, "a", "b")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a:user1", "b:user1"}, answers)
+
+	t.Log("Stream with invalid token")
+	_, err = echo(bad, conn, "a")
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	t.Log("Stream without token")
+	answers, err = echo(context.Background(), conn, "a")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a:"}, answers)
+
+	t.Log("Unary call with forged userID in metadata")
+	forged := metadata.AppendToOutgoingContext(context.Background(), "userID", "user2")
+	err = conn.Invoke(forged, "/test.Identity/Whoami", wrapperspb.String(""), identity)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	t.Log("Stream with valid token and forged userID in metadata")
+	forged = metadata.AppendToOutgoingContext(good, "UserId", "user2")
+	_, err = echo(forged, conn, "a")
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestErrorInterceptor(t *testing.T) {
+	conn := startBufconnServer(t, NewErrorInterceptor())
+
+	t.Log("Unary handler returns storage error")
+	err := conn.Invoke(context.Background(), "/test.Identity/Whoami", wrapperspb.String("fail"), new(wrapperspb.StringValue))
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	t.Log("Stream handler returns unknown error")
+	_, err = echo(context.Background(), conn, "fail")
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.Equal(t, "internal server error.", status.Convert(err).Message())
+}
+
+func TestInterceptorsStreamLimits(t *testing.T) {
+	conn := startBufconnServer(t, NewRateLimiter(0.001, 1))
+
+	_, err := echo(context.Background(), conn, "a")
+	assert.NoError(t, err)
+
+	_, err = echo(context.Background(), conn, "a")
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
+
+func TestLoggedRequest(t *testing.T) {
+	tc := []struct {
+		name   string
+		method string
+		req    interface{}
+		want   interface{}
+	}{
+		{"Login", pb.Gokeeper_Login_FullMethodName, &pb.UserCreds{Login: "user", Password: "secret"}, "[redacted]"},
+		{"Register", pb.Gokeeper_Register_FullMethodName, &pb.UserCreds{Login: "user", Password: "secret"}, "[redacted]"},
+		{"RegisterSRP", pb.Gokeeper_RegisterSRP_FullMethodName, &pb.SRPRegistration{Login: "user", Verifier: "verifier"}, "[redacted]"},
+		{"Record", pb.Gokeeper_GetRecord_FullMethodName, &pb.RecordID{Id: "1"}, &pb.RecordID{Id: "1"}},
+	}
+
+	for _, test := range tc {
+		t.Log(test.name)
+		assert.Equal(t, test.want, loggedRequest(test.method, test.req))
+	}
+}
@@ -2,10 +2,15 @@ package handlers
 
 import (
 	"context"
+	"github.com/impr0ver/gophKeeper/internal/crypt"
 	"github.com/impr0ver/gophKeeper/internal/jwtauth"
+	pb "github.com/impr0ver/gophKeeper/internal/rpc"
 	"github.com/impr0ver/gophKeeper/internal/storage"
 	"github.com/impr0ver/gophKeeper/internal/userdata"
+	"io"
 	"time"
+
+	"google.golang.org/grpc"
 )
 
 // ClientHandlers interface for Client.
@@ -14,9 +19,18 @@ type ClientHandlers interface {
 	Register(credentials userdata.UserCredentials) error
 	GetRecordsInfo() ([]userdata.Record, error)
 	GetRecord(recordID string) (userdata.Record, error)
-	CreateRecord(record userdata.Record) error
+	CreateRecord(record userdata.Record) (userdata.RecordMeta, error)
 	DeleteRecord(recordID string) error
-	SetAESKey(newAESKey string) error
+	CreateRecords(records []userdata.Record) ([]userdata.RecordResult, error)
+	GetRecords(recordIDs []string) ([]userdata.RecordResult, error)
+	DeleteRecords(recordIDs []string) ([]userdata.RecordResult, error)
+	SetAESKey(newAESKey string, label string) error
+	AddAESKey(aesKey string, label string) error
+	LegacyRecords() int
+	ReencryptRecords() (int, error)
+	RotateKey(newAESKey string, label string, progress func(done int, total int)) (int, error)
+	ExportVault(w io.Writer, password string, progress func(done int, total int)) (int, error)
+	ImportVault(r io.Reader, password string, progress func(done int, total int)) (int, int, error)
 }
 
 // NewClientHandlers returns new client handlers (interface).
@@ -33,8 +47,8 @@ type Authenticator interface {
 }
 
 // NewAuthenticatorJWT gets new authenticatorJWT (interface).
-func NewAuthenticatorJWT(secretKey []byte, expirationTime time.Duration) Authenticator {
-	return jwtauth.NewAuthenticatorJWT(secretKey, expirationTime)
+func NewAuthenticatorJWT(secretKey []byte, expirationTime time.Duration, sessions jwtauth.SessionChecker) Authenticator {
+	return jwtauth.NewAuthenticatorJWT(secretKey, expirationTime, sessions)
 }
 
 // ServerHandlers interface for server handlers
@@ -43,15 +57,25 @@ func NewAuthenticatorJWT(secretKey []byte, expirationTime time.Duration) Authent
 type ServerHandlers interface {
 	LoginUser(credentials userdata.UserCredentials) (userdata.AuthToken, error)
 	CreateUser(credentials userdata.UserCredentials) (userdata.AuthToken, error)
-	GetRecordsInfo(ctx context.Context) ([]userdata.Record, error)
-	GetRecord(ctx context.Context, recordID string) (userdata.Record, error)
-	CreateRecord(ctx context.Context, record userdata.Record) error
-	DeleteRecord(ctx context.Context, recordID string) error
+	CreateUserSRP(login string, verifier string) (userdata.AuthToken, error)
+	StartLoginSRP(login string, a []byte) (string, crypt.SRPChallenge, error)
+	FinishLoginSRP(sessionID string, proof []byte) (userdata.AuthToken, []byte, error)
+	GetKeySalt(ctx context.Context, userID userdata.UserID) ([]byte, error)
+	GetRecordsInfo(ctx context.Context, userID userdata.UserID) ([]userdata.Record, error)
+	GetRecord(ctx context.Context, userID userdata.UserID, recordID string) (userdata.Record, error)
+	CreateRecord(ctx context.Context, userID userdata.UserID, record userdata.Record) (userdata.RecordMeta, error)
+	DeleteRecord(ctx context.Context, userID userdata.UserID, recordID string) error
+	CreateRecords(ctx context.Context, userID userdata.UserID, records []userdata.Record) ([]userdata.RecordResult, error)
+	GetRecords(ctx context.Context, userID userdata.UserID, recordIDs []string) ([]userdata.RecordResult, error)
+	DeleteRecords(ctx context.Context, userID userdata.UserID, recordIDs []string) ([]userdata.RecordResult, error)
+	ReplaceRecords(ctx context.Context, userID userdata.UserID, records []userdata.Record) ([]userdata.RecordResult, error)
+	RewrapRecords(ctx context.Context, userID userdata.UserID, records []userdata.Record) ([]userdata.RecordResult, error)
 }
 
-// NewServerHandlers returns server handlers based on storage and authenticator.
-func NewServerHandlers(s storage.Storager, a Authenticator) ServerHandlers {
-	return newServerHandlers(s, a)
+// NewServerHandlers returns server handlers based on storage, authenticator and password hash parameters.
+// Login with password sent to server is allowed only with legacyLogin, SRP login is always allowed.
+func NewServerHandlers(s storage.Storager, a Authenticator, passwords crypt.PasswordParams, legacyLogin bool) ServerHandlers {
+	return newServerHandlers(s, a, passwords, legacyLogin)
 }
 
 // ClientConnection describes client connection.
@@ -60,13 +84,80 @@ func NewServerHandlers(s storage.Storager, a Authenticator) ServerHandlers {
 type ClientConnection interface {
 	Login(credentials userdata.UserCredentials) (string, error)
 	Register(credentials userdata.UserCredentials) (string, error)
+	GetKeySalt(token userdata.AuthToken) ([]byte, error)
 	GetRecordsInfo(token userdata.AuthToken) ([]userdata.Record, error)
 	GetRecord(token userdata.AuthToken, recordID string) (userdata.Record, error)
 	DeleteRecord(token userdata.AuthToken, recordID string) error
-	CreateRecord(token userdata.AuthToken, record userdata.Record) error
+	CreateRecord(token userdata.AuthToken, record userdata.Record) (userdata.RecordMeta, error)
+	CreateRecords(token userdata.AuthToken, records []userdata.Record) ([]userdata.RecordResult, error)
+	GetRecords(token userdata.AuthToken, recordIDs []string) ([]userdata.RecordResult, error)
+	DeleteRecords(token userdata.AuthToken, recordIDs []string) ([]userdata.RecordResult, error)
+	ReplaceRecords(token userdata.AuthToken, records []userdata.Record) ([]userdata.RecordResult, error)
+	RewrapRecords(token userdata.AuthToken, records []userdata.Record) ([]userdata.RecordResult, error)
 }
 
 // NewClientConnection connects to server and returning connection (interface).
-func NewClientConnection(serverAddress string, clientCert string) ClientConnection {
-	return newClientConn(serverAddress, clientCert)
+func NewClientConnection(serverAddress string, clientCert string, legacyLogin bool) ClientConnection {
+	conn := newClientConn(serverAddress, clientCert)
+	conn.LegacyLogin = legacyLogin
+
+	return conn
+}
+
+// NewClientConnectionFromConn returns client connection over established gRPC connection (interface).
+func NewClientConnectionFromConn(conn grpc.ClientConnInterface) ClientConnection {
+	return &ClientConnGPRC{
+		GokeeperClient: pb.NewGokeeperClient(conn),
+		Passwords:      crypt.DefaultPasswordParams,
+	}
+}
+
+// AdminHandlers interface for server administration handlers.
+//
+//go:generate mockery --name AdminHandlers
+type AdminHandlers interface {
+	ListUsers(ctx context.Context) ([]userdata.UserInfo, error)
+	GetStats(ctx context.Context) (userdata.ServerStats, error)
+	IsAdmin(ctx context.Context, userID userdata.UserID) (bool, error)
+	SetUserAdmin(ctx context.Context, login string, admin bool) error
+	SetUserDisabled(ctx context.Context, login string, disabled bool) error
+	SetUserQuota(ctx context.Context, login string, quota int64) error
+	LogoutUser(ctx context.Context, login string) error
+}
+
+// ScrubReporter reports metrics of file checksums verification.
+type ScrubReporter interface {
+	Stats() userdata.ScrubStats
+}
+
+// NewAdminHandlers returns administration handlers based on storage, scrub metrics are added to stats if scrub isn't nil.
+func NewAdminHandlers(s storage.AdminStorager, scrub ScrubReporter) AdminHandlers {
+	return newAdminHandlers(s, scrub)
+}
+
+// AdminConnection describes admin client connection.
+//
+//go:generate mockery --name AdminConnection
+type AdminConnection interface {
+	Login(credentials userdata.UserCredentials) (string, error)
+	ListUsers() ([]userdata.UserInfo, error)
+	GetStats() (userdata.ServerStats, error)
+	SetUserAdmin(login string, admin bool) error
+	SetUserDisabled(login string, disabled bool) error
+	SetUserQuota(login string, quota int64) error
+	ResetUserQuota(login string) error
+	LogoutUser(login string) error
+}
+
+// NewAdminConnection connects to server admin service and returning connection (interface).
+func NewAdminConnection(serverAddress string, clientCert string, adminToken string, legacyLogin bool) AdminConnection {
+	conn := newAdminClientConn(serverAddress, clientCert, adminToken)
+	conn.LegacyLogin = legacyLogin
+
+	return conn
+}
+
+// NewAdminConnectionFromConn returns admin client connection over established gRPC connection (interface).
+func NewAdminConnectionFromConn(conn grpc.ClientConnInterface, adminToken string) AdminConnection {
+	return newAdminConn(conn, adminToken)
 }
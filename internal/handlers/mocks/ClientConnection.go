@@ -3,8 +3,9 @@
 package mocks
 
 import (
-	userdata "github.com/impr0ver/gophKeeper/internal/userdata"
 	mock "github.com/stretchr/testify/mock"
+
+	userdata "github.com/impr0ver/gophKeeper/internal/userdata"
 )
 
 // ClientConnection is an autogenerated mock type for the ClientConnection type
@@ -13,21 +14,61 @@ type ClientConnection struct {
 }
 
 // CreateRecord provides a mock function with given fields: token, record
-func (_m *ClientConnection) CreateRecord(token userdata.AuthToken, record userdata.Record) error {
+func (_m *ClientConnection) CreateRecord(token userdata.AuthToken, record userdata.Record) (userdata.RecordMeta, error) {
 	ret := _m.Called(token, record)
 
 	if len(ret) == 0 {
 		panic("no return value specified for CreateRecord")
 	}
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(userdata.AuthToken, userdata.Record) error); ok {
+	var r0 userdata.RecordMeta
+	var r1 error
+	if rf, ok := ret.Get(0).(func(userdata.AuthToken, userdata.Record) (userdata.RecordMeta, error)); ok {
+		return rf(token, record)
+	}
+	if rf, ok := ret.Get(0).(func(userdata.AuthToken, userdata.Record) userdata.RecordMeta); ok {
 		r0 = rf(token, record)
 	} else {
-		r0 = ret.Error(0)
+		r0 = ret.Get(0).(userdata.RecordMeta)
 	}
 
-	return r0
+	if rf, ok := ret.Get(1).(func(userdata.AuthToken, userdata.Record) error); ok {
+		r1 = rf(token, record)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateRecords provides a mock function with given fields: token, records
+func (_m *ClientConnection) CreateRecords(token userdata.AuthToken, records []userdata.Record) ([]userdata.RecordResult, error) {
+	ret := _m.Called(token, records)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateRecords")
+	}
+
+	var r0 []userdata.RecordResult
+	var r1 error
+	if rf, ok := ret.Get(0).(func(userdata.AuthToken, []userdata.Record) ([]userdata.RecordResult, error)); ok {
+		return rf(token, records)
+	}
+	if rf, ok := ret.Get(0).(func(userdata.AuthToken, []userdata.Record) []userdata.RecordResult); ok {
+		r0 = rf(token, records)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]userdata.RecordResult)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(userdata.AuthToken, []userdata.Record) error); ok {
+		r1 = rf(token, records)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
 // DeleteRecord provides a mock function with given fields: token, recordID
@@ -48,6 +89,66 @@ func (_m *ClientConnection) DeleteRecord(token userdata.AuthToken, recordID stri
 	return r0
 }
 
+// DeleteRecords provides a mock function with given fields: token, recordIDs
+func (_m *ClientConnection) DeleteRecords(token userdata.AuthToken, recordIDs []string) ([]userdata.RecordResult, error) {
+	ret := _m.Called(token, recordIDs)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteRecords")
+	}
+
+	var r0 []userdata.RecordResult
+	var r1 error
+	if rf, ok := ret.Get(0).(func(userdata.AuthToken, []string) ([]userdata.RecordResult, error)); ok {
+		return rf(token, recordIDs)
+	}
+	if rf, ok := ret.Get(0).(func(userdata.AuthToken, []string) []userdata.RecordResult); ok {
+		r0 = rf(token, recordIDs)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]userdata.RecordResult)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(userdata.AuthToken, []string) error); ok {
+		r1 = rf(token, recordIDs)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetKeySalt provides a mock function with given fields: token
+func (_m *ClientConnection) GetKeySalt(token userdata.AuthToken) ([]byte, error) {
+	ret := _m.Called(token)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetKeySalt")
+	}
+
+	var r0 []byte
+	var r1 error
+	if rf, ok := ret.Get(0).(func(userdata.AuthToken) ([]byte, error)); ok {
+		return rf(token)
+	}
+	if rf, ok := ret.Get(0).(func(userdata.AuthToken) []byte); ok {
+		r0 = rf(token)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]byte)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(userdata.AuthToken) error); ok {
+		r1 = rf(token)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetRecord provides a mock function with given fields: token, recordID
 func (_m *ClientConnection) GetRecord(token userdata.AuthToken, recordID string) (userdata.Record, error) {
 	ret := _m.Called(token, recordID)
@@ -76,6 +177,36 @@ func (_m *ClientConnection) GetRecord(token userdata.AuthToken, recordID string)
 	return r0, r1
 }
 
+// GetRecords provides a mock function with given fields: token, recordIDs
+func (_m *ClientConnection) GetRecords(token userdata.AuthToken, recordIDs []string) ([]userdata.RecordResult, error) {
+	ret := _m.Called(token, recordIDs)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetRecords")
+	}
+
+	var r0 []userdata.RecordResult
+	var r1 error
+	if rf, ok := ret.Get(0).(func(userdata.AuthToken, []string) ([]userdata.RecordResult, error)); ok {
+		return rf(token, recordIDs)
+	}
+	if rf, ok := ret.Get(0).(func(userdata.AuthToken, []string) []userdata.RecordResult); ok {
+		r0 = rf(token, recordIDs)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]userdata.RecordResult)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(userdata.AuthToken, []string) error); ok {
+		r1 = rf(token, recordIDs)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetRecordsInfo provides a mock function with given fields: token
 func (_m *ClientConnection) GetRecordsInfo(token userdata.AuthToken) ([]userdata.Record, error) {
 	ret := _m.Called(token)
@@ -162,6 +293,66 @@ func (_m *ClientConnection) Register(credentials userdata.UserCredentials) (stri
 	return r0, r1
 }
 
+// ReplaceRecords provides a mock function with given fields: token, records
+func (_m *ClientConnection) ReplaceRecords(token userdata.AuthToken, records []userdata.Record) ([]userdata.RecordResult, error) {
+	ret := _m.Called(token, records)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReplaceRecords")
+	}
+
+	var r0 []userdata.RecordResult
+	var r1 error
+	if rf, ok := ret.Get(0).(func(userdata.AuthToken, []userdata.Record) ([]userdata.RecordResult, error)); ok {
+		return rf(token, records)
+	}
+	if rf, ok := ret.Get(0).(func(userdata.AuthToken, []userdata.Record) []userdata.RecordResult); ok {
+		r0 = rf(token, records)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]userdata.RecordResult)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(userdata.AuthToken, []userdata.Record) error); ok {
+		r1 = rf(token, records)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RewrapRecords provides a mock function with given fields: token, records
+func (_m *ClientConnection) RewrapRecords(token userdata.AuthToken, records []userdata.Record) ([]userdata.RecordResult, error) {
+	ret := _m.Called(token, records)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RewrapRecords")
+	}
+
+	var r0 []userdata.RecordResult
+	var r1 error
+	if rf, ok := ret.Get(0).(func(userdata.AuthToken, []userdata.Record) ([]userdata.RecordResult, error)); ok {
+		return rf(token, records)
+	}
+	if rf, ok := ret.Get(0).(func(userdata.AuthToken, []userdata.Record) []userdata.RecordResult); ok {
+		r0 = rf(token, records)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]userdata.RecordResult)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(userdata.AuthToken, []userdata.Record) error); ok {
+		r1 = rf(token, records)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // NewClientConnection creates a new instance of ClientConnection. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewClientConnection(t interface {
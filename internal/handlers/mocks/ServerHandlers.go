@@ -7,6 +7,8 @@ import (
 
 	mock "github.com/stretchr/testify/mock"
 
+	crypt "github.com/impr0ver/gophKeeper/internal/crypt"
+
 	userdata "github.com/impr0ver/gophKeeper/internal/userdata"
 )
 
@@ -15,22 +17,62 @@ type ServerHandlers struct {
 	mock.Mock
 }
 
-// CreateRecord provides a mock function with given fields: ctx, record
-func (_m *ServerHandlers) CreateRecord(ctx context.Context, record userdata.Record) error {
-	ret := _m.Called(ctx, record)
+// CreateRecord provides a mock function with given fields: ctx, userID, record
+func (_m *ServerHandlers) CreateRecord(ctx context.Context, userID userdata.UserID, record userdata.Record) (userdata.RecordMeta, error) {
+	ret := _m.Called(ctx, userID, record)
 
 	if len(ret) == 0 {
 		panic("no return value specified for CreateRecord")
 	}
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, userdata.Record) error); ok {
-		r0 = rf(ctx, record)
+	var r0 userdata.RecordMeta
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, userdata.UserID, userdata.Record) (userdata.RecordMeta, error)); ok {
+		return rf(ctx, userID, record)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, userdata.UserID, userdata.Record) userdata.RecordMeta); ok {
+		r0 = rf(ctx, userID, record)
 	} else {
-		r0 = ret.Error(0)
+		r0 = ret.Get(0).(userdata.RecordMeta)
 	}
 
-	return r0
+	if rf, ok := ret.Get(1).(func(context.Context, userdata.UserID, userdata.Record) error); ok {
+		r1 = rf(ctx, userID, record)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateRecords provides a mock function with given fields: ctx, userID, records
+func (_m *ServerHandlers) CreateRecords(ctx context.Context, userID userdata.UserID, records []userdata.Record) ([]userdata.RecordResult, error) {
+	ret := _m.Called(ctx, userID, records)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateRecords")
+	}
+
+	var r0 []userdata.RecordResult
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, userdata.UserID, []userdata.Record) ([]userdata.RecordResult, error)); ok {
+		return rf(ctx, userID, records)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, userdata.UserID, []userdata.Record) []userdata.RecordResult); ok {
+		r0 = rf(ctx, userID, records)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]userdata.RecordResult)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, userdata.UserID, []userdata.Record) error); ok {
+		r1 = rf(ctx, userID, records)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
 // CreateUser provides a mock function with given fields: credentials
@@ -61,17 +103,45 @@ func (_m *ServerHandlers) CreateUser(credentials userdata.UserCredentials) (user
 	return r0, r1
 }
 
-// DeleteRecord provides a mock function with given fields: ctx, recordID
-func (_m *ServerHandlers) DeleteRecord(ctx context.Context, recordID string) error {
-	ret := _m.Called(ctx, recordID)
+// CreateUserSRP provides a mock function with given fields: login, verifier
+func (_m *ServerHandlers) CreateUserSRP(login string, verifier string) (userdata.AuthToken, error) {
+	ret := _m.Called(login, verifier)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateUserSRP")
+	}
+
+	var r0 userdata.AuthToken
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, string) (userdata.AuthToken, error)); ok {
+		return rf(login, verifier)
+	}
+	if rf, ok := ret.Get(0).(func(string, string) userdata.AuthToken); ok {
+		r0 = rf(login, verifier)
+	} else {
+		r0 = ret.Get(0).(userdata.AuthToken)
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(login, verifier)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteRecord provides a mock function with given fields: ctx, userID, recordID
+func (_m *ServerHandlers) DeleteRecord(ctx context.Context, userID userdata.UserID, recordID string) error {
+	ret := _m.Called(ctx, userID, recordID)
 
 	if len(ret) == 0 {
 		panic("no return value specified for DeleteRecord")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
-		r0 = rf(ctx, recordID)
+	if rf, ok := ret.Get(0).(func(context.Context, userdata.UserID, string) error); ok {
+		r0 = rf(ctx, userID, recordID)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -79,9 +149,106 @@ func (_m *ServerHandlers) DeleteRecord(ctx context.Context, recordID string) err
 	return r0
 }
 
-// GetRecord provides a mock function with given fields: ctx, recordID
-func (_m *ServerHandlers) GetRecord(ctx context.Context, recordID string) (userdata.Record, error) {
-	ret := _m.Called(ctx, recordID)
+// DeleteRecords provides a mock function with given fields: ctx, userID, recordIDs
+func (_m *ServerHandlers) DeleteRecords(ctx context.Context, userID userdata.UserID, recordIDs []string) ([]userdata.RecordResult, error) {
+	ret := _m.Called(ctx, userID, recordIDs)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteRecords")
+	}
+
+	var r0 []userdata.RecordResult
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, userdata.UserID, []string) ([]userdata.RecordResult, error)); ok {
+		return rf(ctx, userID, recordIDs)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, userdata.UserID, []string) []userdata.RecordResult); ok {
+		r0 = rf(ctx, userID, recordIDs)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]userdata.RecordResult)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, userdata.UserID, []string) error); ok {
+		r1 = rf(ctx, userID, recordIDs)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FinishLoginSRP provides a mock function with given fields: sessionID, proof
+func (_m *ServerHandlers) FinishLoginSRP(sessionID string, proof []byte) (userdata.AuthToken, []byte, error) {
+	ret := _m.Called(sessionID, proof)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FinishLoginSRP")
+	}
+
+	var r0 userdata.AuthToken
+	var r1 []byte
+	var r2 error
+	if rf, ok := ret.Get(0).(func(string, []byte) (userdata.AuthToken, []byte, error)); ok {
+		return rf(sessionID, proof)
+	}
+	if rf, ok := ret.Get(0).(func(string, []byte) userdata.AuthToken); ok {
+		r0 = rf(sessionID, proof)
+	} else {
+		r0 = ret.Get(0).(userdata.AuthToken)
+	}
+
+	if rf, ok := ret.Get(1).(func(string, []byte) []byte); ok {
+		r1 = rf(sessionID, proof)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).([]byte)
+		}
+	}
+
+	if rf, ok := ret.Get(2).(func(string, []byte) error); ok {
+		r2 = rf(sessionID, proof)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetKeySalt provides a mock function with given fields: ctx, userID
+func (_m *ServerHandlers) GetKeySalt(ctx context.Context, userID userdata.UserID) ([]byte, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetKeySalt")
+	}
+
+	var r0 []byte
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, userdata.UserID) ([]byte, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, userdata.UserID) []byte); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]byte)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, userdata.UserID) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetRecord provides a mock function with given fields: ctx, userID, recordID
+func (_m *ServerHandlers) GetRecord(ctx context.Context, userID userdata.UserID, recordID string) (userdata.Record, error) {
+	ret := _m.Called(ctx, userID, recordID)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetRecord")
@@ -89,17 +256,17 @@ func (_m *ServerHandlers) GetRecord(ctx context.Context, recordID string) (userd
 
 	var r0 userdata.Record
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) (userdata.Record, error)); ok {
-		return rf(ctx, recordID)
+	if rf, ok := ret.Get(0).(func(context.Context, userdata.UserID, string) (userdata.Record, error)); ok {
+		return rf(ctx, userID, recordID)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string) userdata.Record); ok {
-		r0 = rf(ctx, recordID)
+	if rf, ok := ret.Get(0).(func(context.Context, userdata.UserID, string) userdata.Record); ok {
+		r0 = rf(ctx, userID, recordID)
 	} else {
 		r0 = ret.Get(0).(userdata.Record)
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = rf(ctx, recordID)
+	if rf, ok := ret.Get(1).(func(context.Context, userdata.UserID, string) error); ok {
+		r1 = rf(ctx, userID, recordID)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -107,9 +274,39 @@ func (_m *ServerHandlers) GetRecord(ctx context.Context, recordID string) (userd
 	return r0, r1
 }
 
-// GetRecordsInfo provides a mock function with given fields: ctx
-func (_m *ServerHandlers) GetRecordsInfo(ctx context.Context) ([]userdata.Record, error) {
-	ret := _m.Called(ctx)
+// GetRecords provides a mock function with given fields: ctx, userID, recordIDs
+func (_m *ServerHandlers) GetRecords(ctx context.Context, userID userdata.UserID, recordIDs []string) ([]userdata.RecordResult, error) {
+	ret := _m.Called(ctx, userID, recordIDs)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetRecords")
+	}
+
+	var r0 []userdata.RecordResult
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, userdata.UserID, []string) ([]userdata.RecordResult, error)); ok {
+		return rf(ctx, userID, recordIDs)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, userdata.UserID, []string) []userdata.RecordResult); ok {
+		r0 = rf(ctx, userID, recordIDs)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]userdata.RecordResult)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, userdata.UserID, []string) error); ok {
+		r1 = rf(ctx, userID, recordIDs)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetRecordsInfo provides a mock function with given fields: ctx, userID
+func (_m *ServerHandlers) GetRecordsInfo(ctx context.Context, userID userdata.UserID) ([]userdata.Record, error) {
+	ret := _m.Called(ctx, userID)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetRecordsInfo")
@@ -117,19 +314,19 @@ func (_m *ServerHandlers) GetRecordsInfo(ctx context.Context) ([]userdata.Record
 
 	var r0 []userdata.Record
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context) ([]userdata.Record, error)); ok {
-		return rf(ctx)
+	if rf, ok := ret.Get(0).(func(context.Context, userdata.UserID) ([]userdata.Record, error)); ok {
+		return rf(ctx, userID)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context) []userdata.Record); ok {
-		r0 = rf(ctx)
+	if rf, ok := ret.Get(0).(func(context.Context, userdata.UserID) []userdata.Record); ok {
+		r0 = rf(ctx, userID)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]userdata.Record)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
-		r1 = rf(ctx)
+	if rf, ok := ret.Get(1).(func(context.Context, userdata.UserID) error); ok {
+		r1 = rf(ctx, userID)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -165,6 +362,101 @@ func (_m *ServerHandlers) LoginUser(credentials userdata.UserCredentials) (userd
 	return r0, r1
 }
 
+// ReplaceRecords provides a mock function with given fields: ctx, userID, records
+func (_m *ServerHandlers) ReplaceRecords(ctx context.Context, userID userdata.UserID, records []userdata.Record) ([]userdata.RecordResult, error) {
+	ret := _m.Called(ctx, userID, records)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReplaceRecords")
+	}
+
+	var r0 []userdata.RecordResult
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, userdata.UserID, []userdata.Record) ([]userdata.RecordResult, error)); ok {
+		return rf(ctx, userID, records)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, userdata.UserID, []userdata.Record) []userdata.RecordResult); ok {
+		r0 = rf(ctx, userID, records)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]userdata.RecordResult)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, userdata.UserID, []userdata.Record) error); ok {
+		r1 = rf(ctx, userID, records)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RewrapRecords provides a mock function with given fields: ctx, userID, records
+func (_m *ServerHandlers) RewrapRecords(ctx context.Context, userID userdata.UserID, records []userdata.Record) ([]userdata.RecordResult, error) {
+	ret := _m.Called(ctx, userID, records)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RewrapRecords")
+	}
+
+	var r0 []userdata.RecordResult
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, userdata.UserID, []userdata.Record) ([]userdata.RecordResult, error)); ok {
+		return rf(ctx, userID, records)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, userdata.UserID, []userdata.Record) []userdata.RecordResult); ok {
+		r0 = rf(ctx, userID, records)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]userdata.RecordResult)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, userdata.UserID, []userdata.Record) error); ok {
+		r1 = rf(ctx, userID, records)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// StartLoginSRP provides a mock function with given fields: login, a
+func (_m *ServerHandlers) StartLoginSRP(login string, a []byte) (string, crypt.SRPChallenge, error) {
+	ret := _m.Called(login, a)
+
+	if len(ret) == 0 {
+		panic("no return value specified for StartLoginSRP")
+	}
+
+	var r0 string
+	var r1 crypt.SRPChallenge
+	var r2 error
+	if rf, ok := ret.Get(0).(func(string, []byte) (string, crypt.SRPChallenge, error)); ok {
+		return rf(login, a)
+	}
+	if rf, ok := ret.Get(0).(func(string, []byte) string); ok {
+		r0 = rf(login, a)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(string, []byte) crypt.SRPChallenge); ok {
+		r1 = rf(login, a)
+	} else {
+		r1 = ret.Get(1).(crypt.SRPChallenge)
+	}
+
+	if rf, ok := ret.Get(2).(func(string, []byte) error); ok {
+		r2 = rf(login, a)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // NewServerHandlers creates a new instance of ServerHandlers. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewServerHandlers(t interface {
@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+)
+
+// Limiters of clients idle for limiterIdle are evicted, map is swept not more often than limiterSweep.
+const (
+	limiterIdle  = 10 * time.Minute
+	limiterSweep = time.Minute
+)
+
+// RateLimiter limits requests rate for each client, client is user or peer IP for unauthenticated requests.
+type RateLimiter struct {
+	mu        sync.Mutex
+	limit     rate.Limit
+	burst     int
+	limiters  map[string]*clientLimiter
+	lastSweep time.Time
+}
+
+// clientLimiter is limiter of client with time of its last request.
+type clientLimiter struct {
+	*rate.Limiter
+	lastSeen time.Time
+}
+
+// NewRateLimiter returns rate limiter, zero rps disables limiting.
+func NewRateLimiter(rps float64, burst int) *RateLimiter {
+	l := &RateLimiter{limiters: make(map[string]*clientLimiter), lastSweep: time.Now()}
+	l.SetLimits(rps, burst)
+
+	return l
+}
+
+// SetLimits changes limits for all clients without dropping their state.
+func (l *RateLimiter) SetLimits(rps float64, burst int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.limit = rate.Limit(rps)
+	if rps <= 0 {
+		l.limit = rate.Inf
+	}
+	l.burst = burst
+
+	for _, limiter := range l.limiters {
+		limiter.SetLimit(l.limit)
+		limiter.SetBurst(l.burst)
+	}
+}
+
+// Allow reports whether request of client may happen now.
+func (l *RateLimiter) Allow(client string) bool {
+	return l.reserve(client) == 0
+}
+
+// reserve takes token of client if it's available and returns zero, else returns delay until next token.
+func (l *RateLimiter) reserve(client string) time.Duration {
+	l.mu.Lock()
+	if l.limit == rate.Inf {
+		l.mu.Unlock()
+		return 0
+	}
+
+	now := time.Now()
+	if now.Sub(l.lastSweep) >= limiterSweep {
+		l.sweep(now)
+	}
+
+	limiter, ok := l.limiters[client]
+	if !ok {
+		limiter = &clientLimiter{Limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.limiters[client] = limiter
+	}
+	limiter.lastSeen = now
+	limit := l.limit
+	l.mu.Unlock()
+
+	reservation := limiter.ReserveN(now, 1)
+	if !reservation.OK() {
+		// Zero burst never allows requests, next token interval is still useful for client
+		return time.Duration(float64(time.Second) / float64(limit))
+	}
+
+	delay := reservation.DelayFrom(now)
+	if delay > 0 {
+		reservation.CancelAt(now)
+	}
+
+	return delay
+}
+
+// sweep evicts limiters of idle clients, so clients from many addresses don't grow memory.
+// Limiter is evicted only after its bucket is full again, so new limiter of client is the same. l.mu must be held.
+func (l *RateLimiter) sweep(now time.Time) {
+	l.lastSweep = now
+
+	idle := limiterIdle
+	if refill := time.Duration(float64(l.burst) / float64(l.limit) * float64(time.Second)); l.limit > 0 && refill > idle {
+		idle = refill
+	}
+
+	for client, limiter := range l.limiters {
+		if now.Sub(limiter.lastSeen) >= idle {
+			delete(l.limiters, client)
+		}
+	}
+}
+
+// Unary rejects unary requests over limit with ResourceExhausted status.
+func (l *RateLimiter) Unary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := l.check(ctx, info.FullMethod); err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// Stream rejects opening of streams over limit with ResourceExhausted status.
+func (l *RateLimiter) Stream() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := l.check(ss.Context(), info.FullMethod); err != nil {
+			return err
+		}
+
+		return handler(srv, ss)
+	}
+}
+
+// check returns error if client of request is over limit.
+func (l *RateLimiter) check(ctx context.Context, method string) error {
+	client := rateLimitClient(ctx)
+	if delay := l.reserve(client); delay > 0 {
+		log.Warnf("rate limit exceeded by %s on %s", client, method)
+
+		return toStatus(&RetryError{Delay: delay, Err: ErrRateLimited})
+	}
+
+	return nil
+}
+
+// rateLimitClient gets client key from validated userID or peer IP.
+func rateLimitClient(ctx context.Context) string {
+	if userID, ok := UserIDFromContext(ctx); ok {
+		return "user:" + string(userID)
+	}
+
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		host, _, err := net.SplitHostPort(p.Addr.String())
+		if err != nil {
+			host = p.Addr.String()
+		}
+
+		return "ip:" + host
+	}
+
+	return "unknown"
+}
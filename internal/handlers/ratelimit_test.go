@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func TestRateLimiter(t *testing.T) {
+	limiter := NewRateLimiter(0, 1)
+
+	for i := 0; i < 10; i++ {
+		assert.True(t, limiter.Allow("client"), "unlimited")
+	}
+
+	limiter.SetLimits(0.001, 2)
+	assert.True(t, limiter.Allow("client"))
+	assert.True(t, limiter.Allow("client"))
+	assert.False(t, limiter.Allow("client"))
+	assert.True(t, limiter.Allow("other"), "separate clients")
+
+	limiter.SetLimits(0, 2)
+	assert.True(t, limiter.Allow("client"), "limit disabled by reload")
+}
+
+func TestRateLimiter_Sweep(t *testing.T) {
+	limiter := NewRateLimiter(1, 2)
+	assert.True(t, limiter.Allow("idle"))
+	assert.True(t, limiter.Allow("active"))
+
+	limiter.mu.Lock()
+	limiter.limiters["active"].lastSeen = time.Now().Add(limiterIdle)
+	limiter.sweep(time.Now().Add(limiterIdle))
+	_, idle := limiter.limiters["idle"]
+	_, active := limiter.limiters["active"]
+	limiter.mu.Unlock()
+	assert.False(t, idle, "idle client is evicted")
+	assert.True(t, active)
+
+	// Client isn't evicted until its tokens are refilled
+	limiter.SetLimits(0.001, 2)
+	assert.True(t, limiter.Allow("slow"))
+
+	limiter.mu.Lock()
+	limiter.sweep(time.Now().Add(limiterIdle))
+	_, slow := limiter.limiters["slow"]
+	limiter.mu.Unlock()
+	assert.True(t, slow)
+}
+
+func TestRateLimiter_Interceptor(t *testing.T) {
+	limiter := NewRateLimiter(0.001, 1)
+	interceptor := limiter.Unary()
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "ok", nil
+	}
+	info := &grpc.UnaryServerInfo{FullMethod: "/rpc.Gokeeper/GetRecordsInfo"}
+
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 5000}})
+	resp, err := interceptor(ctx, nil, info, handler)
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", resp)
+
+	_, err = interceptor(ctx, nil, info, handler)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	var statusErr *StatusError
+	assert.True(t, errors.As(fromStatus(err), &statusErr))
+	assert.ErrorIs(t, statusErr, ErrRateLimited)
+	assert.Greater(t, statusErr.RetryDelay, time.Duration(0), "retry delay is sent to client")
+
+	_, err = interceptor(withUserID(ctx, "1"), nil, info, handler)
+	assert.NoError(t, err, "user is limited separately from peer")
+
+	forged := metadata.NewIncomingContext(ctx, metadata.Pairs("userID", "2"))
+	_, err = interceptor(forged, nil, info, handler)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err), "metadata userID is not trusted")
+}
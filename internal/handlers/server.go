@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"context"
+	"errors"
 
 	"github.com/impr0ver/gophKeeper/internal/crypt"
 	"github.com/impr0ver/gophKeeper/internal/storage"
@@ -10,37 +11,111 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
+// maxBatchSize is max number of records in one batch request.
+const maxBatchSize = 500
+
+// maxDataKeySize limits wrapped data key of record, it isn't counted in quota.
+const maxDataKeySize = 128
+
 // server struct for server handlers.
 type server struct {
 	Storage       storage.Storager
 	Authenticator Authenticator
+	// Passwords are cost parameters of password hashes.
+	Passwords crypt.PasswordParams
+	// LegacyLogin allows login and registration with password sent to server.
+	LegacyLogin bool
+	srp         *srpSessions
 }
 
-// newServerHandlers returns server handlers based on storage and authenticator (interface).
-func newServerHandlers(storage storage.Storager, authenticator Authenticator) *server {
+// newServerHandlers returns server handlers based on storage, authenticator and password hash parameters (interface).
+func newServerHandlers(storage storage.Storager, authenticator Authenticator, passwords crypt.PasswordParams, legacyLogin bool) *server {
 	return &server{
 		Storage:       storage,
 		Authenticator: authenticator,
+		Passwords:     passwords,
+		LegacyLogin:   legacyLogin,
+		srp:           newSRPSessions(),
+	}
+}
+
+// checkCredentials returns error of first empty credentials field.
+func checkCredentials(credentials userdata.UserCredentials) error {
+	if credentials.Login == "" {
+		return emptyField("login")
+	}
+
+	if credentials.Password == "" {
+		return emptyField("password")
+	}
+
+	return nil
+}
+
+// checkBatch returns error if batch in request field is too large.
+func checkBatch(field string, size int) error {
+	if size > maxBatchSize {
+		return &FieldError{Field: field, Err: ErrBatchTooLarge}
+	}
+
+	return nil
+}
+
+// checkRecordID checks ID of new record chosen by client, empty ID is generated by storage.
+func checkRecordID(field string, id string) error {
+	if id != "" && !storage.ValidRecordID(id) {
+		return &FieldError{Field: field, Err: ErrInvalidRecordID}
 	}
+
+	return nil
+}
+
+// checkDataKey checks size of wrapped data key of record.
+func checkDataKey(field string, key []byte) error {
+	if len(key) > maxDataKeySize {
+		return &FieldError{Field: field, Err: ErrInvalidDataKey}
+	}
+
+	return nil
 }
 
-// LoginUser logins user by login and password.
+// scrubKeyHint returns key hint, if it's fingerprint and label of key.
+// Older clients send masked AES key as hint, it isn't saved.
+func scrubKeyHint(hint string) string {
+	if _, _, ok := userdata.ParseKeyHint(hint); !ok {
+		return ""
+	}
+
+	return hint
+}
+
+// LoginUser logins user by login and password, if legacy login is allowed.
 func (s *server) LoginUser(credentials userdata.UserCredentials) (userdata.AuthToken, error) {
-	if credentials.Login == "" || credentials.Password == "" {
-		return "", ErrEmptyField
+	if !s.LegacyLogin {
+		return "", ErrLegacyLogin
 	}
 
-	credentials.Password = crypt.PasswordHash(credentials)
+	if err := checkCredentials(credentials); err != nil {
+		return "", err
+	}
 
-	userID, err := s.Storage.LoginUser(credentials)
+	// Password hashes and verifiers with other parameters are replaced by SRP verifiers on login
+	userID, err := s.Storage.LoginUser(credentials.Login, func(hash string) (string, error) {
+		return crypt.VerifyPassword(credentials, hash, s.Passwords)
+	})
 	if err != nil {
 		log.Warnf("%s :: %v", "get user login error", err)
 
 		return "", err
 	}
 
-	authToken, errCreateToken := s.Authenticator.CreateToken(userID)
-	if errCreateToken != nil {
+	return s.createToken(userID)
+}
+
+// createToken creates token of logged in user.
+func (s *server) createToken(userID userdata.UserID) (userdata.AuthToken, error) {
+	authToken, err := s.Authenticator.CreateToken(userID)
+	if err != nil {
 		log.Warnf("%s :: %v", "create token error", err)
 
 		return "", storage.ErrUnknown
@@ -49,15 +124,27 @@ func (s *server) LoginUser(credentials userdata.UserCredentials) (userdata.AuthT
 	return authToken, nil
 }
 
-// CreateUser creates new user by login and password.
+// CreateUser creates new user by login and password, if legacy login is allowed.
+// Server makes SRP verifier of password, so user may login with SRP later.
 func (s *server) CreateUser(credentials userdata.UserCredentials) (userdata.AuthToken, error) {
-	if credentials.Login == "" || credentials.Password == "" {
-		return "", ErrEmptyField
+	if !s.LegacyLogin {
+		return "", ErrLegacyLogin
+	}
+
+	if err := checkCredentials(credentials); err != nil {
+		return "", err
+	}
+
+	hash, err := crypt.NewSRPVerifier(credentials, s.Passwords)
+	if err != nil {
+		log.Warnf("%s :: %v", "hash password error", err)
+
+		return "", storage.ErrUnknown
 	}
 
 	if err := s.Storage.CreateUser(userdata.UserCredentials{
 		Login:    credentials.Login,
-		Password: crypt.PasswordHash(credentials),
+		Password: hash,
 	}); err != nil {
 		log.Warnf("%s :: %v", "create new user error", err)
 
@@ -67,23 +154,224 @@ func (s *server) CreateUser(credentials userdata.UserCredentials) (userdata.Auth
 	return s.LoginUser(credentials)
 }
 
-// CreateRecord added record to storage.
-func (s *server) CreateRecord(ctx context.Context, record userdata.Record) error {
-	_, err := s.Storage.CreateRecord(ctx, record)
-	return err
+// CreateUserSRP creates new user with SRP verifier made by client, server never gets password.
+func (s *server) CreateUserSRP(login string, verifier string) (userdata.AuthToken, error) {
+	if login == "" {
+		return "", emptyField("login")
+	}
+
+	if !crypt.ValidSRPVerifier(verifier, s.Passwords) {
+		return "", &FieldError{Field: "verifier", Err: ErrInvalidVerifier}
+	}
+
+	if err := s.Storage.CreateUser(userdata.UserCredentials{
+		Login:    login,
+		Password: verifier,
+	}); err != nil {
+		log.Warnf("%s :: %v", "create new user error", err)
+
+		return "", err
+	}
+
+	userID, err := s.Storage.LoginUser(login, func(hash string) (string, error) {
+		if hash != verifier {
+			return "", crypt.ErrWrongPassword
+		}
+
+		return "", nil
+	})
+	if err != nil {
+		log.Warnf("%s :: %v", "get user login error", err)
+
+		return "", err
+	}
+
+	return s.createToken(userID)
+}
+
+// StartLoginSRP starts SRP login of user with public value of client and returns session ID and challenge.
+// Unknown logins and users without SRP verifier get challenge of dummy verifier, so login fails on proof.
+func (s *server) StartLoginSRP(login string, a []byte) (string, crypt.SRPChallenge, error) {
+	if login == "" {
+		return "", crypt.SRPChallenge{}, emptyField("login")
+	}
+
+	hash, err := s.Storage.GetPasswordHash(login)
+	if err != nil && !errors.Is(err, storage.ErrWrongCredentials) {
+		return "", crypt.SRPChallenge{}, err
+	}
+
+	if err != nil || !crypt.IsSRPVerifier(hash) {
+		hash, err = crypt.DummySRPVerifier(s.srp.dummySalt(login), s.Passwords)
+		if err != nil {
+			log.Warnf("%s :: %v", "dummy verifier error", err)
+
+			return "", crypt.SRPChallenge{}, storage.ErrUnknown
+		}
+	}
+
+	srpServer, err := crypt.NewSRPServer(hash, a)
+	if err != nil {
+		log.Infoln(err)
+
+		return "", crypt.SRPChallenge{}, storage.ErrWrongCredentials
+	}
+
+	sessionID, err := s.srp.add(srpSession{login: login, hash: hash, server: srpServer})
+	if err != nil {
+		return "", crypt.SRPChallenge{}, err
+	}
+
+	return sessionID, srpServer.Challenge(), nil
+}
+
+// FinishLoginSRP checks proof of client and returns token and proof of server.
+func (s *server) FinishLoginSRP(sessionID string, proof []byte) (userdata.AuthToken, []byte, error) {
+	session, ok := s.srp.take(sessionID)
+	if !ok {
+		return "", nil, storage.ErrWrongCredentials
+	}
+
+	var serverProof []byte
+
+	// Verifier mustn't be changed since challenge, dummy one never matches saved hash
+	userID, err := s.Storage.LoginUser(session.login, func(hash string) (string, error) {
+		if hash != session.hash {
+			return "", crypt.ErrWrongPassword
+		}
+
+		var err error
+		serverProof, err = session.server.Verify(proof)
+
+		return "", err
+	})
+	if err != nil {
+		log.Warnf("%s :: %v", "get user login error", err)
+
+		return "", nil, err
+	}
+
+	token, err := s.createToken(userID)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return token, serverProof, nil
+}
+
+// GetKeySalt gets salt of user encryption key from storage.
+func (s *server) GetKeySalt(ctx context.Context, userID userdata.UserID) ([]byte, error) {
+	return s.Storage.GetKeySalt(ctx, userID)
+}
+
+// CreateRecord added record to storage and returns its meta.
+func (s *server) CreateRecord(ctx context.Context, userID userdata.UserID, record userdata.Record) (userdata.RecordMeta, error) {
+	if err := checkRecordID("id", record.ID); err != nil {
+		return userdata.RecordMeta{}, err
+	}
+	if err := checkDataKey("data_key", record.DataKey); err != nil {
+		return userdata.RecordMeta{}, err
+	}
+	record.KeyHint = scrubKeyHint(record.KeyHint)
+
+	return s.Storage.CreateRecord(ctx, userID, record)
 }
 
 // GetRecordsInfo gets all records from storage.
-func (s *server) GetRecordsInfo(ctx context.Context) ([]userdata.Record, error) {
-	return s.Storage.GetRecordsInfo(ctx)
+func (s *server) GetRecordsInfo(ctx context.Context, userID userdata.UserID) ([]userdata.Record, error) {
+	return s.Storage.GetRecordsInfo(ctx, userID)
 }
 
 // GetRecord get record from storage by ID.
-func (s *server) GetRecord(ctx context.Context, recordID string) (userdata.Record, error) {
-	return s.Storage.GetRecord(ctx, recordID)
+func (s *server) GetRecord(ctx context.Context, userID userdata.UserID, recordID string) (userdata.Record, error) {
+	return s.Storage.GetRecord(ctx, userID, recordID)
 }
 
 // DeleteRecord deletes record from storage.
-func (s *server) DeleteRecord(ctx context.Context, recordID string) error {
-	return s.Storage.DeleteRecord(ctx, recordID)
+func (s *server) DeleteRecord(ctx context.Context, userID userdata.UserID, recordID string) error {
+	return s.Storage.DeleteRecord(ctx, userID, recordID)
+}
+
+// CreateRecords adds records to storage with one batch.
+func (s *server) CreateRecords(ctx context.Context, userID userdata.UserID, records []userdata.Record) ([]userdata.RecordResult, error) {
+	if err := checkBatch("records", len(records)); err != nil {
+		return nil, err
+	}
+
+	for i, record := range records {
+		if err := checkRecordID("records.id", record.ID); err != nil {
+			return nil, err
+		}
+		if err := checkDataKey("records.data_key", record.DataKey); err != nil {
+			return nil, err
+		}
+		records[i].KeyHint = scrubKeyHint(record.KeyHint)
+	}
+
+	return s.Storage.CreateRecords(ctx, userID, records)
+}
+
+// GetRecords gets records from storage by IDs with one batch.
+func (s *server) GetRecords(ctx context.Context, userID userdata.UserID, recordIDs []string) ([]userdata.RecordResult, error) {
+	if err := checkBatch("ids", len(recordIDs)); err != nil {
+		return nil, err
+	}
+
+	return s.Storage.GetRecords(ctx, userID, recordIDs)
+}
+
+// DeleteRecords deletes records from storage by IDs with one batch.
+func (s *server) DeleteRecords(ctx context.Context, userID userdata.UserID, recordIDs []string) ([]userdata.RecordResult, error) {
+	if err := checkBatch("ids", len(recordIDs)); err != nil {
+		return nil, err
+	}
+
+	return s.Storage.DeleteRecords(ctx, userID, recordIDs)
+}
+
+// ReplaceRecords replaces data of records with versions in storage with one batch.
+func (s *server) ReplaceRecords(ctx context.Context, userID userdata.UserID, records []userdata.Record) ([]userdata.RecordResult, error) {
+	if err := checkBatch("records", len(records)); err != nil {
+		return nil, err
+	}
+
+	for i, record := range records {
+		if record.ID == "" {
+			return nil, emptyField("records.id")
+		}
+		if err := checkRecordID("records.id", record.ID); err != nil {
+			return nil, err
+		}
+		if err := checkDataKey("records.data_key", record.DataKey); err != nil {
+			return nil, err
+		}
+		records[i].KeyHint = scrubKeyHint(record.KeyHint)
+	}
+
+	return s.Storage.ReplaceRecords(ctx, userID, records)
+}
+
+// RewrapRecords replaces data keys of records with versions in storage with one batch.
+func (s *server) RewrapRecords(ctx context.Context, userID userdata.UserID, records []userdata.Record) ([]userdata.RecordResult, error) {
+	if err := checkBatch("records", len(records)); err != nil {
+		return nil, err
+	}
+
+	for i, record := range records {
+		if record.ID == "" {
+			return nil, emptyField("records.id")
+		}
+		if err := checkRecordID("records.id", record.ID); err != nil {
+			return nil, err
+		}
+		if err := checkDataKey("records.data_key", record.DataKey); err != nil {
+			return nil, err
+		}
+		if len(record.DataKey) == 0 {
+			return nil, emptyField("records.data_key")
+		}
+		records[i].KeyHint = scrubKeyHint(record.KeyHint)
+	}
+
+	return s.Storage.RewrapRecords(ctx, userID, records)
 }
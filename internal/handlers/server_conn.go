@@ -5,6 +5,7 @@ import (
 	"crypto/tls"
 	"errors"
 	"net"
+	"sync/atomic"
 
 	"github.com/impr0ver/gophKeeper/internal/logger"
 	pb "github.com/impr0ver/gophKeeper/internal/rpc"
@@ -13,47 +14,56 @@ import (
 
 	log "github.com/sirupsen/logrus"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
-	"google.golang.org/grpc/metadata"
-	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/emptypb"
 )
 
 // ServerConn keeps server endpoints alive.
 type ServerConn struct {
 	pb.UnimplementedGokeeperServer
-	Handlers         ServerHandlers
-	server           *grpc.Server
-	Authenticator    Authenticator
-	ServerCert       string
-	ServerKey        string
-	ServerConsoleLog bool
+	Handlers      ServerHandlers
+	Admin         *AdminConn
+	server        *grpc.Server
+	Authenticator Authenticator
+	ServerCert    string
+	ServerKey     string
+	Limiter       *RateLimiter
+	Idempotency   *Idempotency
+	consoleLog    atomic.Bool
 }
 
 // NewServerConn returns new server connection.
 func NewServerConn(handlers ServerHandlers, authenticator Authenticator, serverCert string, serverKey string, serverConsoleLog bool) *ServerConn {
-	return &ServerConn{
-		Handlers:         handlers,
-		Authenticator:    authenticator,
-		ServerCert:       serverCert,
-		ServerKey:        serverKey,
-		ServerConsoleLog: serverConsoleLog,
+	s := &ServerConn{
+		Handlers:      handlers,
+		Authenticator: authenticator,
+		ServerCert:    serverCert,
+		ServerKey:     serverKey,
 	}
+	s.SetConsoleLog(serverConsoleLog)
+
+	return s
+}
+
+// SetConsoleLog enables or disables console logging of requests on interceptors.
+func (s *ServerConn) SetConsoleLog(enabled bool) {
+	s.consoleLog.Store(enabled)
 }
 
-// LoadTLSCredentials load certificates and provate key.
-func (s *ServerConn) loadTLSCredentials() (credentials.TransportCredentials, error) {
+// LoadTLSCredentials load certificates and provate key, they are reloaded on change of files until ctx is done.
+func (s *ServerConn) loadTLSCredentials(ctx context.Context) (credentials.TransportCredentials, error) {
 	// Load server's certificate and private key
-	serverCert, err := tls.LoadX509KeyPair(s.ServerCert, s.ServerKey)
+	reloader, err := NewCertReloader(s.ServerCert, s.ServerKey)
 	if err != nil {
 		return nil, err
 	}
 
+	go reloader.Watch(ctx, certCheckInterval)
+
 	// Create the credentials and return it
 	config := &tls.Config{
-		Certificates: []tls.Certificate{serverCert},
-		ClientAuth:   tls.NoClientCert,
+		GetCertificate: reloader.GetCertificate,
+		ClientAuth:     tls.NoClientCert,
 	}
 
 	return credentials.NewTLS(config), nil
@@ -67,16 +77,42 @@ func (s *ServerConn) Start(ctx context.Context, runAddress string) {
 		log.Fatal(err)
 	}
 
-	tlsCredentials, err := s.loadTLSCredentials()
+	tlsCredentials, err := s.loadTLSCredentials(ctx)
 	if err != nil {
 		log.Infof("cannot load TLS credentials: %v\n", err)
 		sLogger.Fatalf("cannot load TLS credentials: %v\n", err)
 	}
 
-	grpcServ := grpc.NewServer(grpc.Creds(tlsCredentials), grpc.ChainUnaryInterceptor(grpc.UnaryServerInterceptor(s.LoggingInterceptor),
-		grpc.UnaryServerInterceptor(s.VerifyAuth())))
+	s.Serve(ctx, listen, tlsCredentials)
+}
+
+// Serve runs server on listener with transport credentials, it's used by Start and by in-process test server.
+func (s *ServerConn) Serve(ctx context.Context, listen net.Listener, transportCredentials credentials.TransportCredentials) {
+	sLogger := logger.NewSugarLogger()
+
+	interceptors := []Interceptor{
+		NewLoggingInterceptor(s.consoleLog.Load),
+		NewErrorInterceptor(),
+		NewAuthInterceptor(s.Authenticator, s.consoleLog.Load),
+	}
+	if s.Limiter != nil {
+		interceptors = append(interceptors, s.Limiter)
+	}
+	if s.Admin != nil {
+		interceptors = append(interceptors, s.Admin.VerifyAdmin())
+	}
+	if s.Idempotency != nil {
+		interceptors = append(interceptors, s.Idempotency)
+
+		go s.Idempotency.Cleanup(ctx, idempotencyCleanupInterval)
+	}
+
+	grpcServ := grpc.NewServer(append(ChainInterceptors(interceptors...), grpc.Creds(transportCredentials))...)
 
 	pb.RegisterGokeeperServer(grpcServ, s)
+	if s.Admin != nil {
+		pb.RegisterAdminServer(grpcServ, s.Admin)
+	}
 
 	go func() {
 		for {
@@ -88,7 +124,10 @@ func (s *ServerConn) Start(ctx context.Context, runAddress string) {
 				log.Println("gRPC server is start...")
 				sLogger.Info("gRPC server is start...")
 
-				if err := grpcServ.Serve(listen); err != nil {
+				// Server may be stopped before it's served
+				if err := grpcServ.Serve(listen); errors.Is(err, grpc.ErrServerStopped) {
+					return
+				} else if err != nil {
 					log.Fatal(err)
 				}
 			}
@@ -109,23 +148,8 @@ func (s *ServerConn) Register(_ context.Context, credentials *pb.UserCreds) (*pb
 		Login:    credentials.Login,
 		Password: credentials.Password,
 	})
-
-	if errors.Is(err, ErrEmptyField) {
-		log.Infoln(err)
-
-		return nil, status.Errorf(codes.InvalidArgument, "login or password is empty.")
-	}
-
-	if errors.Is(err, storage.ErrLoginExists) {
-		log.Infoln(err)
-
-		return nil, status.Errorf(codes.AlreadyExists, "login already exists.")
-	}
-
 	if err != nil {
-		log.Warnf("%s %s :: %v", "register new user error", credentials.Login, err)
-
-		return nil, status.Errorf(codes.Internal, "internal server error.")
+		return nil, err
 	}
 
 	return &pb.Token{Token: string(token)}, nil
@@ -137,58 +161,81 @@ func (s *ServerConn) Login(_ context.Context, credentials *pb.UserCreds) (*pb.To
 		Login:    credentials.Login,
 		Password: credentials.Password,
 	})
+	if err != nil {
+		return nil, err
+	}
 
-	if errors.Is(err, ErrEmptyField) {
-		log.Infoln(err)
+	return &pb.Token{Token: string(token)}, nil
+}
 
-		return nil, status.Errorf(codes.InvalidArgument, "login or password is empty.")
+// RegisterSRP process register with SRP verifier on server side.
+func (s *ServerConn) RegisterSRP(_ context.Context, registration *pb.SRPRegistration) (*pb.Token, error) {
+	token, err := s.Handlers.CreateUserSRP(registration.Login, registration.Verifier)
+	if err != nil {
+		return nil, err
 	}
 
-	if errors.Is(err, storage.ErrWrongCredentials) {
-		log.Infoln(err)
+	return &pb.Token{Token: string(token)}, nil
+}
 
-		return nil, status.Errorf(codes.Unauthenticated, "wrong login or password.")
+// StartLoginSRP process start of SRP login on server side.
+func (s *ServerConn) StartLoginSRP(_ context.Context, start *pb.SRPStart) (*pb.SRPChallenge, error) {
+	sessionID, challenge, err := s.Handlers.StartLoginSRP(start.Login, start.A)
+	if err != nil {
+		return nil, err
 	}
 
-	if err != nil {
-		log.Warnf("%s %s :: %v", "login error", credentials.Login, err)
+	return &pb.SRPChallenge{
+		SessionId:   sessionID,
+		Salt:        challenge.Salt,
+		Memory:      challenge.Params.Memory,
+		Iterations:  challenge.Params.Iterations,
+		Parallelism: uint32(challenge.Params.Parallelism),
+		B:           challenge.B,
+	}, nil
+}
 
-		return nil, status.Errorf(codes.Internal, "Internal server error.")
+// FinishLoginSRP process finish of SRP login on server side.
+func (s *ServerConn) FinishLoginSRP(_ context.Context, proof *pb.SRPProof) (*pb.SRPSession, error) {
+	token, serverProof, err := s.Handlers.FinishLoginSRP(proof.SessionId, proof.Proof)
+	if err != nil {
+		return nil, err
 	}
 
-	return &pb.Token{Token: string(token)}, nil
+	return &pb.SRPSession{Token: string(token), Proof: serverProof}, nil
 }
 
-// GetRecordsInfo process get all records endpoint on server side.
-func (s *ServerConn) GetRecordsInfo(ctx context.Context, _ *emptypb.Empty) (*pb.RecordsList, error) {
-	md, ok := metadata.FromIncomingContext(ctx)
-	if !ok || len(md.Get("authToken")) == 0 {
-		return nil, status.Errorf(codes.Unauthenticated, "metadata for authentication not found.")
+// GetKeySalt process get key salt endpoint on server side.
+func (s *ServerConn) GetKeySalt(ctx context.Context, _ *emptypb.Empty) (*pb.KeySalt, error) {
+	userID, ok := UserIDFromContext(ctx)
+	if !ok {
+		return nil, storage.ErrUnauthenticated
 	}
 
-	records, err := s.Handlers.GetRecordsInfo(ctx)
+	salt, err := s.Handlers.GetKeySalt(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
 
-	if errors.Is(err, storage.ErrUnauthenticated) {
-		log.Infoln(err)
+	return &pb.KeySalt{Salt: salt}, nil
+}
 
-		return nil, status.Errorf(codes.Unauthenticated, "bad token.")
+// GetRecordsInfo process get all records endpoint on server side.
+func (s *ServerConn) GetRecordsInfo(ctx context.Context, _ *emptypb.Empty) (*pb.RecordsList, error) {
+	userID, ok := UserIDFromContext(ctx)
+	if !ok {
+		return nil, storage.ErrUnauthenticated
 	}
 
+	records, err := s.Handlers.GetRecordsInfo(ctx, userID)
 	if err != nil {
-		log.Warnf("%s :: %v", "get record info error", err)
-
-		return nil, status.Errorf(codes.Internal, "internal server error.")
+		return nil, err
 	}
 
 	recordsList := make([]*pb.Record, 0, len(records))
 
 	for _, record := range records {
-		recordsList = append(recordsList, &pb.Record{
-			Id:       record.ID,
-			Metadata: record.Metadata,
-			Keyhint:  record.KeyHint,
-			Type:     pb.MessageType(record.Type),
-		})
+		recordsList = append(recordsList, toPBRecord(record))
 	}
 
 	return &pb.RecordsList{Records: recordsList}, nil
@@ -196,95 +243,179 @@ func (s *ServerConn) GetRecordsInfo(ctx context.Context, _ *emptypb.Empty) (*pb.
 
 // GetRecord process get record endpoint on server side.
 func (s *ServerConn) GetRecord(ctx context.Context, recordID *pb.RecordID) (*pb.Record, error) {
-	md, ok := metadata.FromIncomingContext(ctx)
-	if !ok || len(md.Get("authToken")) == 0 {
-		return nil, status.Errorf(codes.Unauthenticated, "metadata for authentication not found.")
-	}
-
-	record, err := s.Handlers.GetRecord(ctx, recordID.Id)
-
-	if errors.Is(err, storage.ErrUnauthenticated) {
-		log.Infoln(err)
-
-		return nil, status.Errorf(codes.Unauthenticated, "bad token.")
-	}
-
-	if errors.Is(err, storage.ErrNotFound) {
-		log.Infoln(err)
-
-		return nil, status.Errorf(codes.NotFound, "not found record by id.")
+	userID, ok := UserIDFromContext(ctx)
+	if !ok {
+		return nil, storage.ErrUnauthenticated
 	}
 
+	record, err := s.Handlers.GetRecord(ctx, userID, recordID.Id)
 	if err != nil {
-		log.Warnf("%s :: %v", "get record error", err)
-
-		return nil, status.Errorf(codes.Internal, "internal server error.")
+		return nil, err
 	}
 
-	return &pb.Record{
-		Id:         record.ID,
-		Type:       pb.MessageType(record.Type),
-		Keyhint:    record.KeyHint,
-		Metadata:   record.Metadata,
-		StoredData: record.Data,
-	}, nil
+	return toPBRecord(record), nil
 }
 
 // CreateRecord process create record endpoint on server side.
-func (s *ServerConn) CreateRecord(ctx context.Context, record *pb.Record) (*emptypb.Empty, error) {
-	md, ok := metadata.FromIncomingContext(ctx)
-	if !ok || len(md.Get("authToken")) == 0 {
-		return nil, status.Errorf(codes.Unauthenticated, "metadata for authentication not found.")
+func (s *ServerConn) CreateRecord(ctx context.Context, record *pb.Record) (*pb.RecordMeta, error) {
+	userID, ok := UserIDFromContext(ctx)
+	if !ok {
+		return nil, storage.ErrUnauthenticated
 	}
 
-	err := s.Handlers.CreateRecord(ctx, userdata.Record{
+	meta, err := s.Handlers.CreateRecord(ctx, userID, userdata.Record{
+		ID:       record.Id,
 		Metadata: record.Metadata,
 		KeyHint:  record.Keyhint,
+		DataKey:  record.DataKey,
 		Type:     userdata.RecordType(record.Type),
 		Data:     record.StoredData,
 	})
+	if err != nil {
+		return nil, err
+	}
 
-	if errors.Is(err, storage.ErrUnauthenticated) {
-		log.Infoln(err)
+	return toPBRecordMeta(meta), nil
+}
 
-		return &emptypb.Empty{}, status.Errorf(codes.Unauthenticated, "bad token.")
+// DeleteRecord process delete record endpoint on server side.
+func (s *ServerConn) DeleteRecord(ctx context.Context, recordID *pb.RecordID) (*emptypb.Empty, error) {
+	userID, ok := UserIDFromContext(ctx)
+	if !ok {
+		return nil, storage.ErrUnauthenticated
 	}
 
+	if err := s.Handlers.DeleteRecord(ctx, userID, recordID.Id); err != nil {
+		return nil, err
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// CreateRecords process batch create records endpoint on server side.
+func (s *ServerConn) CreateRecords(ctx context.Context, recordsList *pb.RecordsList) (*pb.RecordResults, error) {
+	userID, ok := UserIDFromContext(ctx)
+	if !ok {
+		return nil, storage.ErrUnauthenticated
+	}
+
+	records := make([]userdata.Record, 0, len(recordsList.Records))
+	for _, record := range recordsList.Records {
+		records = append(records, userdata.Record{
+			ID:       record.Id,
+			Metadata: record.Metadata,
+			KeyHint:  record.Keyhint,
+			DataKey:  record.DataKey,
+			Type:     userdata.RecordType(record.Type),
+			Data:     record.StoredData,
+		})
+	}
+
+	results, err := s.Handlers.CreateRecords(ctx, userID, records)
 	if err != nil {
-		log.Warnf("%s :: %v", "create record error", err)
+		return nil, err
+	}
 
-		return &emptypb.Empty{}, status.Errorf(codes.Internal, "internal server error.")
+	return recordResults(results), nil
+}
+
+// GetRecords process batch get records endpoint on server side.
+func (s *ServerConn) GetRecords(ctx context.Context, recordIDs *pb.RecordIDs) (*pb.RecordResults, error) {
+	userID, ok := UserIDFromContext(ctx)
+	if !ok {
+		return nil, storage.ErrUnauthenticated
 	}
 
-	return &emptypb.Empty{}, nil
+	results, err := s.Handlers.GetRecords(ctx, userID, recordIDs.Ids)
+	if err != nil {
+		return nil, err
+	}
+
+	return recordResults(results), nil
 }
 
-// DeleteRecord process delete record endpoint on server side.
-func (s *ServerConn) DeleteRecord(ctx context.Context, recordID *pb.RecordID) (*emptypb.Empty, error) {
-	md, ok := metadata.FromIncomingContext(ctx)
-	if !ok || len(md.Get("authToken")) == 0 {
-		return nil, status.Errorf(codes.Unauthenticated, "metadata for authentication not found.")
+// DeleteRecords process batch delete records endpoint on server side.
+func (s *ServerConn) DeleteRecords(ctx context.Context, recordIDs *pb.RecordIDs) (*pb.RecordResults, error) {
+	userID, ok := UserIDFromContext(ctx)
+	if !ok {
+		return nil, storage.ErrUnauthenticated
 	}
 
-	err := s.Handlers.DeleteRecord(ctx, recordID.Id)
+	results, err := s.Handlers.DeleteRecords(ctx, userID, recordIDs.Ids)
+	if err != nil {
+		return nil, err
+	}
 
-	if errors.Is(err, storage.ErrUnauthenticated) {
-		log.Infoln(err)
+	return recordResults(results), nil
+}
 
-		return &emptypb.Empty{}, status.Errorf(codes.Unauthenticated, "bad token.")
+// ReplaceRecords process batch replace records endpoint on server side.
+func (s *ServerConn) ReplaceRecords(ctx context.Context, recordsList *pb.RecordsList) (*pb.RecordResults, error) {
+	userID, ok := UserIDFromContext(ctx)
+	if !ok {
+		return nil, storage.ErrUnauthenticated
 	}
 
-	if errors.Is(err, storage.ErrNotFound) {
-		log.Infoln(err)
+	records := make([]userdata.Record, 0, len(recordsList.Records))
+	for _, record := range recordsList.Records {
+		records = append(records, userdata.Record{
+			ID:      record.Id,
+			KeyHint: record.Keyhint,
+			DataKey: record.DataKey,
+			Type:    userdata.RecordType(record.Type),
+			Data:    record.StoredData,
+			Version: record.Version,
+		})
+	}
 
-		return nil, status.Errorf(codes.NotFound, "not found record by id.")
+	results, err := s.Handlers.ReplaceRecords(ctx, userID, records)
+	if err != nil {
+		return nil, err
 	}
 
+	return recordResults(results), nil
+}
+
+// RewrapRecords process batch rewrap records endpoint on server side.
+func (s *ServerConn) RewrapRecords(ctx context.Context, recordsList *pb.RecordsList) (*pb.RecordResults, error) {
+	userID, ok := UserIDFromContext(ctx)
+	if !ok {
+		return nil, storage.ErrUnauthenticated
+	}
+
+	records := make([]userdata.Record, 0, len(recordsList.Records))
+	for _, record := range recordsList.Records {
+		records = append(records, userdata.Record{
+			ID:      record.Id,
+			KeyHint: record.Keyhint,
+			DataKey: record.DataKey,
+			Type:    userdata.RecordType(record.Type),
+			Version: record.Version,
+		})
+	}
+
+	results, err := s.Handlers.RewrapRecords(ctx, userID, records)
 	if err != nil {
-		log.Warnf("%s :: %v", "delete record error", err)
+		return nil, err
+	}
 
-		return &emptypb.Empty{}, status.Errorf(codes.Internal, "internal server error.")
+	return recordResults(results), nil
+}
+
+// recordResults converts batch results to response, records are sent only if they are got.
+func recordResults(results []userdata.RecordResult) *pb.RecordResults {
+	pbResults := make([]*pb.RecordResult, 0, len(results))
+
+	for _, result := range results {
+		pbResult := &pb.RecordResult{Id: result.ID}
+		pbResult.ErrorReason, pbResult.ErrorMessage = errorReason(result.Err)
+
+		if result.Err == nil && result.Record.ID != "" {
+			pbResult.Record = toPBRecord(result.Record)
+		}
+
+		pbResults = append(pbResults, pbResult)
 	}
 
-	return &emptypb.Empty{}, nil
+	return &pb.RecordResults{Results: pbResults}
 }
@@ -2,9 +2,12 @@ package handlers
 
 import (
 	"context"
+	"strings"
 	"testing"
 
+	"github.com/impr0ver/gophKeeper/internal/crypt"
 	"github.com/impr0ver/gophKeeper/internal/handlers/mocks"
+	"github.com/impr0ver/gophKeeper/internal/storage"
 	storMocks "github.com/impr0ver/gophKeeper/internal/storage/mocks"
 	"github.com/impr0ver/gophKeeper/internal/userdata"
 
@@ -13,10 +16,22 @@ import (
 	"google.golang.org/grpc/metadata"
 )
 
+// testPasswords are cheap password hash parameters for tests.
+var testPasswords = crypt.PasswordParams{Memory: 64, Iterations: 1, Parallelism: 1}
+
+// verifyArg calls verify passed to storage with saved hash and checks its result.
+func verifyArg(t *testing.T, hash string, rehash bool, valid bool) func(args mock.Arguments) {
+	return func(args mock.Arguments) {
+		newHash, err := args.Get(1).(func(string) (string, error))(hash)
+		assert.Equal(t, valid, err == nil)
+		assert.Equal(t, rehash, newHash != "")
+	}
+}
+
 func TestNewServerHandlers(t *testing.T) {
 	store := storMocks.NewStorager(t)
 	auth := mocks.NewAuthenticator(t)
-	handlers := NewServerHandlers(store, auth)
+	handlers := NewServerHandlers(store, auth, testPasswords, true)
 
 	assert.NotEmpty(t, handlers)
 }
@@ -24,7 +39,9 @@ func TestNewServerHandlers(t *testing.T) {
 func TestServer_CreateUser(t *testing.T) {
 	store := storMocks.NewStorager(t)
 	auth := mocks.NewAuthenticator(t)
-	handlers := NewServerHandlers(store, auth)
+	handlers := NewServerHandlers(store, auth, testPasswords, true)
+
+	var saved string
 
 	tc := []struct {
 		name string
@@ -33,16 +50,15 @@ func TestServer_CreateUser(t *testing.T) {
 		want error
 	}{
 		{
-			"User with creds",
-			func() {
-				store.On("CreateUser", userdata.UserCredentials{
-					Login:    "Admin",
-					Password: "b07e019b4662035489e1664afa63e28929a9df529f7a7fd6989e682e3cb695fd",
-				}).Return(nil).Once()
-				store.On("LoginUser", userdata.UserCredentials{
-					Login:    "Admin",
-					Password: "b07e019b4662035489e1664afa63e28929a9df529f7a7fd6989e682e3cb695fd",
-				}).Return(userdata.UserID("userID"), nil).Once()
+			"User with creds, SRP verifier is saved",
+			func() {
+				store.On("CreateUser", mock.MatchedBy(func(credentials userdata.UserCredentials) bool {
+					saved = credentials.Password
+					return credentials.Login == "Admin" && strings.HasPrefix(credentials.Password, "$srp6a$m=64,t=1,p=1$")
+				})).Return(nil).Once()
+				store.On("LoginUser", "Admin", mock.AnythingOfType("func(string) (string, error)")).
+					Run(func(args mock.Arguments) { verifyArg(t, saved, false, true)(args) }).
+					Return(userdata.UserID("userID"), nil).Once()
 				auth.On("CreateToken", userdata.UserID("userID")).Return(userdata.AuthToken("token"), nil).Once()
 			},
 			userdata.UserCredentials{
@@ -58,7 +74,7 @@ func TestServer_CreateUser(t *testing.T) {
 				Login:    "",
 				Password: "",
 			},
-			ErrEmptyField,
+			emptyField("login"),
 		},
 	}
 
@@ -72,12 +88,22 @@ func TestServer_CreateUser(t *testing.T) {
 		auth.AssertExpectations(t)
 	}
 
+	_, err := NewServerHandlers(store, auth, crypt.PasswordParams{}, true).CreateUser(userdata.UserCredentials{Login: "Admin", Password: "password"})
+	assert.Equal(t, storage.ErrUnknown, err)
+
+	_, err = NewServerHandlers(store, auth, testPasswords, false).CreateUser(userdata.UserCredentials{Login: "Admin", Password: "password"})
+	assert.Equal(t, ErrLegacyLogin, err)
 }
 
 func TestServer_LoginUser(t *testing.T) {
 	store := storMocks.NewStorager(t)
 	auth := mocks.NewAuthenticator(t)
-	handlers := NewServerHandlers(store, auth)
+	handlers := NewServerHandlers(store, auth, testPasswords, true)
+
+	hash, err := crypt.HashPassword("password", testPasswords)
+	assert.NoError(t, err)
+	verifier, err := crypt.NewSRPVerifier(userdata.UserCredentials{Login: "Admin", Password: "password"}, testPasswords)
+	assert.NoError(t, err)
 
 	tc := []struct {
 		name string
@@ -88,10 +114,37 @@ func TestServer_LoginUser(t *testing.T) {
 		{
 			"Login user with creds",
 			func() {
-				store.On("LoginUser", userdata.UserCredentials{
-					Login:    "Admin",
-					Password: "b07e019b4662035489e1664afa63e28929a9df529f7a7fd6989e682e3cb695fd",
-				}).Return(userdata.UserID("userID"), nil).Once()
+				store.On("LoginUser", "Admin", mock.AnythingOfType("func(string) (string, error)")).
+					Run(verifyArg(t, verifier, false, true)).
+					Return(userdata.UserID("userID"), nil).Once()
+				auth.On("CreateToken", userdata.UserID("userID")).Return(userdata.AuthToken("token"), nil).Once()
+			},
+			userdata.UserCredentials{
+				Login:    "Admin",
+				Password: "password",
+			},
+			nil,
+		},
+		{
+			"Login user with Argon2id hash, it is replaced by verifier",
+			func() {
+				store.On("LoginUser", "Admin", mock.AnythingOfType("func(string) (string, error)")).
+					Run(verifyArg(t, hash, true, true)).
+					Return(userdata.UserID("userID"), nil).Once()
+				auth.On("CreateToken", userdata.UserID("userID")).Return(userdata.AuthToken("token"), nil).Once()
+			},
+			userdata.UserCredentials{
+				Login:    "Admin",
+				Password: "password",
+			},
+			nil,
+		},
+		{
+			"Login user with legacy hash, it is rehashed",
+			func() {
+				store.On("LoginUser", "Admin", mock.AnythingOfType("func(string) (string, error)")).
+					Run(verifyArg(t, "b07e019b4662035489e1664afa63e28929a9df529f7a7fd6989e682e3cb695fd", true, true)).
+					Return(userdata.UserID("userID"), nil).Once()
 				auth.On("CreateToken", userdata.UserID("userID")).Return(userdata.AuthToken("token"), nil).Once()
 			},
 			userdata.UserCredentials{
@@ -100,6 +153,19 @@ func TestServer_LoginUser(t *testing.T) {
 			},
 			nil,
 		},
+		{
+			"Login user with wrong password",
+			func() {
+				store.On("LoginUser", "Admin", mock.AnythingOfType("func(string) (string, error)")).
+					Run(verifyArg(t, hash, false, false)).
+					Return(userdata.UserID(""), storage.ErrWrongCredentials).Once()
+			},
+			userdata.UserCredentials{
+				Login:    "Admin",
+				Password: "wrong",
+			},
+			storage.ErrWrongCredentials,
+		},
 		{
 			"Login user with bad creds",
 			func() {},
@@ -107,7 +173,7 @@ func TestServer_LoginUser(t *testing.T) {
 				Login:    "",
 				Password: "",
 			},
-			ErrEmptyField,
+			emptyField("login"),
 		},
 	}
 
@@ -121,12 +187,186 @@ func TestServer_LoginUser(t *testing.T) {
 		auth.AssertExpectations(t)
 	}
 
+	_, err = NewServerHandlers(store, auth, testPasswords, false).LoginUser(userdata.UserCredentials{Login: "Admin", Password: "password"})
+	assert.Equal(t, ErrLegacyLogin, err)
+}
+
+func TestServer_CreateUserSRP(t *testing.T) {
+	store := storMocks.NewStorager(t)
+	auth := mocks.NewAuthenticator(t)
+	handlers := NewServerHandlers(store, auth, testPasswords, false)
+
+	verifier, err := crypt.NewSRPVerifier(userdata.UserCredentials{Login: "Admin", Password: "password"}, testPasswords)
+	assert.NoError(t, err)
+
+	costly, err := crypt.NewSRPVerifier(userdata.UserCredentials{Login: "Admin", Password: "password"}, crypt.PasswordParams{Memory: 64, Iterations: 2, Parallelism: 1})
+	assert.NoError(t, err)
+
+	tc := []struct {
+		name     string
+		mock     func()
+		login    string
+		verifier string
+		want     error
+	}{
+		{
+			"User with verifier",
+			func() {
+				store.On("CreateUser", userdata.UserCredentials{Login: "Admin", Password: verifier}).Return(nil).Once()
+				store.On("LoginUser", "Admin", mock.AnythingOfType("func(string) (string, error)")).
+					Run(verifyArg(t, verifier, false, true)).
+					Return(userdata.UserID("userID"), nil).Once()
+				auth.On("CreateToken", userdata.UserID("userID")).Return(userdata.AuthToken("token"), nil).Once()
+			},
+			"Admin",
+			verifier,
+			nil,
+		},
+		{
+			"User exists",
+			func() {
+				store.On("CreateUser", userdata.UserCredentials{Login: "Admin", Password: verifier}).Return(storage.ErrLoginExists).Once()
+			},
+			"Admin",
+			verifier,
+			storage.ErrLoginExists,
+		},
+		{
+			"User with password instead of verifier",
+			func() {},
+			"Admin",
+			"password",
+			&FieldError{Field: "verifier", Err: ErrInvalidVerifier},
+		},
+		{
+			"User with verifier more costly than server hashes",
+			func() {},
+			"Admin",
+			costly,
+			&FieldError{Field: "verifier", Err: ErrInvalidVerifier},
+		},
+		{
+			"User without login",
+			func() {},
+			"",
+			verifier,
+			emptyField("login"),
+		},
+	}
+
+	for _, test := range tc {
+		t.Log(test.name)
+		test.mock()
+		_, err := handlers.CreateUserSRP(test.login, test.verifier)
+		assert.Equal(t, test.want, err)
+
+		store.AssertExpectations(t)
+		auth.AssertExpectations(t)
+	}
+}
+
+func TestServer_LoginSRP(t *testing.T) {
+	store := storMocks.NewStorager(t)
+	auth := mocks.NewAuthenticator(t)
+	handlers := NewServerHandlers(store, auth, testPasswords, false)
+
+	credentials := userdata.UserCredentials{Login: "Admin", Password: "password"}
+	verifier, err := crypt.NewSRPVerifier(credentials, testPasswords)
+	assert.NoError(t, err)
+
+	// login makes SRP login with credentials, hash is saved one on start and on finish
+	login := func(credentials userdata.UserCredentials, hash string) (userdata.AuthToken, error) {
+		client, err := crypt.NewSRPClient()
+		assert.NoError(t, err)
+
+		sessionID, challenge, err := handlers.StartLoginSRP(credentials.Login, client.Public())
+		if err != nil {
+			return "", err
+		}
+
+		proof, err := client.Proof(credentials, challenge)
+		assert.NoError(t, err)
+
+		store.On("LoginUser", credentials.Login, mock.AnythingOfType("func(string) (string, error)")).
+			Return(func(_ string, verify func(string) (string, error)) (userdata.UserID, error) {
+				if _, err := verify(hash); err != nil {
+					return "", storage.ErrWrongCredentials
+				}
+
+				return "userID", nil
+			}).Once()
+
+		token, serverProof, err := handlers.FinishLoginSRP(sessionID, proof)
+		if err != nil {
+			return "", err
+		}
+
+		assert.NoError(t, client.VerifyServer(serverProof))
+
+		_, _, err = handlers.FinishLoginSRP(sessionID, proof)
+		assert.ErrorIs(t, err, storage.ErrWrongCredentials, "session is used once")
+
+		return token, nil
+	}
+
+	store.On("GetPasswordHash", "Admin").Return(verifier, nil).Once()
+	auth.On("CreateToken", userdata.UserID("userID")).Return(userdata.AuthToken("token"), nil).Once()
+	token, err := login(credentials, verifier)
+	assert.NoError(t, err)
+	assert.Equal(t, userdata.AuthToken("token"), token)
+
+	store.On("GetPasswordHash", "Admin").Return(verifier, nil).Once()
+	_, err = login(userdata.UserCredentials{Login: "Admin", Password: "wrong"}, verifier)
+	assert.ErrorIs(t, err, storage.ErrWrongCredentials)
+
+	// Verifier is changed since challenge
+	other, err := crypt.NewSRPVerifier(credentials, testPasswords)
+	assert.NoError(t, err)
+	store.On("GetPasswordHash", "Admin").Return(verifier, nil).Once()
+	_, err = login(credentials, other)
+	assert.ErrorIs(t, err, storage.ErrWrongCredentials)
+
+	// Unknown user and user without verifier get dummy challenge with the same salt
+	store.On("GetPasswordHash", "Bob").Return("", storage.ErrWrongCredentials).Twice()
+	_, first, err := handlers.StartLoginSRP("Bob", mustSRPPublic(t))
+	assert.NoError(t, err)
+	_, second, err := handlers.StartLoginSRP("Bob", mustSRPPublic(t))
+	assert.NoError(t, err)
+	assert.Equal(t, first.Salt, second.Salt)
+	assert.Equal(t, testPasswords, first.Params)
+
+	store.On("GetPasswordHash", "Bob").Return("", storage.ErrWrongCredentials).Once()
+	_, err = login(userdata.UserCredentials{Login: "Bob", Password: "password"}, "")
+	assert.ErrorIs(t, err, storage.ErrWrongCredentials)
+
+	store.On("GetPasswordHash", "Legacy").Return("b07e019b4662035489e1664afa63e28929a9df529f7a7fd6989e682e3cb695fd", nil).Once()
+	_, err = login(userdata.UserCredentials{Login: "Legacy", Password: "password"}, "b07e019b4662035489e1664afa63e28929a9df529f7a7fd6989e682e3cb695fd")
+	assert.ErrorIs(t, err, storage.ErrWrongCredentials)
+
+	store.On("GetPasswordHash", "Admin").Return("", storage.ErrUnknown).Once()
+	_, _, err = handlers.StartLoginSRP("Admin", mustSRPPublic(t))
+	assert.ErrorIs(t, err, storage.ErrUnknown)
+
+	store.On("GetPasswordHash", "Admin").Return(verifier, nil).Once()
+	_, _, err = handlers.StartLoginSRP("Admin", make([]byte, 256))
+	assert.ErrorIs(t, err, storage.ErrWrongCredentials)
+
+	_, _, err = handlers.FinishLoginSRP("unknown", nil)
+	assert.ErrorIs(t, err, storage.ErrWrongCredentials)
+}
+
+// mustSRPPublic returns public value of new SRP client.
+func mustSRPPublic(t *testing.T) []byte {
+	client, err := crypt.NewSRPClient()
+	assert.NoError(t, err)
+
+	return client.Public()
 }
 
 func TestServer_GetRecordsInfo(t *testing.T) {
 	store := storMocks.NewStorager(t)
 	auth := mocks.NewAuthenticator(t)
-	handlers := NewServerHandlers(store, auth)
+	handlers := NewServerHandlers(store, auth, testPasswords, true)
 
 	tc := []struct {
 		name  string
@@ -136,14 +376,14 @@ func TestServer_GetRecordsInfo(t *testing.T) {
 		{
 			"Get all records",
 			func() {
-				store.On("GetRecordsInfo", mock.AnythingOfType("*context.valueCtx")).Return([]userdata.Record{}, nil).Once()
+				store.On("GetRecordsInfo", mock.AnythingOfType("*context.valueCtx"), userdata.UserID("userID")).Return([]userdata.Record{}, nil).Once()
 
 			},
 			func() {
 				md := metadata.Pairs("authToken", string("token"))
 				ctx := metadata.NewIncomingContext(context.Background(), md)
 
-				recInfo, err := handlers.GetRecordsInfo(ctx)
+				recInfo, err := handlers.GetRecordsInfo(ctx, "userID")
 				assert.NoError(t, err)
 				assert.Equal(t, []userdata.Record{}, recInfo)
 			},
@@ -164,7 +404,7 @@ func TestServer_GetRecordsInfo(t *testing.T) {
 func TestServer_GetRecord(t *testing.T) {
 	store := storMocks.NewStorager(t)
 	auth := mocks.NewAuthenticator(t)
-	handlers := NewServerHandlers(store, auth)
+	handlers := NewServerHandlers(store, auth, testPasswords, true)
 
 	tc := []struct {
 		name  string
@@ -174,12 +414,12 @@ func TestServer_GetRecord(t *testing.T) {
 		{
 			"Get record",
 			func() {
-				store.On("GetRecord", mock.AnythingOfType("*context.valueCtx"), "recordID").Return(userdata.Record{}, nil).Once()
+				store.On("GetRecord", mock.AnythingOfType("*context.valueCtx"), userdata.UserID("userID"), "recordID").Return(userdata.Record{}, nil).Once()
 			},
 			func() {
 				md := metadata.Pairs("authToken", string("token"))
 				ctx := metadata.NewIncomingContext(context.Background(), md)
-				_, err := handlers.GetRecord(ctx, "recordID")
+				_, err := handlers.GetRecord(ctx, "userID", "recordID")
 				assert.NoError(t, err)
 			},
 		},
@@ -199,7 +439,7 @@ func TestServer_GetRecord(t *testing.T) {
 func TestServer_CreateRecord(t *testing.T) {
 	store := storMocks.NewStorager(t)
 	auth := mocks.NewAuthenticator(t)
-	handlers := NewServerHandlers(store, auth)
+	handlers := NewServerHandlers(store, auth, testPasswords, true)
 
 	tc := []struct {
 		name  string
@@ -209,12 +449,41 @@ func TestServer_CreateRecord(t *testing.T) {
 		{
 			"Create record",
 			func() {
-				store.On("CreateRecord", mock.AnythingOfType("*context.valueCtx"), mock.AnythingOfType("userdata.Record")).Return("", nil).Once()
+				store.On("CreateRecord", mock.AnythingOfType("*context.valueCtx"), userdata.UserID("userID"), mock.AnythingOfType("userdata.Record")).Return(userdata.RecordMeta{ID: "1", Version: 1}, nil).Once()
 			},
 			func() {
 				md := metadata.Pairs("authToken", string("token"))
 				ctx := metadata.NewIncomingContext(context.Background(), md)
-				err := handlers.CreateRecord(ctx, userdata.Record{})
+				meta, err := handlers.CreateRecord(ctx, "userID", userdata.Record{})
+				assert.NoError(t, err)
+				assert.Equal(t, "1", meta.ID)
+			},
+		},
+		{
+			"Create record with invalid ID",
+			func() {},
+			func() {
+				_, err := handlers.CreateRecord(context.Background(), "userID", userdata.Record{ID: "1"})
+				assert.Equal(t, &FieldError{Field: "id", Err: ErrInvalidRecordID}, err)
+			},
+		},
+		{
+			"Create record with key fingerprint",
+			func() {
+				store.On("CreateRecord", mock.Anything, userdata.UserID("userID"), userdata.Record{KeyHint: "fp:0123456789abcdef:home"}).Return(userdata.RecordMeta{ID: "1", Version: 1}, nil).Once()
+			},
+			func() {
+				_, err := handlers.CreateRecord(context.Background(), "userID", userdata.Record{KeyHint: "fp:0123456789abcdef:home"})
+				assert.NoError(t, err)
+			},
+		},
+		{
+			"Create record with masked AES key of older client, it isn't saved",
+			func() {
+				store.On("CreateRecord", mock.Anything, userdata.UserID("userID"), userdata.Record{}).Return(userdata.RecordMeta{ID: "1", Version: 1}, nil).Once()
+			},
+			func() {
+				_, err := handlers.CreateRecord(context.Background(), "userID", userdata.Record{KeyHint: "mast*****"})
 				assert.NoError(t, err)
 			},
 		},
@@ -234,7 +503,7 @@ func TestServer_CreateRecord(t *testing.T) {
 func TestServer_DeleteRecord(t *testing.T) {
 	store := storMocks.NewStorager(t)
 	auth := mocks.NewAuthenticator(t)
-	handlers := NewServerHandlers(store, auth)
+	handlers := NewServerHandlers(store, auth, testPasswords, true)
 
 	tc := []struct {
 		name  string
@@ -244,12 +513,12 @@ func TestServer_DeleteRecord(t *testing.T) {
 		{
 			"Delete record",
 			func() {
-				store.On("DeleteRecord", mock.AnythingOfType("*context.valueCtx"), "recordID").Return(nil).Once()
+				store.On("DeleteRecord", mock.AnythingOfType("*context.valueCtx"), userdata.UserID("userID"), "recordID").Return(nil).Once()
 			},
 			func() {
 				md := metadata.Pairs("authToken", string("token"))
 				ctx := metadata.NewIncomingContext(context.Background(), md)
-				err := handlers.DeleteRecord(ctx, "recordID")
+				err := handlers.DeleteRecord(ctx, "userID", "recordID")
 				assert.NoError(t, err)
 			},
 		},
@@ -265,3 +534,121 @@ func TestServer_DeleteRecord(t *testing.T) {
 	}
 
 }
+
+func TestServer_BatchRecords(t *testing.T) {
+	const recordID = "6f1c1f4e-3f7a-4b8e-9a53-0c2f5d8e1a01"
+
+	store := storMocks.NewStorager(t)
+	auth := mocks.NewAuthenticator(t)
+	handlers := NewServerHandlers(store, auth, testPasswords, true)
+
+	tc := []struct {
+		name  string
+		mock  func()
+		valid func()
+	}{
+		{
+			"Create records",
+			func() {
+				store.On("CreateRecords", context.Background(), userdata.UserID("userID"), []userdata.Record{{}}).
+					Return([]userdata.RecordResult{{ID: "1"}}, nil).Once()
+			},
+			func() {
+				results, err := handlers.CreateRecords(context.Background(), "userID", []userdata.Record{{}})
+				assert.NoError(t, err)
+				assert.Equal(t, []userdata.RecordResult{{ID: "1"}}, results)
+			},
+		},
+		{
+			"Create records with invalid ID",
+			func() {},
+			func() {
+				_, err := handlers.CreateRecords(context.Background(), "userID", []userdata.Record{{}, {ID: "bad"}})
+				assert.ErrorIs(t, err, ErrInvalidRecordID)
+			},
+		},
+		{
+			"Get and delete records",
+			func() {
+				store.On("GetRecords", context.Background(), userdata.UserID("userID"), []string{"1"}).
+					Return([]userdata.RecordResult{{ID: "1"}}, nil).Once()
+				store.On("DeleteRecords", context.Background(), userdata.UserID("userID"), []string{"1"}).
+					Return([]userdata.RecordResult{{ID: "1"}}, nil).Once()
+			},
+			func() {
+				_, err := handlers.GetRecords(context.Background(), "userID", []string{"1"})
+				assert.NoError(t, err)
+				_, err = handlers.DeleteRecords(context.Background(), "userID", []string{"1"})
+				assert.NoError(t, err)
+			},
+		},
+		{
+			"Replace records, hint which isn't fingerprint is not saved",
+			func() {
+				store.On("ReplaceRecords", context.Background(), userdata.UserID("userID"), []userdata.Record{{ID: recordID, Version: 2}}).
+					Return([]userdata.RecordResult{{ID: recordID}}, nil).Once()
+			},
+			func() {
+				results, err := handlers.ReplaceRecords(context.Background(), "userID", []userdata.Record{{ID: recordID, KeyHint: "abc***", Version: 2}})
+				assert.NoError(t, err)
+				assert.Equal(t, []userdata.RecordResult{{ID: recordID}}, results)
+			},
+		},
+		{
+			"Replace records without ID",
+			func() {},
+			func() {
+				_, err := handlers.ReplaceRecords(context.Background(), "userID", []userdata.Record{{}})
+				assert.Equal(t, &FieldError{Field: "records.id", Err: ErrEmptyField}, err)
+
+				_, err = handlers.ReplaceRecords(context.Background(), "userID", []userdata.Record{{ID: "bad"}})
+				assert.ErrorIs(t, err, ErrInvalidRecordID)
+			},
+		},
+		{
+			"Rewrap records, data key is required",
+			func() {
+				store.On("RewrapRecords", context.Background(), userdata.UserID("userID"), []userdata.Record{{ID: recordID, DataKey: []byte("key"), Version: 2}}).
+					Return([]userdata.RecordResult{{ID: recordID}}, nil).Once()
+			},
+			func() {
+				results, err := handlers.RewrapRecords(context.Background(), "userID", []userdata.Record{{ID: recordID, KeyHint: "abc***", DataKey: []byte("key"), Version: 2}})
+				assert.NoError(t, err)
+				assert.Equal(t, []userdata.RecordResult{{ID: recordID}}, results)
+
+				_, err = handlers.RewrapRecords(context.Background(), "userID", []userdata.Record{{ID: recordID}})
+				assert.Equal(t, &FieldError{Field: "records.data_key", Err: ErrEmptyField}, err)
+			},
+		},
+		{
+			"Data key is too large",
+			func() {},
+			func() {
+				_, err := handlers.CreateRecord(context.Background(), "userID", userdata.Record{DataKey: make([]byte, maxDataKeySize+1)})
+				assert.Equal(t, &FieldError{Field: "data_key", Err: ErrInvalidDataKey}, err)
+
+				_, err = handlers.RewrapRecords(context.Background(), "userID", []userdata.Record{{ID: recordID, DataKey: make([]byte, maxDataKeySize+1)}})
+				assert.ErrorIs(t, err, ErrInvalidDataKey)
+			},
+		},
+		{
+			"Too large batch",
+			func() {},
+			func() {
+				_, err := handlers.CreateRecords(context.Background(), "userID", make([]userdata.Record, maxBatchSize+1))
+				assert.Equal(t, &FieldError{Field: "records", Err: ErrBatchTooLarge}, err)
+
+				_, err = handlers.DeleteRecords(context.Background(), "userID", make([]string, maxBatchSize+1))
+				assert.ErrorIs(t, err, ErrBatchTooLarge)
+			},
+		},
+	}
+
+	for _, test := range tc {
+		t.Log(test.name)
+		test.mock()
+		test.valid()
+
+		store.AssertExpectations(t)
+	}
+}
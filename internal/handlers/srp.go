@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/impr0ver/gophKeeper/internal/crypt"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// SRP login sessions limits.
+const (
+	srpSessionTTL  = time.Minute
+	maxSRPSessions = 10000
+	srpDummySalt   = 16
+)
+
+// srpSession is started SRP login, it's waiting for proof of client.
+type srpSession struct {
+	login   string
+	hash    string
+	server  *crypt.SRPServer
+	expires time.Time
+}
+
+// srpSessions keeps started SRP logins in memory, each session may be finished only once.
+type srpSessions struct {
+	mu       sync.Mutex
+	sessions map[string]srpSession
+	// dummyKey makes salts of unknown logins, they are stable, so logins can't be checked by salt changes.
+	dummyKey []byte
+}
+
+// newSRPSessions returns empty sessions with random dummy key.
+func newSRPSessions() *srpSessions {
+	key, err := crypt.GenerateRand(sha256.Size)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return &srpSessions{
+		sessions: make(map[string]srpSession),
+		dummyKey: key,
+	}
+}
+
+// add saves session and returns its ID, expired sessions are removed.
+func (s *srpSessions) add(session srpSession) (string, error) {
+	id, err := crypt.GenerateRand(16)
+	if err != nil {
+		return "", err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for sessionID, saved := range s.sessions {
+		if now.After(saved.expires) {
+			delete(s.sessions, sessionID)
+		}
+	}
+
+	if len(s.sessions) >= maxSRPSessions {
+		return "", &RetryError{Delay: srpSessionTTL, Err: ErrRateLimited}
+	}
+
+	session.expires = now.Add(srpSessionTTL)
+	s.sessions[hex.EncodeToString(id)] = session
+
+	return hex.EncodeToString(id), nil
+}
+
+// take removes session and returns it, if it isn't expired.
+func (s *srpSessions) take(id string) (srpSession, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[id]
+	delete(s.sessions, id)
+
+	return session, ok && time.Now().Before(session.expires)
+}
+
+// dummySalt returns salt of verifier for unknown login.
+func (s *srpSessions) dummySalt(login string) []byte {
+	mac := hmac.New(sha256.New, s.dummyKey)
+	mac.Write([]byte(login))
+
+	return mac.Sum(nil)[:srpDummySalt]
+}
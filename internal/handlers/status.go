@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/impr0ver/gophKeeper/internal/storage"
+
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// errorDomain is domain of ErrorInfo details sent by server.
+const errorDomain = "gophkeeper"
+
+// reasonInternal is reason of errors which are hidden from client.
+const reasonInternal = "INTERNAL"
+
+// errorModel maps known errors to gRPC codes and ErrorInfo reasons, it's used on both sides.
+var errorModel = []struct {
+	err    error
+	code   codes.Code
+	reason string
+}{
+	{ErrEmptyField, codes.InvalidArgument, "EMPTY_FIELD"},
+	{ErrReservedMetadata, codes.InvalidArgument, "RESERVED_METADATA"},
+	{ErrInvalidVerifier, codes.InvalidArgument, "INVALID_VERIFIER"},
+	{ErrLegacyLogin, codes.FailedPrecondition, "LEGACY_LOGIN_DISABLED"},
+	{ErrPermissionDenied, codes.PermissionDenied, "ADMIN_REQUIRED"},
+	{ErrRateLimited, codes.ResourceExhausted, "RATE_LIMITED"},
+	{ErrBatchTooLarge, codes.InvalidArgument, "BATCH_TOO_LARGE"},
+	{ErrInvalidRecordID, codes.InvalidArgument, "INVALID_RECORD_ID"},
+	{ErrInvalidDataKey, codes.InvalidArgument, "INVALID_DATA_KEY"},
+	{ErrIdempotencyKeyLong, codes.InvalidArgument, "IDEMPOTENCY_KEY_TOO_LONG"},
+	{ErrIdempotencyKeyReused, codes.InvalidArgument, "IDEMPOTENCY_KEY_REUSED"},
+	{ErrRequestInProgress, codes.Aborted, "REQUEST_IN_PROGRESS"},
+	{storage.ErrLoginExists, codes.AlreadyExists, "LOGIN_EXISTS"},
+	{storage.ErrWrongCredentials, codes.Unauthenticated, "WRONG_CREDENTIALS"},
+	{storage.ErrUnauthenticated, codes.Unauthenticated, "UNAUTHENTICATED"},
+	{storage.ErrUserDisabled, codes.PermissionDenied, "USER_DISABLED"},
+	{storage.ErrUserNotFound, codes.NotFound, "USER_NOT_FOUND"},
+	{storage.ErrNotFound, codes.NotFound, "RECORD_NOT_FOUND"},
+	{storage.ErrQuotaExceeded, codes.ResourceExhausted, "QUOTA_EXCEEDED"},
+	{storage.ErrDataLoss, codes.DataLoss, "DATA_LOSS"},
+	{storage.ErrVersionConflict, codes.FailedPrecondition, "VERSION_CONFLICT"},
+	{storage.ErrAlreadyExists, codes.AlreadyExists, "RECORD_EXISTS"},
+	{storage.ErrUnknown, codes.Internal, reasonInternal},
+	{context.Canceled, codes.Canceled, "CANCELED"},
+	{context.DeadlineExceeded, codes.DeadlineExceeded, "DEADLINE_EXCEEDED"},
+}
+
+// codeErrors maps codes to errors on client side, when status has no ErrorInfo.
+var codeErrors = map[codes.Code]error{
+	codes.InvalidArgument:   ErrEmptyField,
+	codes.Unauthenticated:   storage.ErrUnauthenticated,
+	codes.PermissionDenied:  ErrPermissionDenied,
+	codes.NotFound:          storage.ErrNotFound,
+	codes.AlreadyExists:     storage.ErrLoginExists,
+	codes.ResourceExhausted: ErrRateLimited,
+	codes.Unavailable:       ErrUnavailable,
+	codes.Aborted:           ErrRequestInProgress,
+	codes.DataLoss:          storage.ErrDataLoss,
+	codes.Canceled:          context.Canceled,
+	codes.DeadlineExceeded:  context.DeadlineExceeded,
+}
+
+// toStatus converts error to gRPC status with error details, unknown errors are hidden from client.
+func toStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	for _, known := range errorModel {
+		if errors.Is(err, known.err) {
+			return newStatus(known.code, known.err.Error(), errorDetails(err, known.reason)...)
+		}
+	}
+
+	log.Warnf("%s :: %v", "internal error", err)
+
+	return newStatus(codes.Internal, "internal server error.", errorDetails(err, reasonInternal)...)
+}
+
+// errorReason returns reason and message of batch item error, unknown errors are hidden from client.
+func errorReason(err error) (string, string) {
+	if err == nil {
+		return "", ""
+	}
+
+	for _, known := range errorModel {
+		if errors.Is(err, known.err) {
+			return known.reason, known.err.Error()
+		}
+	}
+
+	log.Warnf("%s :: %v", "internal error", err)
+
+	return reasonInternal, "internal server error."
+}
+
+// errorDetails returns ErrorInfo with reason and, if error has them, bad request field and retry delay.
+func errorDetails(err error, reason string) []protoadapt.MessageV1 {
+	info := &errdetails.ErrorInfo{Reason: reason, Domain: errorDomain}
+	details := []protoadapt.MessageV1{info}
+
+	var fieldErr *FieldError
+	if errors.As(err, &fieldErr) {
+		info.Metadata = map[string]string{"field": fieldErr.Field}
+		details = append(details, &errdetails.BadRequest{
+			FieldViolations: []*errdetails.BadRequest_FieldViolation{{
+				Field:       fieldErr.Field,
+				Description: fieldErr.Err.Error(),
+			}},
+		})
+	}
+
+	var retryErr *RetryError
+	if errors.As(err, &retryErr) {
+		details = append(details, &errdetails.RetryInfo{RetryDelay: durationpb.New(retryErr.Delay)})
+	}
+
+	return details
+}
+
+// newStatus returns status error with details, details are dropped if they can't be added.
+func newStatus(code codes.Code, message string, details ...protoadapt.MessageV1) error {
+	st, err := status.New(code, message).WithDetails(details...)
+	if err != nil {
+		log.Warnf("%s :: %v", "add error details error", err)
+
+		return status.Error(code, message)
+	}
+
+	return st.Err()
+}
+
+// FieldViolation is bad request field from server error details.
+type FieldViolation struct {
+	Field       string
+	Description string
+}
+
+// StatusError is error got from server, it unwraps to known error, so errors.Is works on client side.
+type StatusError struct {
+	Err        error
+	Code       codes.Code
+	Reason     string
+	Message    string
+	Fields     []FieldViolation
+	RetryDelay time.Duration
+}
+
+// Error returns message of server.
+func (e *StatusError) Error() string {
+	return e.Message
+}
+
+// Unwrap returns known error.
+func (e *StatusError) Unwrap() error {
+	return e.Err
+}
+
+// fromStatus converts gRPC status from server to StatusError, known error is found by ErrorInfo reason or by code.
+func fromStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+
+	statusErr := &StatusError{Code: st.Code(), Message: st.Message()}
+
+	for _, detail := range st.Details() {
+		switch detail := detail.(type) {
+		case *errdetails.ErrorInfo:
+			if detail.Domain == errorDomain {
+				statusErr.Reason = detail.Reason
+			}
+		case *errdetails.BadRequest:
+			for _, violation := range detail.FieldViolations {
+				statusErr.Fields = append(statusErr.Fields, FieldViolation{
+					Field:       violation.Field,
+					Description: violation.Description,
+				})
+			}
+		case *errdetails.RetryInfo:
+			statusErr.RetryDelay = detail.RetryDelay.AsDuration()
+		}
+	}
+
+	statusErr.Err = knownError(statusErr.Code, statusErr.Reason)
+
+	return statusErr
+}
+
+// fromReason converts batch item error from server to StatusError, empty reason means no error.
+func fromReason(reason, message string) error {
+	if reason == "" {
+		return nil
+	}
+
+	statusErr := &StatusError{Code: codes.Unknown, Reason: reason, Message: message}
+	for _, known := range errorModel {
+		if known.reason == reason {
+			statusErr.Code = known.code
+			break
+		}
+	}
+	statusErr.Err = knownError(statusErr.Code, reason)
+
+	return statusErr
+}
+
+// knownError finds known error by reason, then by code, other errors are unknown.
+func knownError(code codes.Code, reason string) error {
+	for _, known := range errorModel {
+		if reason != "" && known.reason == reason {
+			return known.err
+		}
+	}
+
+	if err, ok := codeErrors[code]; ok {
+		return err
+	}
+
+	return storage.ErrUnknown
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/impr0ver/gophKeeper/internal/storage"
+	"github.com/impr0ver/gophKeeper/internal/userdata"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestToStatus(t *testing.T) {
+	assert.NoError(t, toStatus(nil))
+	assert.Equal(t, codes.PermissionDenied, status.Code(toStatus(storage.ErrUserDisabled)))
+	assert.Equal(t, codes.AlreadyExists, status.Code(toStatus(storage.ErrLoginExists)))
+	assert.ErrorIs(t, fromStatus(toStatus(storage.ErrAlreadyExists)), storage.ErrAlreadyExists)
+	assert.ErrorIs(t, fromReason(errorReason(storage.ErrAlreadyExists)), storage.ErrAlreadyExists)
+	assert.Equal(t, codes.DataLoss, status.Code(toStatus(storage.ErrDataLoss)))
+	assert.ErrorIs(t, fromStatus(toStatus(storage.ErrDataLoss)), storage.ErrDataLoss)
+	assert.ErrorIs(t, fromStatus(toStatus(checkRecordID("id", "1"))), ErrInvalidRecordID)
+	assert.Equal(t, codes.FailedPrecondition, status.Code(toStatus(ErrLegacyLogin)))
+	assert.ErrorIs(t, fromStatus(toStatus(ErrLegacyLogin)), ErrLegacyLogin)
+
+	original := status.Error(codes.Aborted, "aborted")
+	assert.Equal(t, original, toStatus(original))
+
+	st := status.Convert(toStatus(emptyField("login")))
+	assert.Equal(t, codes.InvalidArgument, st.Code())
+	assert.Len(t, st.Details(), 2)
+	assert.Equal(t, "EMPTY_FIELD", st.Details()[0].(*errdetails.ErrorInfo).Reason)
+	assert.Equal(t, "login", st.Details()[1].(*errdetails.BadRequest).FieldViolations[0].Field)
+
+	st = status.Convert(toStatus(errors.New("connection refused")))
+	assert.Equal(t, codes.Internal, st.Code())
+	assert.Equal(t, "internal server error.", st.Message())
+}
+
+func TestFromStatus(t *testing.T) {
+	tc := []struct {
+		name  string
+		err   error
+		valid func(err error)
+	}{
+		{
+			"No error",
+			nil,
+			func(err error) {
+				assert.NoError(t, err)
+			},
+		},
+		{
+			"Known error with field",
+			toStatus(emptyField("password")),
+			func(err error) {
+				assert.ErrorIs(t, err, ErrEmptyField)
+
+				var statusErr *StatusError
+				assert.True(t, errors.As(err, &statusErr))
+				assert.Equal(t, "EMPTY_FIELD", statusErr.Reason)
+				assert.Equal(t, []FieldViolation{{Field: "password", Description: ErrEmptyField.Error()}}, statusErr.Fields)
+			},
+		},
+		{
+			"Errors with same code are found by reason",
+			toStatus(storage.ErrWrongCredentials),
+			func(err error) {
+				assert.ErrorIs(t, err, storage.ErrWrongCredentials)
+				assert.False(t, errors.Is(err, storage.ErrUnauthenticated))
+			},
+		},
+		{
+			"Retry delay",
+			toStatus(&RetryError{Delay: 2 * time.Second, Err: ErrRateLimited}),
+			func(err error) {
+				assert.ErrorIs(t, err, ErrRateLimited)
+
+				var statusErr *StatusError
+				assert.True(t, errors.As(err, &statusErr))
+				assert.Equal(t, 2*time.Second, statusErr.RetryDelay)
+			},
+		},
+		{
+			"Status without details is found by code",
+			status.Error(codes.NotFound, "not found"),
+			func(err error) {
+				assert.ErrorIs(t, err, storage.ErrNotFound)
+			},
+		},
+		{
+			"Unexpected code is unknown error",
+			status.Error(codes.OutOfRange, "out of range"),
+			func(err error) {
+				assert.ErrorIs(t, err, storage.ErrUnknown)
+			},
+		},
+	}
+
+	for _, test := range tc {
+		t.Log(test.name)
+		test.valid(fromStatus(test.err))
+	}
+}
+
+func TestClientConn_Unavailable(t *testing.T) {
+	client := newClientConn("127.0.0.1:1", "../../cmd/cert/ca-cert.pem")
+
+	_, err := client.Register(userdata.UserCredentials{Login: "login", Password: "password"})
+	assert.ErrorIs(t, err, ErrUnavailable)
+
+	_, err = client.GetRecordsInfo("token")
+	assert.ErrorIs(t, err, ErrUnavailable)
+
+	_, err = client.GetRecord("token", "recordID")
+	assert.ErrorIs(t, err, ErrUnavailable)
+
+	_, err = client.CreateRecord("token", userdata.Record{})
+	assert.ErrorIs(t, err, ErrUnavailable)
+
+}
@@ -3,24 +3,39 @@ package jwtauth
 import (
 	"github.com/impr0ver/gophKeeper/internal/userdata"
 	"github.com/impr0ver/gophKeeper/internal/storage"
+	"sync/atomic"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	log "github.com/sirupsen/logrus"
 )
 
+// SessionChecker checks that user session is still valid (user is not disabled or logged out).
+type SessionChecker interface {
+	CheckSession(userID userdata.UserID, issuedAt time.Time) error
+}
+
 // authenticatorJWT is authenticator which uses JWT.
 type authenticatorJWT struct {
 	secretKey      []byte
-	expirationTime time.Duration
+	expirationTime atomic.Int64
+	sessions       SessionChecker
 }
 
-// NewAuthenticatorJWT gets new authenticatorJWT.
-func NewAuthenticatorJWT(secretKey []byte, expirationTime time.Duration) *authenticatorJWT {
-	return &authenticatorJWT{
-		secretKey:      secretKey,
-		expirationTime: expirationTime,
+// NewAuthenticatorJWT gets new authenticatorJWT. Sessions may be nil, then only token signature and expiration are checked.
+func NewAuthenticatorJWT(secretKey []byte, expirationTime time.Duration, sessions SessionChecker) *authenticatorJWT {
+	a := &authenticatorJWT{
+		secretKey: secretKey,
+		sessions:  sessions,
 	}
+	a.SetExpirationTime(expirationTime)
+
+	return a
+}
+
+// SetExpirationTime changes lifetime of new tokens, already issued tokens are not affected.
+func (a *authenticatorJWT) SetExpirationTime(expirationTime time.Duration) {
+	a.expirationTime.Store(int64(expirationTime))
 }
 
 // CreateToken implementation of Authenticator interface. Creates token, which stores userID.
@@ -30,7 +45,9 @@ func (a *authenticatorJWT) CreateToken(userID userdata.UserID) (userdata.AuthTok
 	claims := token.Claims.(jwt.MapClaims)
 	
 	// Time now + expiration time from cfg config
-	claims["exp"] = time.Now().Add(a.expirationTime).Unix()
+	now := time.Now()
+	claims["iat"] = now.Unix()
+	claims["exp"] = now.Add(time.Duration(a.expirationTime.Load())).Unix()
 	claims["userID"] = userID
 
 	tokenString, err := token.SignedString(a.secretKey)
@@ -65,5 +82,18 @@ func (a *authenticatorJWT) ValidateToken(token userdata.AuthToken) (userdata.Use
 		return "", storage.ErrUnauthenticated
 	}
 
+	if a.sessions != nil {
+		issuedAt, err := claims.GetIssuedAt()
+		if err != nil || issuedAt == nil {
+			return "", storage.ErrUnauthenticated
+		}
+
+		if err := a.sessions.CheckSession(userdata.UserID(userID), issuedAt.Time); err != nil {
+			log.Warning(err)
+
+			return "", err
+		}
+	}
+
 	return userdata.UserID(userID), nil
 }
@@ -4,18 +4,19 @@ import (
 	"testing"
 	"time"
 
+	"github.com/impr0ver/gophKeeper/internal/storage"
 	"github.com/impr0ver/gophKeeper/internal/userdata"
 
 	"github.com/stretchr/testify/assert"
 )
 
 func TestNewAuthenticatorJWT(t *testing.T) {
-	auth := NewAuthenticatorJWT([]byte("mySuperSecretKey"), time.Duration(1 * time.Hour))
+	auth := NewAuthenticatorJWT([]byte("mySuperSecretKey"), time.Duration(1 * time.Hour), nil)
 	assert.NotEmpty(t, auth)
 }
 
 func TestAuthenticatorJWT(t *testing.T) {
-	auth := NewAuthenticatorJWT([]byte("mySuperSecretKey"), time.Duration(1 * time.Hour))
+	auth := NewAuthenticatorJWT([]byte("mySuperSecretKey"), time.Duration(1 * time.Hour), nil)
 
 	userID := userdata.UserID("ID7777")
 
@@ -26,3 +27,40 @@ func TestAuthenticatorJWT(t *testing.T) {
 	assert.NoError(t, errValidate)
 	assert.Equal(t, userID, id)
 }
+
+type sessionsStub struct {
+	err      error
+	issuedAt time.Time
+}
+
+func (s *sessionsStub) CheckSession(_ userdata.UserID, issuedAt time.Time) error {
+	s.issuedAt = issuedAt
+	return s.err
+}
+
+func TestAuthenticatorJWT_Sessions(t *testing.T) {
+	sessions := &sessionsStub{}
+	auth := NewAuthenticatorJWT([]byte("mySuperSecretKey"), time.Duration(1*time.Hour), sessions)
+
+	token, err := auth.CreateToken(userdata.UserID("ID7777"))
+	assert.NoError(t, err)
+
+	_, err = auth.ValidateToken(token)
+	assert.NoError(t, err)
+	assert.WithinDuration(t, time.Now(), sessions.issuedAt, 2*time.Second)
+
+	sessions.err = storage.ErrUserDisabled
+	_, err = auth.ValidateToken(token)
+	assert.Equal(t, storage.ErrUserDisabled, err)
+}
+
+func TestAuthenticatorJWT_SetExpirationTime(t *testing.T) {
+	auth := NewAuthenticatorJWT([]byte("mySuperSecretKey"), time.Duration(1*time.Hour), nil)
+	auth.SetExpirationTime(-time.Minute)
+
+	token, err := auth.CreateToken("ID7777")
+	assert.NoError(t, err)
+
+	_, err = auth.ValidateToken(token)
+	assert.Equal(t, storage.ErrUnauthenticated, err)
+}
@@ -39,3 +39,15 @@ func NewLogrusLogger() {
 	// Log level.
 	log.SetLevel(log.InfoLevel)
 }
+
+// SetLogLevel sets logrus log level by name.
+func SetLogLevel(level string) error {
+	lvl, err := log.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+
+	log.SetLevel(lvl)
+
+	return nil
+}
@@ -29,3 +29,13 @@ func TestNewSugarLogger(t *testing.T){
 	sLogger := NewSugarLogger()
 	assert.NotEmpty(t, sLogger)
 }
+
+func TestSetLogLevel(t *testing.T) {
+	assert.NoError(t, SetLogLevel("warn"))
+	assert.Equal(t, log.WarnLevel, log.GetLevel())
+
+	assert.Error(t, SetLogLevel("loud"))
+	assert.Equal(t, log.WarnLevel, log.GetLevel())
+
+	log.SetLevel(log.InfoLevel)
+}
@@ -26,6 +26,15 @@ const (
 	Gokeeper_GetRecordsInfo_FullMethodName = "/rpc.Gokeeper/GetRecordsInfo"
 	Gokeeper_CreateRecord_FullMethodName   = "/rpc.Gokeeper/CreateRecord"
 	Gokeeper_DeleteRecord_FullMethodName   = "/rpc.Gokeeper/DeleteRecord"
+	Gokeeper_CreateRecords_FullMethodName  = "/rpc.Gokeeper/CreateRecords"
+	Gokeeper_GetRecords_FullMethodName     = "/rpc.Gokeeper/GetRecords"
+	Gokeeper_DeleteRecords_FullMethodName  = "/rpc.Gokeeper/DeleteRecords"
+	Gokeeper_ReplaceRecords_FullMethodName = "/rpc.Gokeeper/ReplaceRecords"
+	Gokeeper_RewrapRecords_FullMethodName  = "/rpc.Gokeeper/RewrapRecords"
+	Gokeeper_GetKeySalt_FullMethodName     = "/rpc.Gokeeper/GetKeySalt"
+	Gokeeper_RegisterSRP_FullMethodName    = "/rpc.Gokeeper/RegisterSRP"
+	Gokeeper_StartLoginSRP_FullMethodName  = "/rpc.Gokeeper/StartLoginSRP"
+	Gokeeper_FinishLoginSRP_FullMethodName = "/rpc.Gokeeper/FinishLoginSRP"
 )
 
 // GokeeperClient is the client API for Gokeeper service.
@@ -36,8 +45,19 @@ type GokeeperClient interface {
 	Register(ctx context.Context, in *UserCreds, opts ...grpc.CallOption) (*Token, error)
 	GetRecord(ctx context.Context, in *RecordID, opts ...grpc.CallOption) (*Record, error)
 	GetRecordsInfo(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*RecordsList, error)
-	CreateRecord(ctx context.Context, in *Record, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	CreateRecord(ctx context.Context, in *Record, opts ...grpc.CallOption) (*RecordMeta, error)
 	DeleteRecord(ctx context.Context, in *RecordID, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	CreateRecords(ctx context.Context, in *RecordsList, opts ...grpc.CallOption) (*RecordResults, error)
+	GetRecords(ctx context.Context, in *RecordIDs, opts ...grpc.CallOption) (*RecordResults, error)
+	DeleteRecords(ctx context.Context, in *RecordIDs, opts ...grpc.CallOption) (*RecordResults, error)
+	// ReplaceRecords replaces data and key hints of records with versions, batch is replaced entirely or not at all.
+	ReplaceRecords(ctx context.Context, in *RecordsList, opts ...grpc.CallOption) (*RecordResults, error)
+	// RewrapRecords replaces key hints and data keys of records with versions, data isn't sent.
+	RewrapRecords(ctx context.Context, in *RecordsList, opts ...grpc.CallOption) (*RecordResults, error)
+	GetKeySalt(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*KeySalt, error)
+	RegisterSRP(ctx context.Context, in *SRPRegistration, opts ...grpc.CallOption) (*Token, error)
+	StartLoginSRP(ctx context.Context, in *SRPStart, opts ...grpc.CallOption) (*SRPChallenge, error)
+	FinishLoginSRP(ctx context.Context, in *SRPProof, opts ...grpc.CallOption) (*SRPSession, error)
 }
 
 type gokeeperClient struct {
@@ -84,8 +104,8 @@ func (c *gokeeperClient) GetRecordsInfo(ctx context.Context, in *emptypb.Empty,
 	return out, nil
 }
 
-func (c *gokeeperClient) CreateRecord(ctx context.Context, in *Record, opts ...grpc.CallOption) (*emptypb.Empty, error) {
-	out := new(emptypb.Empty)
+func (c *gokeeperClient) CreateRecord(ctx context.Context, in *Record, opts ...grpc.CallOption) (*RecordMeta, error) {
+	out := new(RecordMeta)
 	err := c.cc.Invoke(ctx, Gokeeper_CreateRecord_FullMethodName, in, out, opts...)
 	if err != nil {
 		return nil, err
@@ -102,6 +122,87 @@ func (c *gokeeperClient) DeleteRecord(ctx context.Context, in *RecordID, opts ..
 	return out, nil
 }
 
+func (c *gokeeperClient) CreateRecords(ctx context.Context, in *RecordsList, opts ...grpc.CallOption) (*RecordResults, error) {
+	out := new(RecordResults)
+	err := c.cc.Invoke(ctx, Gokeeper_CreateRecords_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gokeeperClient) GetRecords(ctx context.Context, in *RecordIDs, opts ...grpc.CallOption) (*RecordResults, error) {
+	out := new(RecordResults)
+	err := c.cc.Invoke(ctx, Gokeeper_GetRecords_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gokeeperClient) DeleteRecords(ctx context.Context, in *RecordIDs, opts ...grpc.CallOption) (*RecordResults, error) {
+	out := new(RecordResults)
+	err := c.cc.Invoke(ctx, Gokeeper_DeleteRecords_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gokeeperClient) ReplaceRecords(ctx context.Context, in *RecordsList, opts ...grpc.CallOption) (*RecordResults, error) {
+	out := new(RecordResults)
+	err := c.cc.Invoke(ctx, Gokeeper_ReplaceRecords_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gokeeperClient) RewrapRecords(ctx context.Context, in *RecordsList, opts ...grpc.CallOption) (*RecordResults, error) {
+	out := new(RecordResults)
+	err := c.cc.Invoke(ctx, Gokeeper_RewrapRecords_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gokeeperClient) GetKeySalt(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*KeySalt, error) {
+	out := new(KeySalt)
+	err := c.cc.Invoke(ctx, Gokeeper_GetKeySalt_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gokeeperClient) RegisterSRP(ctx context.Context, in *SRPRegistration, opts ...grpc.CallOption) (*Token, error) {
+	out := new(Token)
+	err := c.cc.Invoke(ctx, Gokeeper_RegisterSRP_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gokeeperClient) StartLoginSRP(ctx context.Context, in *SRPStart, opts ...grpc.CallOption) (*SRPChallenge, error) {
+	out := new(SRPChallenge)
+	err := c.cc.Invoke(ctx, Gokeeper_StartLoginSRP_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gokeeperClient) FinishLoginSRP(ctx context.Context, in *SRPProof, opts ...grpc.CallOption) (*SRPSession, error) {
+	out := new(SRPSession)
+	err := c.cc.Invoke(ctx, Gokeeper_FinishLoginSRP_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // GokeeperServer is the server API for Gokeeper service.
 // All implementations must embed UnimplementedGokeeperServer
 // for forward compatibility
@@ -110,8 +211,19 @@ type GokeeperServer interface {
 	Register(context.Context, *UserCreds) (*Token, error)
 	GetRecord(context.Context, *RecordID) (*Record, error)
 	GetRecordsInfo(context.Context, *emptypb.Empty) (*RecordsList, error)
-	CreateRecord(context.Context, *Record) (*emptypb.Empty, error)
+	CreateRecord(context.Context, *Record) (*RecordMeta, error)
 	DeleteRecord(context.Context, *RecordID) (*emptypb.Empty, error)
+	CreateRecords(context.Context, *RecordsList) (*RecordResults, error)
+	GetRecords(context.Context, *RecordIDs) (*RecordResults, error)
+	DeleteRecords(context.Context, *RecordIDs) (*RecordResults, error)
+	// ReplaceRecords replaces data and key hints of records with versions, batch is replaced entirely or not at all.
+	ReplaceRecords(context.Context, *RecordsList) (*RecordResults, error)
+	// RewrapRecords replaces key hints and data keys of records with versions, data isn't sent.
+	RewrapRecords(context.Context, *RecordsList) (*RecordResults, error)
+	GetKeySalt(context.Context, *emptypb.Empty) (*KeySalt, error)
+	RegisterSRP(context.Context, *SRPRegistration) (*Token, error)
+	StartLoginSRP(context.Context, *SRPStart) (*SRPChallenge, error)
+	FinishLoginSRP(context.Context, *SRPProof) (*SRPSession, error)
 	mustEmbedUnimplementedGokeeperServer()
 }
 
@@ -131,12 +243,39 @@ func (UnimplementedGokeeperServer) GetRecord(context.Context, *RecordID) (*Recor
 func (UnimplementedGokeeperServer) GetRecordsInfo(context.Context, *emptypb.Empty) (*RecordsList, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetRecordsInfo not implemented")
 }
-func (UnimplementedGokeeperServer) CreateRecord(context.Context, *Record) (*emptypb.Empty, error) {
+func (UnimplementedGokeeperServer) CreateRecord(context.Context, *Record) (*RecordMeta, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method CreateRecord not implemented")
 }
 func (UnimplementedGokeeperServer) DeleteRecord(context.Context, *RecordID) (*emptypb.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DeleteRecord not implemented")
 }
+func (UnimplementedGokeeperServer) CreateRecords(context.Context, *RecordsList) (*RecordResults, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateRecords not implemented")
+}
+func (UnimplementedGokeeperServer) GetRecords(context.Context, *RecordIDs) (*RecordResults, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRecords not implemented")
+}
+func (UnimplementedGokeeperServer) DeleteRecords(context.Context, *RecordIDs) (*RecordResults, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteRecords not implemented")
+}
+func (UnimplementedGokeeperServer) ReplaceRecords(context.Context, *RecordsList) (*RecordResults, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReplaceRecords not implemented")
+}
+func (UnimplementedGokeeperServer) RewrapRecords(context.Context, *RecordsList) (*RecordResults, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RewrapRecords not implemented")
+}
+func (UnimplementedGokeeperServer) GetKeySalt(context.Context, *emptypb.Empty) (*KeySalt, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetKeySalt not implemented")
+}
+func (UnimplementedGokeeperServer) RegisterSRP(context.Context, *SRPRegistration) (*Token, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RegisterSRP not implemented")
+}
+func (UnimplementedGokeeperServer) StartLoginSRP(context.Context, *SRPStart) (*SRPChallenge, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method StartLoginSRP not implemented")
+}
+func (UnimplementedGokeeperServer) FinishLoginSRP(context.Context, *SRPProof) (*SRPSession, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FinishLoginSRP not implemented")
+}
 func (UnimplementedGokeeperServer) mustEmbedUnimplementedGokeeperServer() {}
 
 // UnsafeGokeeperServer may be embedded to opt out of forward compatibility for this service.
@@ -258,6 +397,168 @@ func _Gokeeper_DeleteRecord_Handler(srv interface{}, ctx context.Context, dec fu
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Gokeeper_CreateRecords_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RecordsList)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GokeeperServer).CreateRecords(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Gokeeper_CreateRecords_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GokeeperServer).CreateRecords(ctx, req.(*RecordsList))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Gokeeper_GetRecords_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RecordIDs)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GokeeperServer).GetRecords(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Gokeeper_GetRecords_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GokeeperServer).GetRecords(ctx, req.(*RecordIDs))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Gokeeper_DeleteRecords_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RecordIDs)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GokeeperServer).DeleteRecords(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Gokeeper_DeleteRecords_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GokeeperServer).DeleteRecords(ctx, req.(*RecordIDs))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Gokeeper_ReplaceRecords_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RecordsList)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GokeeperServer).ReplaceRecords(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Gokeeper_ReplaceRecords_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GokeeperServer).ReplaceRecords(ctx, req.(*RecordsList))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Gokeeper_RewrapRecords_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RecordsList)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GokeeperServer).RewrapRecords(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Gokeeper_RewrapRecords_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GokeeperServer).RewrapRecords(ctx, req.(*RecordsList))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Gokeeper_GetKeySalt_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GokeeperServer).GetKeySalt(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Gokeeper_GetKeySalt_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GokeeperServer).GetKeySalt(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Gokeeper_RegisterSRP_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SRPRegistration)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GokeeperServer).RegisterSRP(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Gokeeper_RegisterSRP_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GokeeperServer).RegisterSRP(ctx, req.(*SRPRegistration))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Gokeeper_StartLoginSRP_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SRPStart)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GokeeperServer).StartLoginSRP(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Gokeeper_StartLoginSRP_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GokeeperServer).StartLoginSRP(ctx, req.(*SRPStart))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Gokeeper_FinishLoginSRP_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SRPProof)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GokeeperServer).FinishLoginSRP(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Gokeeper_FinishLoginSRP_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GokeeperServer).FinishLoginSRP(ctx, req.(*SRPProof))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Gokeeper_ServiceDesc is the grpc.ServiceDesc for Gokeeper service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -289,6 +590,42 @@ var Gokeeper_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "DeleteRecord",
 			Handler:    _Gokeeper_DeleteRecord_Handler,
 		},
+		{
+			MethodName: "CreateRecords",
+			Handler:    _Gokeeper_CreateRecords_Handler,
+		},
+		{
+			MethodName: "GetRecords",
+			Handler:    _Gokeeper_GetRecords_Handler,
+		},
+		{
+			MethodName: "DeleteRecords",
+			Handler:    _Gokeeper_DeleteRecords_Handler,
+		},
+		{
+			MethodName: "ReplaceRecords",
+			Handler:    _Gokeeper_ReplaceRecords_Handler,
+		},
+		{
+			MethodName: "RewrapRecords",
+			Handler:    _Gokeeper_RewrapRecords_Handler,
+		},
+		{
+			MethodName: "GetKeySalt",
+			Handler:    _Gokeeper_GetKeySalt_Handler,
+		},
+		{
+			MethodName: "RegisterSRP",
+			Handler:    _Gokeeper_RegisterSRP_Handler,
+		},
+		{
+			MethodName: "StartLoginSRP",
+			Handler:    _Gokeeper_StartLoginSRP_Handler,
+		},
+		{
+			MethodName: "FinishLoginSRP",
+			Handler:    _Gokeeper_FinishLoginSRP_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "internal/rpc/rpc.proto",
@@ -1,60 +1,269 @@
 package serverconfig
 
 import (
+	"errors"
 	"flag"
+	"fmt"
 	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
 	"strconv"
+	"strings"
 	"time"
+
+	"github.com/impr0ver/gophKeeper/internal/crypt"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
 )
 
 // ServerConfig struct for server config.
 type ServerConfig struct {
-	ListenAddr       string
-	DatabaseDSN      string
-	FilesStore       string
-	JWTAuth          AuthConfig
-	ServerCert       string
-	ServerKey        string
-	MigrationsURL    string
-	ServerConsoleLog bool
+	ListenAddr        string          `yaml:"listen_addr" toml:"listen_addr"`
+	DatabaseDSN       string          `yaml:"database_dsn" toml:"database_dsn"`
+	Storage           string          `yaml:"storage" toml:"storage"`
+	FilesStore        string          `yaml:"files_store" toml:"files_store"`
+	JWTAuth           AuthConfig      `yaml:"jwt" toml:"jwt"`
+	ServerCert        string          `yaml:"server_cert" toml:"server_cert"`
+	ServerKey         string          `yaml:"server_key" toml:"server_key"`
+	MigrationsURL     string          `yaml:"migrations_url" toml:"migrations_url"`
+	ServerConsoleLog  bool            `yaml:"console_log" toml:"console_log"`
+	AdminToken        string          `yaml:"admin_token" toml:"admin_token"`
+	UserQuota         int64           `yaml:"user_quota" toml:"user_quota"`
+	LogLevel          string          `yaml:"log_level" toml:"log_level"`
+	RateLimit         RateLimitConfig `yaml:"rate_limit" toml:"rate_limit"`
+	IdempotencyWindow time.Duration   `yaml:"idempotency_window" toml:"idempotency_window"`
+	Fsck              FsckConfig      `yaml:"fsck" toml:"fsck"`
+	Blob              BlobConfig      `yaml:"blob" toml:"blob"`
+	ScrubInterval     time.Duration   `yaml:"scrub_interval" toml:"scrub_interval"`
+	PasswordHash      PasswordConfig  `yaml:"password_hash" toml:"password_hash"`
+	LegacyLogin       bool            `yaml:"legacy_login" toml:"legacy_login"`
+	ConfigFile        string          `yaml:"-" toml:"-"`
+	PrintConfig       bool            `yaml:"-" toml:"-"`
+
+	args []string
 }
 
 // AuthConfig auth settings.
 type AuthConfig struct {
-	SecretJWT      string
-	ExpirationTime time.Duration
+	SecretJWT      string        `yaml:"secret" toml:"secret"`
+	ExpirationTime time.Duration `yaml:"expiration_time" toml:"expiration_time"`
+}
+
+// RateLimitConfig requests rate limit settings for one client.
+type RateLimitConfig struct {
+	RPS   float64 `yaml:"rps" toml:"rps"`
+	Burst int     `yaml:"burst" toml:"burst"`
+}
+
+// FsckConfig storage consistency check settings.
+type FsckConfig struct {
+	// Action is done with orphans found: report, quarantine or delete.
+	Action string `yaml:"action" toml:"action"`
+	// Interval of background check, zero disables it.
+	Interval time.Duration `yaml:"interval" toml:"interval"`
+	// Grace is age of files, which may belong to records being saved right now.
+	Grace time.Duration `yaml:"grace" toml:"grace"`
+}
+
+// BlobConfig storage backend settings of file records.
+type BlobConfig struct {
+	// Backend is local (directory files_store), memory or s3.
+	Backend string   `yaml:"backend" toml:"backend"`
+	S3      S3Config `yaml:"s3" toml:"s3"`
+}
+
+// S3Config settings of S3-compatible object storage.
+type S3Config struct {
+	Endpoint  string `yaml:"endpoint" toml:"endpoint"`
+	Region    string `yaml:"region" toml:"region"`
+	Bucket    string `yaml:"bucket" toml:"bucket"`
+	AccessKey string `yaml:"access_key" toml:"access_key"`
+	SecretKey string `yaml:"secret_key" toml:"secret_key"`
+	Prefix    string `yaml:"prefix" toml:"prefix"`
 }
 
+// PasswordConfig cost parameters of Argon2id hashes of user passwords.
+// Hashes with other parameters are replaced on next login.
+type PasswordConfig struct {
+	// Memory in KiB.
+	Memory      uint `yaml:"memory" toml:"memory"`
+	Iterations  uint `yaml:"iterations" toml:"iterations"`
+	Parallelism uint `yaml:"parallelism" toml:"parallelism"`
+}
+
+// Params returns password hash parameters, if they are valid.
+func (p PasswordConfig) Params() (crypt.PasswordParams, error) {
+	params := crypt.PasswordParams{
+		Memory:      uint32(p.Memory),
+		Iterations:  uint32(p.Iterations),
+		Parallelism: uint8(p.Parallelism),
+	}
+
+	if uint(params.Memory) != p.Memory || uint(params.Iterations) != p.Iterations || uint(params.Parallelism) != p.Parallelism || !params.Valid() {
+		return params, fmt.Errorf("%w: %+v", ErrPasswordHash, p)
+	}
+
+	return params, nil
+}
+
+// Config file errors.
 var (
-	defaultListenAddr       = "127.0.0.1:9000"
-	defaultFilesStore       = "data"
-	defaultDSN              = "" //user=postgres password=password host=localhost port=5432 dbname=gokeeper sslmode=disable
-	defaultJWTSecret        = "mySuperSecretKey"
-	defaultExpirationTime   = time.Duration(2 * time.Minute)
-	defaultServerCert       = "../../cmd/cert/server-cert.pem"
-	defaultServerKey        = "../../cmd/cert/server-key.pem"
-	defaultMigrationsURL    = "../../migrations"
-	defaultServerConsoleLog = true
+	ErrConfigFormat = errors.New("unknown config file format, use .yaml, .yml or .toml")
+	ErrPasswordHash = errors.New("invalid password hash parameters")
 )
 
-// NewServerConfig gets server config.
+// redacted replaces secrets in printed config.
+const redacted = "[REDACTED]"
+
+var (
+	defaultListenAddr        = "127.0.0.1:9000"
+	defaultFilesStore        = "data"
+	defaultDSN               = "" //user=postgres password=password host=localhost port=5432 dbname=gokeeper sslmode=disable
+	defaultStorage           = "postgres"
+	defaultJWTSecret         = "mySuperSecretKey"
+	defaultExpirationTime    = time.Duration(2 * time.Minute)
+	defaultServerCert        = "../../cmd/cert/server-cert.pem"
+	defaultServerKey         = "../../cmd/cert/server-key.pem"
+	defaultMigrationsURL     = "../../migrations"
+	defaultServerConsoleLog  = true
+	defaultAdminToken        = ""
+	defaultUserQuota         = int64(0)
+	defaultLogLevel          = "info"
+	defaultRateLimitRPS      = float64(0)
+	defaultRateLimitBurst    = 20
+	defaultIdempotencyWindow = 24 * time.Hour
+	defaultFsckAction        = "report"
+	defaultFsckInterval      = time.Duration(0)
+	defaultFsckGrace         = time.Hour
+	defaultBlobBackend       = "local"
+	defaultS3Region          = "us-east-1"
+	defaultScrubInterval     = time.Duration(0)
+	defaultPasswordMemory    = uint(crypt.DefaultPasswordParams.Memory)
+	defaultPasswordIter      = uint(crypt.DefaultPasswordParams.Iterations)
+	defaultPasswordThreads   = uint(crypt.DefaultPasswordParams.Parallelism)
+	defaultLegacyLogin       = true
+)
+
+// NewServerConfig gets server config. Values are taken with precedence defaults < config file < env < flags.
 func NewServerConfig() ServerConfig {
-	var (
-		cfg ServerConfig
-		err error
-	)
-
-	flag.StringVar(&cfg.ListenAddr, "listenaddr", defaultListenAddr, "Server address and port")
-	flag.StringVar(&cfg.DatabaseDSN, "dsn", defaultDSN, "Source to DB")
-	flag.StringVar(&cfg.FilesStore, "filepath", defaultFilesStore, "Path to files store")
-	flag.StringVar(&cfg.JWTAuth.SecretJWT, "jwtsecr", defaultJWTSecret, "JWT secret")
-	flag.DurationVar(&cfg.JWTAuth.ExpirationTime, "exptime", defaultExpirationTime, "Token expiration time")
-	flag.StringVar(&cfg.ServerCert, "servcert", defaultServerCert, "Path to server certificat for TLS")
-	flag.StringVar(&cfg.ServerKey, "servkey", defaultServerKey, "Path to server key for TLS")
-	flag.StringVar(&cfg.MigrationsURL, "migrateURL", defaultMigrationsURL, "Path to migrations for DB")
-	flag.BoolVar(&cfg.ServerConsoleLog, "servconslog", defaultServerConsoleLog, "Console log request and MD data on server interceptors")
-
-	flag.Parse()
+	cfg, err := parseServerConfig(flag.CommandLine, os.Args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "config error: %v\n", err)
+		os.Exit(2)
+	}
+
+	return cfg
+}
+
+// ParseServerConfig gets server config from args of server subcommand.
+func ParseServerConfig(name string, args []string) (ServerConfig, error) {
+	return parseServerConfig(flag.NewFlagSet(name, flag.ContinueOnError), args)
+}
+
+// Reload reads config again from the same sources: defaults, config file, env and flags.
+func (cfg ServerConfig) Reload() (ServerConfig, error) {
+	return parseServerConfig(flag.NewFlagSet("reload", flag.ContinueOnError), cfg.args)
+}
+
+// parseServerConfig parses args with flag set and merges them with config file and env.
+func parseServerConfig(fs *flag.FlagSet, args []string) (ServerConfig, error) {
+	var cfg ServerConfig
+
+	fs.StringVar(&cfg.ConfigFile, "config", "", "Path to config file (.yaml, .yml or .toml)")
+	fs.BoolVar(&cfg.PrintConfig, "print-config", false, "Print effective config with redacted secrets and exit")
+	fs.StringVar(&cfg.ListenAddr, "listenaddr", defaultListenAddr, "Server address and port")
+	fs.StringVar(&cfg.DatabaseDSN, "dsn", defaultDSN, "Source to DB")
+	fs.StringVar(&cfg.Storage, "storage", defaultStorage, "DB storage: postgres (connection from dsn), sqlite:path or memory")
+	fs.StringVar(&cfg.FilesStore, "filepath", defaultFilesStore, "Path to files store")
+	fs.StringVar(&cfg.JWTAuth.SecretJWT, "jwtsecr", defaultJWTSecret, "JWT secret")
+	fs.DurationVar(&cfg.JWTAuth.ExpirationTime, "exptime", defaultExpirationTime, "Token expiration time")
+	fs.StringVar(&cfg.ServerCert, "servcert", defaultServerCert, "Path to server certificat for TLS")
+	fs.StringVar(&cfg.ServerKey, "servkey", defaultServerKey, "Path to server key for TLS")
+	fs.StringVar(&cfg.MigrationsURL, "migrateURL", defaultMigrationsURL, "Path to migrations for DB")
+	fs.BoolVar(&cfg.ServerConsoleLog, "servconslog", defaultServerConsoleLog, "Console log request and MD data on server interceptors")
+	fs.StringVar(&cfg.AdminToken, "admintoken", defaultAdminToken, "Token for admin service access (empty - only admin-role users)")
+	fs.Int64Var(&cfg.UserQuota, "quota", defaultUserQuota, "Default user storage quota in bytes (0 - unlimited)")
+	fs.StringVar(&cfg.LogLevel, "loglevel", defaultLogLevel, "Log level (debug, info, warn, error)")
+	fs.Float64Var(&cfg.RateLimit.RPS, "ratelimit", defaultRateLimitRPS, "Requests per second limit for one client (0 - unlimited)")
+	fs.IntVar(&cfg.RateLimit.Burst, "rateburst", defaultRateLimitBurst, "Requests burst for one client")
+	fs.DurationVar(&cfg.IdempotencyWindow, "idempotencywindow", defaultIdempotencyWindow, "Time while responses are replayed for requests with the same idempotency key")
+	fs.StringVar(&cfg.Fsck.Action, "fsckaction", defaultFsckAction, "Action with orphans found by storage check (report, quarantine, delete)")
+	fs.DurationVar(&cfg.Fsck.Interval, "fsckinterval", defaultFsckInterval, "Interval of background storage check (0 - disabled)")
+	fs.DurationVar(&cfg.Fsck.Grace, "fsckgrace", defaultFsckGrace, "Age of files, which are not checked yet, because records may be saved right now")
+	fs.StringVar(&cfg.Blob.Backend, "blobbackend", defaultBlobBackend, "Storage backend of file records (local, memory, s3)")
+	fs.StringVar(&cfg.Blob.S3.Endpoint, "s3endpoint", "", "URL of S3-compatible storage")
+	fs.StringVar(&cfg.Blob.S3.Region, "s3region", defaultS3Region, "Region of S3 storage")
+	fs.StringVar(&cfg.Blob.S3.Bucket, "s3bucket", "", "Bucket of S3 storage")
+	fs.StringVar(&cfg.Blob.S3.AccessKey, "s3accesskey", "", "Access key of S3 storage")
+	fs.StringVar(&cfg.Blob.S3.SecretKey, "s3secretkey", "", "Secret key of S3 storage")
+	fs.StringVar(&cfg.Blob.S3.Prefix, "s3prefix", "", "Prefix of object keys in S3 bucket")
+	fs.DurationVar(&cfg.ScrubInterval, "scrubinterval", defaultScrubInterval, "Interval of background verification of file checksums (0 - disabled)")
+	fs.UintVar(&cfg.PasswordHash.Memory, "argon2memory", defaultPasswordMemory, "Memory of Argon2id password hash in KiB")
+	fs.UintVar(&cfg.PasswordHash.Iterations, "argon2iter", defaultPasswordIter, "Iterations of Argon2id password hash")
+	fs.UintVar(&cfg.PasswordHash.Parallelism, "argon2threads", defaultPasswordThreads, "Parallelism of Argon2id password hash")
+	fs.BoolVar(&cfg.LegacyLogin, "legacylogin", defaultLegacyLogin, "Allow login with password sent to server, while users migrate to SRP login")
+
+	if err := fs.Parse(args); err != nil {
+		return cfg, err
+	}
+
+	// Remember explicitly set flags, they have the highest priority
+	setFlags := make(map[string]string)
+	fs.Visit(func(f *flag.Flag) {
+		setFlags[f.Name] = f.Value.String()
+	})
+
+	if v, ok := os.LookupEnv("CONFIG_FILE"); ok && setFlags["config"] == "" {
+		cfg.ConfigFile = v
+	}
+
+	if cfg.ConfigFile != "" {
+		if err := loadConfigFile(cfg.ConfigFile, &cfg); err != nil {
+			return cfg, err
+		}
+	}
+
+	loadEnv(&cfg)
+
+	for name, value := range setFlags {
+		if err := fs.Set(name, value); err != nil {
+			return cfg, err
+		}
+	}
+
+	cfg.args = args
+
+	return cfg, nil
+}
+
+// loadConfigFile reads YAML or TOML config file over current values.
+func loadConfigFile(path string, cfg *ServerConfig) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config file: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, cfg)
+	case ".toml":
+		err = toml.Unmarshal(data, cfg)
+	default:
+		return ErrConfigFormat
+	}
+
+	if err != nil {
+		return fmt.Errorf("parse config file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// loadEnv reads env variables over current values.
+func loadEnv(cfg *ServerConfig) {
+	var err error
 
 	if v, ok := os.LookupEnv("SERVER_PORT"); ok {
 		cfg.ListenAddr = v
@@ -64,6 +273,10 @@ func NewServerConfig() ServerConfig {
 		cfg.DatabaseDSN = v
 	}
 
+	if v, ok := os.LookupEnv("STORAGE"); ok {
+		cfg.Storage = v
+	}
+
 	if v, ok := os.LookupEnv("FILE_STORAGE_PATH"); ok {
 		cfg.FilesStore = v
 	}
@@ -98,5 +311,226 @@ func NewServerConfig() ServerConfig {
 		}
 	}
 
+	if v, ok := os.LookupEnv("ADMIN_TOKEN"); ok {
+		cfg.AdminToken = v
+	}
+
+	if v, ok := os.LookupEnv("USER_QUOTA"); ok {
+		cfg.UserQuota, err = strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			cfg.UserQuota = defaultUserQuota
+		}
+	}
+
+	if v, ok := os.LookupEnv("LOG_LEVEL"); ok {
+		cfg.LogLevel = v
+	}
+
+	if v, ok := os.LookupEnv("RATE_LIMIT"); ok {
+		cfg.RateLimit.RPS, err = strconv.ParseFloat(v, 64)
+		if err != nil {
+			cfg.RateLimit.RPS = defaultRateLimitRPS
+		}
+	}
+
+	if v, ok := os.LookupEnv("RATE_BURST"); ok {
+		cfg.RateLimit.Burst, err = strconv.Atoi(v)
+		if err != nil {
+			cfg.RateLimit.Burst = defaultRateLimitBurst
+		}
+	}
+
+	if v, ok := os.LookupEnv("IDEMPOTENCY_WINDOW"); ok {
+		cfg.IdempotencyWindow, err = time.ParseDuration(v)
+		if err != nil {
+			cfg.IdempotencyWindow = defaultIdempotencyWindow
+		}
+	}
+
+	if v, ok := os.LookupEnv("FSCK_ACTION"); ok {
+		cfg.Fsck.Action = v
+	}
+
+	if v, ok := os.LookupEnv("FSCK_INTERVAL"); ok {
+		cfg.Fsck.Interval, err = time.ParseDuration(v)
+		if err != nil {
+			cfg.Fsck.Interval = defaultFsckInterval
+		}
+	}
+
+	if v, ok := os.LookupEnv("FSCK_GRACE"); ok {
+		cfg.Fsck.Grace, err = time.ParseDuration(v)
+		if err != nil {
+			cfg.Fsck.Grace = defaultFsckGrace
+		}
+	}
+
+	if v, ok := os.LookupEnv("BLOB_BACKEND"); ok {
+		cfg.Blob.Backend = v
+	}
+
+	if v, ok := os.LookupEnv("S3_ENDPOINT"); ok {
+		cfg.Blob.S3.Endpoint = v
+	}
+
+	if v, ok := os.LookupEnv("S3_REGION"); ok {
+		cfg.Blob.S3.Region = v
+	}
+
+	if v, ok := os.LookupEnv("S3_BUCKET"); ok {
+		cfg.Blob.S3.Bucket = v
+	}
+
+	if v, ok := os.LookupEnv("S3_ACCESS_KEY"); ok {
+		cfg.Blob.S3.AccessKey = v
+	}
+
+	if v, ok := os.LookupEnv("S3_SECRET_KEY"); ok {
+		cfg.Blob.S3.SecretKey = v
+	}
+
+	if v, ok := os.LookupEnv("S3_PREFIX"); ok {
+		cfg.Blob.S3.Prefix = v
+	}
+
+	if v, ok := os.LookupEnv("SCRUB_INTERVAL"); ok {
+		cfg.ScrubInterval, err = time.ParseDuration(v)
+		if err != nil {
+			cfg.ScrubInterval = defaultScrubInterval
+		}
+	}
+
+	if v, ok := os.LookupEnv("ARGON2_MEMORY"); ok {
+		cfg.PasswordHash.Memory, err = parseUint(v)
+		if err != nil {
+			cfg.PasswordHash.Memory = defaultPasswordMemory
+		}
+	}
+
+	if v, ok := os.LookupEnv("ARGON2_ITER"); ok {
+		cfg.PasswordHash.Iterations, err = parseUint(v)
+		if err != nil {
+			cfg.PasswordHash.Iterations = defaultPasswordIter
+		}
+	}
+
+	if v, ok := os.LookupEnv("ARGON2_THREADS"); ok {
+		cfg.PasswordHash.Parallelism, err = parseUint(v)
+		if err != nil {
+			cfg.PasswordHash.Parallelism = defaultPasswordThreads
+		}
+	}
+
+	if v, ok := os.LookupEnv("LEGACY_LOGIN"); ok {
+		cfg.LegacyLogin, err = strconv.ParseBool(v)
+		if err != nil {
+			cfg.LegacyLogin = defaultLegacyLogin
+		}
+	}
+}
+
+// parseUint parses unsigned integer env value.
+func parseUint(v string) (uint, error) {
+	n, err := strconv.ParseUint(v, 10, 0)
+
+	return uint(n), err
+}
+
+// RestartRequired reports whether new config changes settings which can't be reloaded on the fly.
+func (cfg ServerConfig) RestartRequired(newCfg ServerConfig) bool {
+	return !reflect.DeepEqual(cfg.static(), newCfg.static())
+}
+
+// static returns config without settings which are reloaded on the fly.
+func (cfg ServerConfig) static() ServerConfig {
+	cfg.LogLevel = ""
+	cfg.ServerConsoleLog = false
+	cfg.RateLimit = RateLimitConfig{}
+	cfg.IdempotencyWindow = 0
+	cfg.JWTAuth.ExpirationTime = 0
+	cfg.args = nil
+
+	return cfg
+}
+
+// Reloaded returns config with settings of new config which are reloaded on the fly, others are kept until restart.
+func (cfg ServerConfig) Reloaded(newCfg ServerConfig) ServerConfig {
+	cfg.LogLevel = newCfg.LogLevel
+	cfg.ServerConsoleLog = newCfg.ServerConsoleLog
+	cfg.RateLimit = newCfg.RateLimit
+	cfg.IdempotencyWindow = newCfg.IdempotencyWindow
+	cfg.JWTAuth.ExpirationTime = newCfg.JWTAuth.ExpirationTime
+
+	return cfg
+}
+
+// dsnPassword matches password in key-value and URL forms of DSN.
+var dsnPassword = regexp.MustCompile(`(password=)\S+|(://[^:/@]+:)[^@]+(@)`)
+
+// Redacted returns copy of config with hidden secrets.
+func (cfg ServerConfig) Redacted() ServerConfig {
+	if cfg.JWTAuth.SecretJWT != "" {
+		cfg.JWTAuth.SecretJWT = redacted
+	}
+
+	if cfg.AdminToken != "" {
+		cfg.AdminToken = redacted
+	}
+
+	if cfg.Blob.S3.SecretKey != "" {
+		cfg.Blob.S3.SecretKey = redacted
+	}
+
+	cfg.DatabaseDSN = dsnPassword.ReplaceAllString(cfg.DatabaseDSN, "${1}${2}"+redacted+"${3}")
+
 	return cfg
 }
+
+// Print writes effective config with redacted secrets in YAML format.
+func (cfg ServerConfig) Print() (string, error) {
+	data, err := yaml.Marshal(cfg.Redacted())
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+// MarshalYAML prints idempotency window and scrub interval as duration strings.
+func (cfg ServerConfig) MarshalYAML() (interface{}, error) {
+	type plain ServerConfig
+
+	var node yaml.Node
+	if err := node.Encode(plain(cfg)); err != nil {
+		return nil, err
+	}
+
+	durations := map[string]time.Duration{
+		"idempotency_window": cfg.IdempotencyWindow,
+		"scrub_interval":     cfg.ScrubInterval,
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if d, ok := durations[node.Content[i].Value]; ok {
+			node.Content[i+1].SetString(d.String())
+		}
+	}
+
+	return &node, nil
+}
+
+// MarshalYAML prints token expiration time as duration string.
+func (a AuthConfig) MarshalYAML() (interface{}, error) {
+	return struct {
+		SecretJWT      string `yaml:"secret"`
+		ExpirationTime string `yaml:"expiration_time"`
+	}{a.SecretJWT, a.ExpirationTime.String()}, nil
+}
+
+// MarshalYAML prints check interval and grace as duration strings.
+func (f FsckConfig) MarshalYAML() (interface{}, error) {
+	return struct {
+		Action   string `yaml:"action"`
+		Interval string `yaml:"interval"`
+		Grace    string `yaml:"grace"`
+	}{f.Action, f.Interval.String(), f.Grace.String()}, nil
+}
@@ -0,0 +1,360 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/impr0ver/gophKeeper/internal/userdata"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// BlobStore interface for storage of blobs by key, data is streamed. Keys are slash separated relative paths.
+//
+//go:generate mockery --name BlobStore
+type BlobStore interface {
+	// Put saves blob, size is length of data or -1 if it's unknown.
+	Put(ctx context.Context, key string, data io.Reader, size int64) error
+	// Get returns reader of blob, it must be closed.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	// Rename moves blob to other key, existing blob with the key is replaced.
+	Rename(ctx context.Context, from string, to string) error
+	// List returns blobs on top level, blobs with nested keys are skipped.
+	List(ctx context.Context) ([]userdata.StoredFile, error)
+}
+
+// Blob store backends.
+const (
+	BlobLocal  = "local"
+	BlobMemory = "memory"
+	BlobS3     = "s3"
+)
+
+// NewBlobStore returns blob store of backend, directory is used by local backend and s3 settings by S3 one.
+func NewBlobStore(backend string, directory string, s3 S3Config) (BlobStore, error) {
+	switch backend {
+	case BlobLocal:
+		return NewLocalBlobStore(directory)
+	case BlobMemory:
+		return NewMemoryBlobStore(), nil
+	case BlobS3:
+		return NewS3BlobStore(s3)
+	default:
+		log.Println("Unknown blob store backend:", backend)
+		return nil, ErrBlobConfig
+	}
+}
+
+// validKey checks that key is relative path without parent references.
+func validKey(key string) bool {
+	return key != "" && !strings.HasPrefix(key, "/") && path.Clean(key) == key && key != ".." && !strings.HasPrefix(key, "../")
+}
+
+// localBlobStore stores blobs as files in directory.
+type localBlobStore struct {
+	directory string
+}
+
+// NewLocalBlobStore returns blob store in local directory, directory is created if it doesn't exist.
+func NewLocalBlobStore(directory string) (BlobStore, error) {
+	return newLocalBlobStore(directory)
+}
+
+// newLocalBlobStore returns blob store in local directory.
+func newLocalBlobStore(directory string) (*localBlobStore, error) {
+	if err := os.Mkdir(directory, os.ModePerm); err != nil && !os.IsExist(err) {
+		return nil, err
+	}
+
+	return &localBlobStore{directory: directory}, nil
+}
+
+// filename returns file of blob.
+func (store *localBlobStore) filename(key string) string {
+	return filepath.Join(store.directory, filepath.FromSlash(key))
+}
+
+// Put writes blob to file and syncs it to disk.
+func (store *localBlobStore) Put(_ context.Context, key string, data io.Reader, size int64) error {
+	if !validKey(key) {
+		log.Println("Wrong blob key in putting blob:", key)
+		return ErrUnknown
+	}
+
+	file, err := os.OpenFile(store.filename(key), os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		log.Infoln(err)
+
+		return ErrUnknown
+	}
+
+	written, err := io.Copy(file, data)
+	if err == nil && size >= 0 && written != size {
+		err = io.ErrUnexpectedEOF
+	}
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		log.Infoln(err)
+
+		if err := os.Remove(file.Name()); err != nil {
+			log.Infoln(err)
+		}
+
+		return ErrUnknown
+	}
+
+	return store.syncDirectory(filepath.Dir(file.Name()))
+}
+
+// Get opens file of blob.
+func (store *localBlobStore) Get(_ context.Context, key string) (io.ReadCloser, error) {
+	if !validKey(key) {
+		log.Println("Wrong blob key in getting blob:", key)
+		return nil, ErrUnknown
+	}
+
+	file, err := os.Open(store.filename(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		log.Infoln(err)
+
+		return nil, ErrUnknown
+	}
+
+	return file, nil
+}
+
+// Delete removes file of blob.
+func (store *localBlobStore) Delete(_ context.Context, key string) error {
+	if !validKey(key) {
+		log.Println("Wrong blob key in deleting blob:", key)
+		return ErrUnknown
+	}
+
+	err := os.Remove(store.filename(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return ErrNotFound
+	}
+	if err != nil {
+		log.Infoln(err)
+
+		return ErrUnknown
+	}
+
+	return nil
+}
+
+// Rename renames file of blob and syncs directory, so rename survives crash.
+func (store *localBlobStore) Rename(_ context.Context, from string, to string) error {
+	if !validKey(from) || !validKey(to) {
+		log.Println("Wrong blob key in renaming blob:", from, to)
+		return ErrUnknown
+	}
+
+	target := store.filename(to)
+	if err := os.MkdirAll(filepath.Dir(target), 0700); err != nil {
+		log.Infoln(err)
+
+		return ErrUnknown
+	}
+
+	err := os.Rename(store.filename(from), target)
+	if errors.Is(err, os.ErrNotExist) {
+		return ErrNotFound
+	}
+	if err != nil {
+		log.Infoln(err)
+
+		return ErrUnknown
+	}
+
+	return store.syncDirectory(filepath.Dir(target))
+}
+
+// List lists files in directory, nested directories are skipped.
+func (store *localBlobStore) List(_ context.Context) ([]userdata.StoredFile, error) {
+	entries, err := os.ReadDir(store.directory)
+	if err != nil {
+		log.Infoln(err)
+
+		return nil, ErrUnknown
+	}
+
+	files := make([]userdata.StoredFile, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		info, err := entry.Info()
+		if errors.Is(err, os.ErrNotExist) {
+			// File is renamed or deleted right now
+			continue
+		}
+		if err != nil {
+			log.Infoln(err)
+
+			return nil, ErrUnknown
+		}
+
+		files = append(files, userdata.StoredFile{
+			Name:    entry.Name(),
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+		})
+	}
+
+	return files, nil
+}
+
+// syncDirectory syncs directory entries to disk.
+func (store *localBlobStore) syncDirectory(directory string) error {
+	dir, err := os.Open(directory)
+	if err != nil {
+		log.Infoln(err)
+
+		return ErrUnknown
+	}
+	defer dir.Close()
+
+	if err := dir.Sync(); err != nil {
+		log.Infoln(err)
+
+		return ErrUnknown
+	}
+
+	return nil
+}
+
+// memoryBlob is blob saved in memory.
+type memoryBlob struct {
+	data    []byte
+	modTime time.Time
+}
+
+// memoryBlobStore stores blobs in memory, it's used in tests and single process setups without persistence.
+type memoryBlobStore struct {
+	mu    sync.RWMutex
+	blobs map[string]memoryBlob
+}
+
+// NewMemoryBlobStore returns empty blob store in memory.
+func NewMemoryBlobStore() BlobStore {
+	return &memoryBlobStore{blobs: make(map[string]memoryBlob)}
+}
+
+// Put reads blob to memory.
+func (store *memoryBlobStore) Put(_ context.Context, key string, data io.Reader, size int64) error {
+	if !validKey(key) {
+		log.Println("Wrong blob key in putting blob:", key)
+		return ErrUnknown
+	}
+
+	blob, err := io.ReadAll(data)
+	if err != nil || size >= 0 && int64(len(blob)) != size {
+		log.Infoln("Failed read blob:", err)
+		return ErrUnknown
+	}
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	store.blobs[key] = memoryBlob{data: blob, modTime: time.Now()}
+
+	return nil
+}
+
+// Get returns reader of blob copy.
+func (store *memoryBlobStore) Get(_ context.Context, key string) (io.ReadCloser, error) {
+	if !validKey(key) {
+		log.Println("Wrong blob key in getting blob:", key)
+		return nil, ErrUnknown
+	}
+
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	blob, ok := store.blobs[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return io.NopCloser(bytes.NewReader(bytes.Clone(blob.data))), nil
+}
+
+// Delete removes blob.
+func (store *memoryBlobStore) Delete(_ context.Context, key string) error {
+	if !validKey(key) {
+		log.Println("Wrong blob key in deleting blob:", key)
+		return ErrUnknown
+	}
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if _, ok := store.blobs[key]; !ok {
+		return ErrNotFound
+	}
+
+	delete(store.blobs, key)
+
+	return nil
+}
+
+// Rename moves blob to other key.
+func (store *memoryBlobStore) Rename(_ context.Context, from string, to string) error {
+	if !validKey(from) || !validKey(to) {
+		log.Println("Wrong blob key in renaming blob:", from, to)
+		return ErrUnknown
+	}
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	blob, ok := store.blobs[from]
+	if !ok {
+		return ErrNotFound
+	}
+
+	delete(store.blobs, from)
+	store.blobs[to] = blob
+
+	return nil
+}
+
+// List returns blobs with keys without slashes sorted by key.
+func (store *memoryBlobStore) List(_ context.Context) ([]userdata.StoredFile, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	files := make([]userdata.StoredFile, 0, len(store.blobs))
+	for key, blob := range store.blobs {
+		if strings.Contains(key, "/") {
+			continue
+		}
+
+		files = append(files, userdata.StoredFile{Name: key, Size: int64(len(blob.data)), ModTime: blob.modTime})
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+
+	return files, nil
+}
@@ -0,0 +1,137 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/impr0ver/gophKeeper/internal/userdata"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/metadata"
+)
+
+// blobStores returns all blob store backends for shared tests.
+func blobStores(t *testing.T) map[string]BlobStore {
+	local, err := NewLocalBlobStore(t.TempDir())
+	assert.NoError(t, err)
+
+	s3, _ := newFakeS3BlobStore(t, "")
+
+	return map[string]BlobStore{
+		"local":  local,
+		"memory": NewMemoryBlobStore(),
+		"s3":     s3,
+	}
+}
+
+// readBlob reads whole blob.
+func readBlob(t *testing.T, store BlobStore, key string) string {
+	blob, err := store.Get(context.Background(), key)
+	if !assert.NoError(t, err) {
+		return ""
+	}
+	defer blob.Close()
+
+	data, err := io.ReadAll(blob)
+	assert.NoError(t, err)
+
+	return string(data)
+}
+
+func TestBlobStore(t *testing.T) {
+	ctx := context.Background()
+
+	for name, store := range blobStores(t) {
+		t.Log(name)
+
+		assert.NoError(t, store.Put(ctx, "1", strings.NewReader("data"), 4))
+		assert.NoError(t, store.Put(ctx, "2", strings.NewReader("unknown size"), -1))
+		assert.Equal(t, "data", readBlob(t, store, "1"))
+		assert.Equal(t, "unknown size", readBlob(t, store, "2"))
+
+		// Put replaces blob
+		assert.NoError(t, store.Put(ctx, "1", strings.NewReader("new"), 3))
+		assert.Equal(t, "new", readBlob(t, store, "1"))
+
+		// Rename replaces target, nested keys are not listed
+		assert.NoError(t, store.Rename(ctx, "2", "1"))
+		assert.Equal(t, "unknown size", readBlob(t, store, "1"))
+		assert.NoError(t, store.Rename(ctx, "1", "nested/1"))
+		assert.Equal(t, "unknown size", readBlob(t, store, "nested/1"))
+		assert.NoError(t, store.Put(ctx, "3", strings.NewReader(""), 0))
+
+		files, err := store.List(ctx)
+		assert.NoError(t, err)
+		if assert.Len(t, files, 1) {
+			assert.Equal(t, "3", files[0].Name)
+			assert.Equal(t, int64(0), files[0].Size)
+			assert.False(t, files[0].ModTime.IsZero())
+		}
+
+		// Missing blobs
+		_, err = store.Get(ctx, "1")
+		assert.Equal(t, ErrNotFound, err)
+		assert.Equal(t, ErrNotFound, store.Delete(ctx, "1"))
+		assert.Equal(t, ErrNotFound, store.Rename(ctx, "1", "4"))
+
+		assert.NoError(t, store.Delete(ctx, "3"))
+		_, err = store.Get(ctx, "3")
+		assert.Equal(t, ErrNotFound, err)
+
+		// Keys out of store
+		assert.Equal(t, ErrUnknown, store.Put(ctx, "../1", strings.NewReader("data"), 4))
+		assert.Equal(t, ErrUnknown, store.Put(ctx, "/1", strings.NewReader("data"), 4))
+		_, err = store.Get(ctx, "../1")
+		assert.Equal(t, ErrUnknown, err)
+		assert.Equal(t, ErrUnknown, store.Delete(ctx, "../1"))
+		assert.Equal(t, ErrUnknown, store.Rename(ctx, "../1", "5"))
+		assert.Equal(t, ErrUnknown, store.Rename(ctx, "5", "/1"))
+		assert.Equal(t, ErrUnknown, store.Put(ctx, "1", strings.NewReader("data"), 5), "short data")
+	}
+}
+
+func TestBlobFileStorage(t *testing.T) {
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("recordMetadata", "file"))
+
+	for name, store := range blobStores(t) {
+		t.Log(name)
+		storage := newBlobFileStorage(store)
+
+		id, err := storage.CreateRecord(ctx, userdata.Record{ID: "1", Type: userdata.TypeFile, Data: []byte("text")})
+		assert.NoError(t, err)
+		assert.Equal(t, "1", id)
+
+		record, err := storage.GetRecord(ctx, "1")
+		assert.NoError(t, err)
+		assert.Equal(t, userdata.Record{ID: "1", Metadata: "file", Type: userdata.TypeFile, Data: []byte("text")}, record)
+
+		staged, err := storage.StageDelete(ctx, "1")
+		assert.NoError(t, err)
+		_, err = storage.GetRecord(ctx, "1")
+		assert.Equal(t, ErrNotFound, err)
+		assert.NoError(t, storage.CommitRecord(ctx, staged, "1"))
+
+		orphan, err := storage.StageRecord(ctx, []byte("orphan"))
+		assert.NoError(t, err)
+
+		files, err := storage.ListFiles(ctx)
+		assert.NoError(t, err)
+		assert.Len(t, files, 2)
+		for _, file := range files {
+			assert.Equal(t, file.Name == orphan, file.Staged)
+		}
+
+		assert.NoError(t, storage.QuarantineFile(ctx, orphan))
+		assert.Equal(t, "orphan", readBlob(t, store, quarantineDirectory+"/"+orphan))
+		assert.Equal(t, ErrNotFound, storage.QuarantineFile(ctx, orphan))
+
+		assert.NoError(t, storage.DeleteRecord(ctx, "1"))
+		assert.Equal(t, ErrNotFound, storage.DeleteRecord(ctx, "1"))
+
+		files, err = storage.ListFiles(ctx)
+		assert.NoError(t, err)
+		assert.Empty(t, files)
+	}
+}
@@ -0,0 +1,181 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/impr0ver/gophKeeper/internal/userdata"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ListUsers gets all users with their records count and usage.
+func (ds *dbStorage) ListUsers(ctx context.Context) ([]userdata.UserInfo, error) {
+	rows, err := ds.DB.QueryContext(ctx, `SELECT u.user_id, u.login, u.is_admin, u.disabled, COALESCE(u.quota_bytes, 0), COUNT(d.record_id), COALESCE(SUM(d.data_size), 0) FROM users u LEFT JOIN data d ON d.user_id = u.user_id GROUP BY u.user_id ORDER BY u.login`)
+	if err != nil {
+		log.Infoln(err)
+
+		return nil, ErrUnknown
+	}
+
+	defer rows.Close()
+
+	result := make([]userdata.UserInfo, 0, 10)
+
+	var row userdata.UserInfo
+	for rows.Next() {
+		if err := rows.Scan(&row.ID, &row.Login, &row.Admin, &row.Disabled, &row.Usage.Quota, &row.Usage.Records, &row.Usage.Bytes); err != nil {
+			log.Infoln(err)
+
+			return nil, ErrUnknown
+		}
+
+		result = append(result, row)
+	}
+
+	if rows.Err() != nil {
+		log.Println("Failed get rows in listing users:", rows.Err())
+		return nil, ErrUnknown
+	}
+
+	return result, nil
+}
+
+// GetStats gets users and records counters.
+func (ds *dbStorage) GetStats(ctx context.Context) (userdata.ServerStats, error) {
+	stats := userdata.ServerStats{RecordsByType: make(map[userdata.RecordType]int64)}
+
+	row := ds.DB.QueryRowContext(ctx, `SELECT COUNT(*), COUNT(*) FILTER (WHERE disabled), COUNT(*) FILTER (WHERE is_admin) FROM users`)
+	if err := row.Scan(&stats.Users, &stats.DisabledUsers, &stats.Admins); err != nil || row.Err() != nil {
+		log.Infoln(err)
+
+		return stats, ErrUnknown
+	}
+
+	rows, err := ds.DB.QueryContext(ctx, `SELECT record_type, COUNT(*), COALESCE(SUM(data_size), 0) FROM data GROUP BY record_type`)
+	if err != nil {
+		log.Infoln(err)
+
+		return stats, ErrUnknown
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			recordType userdata.RecordType
+			count      int64
+			size       int64
+		)
+		if err := rows.Scan(&recordType, &count, &size); err != nil {
+			log.Infoln(err)
+
+			return stats, ErrUnknown
+		}
+
+		stats.RecordsByType[recordType] = count
+		stats.Records += count
+		stats.StoredBytes += size
+	}
+
+	if rows.Err() != nil {
+		log.Println("Failed get rows in getting stats:", rows.Err())
+		return stats, ErrUnknown
+	}
+
+	return stats, nil
+}
+
+// IsAdmin checks if user has admin role.
+func (ds *dbStorage) IsAdmin(ctx context.Context, userID userdata.UserID) (bool, error) {
+	var admin, disabled bool
+
+	row := ds.DB.QueryRowContext(ctx, `SELECT is_admin, disabled FROM users WHERE user_id = $1`, userID)
+
+	err := row.Scan(&admin, &disabled)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, ErrUserNotFound
+	}
+	if err != nil || row.Err() != nil {
+		log.Infoln(err)
+
+		return false, ErrUnknown
+	}
+
+	return admin && !disabled, nil
+}
+
+// SetUserAdmin grants or revokes admin role of user.
+func (ds *dbStorage) SetUserAdmin(ctx context.Context, login string, admin bool) error {
+	return ds.updateUser(ctx, `UPDATE users SET is_admin = $2 WHERE login = $1`, login, admin)
+}
+
+// SetUserDisabled disables or enables user account.
+func (ds *dbStorage) SetUserDisabled(ctx context.Context, login string, disabled bool) error {
+	return ds.updateUser(ctx, `UPDATE users SET disabled = $2 WHERE login = $1`, login, disabled)
+}
+
+// SetUserQuota sets user storage quota in bytes, zero quota resets it to server default.
+func (ds *dbStorage) SetUserQuota(ctx context.Context, login string, quota int64) error {
+	return ds.updateUser(ctx, `UPDATE users SET quota_bytes = $2 WHERE login = $1`, login, sql.NullInt64{Int64: quota, Valid: quota > 0})
+}
+
+// RevokeTokens makes all tokens of user issued before now invalid.
+func (ds *dbStorage) RevokeTokens(ctx context.Context, login string) error {
+	return ds.updateUser(ctx, `UPDATE users SET tokens_valid_after = CURRENT_TIMESTAMP WHERE login = $1`, login)
+}
+
+// CheckSession checks that user is enabled and token was issued after last revoke.
+func (ds *dbStorage) CheckSession(userID userdata.UserID, issuedAt time.Time) error {
+	var (
+		disabled   bool
+		validAfter sql.NullTime
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	row := ds.DB.QueryRowContext(ctx, `SELECT disabled, tokens_valid_after FROM users WHERE user_id = $1`, userID)
+
+	err := row.Scan(&disabled, &validAfter)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrUnauthenticated
+	}
+	if err != nil || row.Err() != nil {
+		log.Infoln(err)
+
+		return ErrUnknown
+	}
+
+	if disabled {
+		return ErrUserDisabled
+	}
+
+	// JWT issue time has seconds precision
+	if validAfter.Valid && issuedAt.Before(validAfter.Time.Truncate(time.Second)) {
+		return ErrUnauthenticated
+	}
+
+	return nil
+}
+
+// updateUser executes update of user by login.
+func (ds *dbStorage) updateUser(ctx context.Context, query string, args ...interface{}) error {
+	result, err := ds.DB.ExecContext(ctx, query, args...)
+	if err != nil {
+		log.Infoln(err)
+
+		return ErrUnknown
+	}
+
+	if rowsAffected, err := result.RowsAffected(); err != nil {
+		log.Println("Failed get affected users:", err)
+		return ErrUnknown
+	} else if rowsAffected == 0 {
+		return ErrUserNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,251 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/impr0ver/gophKeeper/internal/userdata"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDBStorage_ListUsers(t *testing.T) {
+	storage := newDBStorage("", "")
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.NoError(t, err)
+	storage.DB = db
+
+	const query = `SELECT u.user_id, u.login, u.is_admin, u.disabled, COALESCE(u.quota_bytes, 0), COUNT(d.record_id), COALESCE(SUM(d.data_size), 0) FROM users u LEFT JOIN data d ON d.user_id = u.user_id GROUP BY u.user_id ORDER BY u.login`
+
+	tc := []struct {
+		name  string
+		mock  func()
+		valid func()
+	}{
+		{
+			"List users",
+			func() {
+				mock.ExpectQuery(query).WillReturnRows(
+					sqlmock.NewRows([]string{"user_id", "login", "is_admin", "disabled", "quota_bytes", "count", "sum"}).
+						AddRow("1", "alice", true, false, 0, 2, 100).
+						AddRow("2", "bob", false, true, 1000, 0, 0))
+			},
+			func() {
+				users, err := storage.ListUsers(context.Background())
+				assert.NoError(t, err)
+				assert.Equal(t, []userdata.UserInfo{
+					{ID: "1", Login: "alice", Admin: true, Usage: userdata.Usage{Records: 2, Bytes: 100}},
+					{ID: "2", Login: "bob", Disabled: true, Usage: userdata.Usage{Quota: 1000}},
+				}, users)
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		},
+		{
+			"List users, but DB will return error",
+			func() {
+				mock.ExpectQuery(query).WillReturnError(errors.New("some DB error"))
+			},
+			func() {
+				users, err := storage.ListUsers(context.Background())
+				assert.Equal(t, ErrUnknown, err)
+				assert.Empty(t, users)
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		},
+	}
+
+	for _, test := range tc {
+		t.Log(test.name)
+		test.mock()
+		test.valid()
+	}
+}
+
+func TestDBStorage_GetStats(t *testing.T) {
+	storage := newDBStorage("", "")
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.NoError(t, err)
+	storage.DB = db
+
+	mock.ExpectQuery(
+		`SELECT COUNT(*), COUNT(*) FILTER (WHERE disabled), COUNT(*) FILTER (WHERE is_admin) FROM users`,
+	).WillReturnRows(sqlmock.NewRows([]string{"count", "disabled", "admins"}).AddRow(3, 1, 1))
+	mock.ExpectQuery(
+		`SELECT record_type, COUNT(*), COALESCE(SUM(data_size), 0) FROM data GROUP BY record_type`,
+	).WillReturnRows(sqlmock.NewRows([]string{"record_type", "count", "sum"}).
+		AddRow(userdata.TypeText, 2, 10).
+		AddRow(userdata.TypeFile, 1, 1000))
+
+	stats, err := storage.GetStats(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, userdata.ServerStats{
+		Users:         3,
+		DisabledUsers: 1,
+		Admins:        1,
+		Records:       3,
+		StoredBytes:   1010,
+		RecordsByType: map[userdata.RecordType]int64{userdata.TypeText: 2, userdata.TypeFile: 1},
+	}, stats)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDBStorage_IsAdmin(t *testing.T) {
+	storage := newDBStorage("", "")
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.NoError(t, err)
+	storage.DB = db
+
+	const query = `SELECT is_admin, disabled FROM users WHERE user_id = $1`
+
+	tc := []struct {
+		name     string
+		rows     *sqlmock.Rows
+		expected bool
+		err      error
+	}{
+		{"Admin user", sqlmock.NewRows([]string{"is_admin", "disabled"}).AddRow(true, false), true, nil},
+		{"Disabled admin user", sqlmock.NewRows([]string{"is_admin", "disabled"}).AddRow(true, true), false, nil},
+		{"Regular user", sqlmock.NewRows([]string{"is_admin", "disabled"}).AddRow(false, false), false, nil},
+		{"Unknown user", sqlmock.NewRows([]string{"is_admin", "disabled"}), false, ErrUserNotFound},
+	}
+
+	for _, test := range tc {
+		t.Log(test.name)
+		mock.ExpectQuery(query).WithArgs("1").WillReturnRows(test.rows)
+
+		isAdmin, err := storage.IsAdmin(context.Background(), "1")
+		assert.Equal(t, test.err, err)
+		assert.Equal(t, test.expected, isAdmin)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	}
+}
+
+func TestDBStorage_UpdateUser(t *testing.T) {
+	storage := newDBStorage("", "")
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.NoError(t, err)
+	storage.DB = db
+
+	tc := []struct {
+		name  string
+		mock  func()
+		valid func()
+	}{
+		{
+			"Grant admin role",
+			func() {
+				mock.ExpectExec(`UPDATE users SET is_admin = $2 WHERE login = $1`).
+					WithArgs("alice", true).WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			func() {
+				assert.NoError(t, storage.SetUserAdmin(context.Background(), "alice", true))
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		},
+		{
+			"Disable unknown user",
+			func() {
+				mock.ExpectExec(`UPDATE users SET disabled = $2 WHERE login = $1`).
+					WithArgs("nobody", true).WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			func() {
+				assert.Equal(t, ErrUserNotFound, storage.SetUserDisabled(context.Background(), "nobody", true))
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		},
+		{
+			"Set user quota",
+			func() {
+				mock.ExpectExec(`UPDATE users SET quota_bytes = $2 WHERE login = $1`).
+					WithArgs("alice", sql.NullInt64{Int64: 1024, Valid: true}).WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			func() {
+				assert.NoError(t, storage.SetUserQuota(context.Background(), "alice", 1024))
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		},
+		{
+			"Reset user quota",
+			func() {
+				mock.ExpectExec(`UPDATE users SET quota_bytes = $2 WHERE login = $1`).
+					WithArgs("alice", sql.NullInt64{}).WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			func() {
+				assert.NoError(t, storage.SetUserQuota(context.Background(), "alice", 0))
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		},
+		{
+			"Revoke tokens, but DB will return error",
+			func() {
+				mock.ExpectExec(`UPDATE users SET tokens_valid_after = CURRENT_TIMESTAMP WHERE login = $1`).
+					WithArgs("alice").WillReturnError(errors.New("some DB error"))
+			},
+			func() {
+				assert.Equal(t, ErrUnknown, storage.RevokeTokens(context.Background(), "alice"))
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		},
+	}
+
+	for _, test := range tc {
+		t.Log(test.name)
+		test.mock()
+		test.valid()
+	}
+}
+
+func TestDBStorage_CheckSession(t *testing.T) {
+	storage := newDBStorage("", "")
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.NoError(t, err)
+	storage.DB = db
+
+	const query = `SELECT disabled, tokens_valid_after FROM users WHERE user_id = $1`
+
+	revoked := time.Now()
+
+	tc := []struct {
+		name     string
+		rows     *sqlmock.Rows
+		issuedAt time.Time
+		err      error
+	}{
+		{"Never revoked", sqlmock.NewRows([]string{"disabled", "tokens_valid_after"}).AddRow(false, nil), revoked, nil},
+		{"Issued after revoke", sqlmock.NewRows([]string{"disabled", "tokens_valid_after"}).AddRow(false, revoked), revoked.Add(time.Second), nil},
+		{"Issued before revoke", sqlmock.NewRows([]string{"disabled", "tokens_valid_after"}).AddRow(false, revoked), revoked.Add(-time.Minute), ErrUnauthenticated},
+		{"Disabled user", sqlmock.NewRows([]string{"disabled", "tokens_valid_after"}).AddRow(true, nil), revoked, ErrUserDisabled},
+		{"Deleted user", sqlmock.NewRows([]string{"disabled", "tokens_valid_after"}), revoked, ErrUnauthenticated},
+	}
+
+	for _, test := range tc {
+		t.Log(test.name)
+		mock.ExpectQuery(query).WithArgs("1").WillReturnRows(test.rows)
+
+		assert.Equal(t, test.err, storage.CheckSession("1", test.issuedAt))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	}
+}
+
+func TestDBStorage_GetUserUsage(t *testing.T) {
+	storage := newDBStorage("", "")
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.NoError(t, err)
+	storage.DB = db
+
+	_, err = storage.GetUserUsage(context.Background(), "")
+	assert.Equal(t, ErrUnauthenticated, err)
+
+	mock.ExpectQuery(
+		`SELECT COUNT(d.record_id), COALESCE(SUM(d.data_size), 0), COALESCE(u.quota_bytes, 0) FROM users u LEFT JOIN data d ON d.user_id = u.user_id WHERE u.user_id = $1 GROUP BY u.quota_bytes`,
+	).WithArgs("1").WillReturnRows(sqlmock.NewRows([]string{"count", "sum", "quota"}).AddRow(2, 100, 1000))
+
+	usage, err := storage.GetUserUsage(context.Background(), "1")
+	assert.NoError(t, err)
+	assert.Equal(t, userdata.Usage{Records: 2, Bytes: 100, Quota: 1000}, usage)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,545 @@
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/impr0ver/gophKeeper/internal/userdata"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// recordColumns is number of columns in multi-row insert of records.
+const recordColumns = 9
+
+// recordIDPattern matches UUID of record, other IDs can't be found and are not sent to DB.
+var recordIDPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// NewRecordID returns random UUID for record, so IDs of multi-row insert are known before it.
+// Client may choose ID of new record the same way to bind its data to ID.
+func NewRecordID() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+
+	return formatRecordID(id), nil
+}
+
+// DeriveRecordID returns UUID for record derived from parts, so the same record gets the same ID again.
+func DeriveRecordID(parts ...string) string {
+	h := sha256.New()
+	for _, part := range parts {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+
+	return formatRecordID(h.Sum(nil)[:16])
+}
+
+// formatRecordID formats 16 bytes as UUID of version 4.
+func formatRecordID(id []byte) string {
+	// Version 4, variant RFC 4122
+	id[6] = id[6]&0x0f | 0x40
+	id[8] = id[8]&0x3f | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", id[0:4], id[4:6], id[6:8], id[8:10], id[10:16])
+}
+
+// ValidRecordID checks if ID may be ID of record.
+func ValidRecordID(id string) bool {
+	return recordIDPattern.MatchString(id)
+}
+
+// newRecordID returns ID of new record: ID chosen by client or random one.
+func newRecordID(record userdata.Record) (string, error) {
+	if record.ID != "" {
+		return strings.ToLower(record.ID), nil
+	}
+
+	return NewRecordID()
+}
+
+// validRecordIDs returns IDs which may be found in DB.
+func validRecordIDs(recordIDs []string) []string {
+	valid := make([]string, 0, len(recordIDs))
+	for _, id := range recordIDs {
+		if recordIDPattern.MatchString(id) {
+			valid = append(valid, strings.ToLower(id))
+		}
+	}
+
+	return valid
+}
+
+// userRecordsArgs returns list of placeholders for record IDs, which follow user ID, and args of query.
+func userRecordsArgs(userID userdata.UserID, ids []string) (string, []interface{}) {
+	placeholders := make([]string, len(ids))
+	args := make([]interface{}, 0, len(ids)+1)
+	args = append(args, userID)
+
+	for i, id := range ids {
+		placeholders[i] = fmt.Sprintf("$%d", i+2)
+		args = append(args, id)
+	}
+
+	return "(" + strings.Join(placeholders, ", ") + ")", args
+}
+
+// rollback rollbacks transaction, if it's not committed.
+func rollback(tx *sql.Tx) {
+	if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+		log.Infoln(err)
+	}
+}
+
+// CreateRecords saves records to DB with one multi-row insert in transaction and returns their meta in the same order.
+// Records with used IDs aren't inserted and get ErrAlreadyExists, other records are saved.
+func (ds *dbStorage) CreateRecords(ctx context.Context, userID userdata.UserID, records []userdata.Record) ([]userdata.RecordResult, error) {
+	if userID == "" {
+		log.Println("Empty userID in creating records")
+		return nil, ErrUnauthenticated
+	}
+
+	results := make([]userdata.RecordResult, len(records))
+	if len(records) == 0 {
+		return results, nil
+	}
+
+	values := make([]string, 0, len(records))
+	args := make([]interface{}, 0, len(records)*recordColumns)
+	inserted := make(map[string]bool, len(records))
+
+	for i, record := range records {
+		id, err := newRecordID(record)
+		if err != nil {
+			log.Infoln(err)
+
+			return nil, ErrUnknown
+		}
+
+		results[i].ID = id
+
+		// The first record with ID is inserted, as it would be by separate inserts
+		if inserted[id] {
+			results[i].Err = ErrAlreadyExists
+			continue
+		}
+		inserted[id] = true
+
+		n := len(args)
+		values = append(values, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d)", n+1, n+2, n+3, n+4, n+5, n+6, n+7, n+8, n+9))
+		args = append(args, id, userID, record.Type, record.KeyHint, record.Metadata, record.Data, record.Size, record.Checksum, record.DataKey)
+	}
+
+	tx, err := ds.DB.BeginTx(ctx, nil)
+	if err != nil {
+		log.Infoln(err)
+
+		return nil, ErrUnknown
+	}
+	defer rollback(tx)
+
+	rows, err := tx.QueryContext(ctx, `INSERT INTO data (record_id, user_id, record_type, keyhint, metadata, crypted_data, data_size, checksum, data_key) VALUES `+strings.Join(values, ", ")+` ON CONFLICT (record_id) DO NOTHING RETURNING record_id, version, created_at, updated_at`, args...)
+	if err != nil {
+		log.Infoln(err)
+
+		return nil, ErrUnknown
+	}
+	defer rows.Close()
+
+	// Order of returned rows isn't guaranteed, they are matched by generated IDs, rows with used IDs aren't returned
+	created := make(map[string]userdata.Record, len(records))
+	for rows.Next() {
+		var record userdata.Record
+		if err := rows.Scan(&record.ID, &record.Version, &record.CreatedAt, &record.UpdatedAt); err != nil {
+			log.Infoln(err)
+
+			return nil, ErrUnknown
+		}
+
+		created[record.ID] = record
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Println("Failed get rows in creating records:", err)
+		return nil, ErrUnknown
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Infoln(err)
+
+		return nil, ErrUnknown
+	}
+
+	for i := range results {
+		if results[i].Err != nil {
+			continue
+		}
+
+		record, ok := created[results[i].ID]
+		if !ok {
+			results[i].Err = ErrAlreadyExists
+			continue
+		}
+
+		results[i].Record = record
+	}
+
+	return results, nil
+}
+
+// GetRecords gets records of user from DB in transaction, results are in order of IDs, missing records have ErrNotFound.
+func (ds *dbStorage) GetRecords(ctx context.Context, userID userdata.UserID, recordIDs []string) ([]userdata.RecordResult, error) {
+	if userID == "" {
+		log.Println("Empty userID in getting records")
+		return nil, ErrUnauthenticated
+	}
+
+	found := make(map[string]userdata.Record, len(recordIDs))
+
+	if ids := validRecordIDs(recordIDs); len(ids) > 0 {
+		tx, err := ds.DB.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+		if err != nil {
+			log.Infoln(err)
+
+			return nil, ErrUnknown
+		}
+		defer rollback(tx)
+
+		in, args := userRecordsArgs(userID, ids)
+		rows, err := tx.QueryContext(ctx, `SELECT record_id, record_type, keyhint, data_key, metadata, crypted_data, checksum, version, created_at, updated_at FROM data WHERE user_id = $1 AND record_id IN `+in, args...)
+		if err != nil {
+			log.Infoln(err)
+
+			return nil, ErrUnknown
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var record userdata.Record
+			if err := rows.Scan(&record.ID, &record.Type, &record.KeyHint, &record.DataKey, &record.Metadata, &record.Data, &record.Checksum, &record.Version, &record.CreatedAt, &record.UpdatedAt); err != nil {
+				log.Infoln(err)
+
+				return nil, ErrUnknown
+			}
+
+			found[record.ID] = record
+		}
+
+		if err := rows.Err(); err != nil {
+			log.Println("Failed get rows in getting records:", err)
+			return nil, ErrUnknown
+		}
+
+		if err := tx.Commit(); err != nil {
+			log.Infoln(err)
+
+			return nil, ErrUnknown
+		}
+	}
+
+	results := make([]userdata.RecordResult, len(recordIDs))
+	for i, id := range recordIDs {
+		results[i].ID = id
+
+		record, ok := found[strings.ToLower(id)]
+		if !ok {
+			results[i].Err = ErrNotFound
+			continue
+		}
+
+		results[i].Record = record
+	}
+
+	return results, nil
+}
+
+// DeleteRecords deletes records of user from DB in transaction, results are in order of IDs, missing records have ErrNotFound.
+func (ds *dbStorage) DeleteRecords(ctx context.Context, userID userdata.UserID, recordIDs []string) ([]userdata.RecordResult, error) {
+	return ds.DeleteRecordsTx(ctx, userID, recordIDs, func([]string) error { return nil })
+}
+
+// DeleteRecordsTx deletes records in transaction, which is committed only if commit of deleted IDs succeeds.
+func (ds *dbStorage) DeleteRecordsTx(ctx context.Context, userID userdata.UserID, recordIDs []string, commit func(deleted []string) error) ([]userdata.RecordResult, error) {
+	if userID == "" {
+		log.Println("Empty userID in deleting records")
+		return nil, ErrUnauthenticated
+	}
+
+	deleted := make(map[string]bool, len(recordIDs))
+
+	if ids := validRecordIDs(recordIDs); len(ids) > 0 {
+		tx, err := ds.DB.BeginTx(ctx, nil)
+		if err != nil {
+			log.Infoln(err)
+
+			return nil, ErrUnknown
+		}
+		defer rollback(tx)
+
+		in, args := userRecordsArgs(userID, ids)
+		rows, err := tx.QueryContext(ctx, `DELETE FROM data WHERE user_id = $1 AND record_id IN `+in+` RETURNING record_id`, args...)
+		if err != nil {
+			log.Infoln(err)
+
+			return nil, ErrUnknown
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var id string
+			if err := rows.Scan(&id); err != nil {
+				log.Infoln(err)
+
+				return nil, ErrUnknown
+			}
+
+			deleted[id] = true
+		}
+
+		if err := rows.Err(); err != nil {
+			log.Println("Failed get rows in deleting records:", err)
+			return nil, ErrUnknown
+		}
+
+		ids := make([]string, 0, len(deleted))
+		for id := range deleted {
+			ids = append(ids, id)
+		}
+
+		if err := commit(ids); err != nil {
+			return nil, err
+		}
+
+		if err := tx.Commit(); err != nil {
+			log.Infoln(err)
+
+			return nil, ErrUnknown
+		}
+	}
+
+	results := make([]userdata.RecordResult, len(recordIDs))
+	for i, id := range recordIDs {
+		results[i].ID = id
+
+		if !deleted[strings.ToLower(id)] {
+			results[i].Err = ErrNotFound
+		}
+	}
+
+	return results, nil
+}
+
+// replacedRecord is saved state of record, which is checked before its data is replaced.
+type replacedRecord struct {
+	recordType userdata.RecordType
+	size       int64
+	version    int64
+}
+
+// ReplaceRecords replaces data and key hints of records in one transaction.
+func (ds *dbStorage) ReplaceRecords(ctx context.Context, userID userdata.UserID, records []userdata.Record) ([]userdata.RecordResult, error) {
+	return ds.ReplaceRecordsTx(ctx, userID, records, func(int64) error { return nil })
+}
+
+// ReplaceRecordsTx replaces data and key hints of records in transaction, which is committed only if commit succeeds.
+// Batch is replaced entirely or not at all: ErrNotFound is returned if any record is missing and ErrVersionConflict
+// if any record has other type or version. Commit gets growth of data size in bytes.
+func (ds *dbStorage) ReplaceRecordsTx(ctx context.Context, userID userdata.UserID, records []userdata.Record, commit func(growth int64) error) ([]userdata.RecordResult, error) {
+	if userID == "" {
+		log.Println("Empty userID in replacing records")
+		return nil, ErrUnauthenticated
+	}
+
+	if len(records) == 0 {
+		return []userdata.RecordResult{}, nil
+	}
+
+	ids, err := replacedRecordIDs(records)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := ds.DB.BeginTx(ctx, nil)
+	if err != nil {
+		log.Infoln(err)
+
+		return nil, ErrUnknown
+	}
+	defer rollback(tx)
+
+	growth, err := checkReplacedRecords(ctx, tx, userID, records, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := updateRecords(ctx, tx, records, `UPDATE data SET keyhint = $1, data_key = $2, crypted_data = $3, data_size = $4, checksum = $5, version = version + 1, updated_at = CURRENT_TIMESTAMP WHERE user_id = $6 AND record_id = $7 AND version = $8 RETURNING record_id, version, created_at, updated_at`,
+		func(i int, record userdata.Record) []interface{} {
+			return []interface{}{record.KeyHint, record.DataKey, record.Data, record.Size, record.Checksum, userID, ids[i], record.Version}
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := commit(growth); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Infoln(err)
+
+		return nil, ErrUnknown
+	}
+
+	return results, nil
+}
+
+// RewrapRecords replaces key hints and wrapped data keys of records in one transaction, data of records isn't changed.
+// Batch is checked the same way as in ReplaceRecordsTx.
+func (ds *dbStorage) RewrapRecords(ctx context.Context, userID userdata.UserID, records []userdata.Record) ([]userdata.RecordResult, error) {
+	if userID == "" {
+		log.Println("Empty userID in rewrapping records")
+		return nil, ErrUnauthenticated
+	}
+
+	if len(records) == 0 {
+		return []userdata.RecordResult{}, nil
+	}
+
+	ids, err := replacedRecordIDs(records)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := ds.DB.BeginTx(ctx, nil)
+	if err != nil {
+		log.Infoln(err)
+
+		return nil, ErrUnknown
+	}
+	defer rollback(tx)
+
+	if _, err := checkReplacedRecords(ctx, tx, userID, records, ids); err != nil {
+		return nil, err
+	}
+
+	results, err := updateRecords(ctx, tx, records, `UPDATE data SET keyhint = $1, data_key = $2, version = version + 1, updated_at = CURRENT_TIMESTAMP WHERE user_id = $3 AND record_id = $4 AND version = $5 RETURNING record_id, version, created_at, updated_at`,
+		func(i int, record userdata.Record) []interface{} {
+			return []interface{}{record.KeyHint, record.DataKey, userID, ids[i], record.Version}
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Infoln(err)
+
+		return nil, ErrUnknown
+	}
+
+	return results, nil
+}
+
+// replacedRecordIDs returns IDs of replaced records in lower case, ErrNotFound is returned if any ID is invalid.
+func replacedRecordIDs(records []userdata.Record) ([]string, error) {
+	ids := make([]string, len(records))
+	for i, record := range records {
+		ids[i] = record.ID
+	}
+
+	valid := validRecordIDs(ids)
+	if len(valid) != len(ids) {
+		return nil, ErrNotFound
+	}
+
+	return valid, nil
+}
+
+// checkReplacedRecords checks that all records exist with the same type and version and returns growth of data size.
+func checkReplacedRecords(ctx context.Context, tx *sql.Tx, userID userdata.UserID, records []userdata.Record, ids []string) (int64, error) {
+	saved, err := selectReplacedRecords(ctx, tx, userID, ids)
+	if err != nil {
+		return 0, err
+	}
+
+	var growth int64
+	for i, record := range records {
+		old, ok := saved[ids[i]]
+		if !ok {
+			return 0, ErrNotFound
+		}
+
+		if old.recordType != record.Type || old.version != record.Version {
+			return 0, ErrVersionConflict
+		}
+
+		growth += record.Size - old.size
+	}
+
+	return growth, nil
+}
+
+// updateRecords runs update query with args of each record, query returns meta of updated record.
+func updateRecords(ctx context.Context, tx *sql.Tx, records []userdata.Record, query string, args func(i int, record userdata.Record) []interface{}) ([]userdata.RecordResult, error) {
+	results := make([]userdata.RecordResult, len(records))
+	for i, record := range records {
+		var meta userdata.Record
+		if err := tx.QueryRowContext(ctx, query, args(i, record)...).Scan(&meta.ID, &meta.Version, &meta.CreatedAt, &meta.UpdatedAt); err != nil {
+			// Record is replaced twice in batch
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, ErrVersionConflict
+			}
+
+			log.Infoln(err)
+
+			return nil, ErrUnknown
+		}
+
+		results[i] = userdata.RecordResult{ID: record.ID, Record: meta}
+	}
+
+	return results, nil
+}
+
+// selectReplacedRecords returns type, size and version of records of user by IDs.
+func selectReplacedRecords(ctx context.Context, tx *sql.Tx, userID userdata.UserID, ids []string) (map[string]replacedRecord, error) {
+	in, args := userRecordsArgs(userID, ids)
+	rows, err := tx.QueryContext(ctx, `SELECT record_id, record_type, data_size, version FROM data WHERE user_id = $1 AND record_id IN `+in, args...)
+	if err != nil {
+		log.Infoln(err)
+
+		return nil, ErrUnknown
+	}
+	defer rows.Close()
+
+	saved := make(map[string]replacedRecord, len(ids))
+	for rows.Next() {
+		var (
+			id     string
+			record replacedRecord
+		)
+		if err := rows.Scan(&id, &record.recordType, &record.size, &record.version); err != nil {
+			log.Infoln(err)
+
+			return nil, ErrUnknown
+		}
+
+		saved[id] = record
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Println("Failed get rows in replacing records:", err)
+		return nil, ErrUnknown
+	}
+
+	return saved, nil
+}
@@ -0,0 +1,488 @@
+package storage
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/impr0ver/gophKeeper/internal/userdata"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+// generatedID matches generated record ID and copies it to bytes returned by mocked insert.
+type generatedID []byte
+
+func (id generatedID) Match(v driver.Value) bool {
+	s, ok := v.(string)
+
+	return ok && copy(id, s) == len(recordID1)
+}
+
+const (
+	recordID1 = "6f1c1f4e-3f7a-4b8e-9a53-0c2f5d8e1a01"
+	recordID2 = "6f1c1f4e-3f7a-4b8e-9a53-0c2f5d8e1a02"
+)
+
+func TestNewRecordID(t *testing.T) {
+	id, err := NewRecordID()
+	assert.NoError(t, err)
+	assert.Regexp(t, recordIDPattern, id)
+
+	other, err := NewRecordID()
+	assert.NoError(t, err)
+	assert.NotEqual(t, id, other)
+
+	assert.Equal(t, []string{recordID1}, validRecordIDs([]string{"bad", recordID1, ""}))
+
+	// Derived ID is the same for the same parts
+	derived := DeriveRecordID("user", recordID1)
+	assert.Regexp(t, recordIDPattern, derived)
+	assert.Equal(t, derived, DeriveRecordID("user", recordID1))
+	assert.NotEqual(t, derived, DeriveRecordID("other", recordID1))
+	assert.NotEqual(t, DeriveRecordID("ab", "c"), DeriveRecordID("a", "bc"))
+}
+
+func TestDBStorage_CreateRecords(t *testing.T) {
+	storage := newDBStorage("", "")
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.NoError(t, err)
+	storage.DB = db
+
+	const insertQuery = `INSERT INTO data (record_id, user_id, record_type, keyhint, metadata, crypted_data, data_size, checksum, data_key) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9), ($10, $11, $12, $13, $14, $15, $16, $17, $18) ON CONFLICT (record_id) DO NOTHING RETURNING record_id, version, created_at, updated_at`
+
+	columns := []string{"record_id", "version", "created_at", "updated_at"}
+
+	records := []userdata.Record{
+		{Type: userdata.TypeText, KeyHint: "hint", Metadata: "text", Data: []byte{1, 2}, Size: 2, DataKey: []byte{3}},
+		{Type: userdata.TypeFile, KeyHint: "hint", Metadata: "file", Size: 3, Checksum: "abc"},
+	}
+
+	tc := []struct {
+		name  string
+		mock  func()
+		valid func()
+	}{
+		{
+			"Create records with one insert",
+			func() {
+				id1, id2 := make(generatedID, len(recordID1)), make(generatedID, len(recordID1))
+				mock.ExpectBegin()
+				mock.ExpectQuery(insertQuery).WithArgs(
+					id1, userdata.UserID("1"), userdata.TypeText, "hint", "text", []byte{1, 2}, int64(2), "", []byte{3},
+					id2, userdata.UserID("1"), userdata.TypeFile, "hint", "file", []byte(nil), int64(3), "abc", []byte(nil),
+				).WillReturnRows(sqlmock.NewRows(columns).
+					AddRow([]byte(id2), 1, recordCreated, recordCreated).
+					AddRow([]byte(id1), 1, recordCreated, recordUpdated))
+				mock.ExpectCommit()
+			},
+			func() {
+				results, err := storage.CreateRecords(context.Background(), "1", records)
+				assert.NoError(t, err)
+				assert.Len(t, results, 2)
+				for _, result := range results {
+					assert.Regexp(t, recordIDPattern, result.ID)
+					assert.Equal(t, result.ID, result.Record.ID, "rows are matched by ID")
+					assert.Equal(t, int64(1), result.Record.Version)
+					assert.NoError(t, result.Err)
+				}
+				assert.NotEqual(t, results[0].ID, results[1].ID)
+				assert.Equal(t, recordUpdated, results[0].Record.UpdatedAt)
+				assert.Equal(t, recordCreated, results[1].Record.UpdatedAt)
+			},
+		},
+		{
+			"Record with used ID is not inserted, other one is created",
+			func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(insertQuery).WillReturnRows(sqlmock.NewRows(columns).AddRow(recordID2, 1, recordCreated, recordCreated))
+				mock.ExpectCommit()
+			},
+			func() {
+				chosen := []userdata.Record{{ID: strings.ToUpper(recordID1), Type: userdata.TypeText}, {ID: recordID2, Type: userdata.TypeText}}
+				results, err := storage.CreateRecords(context.Background(), "1", chosen)
+				assert.NoError(t, err)
+				assert.Equal(t, []userdata.RecordResult{
+					{ID: recordID1, Err: ErrAlreadyExists},
+					{ID: recordID2, Record: userdata.Record{ID: recordID2, Version: 1, CreatedAt: recordCreated, UpdatedAt: recordCreated}},
+				}, results)
+			},
+		},
+		{
+			"Record with the same ID in batch is not inserted",
+			func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`INSERT INTO data (record_id, user_id, record_type, keyhint, metadata, crypted_data, data_size, checksum, data_key) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) ON CONFLICT (record_id) DO NOTHING RETURNING record_id, version, created_at, updated_at`).
+					WillReturnRows(sqlmock.NewRows(columns).AddRow(recordID1, 1, recordCreated, recordCreated))
+				mock.ExpectCommit()
+			},
+			func() {
+				chosen := []userdata.Record{{ID: recordID1, Type: userdata.TypeText}, {ID: recordID1, Type: userdata.TypeFile}}
+				results, err := storage.CreateRecords(context.Background(), "1", chosen)
+				assert.NoError(t, err)
+				assert.NoError(t, results[0].Err)
+				assert.Equal(t, ErrAlreadyExists, results[1].Err)
+			},
+		},
+		{
+			"Insert error, transaction is rolled back",
+			func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(insertQuery).WillReturnError(errors.New("connection refused"))
+				mock.ExpectRollback()
+			},
+			func() {
+				results, err := storage.CreateRecords(context.Background(), "1", records)
+				assert.Equal(t, ErrUnknown, err)
+				assert.Nil(t, results)
+			},
+		},
+		{
+			"Empty batch",
+			func() {},
+			func() {
+				results, err := storage.CreateRecords(context.Background(), "1", nil)
+				assert.NoError(t, err)
+				assert.Empty(t, results)
+			},
+		},
+		{
+			"Without user",
+			func() {},
+			func() {
+				_, err := storage.CreateRecords(context.Background(), "", records)
+				assert.Equal(t, ErrUnauthenticated, err)
+			},
+		},
+	}
+
+	for _, test := range tc {
+		t.Log(test.name)
+		test.mock()
+		test.valid()
+		assert.NoError(t, mock.ExpectationsWereMet())
+	}
+}
+
+func TestDBStorage_GetRecords(t *testing.T) {
+	storage := newDBStorage("", "")
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.NoError(t, err)
+	storage.DB = db
+
+	const selectQuery = `SELECT record_id, record_type, keyhint, data_key, metadata, crypted_data, checksum, version, created_at, updated_at FROM data WHERE user_id = $1 AND record_id IN ($2, $3)`
+
+	tc := []struct {
+		name  string
+		mock  func()
+		valid func()
+	}{
+		{
+			"Get records in order of IDs, missing and invalid IDs are not found",
+			func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(selectQuery).WithArgs(userdata.UserID("1"), recordID2, recordID1).
+					WillReturnRows(sqlmock.NewRows([]string{"record_id", "record_type", "keyhint", "data_key", "metadata", "crypted_data", "checksum", "version", "created_at", "updated_at"}).
+						AddRow(recordID1, userdata.TypeText, "hint", []byte{3}, "text", []byte{1, 2}, "", 2, recordCreated, recordUpdated))
+				mock.ExpectCommit()
+			},
+			func() {
+				results, err := storage.GetRecords(context.Background(), "1", []string{recordID2, "bad", recordID1})
+				assert.NoError(t, err)
+				assert.Equal(t, []userdata.RecordResult{
+					{ID: recordID2, Err: ErrNotFound},
+					{ID: "bad", Err: ErrNotFound},
+					{ID: recordID1, Record: userdata.Record{
+						ID:        recordID1,
+						Type:      userdata.TypeText,
+						KeyHint:   "hint",
+						DataKey:   []byte{3},
+						Metadata:  "text",
+						Data:      []byte{1, 2},
+						Version:   2,
+						CreatedAt: recordCreated,
+						UpdatedAt: recordUpdated,
+					}},
+				}, results)
+			},
+		},
+		{
+			"Only invalid IDs, DB is not queried",
+			func() {},
+			func() {
+				results, err := storage.GetRecords(context.Background(), "1", []string{"bad"})
+				assert.NoError(t, err)
+				assert.Equal(t, []userdata.RecordResult{{ID: "bad", Err: ErrNotFound}}, results)
+			},
+		},
+		{
+			"DB error",
+			func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`SELECT record_id, record_type, keyhint, data_key, metadata, crypted_data, checksum, version, created_at, updated_at FROM data WHERE user_id = $1 AND record_id IN ($2)`).
+					WillReturnError(errors.New("connection refused"))
+				mock.ExpectRollback()
+			},
+			func() {
+				_, err := storage.GetRecords(context.Background(), "1", []string{recordID1})
+				assert.Equal(t, ErrUnknown, err)
+			},
+		},
+	}
+
+	for _, test := range tc {
+		t.Log(test.name)
+		test.mock()
+		test.valid()
+		assert.NoError(t, mock.ExpectationsWereMet())
+	}
+}
+
+func TestDBStorage_DeleteRecords(t *testing.T) {
+	storage := newDBStorage("", "")
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.NoError(t, err)
+	storage.DB = db
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`DELETE FROM data WHERE user_id = $1 AND record_id IN ($2, $3) RETURNING record_id`).WithArgs(userdata.UserID("1"), recordID1, recordID2).
+		WillReturnRows(sqlmock.NewRows([]string{"record_id"}).AddRow(recordID2))
+	mock.ExpectCommit()
+
+	results, err := storage.DeleteRecords(context.Background(), "1", []string{recordID1, recordID2})
+	assert.NoError(t, err)
+	assert.Equal(t, []userdata.RecordResult{{ID: recordID1, Err: ErrNotFound}, {ID: recordID2}}, results)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`DELETE FROM data WHERE user_id = $1 AND record_id IN ($2) RETURNING record_id`).WillReturnError(errors.New("connection refused"))
+	mock.ExpectRollback()
+
+	_, err = storage.DeleteRecords(context.Background(), "1", []string{recordID1})
+	assert.Equal(t, ErrUnknown, err)
+
+	_, err = storage.DeleteRecords(context.Background(), "", []string{recordID1})
+	assert.Equal(t, ErrUnauthenticated, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDBStorage_ReplaceRecords(t *testing.T) {
+	storage := newDBStorage("", "")
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.NoError(t, err)
+	storage.DB = db
+
+	const (
+		selectQuery = `SELECT record_id, record_type, data_size, version FROM data WHERE user_id = $1 AND record_id IN ($2, $3)`
+		updateQuery = `UPDATE data SET keyhint = $1, data_key = $2, crypted_data = $3, data_size = $4, checksum = $5, version = version + 1, updated_at = CURRENT_TIMESTAMP WHERE user_id = $6 AND record_id = $7 AND version = $8 RETURNING record_id, version, created_at, updated_at`
+	)
+
+	selectColumns := []string{"record_id", "record_type", "data_size", "version"}
+	updateColumns := []string{"record_id", "version", "created_at", "updated_at"}
+
+	records := []userdata.Record{
+		{ID: recordID1, Type: userdata.TypeText, KeyHint: "new", Data: []byte{1, 2, 3}, Size: 3, Version: 1},
+		{ID: recordID2, Type: userdata.TypeFile, KeyHint: "new", Size: 4, Checksum: "abc", Version: 2},
+	}
+
+	tc := []struct {
+		name  string
+		mock  func()
+		valid func()
+	}{
+		{
+			"Replace records in one transaction",
+			func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(selectQuery).WithArgs(userdata.UserID("1"), recordID1, recordID2).
+					WillReturnRows(sqlmock.NewRows(selectColumns).
+						AddRow(recordID2, userdata.TypeFile, 4, 2).
+						AddRow(recordID1, userdata.TypeText, 2, 1))
+				mock.ExpectQuery(updateQuery).WithArgs("new", []byte(nil), []byte{1, 2, 3}, int64(3), "", userdata.UserID("1"), recordID1, int64(1)).
+					WillReturnRows(sqlmock.NewRows(updateColumns).AddRow(recordID1, 2, recordCreated, recordUpdated))
+				mock.ExpectQuery(updateQuery).WithArgs("new", []byte(nil), []byte(nil), int64(4), "abc", userdata.UserID("1"), recordID2, int64(2)).
+					WillReturnRows(sqlmock.NewRows(updateColumns).AddRow(recordID2, 3, recordCreated, recordUpdated))
+				mock.ExpectCommit()
+			},
+			func() {
+				var growth int64
+				results, err := storage.ReplaceRecordsTx(context.Background(), "1", records, func(g int64) error {
+					growth = g
+
+					return nil
+				})
+				assert.NoError(t, err)
+				assert.Equal(t, int64(1), growth)
+				assert.Equal(t, []userdata.RecordResult{
+					{ID: recordID1, Record: userdata.Record{ID: recordID1, Version: 2, CreatedAt: recordCreated, UpdatedAt: recordUpdated}},
+					{ID: recordID2, Record: userdata.Record{ID: recordID2, Version: 3, CreatedAt: recordCreated, UpdatedAt: recordUpdated}},
+				}, results)
+			},
+		},
+		{
+			"Record has other version, nothing is replaced",
+			func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(selectQuery).
+					WillReturnRows(sqlmock.NewRows(selectColumns).
+						AddRow(recordID1, userdata.TypeText, 2, 1).
+						AddRow(recordID2, userdata.TypeFile, 4, 3))
+				mock.ExpectRollback()
+			},
+			func() {
+				_, err := storage.ReplaceRecords(context.Background(), "1", records)
+				assert.Equal(t, ErrVersionConflict, err)
+			},
+		},
+		{
+			"Record is missing, nothing is replaced",
+			func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(selectQuery).
+					WillReturnRows(sqlmock.NewRows(selectColumns).AddRow(recordID1, userdata.TypeText, 2, 1))
+				mock.ExpectRollback()
+			},
+			func() {
+				_, err := storage.ReplaceRecords(context.Background(), "1", records)
+				assert.Equal(t, ErrNotFound, err)
+			},
+		},
+		{
+			"Commit func fails, transaction is rolled back",
+			func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(selectQuery).
+					WillReturnRows(sqlmock.NewRows(selectColumns).
+						AddRow(recordID1, userdata.TypeText, 2, 1).
+						AddRow(recordID2, userdata.TypeFile, 4, 2))
+				mock.ExpectQuery(updateQuery).WillReturnRows(sqlmock.NewRows(updateColumns).AddRow(recordID1, 2, recordCreated, recordUpdated))
+				mock.ExpectQuery(updateQuery).WillReturnRows(sqlmock.NewRows(updateColumns).AddRow(recordID2, 3, recordCreated, recordUpdated))
+				mock.ExpectRollback()
+			},
+			func() {
+				_, err := storage.ReplaceRecordsTx(context.Background(), "1", records, func(int64) error { return ErrQuotaExceeded })
+				assert.Equal(t, ErrQuotaExceeded, err)
+			},
+		},
+		{
+			"Invalid ID is not found",
+			func() {},
+			func() {
+				_, err := storage.ReplaceRecords(context.Background(), "1", []userdata.Record{{ID: "bad"}})
+				assert.Equal(t, ErrNotFound, err)
+			},
+		},
+		{
+			"Without user",
+			func() {},
+			func() {
+				_, err := storage.ReplaceRecords(context.Background(), "", records)
+				assert.Equal(t, ErrUnauthenticated, err)
+			},
+		},
+	}
+
+	for _, test := range tc {
+		t.Log(test.name)
+		test.mock()
+		test.valid()
+		assert.NoError(t, mock.ExpectationsWereMet())
+	}
+}
+
+func TestDBStorage_RewrapRecords(t *testing.T) {
+	storage := newDBStorage("", "")
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.NoError(t, err)
+	storage.DB = db
+
+	const (
+		selectQuery = `SELECT record_id, record_type, data_size, version FROM data WHERE user_id = $1 AND record_id IN ($2)`
+		updateQuery = `UPDATE data SET keyhint = $1, data_key = $2, version = version + 1, updated_at = CURRENT_TIMESTAMP WHERE user_id = $3 AND record_id = $4 AND version = $5 RETURNING record_id, version, created_at, updated_at`
+	)
+
+	selectColumns := []string{"record_id", "record_type", "data_size", "version"}
+	updateColumns := []string{"record_id", "version", "created_at", "updated_at"}
+
+	records := []userdata.Record{{ID: recordID1, Type: userdata.TypeFile, KeyHint: "new", DataKey: []byte{1, 2}, Version: 1}}
+
+	tc := []struct {
+		name  string
+		mock  func()
+		valid func()
+	}{
+		{
+			"Rewrap records, data is not updated",
+			func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(selectQuery).WithArgs(userdata.UserID("1"), recordID1).
+					WillReturnRows(sqlmock.NewRows(selectColumns).AddRow(recordID1, userdata.TypeFile, 100, 1))
+				mock.ExpectQuery(updateQuery).WithArgs("new", []byte{1, 2}, userdata.UserID("1"), recordID1, int64(1)).
+					WillReturnRows(sqlmock.NewRows(updateColumns).AddRow(recordID1, 2, recordCreated, recordUpdated))
+				mock.ExpectCommit()
+			},
+			func() {
+				results, err := storage.RewrapRecords(context.Background(), "1", records)
+				assert.NoError(t, err)
+				assert.Equal(t, []userdata.RecordResult{
+					{ID: recordID1, Record: userdata.Record{ID: recordID1, Version: 2, CreatedAt: recordCreated, UpdatedAt: recordUpdated}},
+				}, results)
+			},
+		},
+		{
+			"Record has other version",
+			func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(selectQuery).WillReturnRows(sqlmock.NewRows(selectColumns).AddRow(recordID1, userdata.TypeFile, 100, 2))
+				mock.ExpectRollback()
+			},
+			func() {
+				_, err := storage.RewrapRecords(context.Background(), "1", records)
+				assert.Equal(t, ErrVersionConflict, err)
+			},
+		},
+		{
+			"Record is changed after select",
+			func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(selectQuery).WillReturnRows(sqlmock.NewRows(selectColumns).AddRow(recordID1, userdata.TypeFile, 100, 1))
+				mock.ExpectQuery(updateQuery).WillReturnRows(sqlmock.NewRows(updateColumns))
+				mock.ExpectRollback()
+			},
+			func() {
+				_, err := storage.RewrapRecords(context.Background(), "1", records)
+				assert.Equal(t, ErrVersionConflict, err)
+			},
+		},
+		{
+			"DB error",
+			func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(selectQuery).WillReturnError(errors.New("connection refused"))
+				mock.ExpectRollback()
+			},
+			func() {
+				_, err := storage.RewrapRecords(context.Background(), "1", records)
+				assert.Equal(t, ErrUnknown, err)
+			},
+		},
+		{
+			"Without user",
+			func() {},
+			func() {
+				_, err := storage.RewrapRecords(context.Background(), "", records)
+				assert.Equal(t, ErrUnauthenticated, err)
+			},
+		},
+	}
+
+	for _, test := range tc {
+		t.Log(test.name)
+		test.mock()
+		test.valid()
+		assert.NoError(t, mock.ExpectationsWereMet())
+	}
+}
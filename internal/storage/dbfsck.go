@@ -0,0 +1,40 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/impr0ver/gophKeeper/internal/userdata"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ListFileRecords returns IDs of all file records with their owners and update times.
+func (ds *dbStorage) ListFileRecords(ctx context.Context) (map[string]userdata.FileRecordInfo, error) {
+	rows, err := ds.DB.QueryContext(ctx, `SELECT record_id, user_id, updated_at FROM data WHERE record_type = $1`, userdata.TypeFile)
+	if err != nil {
+		log.Infoln(err)
+
+		return nil, ErrUnknown
+	}
+	defer rows.Close()
+
+	records := make(map[string]userdata.FileRecordInfo)
+	for rows.Next() {
+		var recordID string
+		var info userdata.FileRecordInfo
+		if err := rows.Scan(&recordID, &info.UserID, &info.UpdatedAt); err != nil {
+			log.Infoln(err)
+
+			return nil, ErrUnknown
+		}
+
+		records[recordID] = info
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Println("Failed get rows in listing file records:", err)
+		return nil, ErrUnknown
+	}
+
+	return records, nil
+}
@@ -0,0 +1,37 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/impr0ver/gophKeeper/internal/userdata"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDBStorage_ListFileRecords(t *testing.T) {
+	storage := newDBStorage("", "")
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.NoError(t, err)
+	storage.DB = db
+
+	const selectQuery = `SELECT record_id, user_id, updated_at FROM data WHERE record_type = $1`
+	updated := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	mock.ExpectQuery(selectQuery).WithArgs(userdata.TypeFile).
+		WillReturnRows(sqlmock.NewRows([]string{"record_id", "user_id", "updated_at"}).AddRow(recordID1, "1", updated).AddRow(recordID2, "2", updated))
+
+	records, err := storage.ListFileRecords(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]userdata.FileRecordInfo{recordID1: {UserID: "1", UpdatedAt: updated}, recordID2: {UserID: "2", UpdatedAt: updated}}, records)
+
+	mock.ExpectQuery(selectQuery).WillReturnError(errors.New("connection refused"))
+
+	_, err = storage.ListFileRecords(context.Background())
+	assert.Equal(t, ErrUnknown, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
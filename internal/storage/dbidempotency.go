@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/impr0ver/gophKeeper/internal/userdata"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ReserveIdempotencyKey saves new request by key, expired request with the same key is replaced.
+// If key is already used in window, saved request is returned and reserved is false.
+func (ds *dbStorage) ReserveIdempotencyKey(ctx context.Context, request userdata.IdempotentRequest, window time.Duration) (userdata.IdempotentRequest, bool, error) {
+	// Times are saved in UTC, so they are compared right in storages without time zones
+	now := time.Now().UTC()
+
+	row := ds.DB.QueryRowContext(ctx, `INSERT INTO idempotency_keys (scope, key, method, request_hash, created_at) VALUES ($1, $2, $3, $4, $5) ON CONFLICT (scope, key) DO UPDATE SET method = EXCLUDED.method, request_hash = EXCLUDED.request_hash, response = NULL, created_at = EXCLUDED.created_at WHERE idempotency_keys.created_at < $6 RETURNING created_at`,
+		request.Scope, request.Key, request.Method, request.RequestHash, now, now.Add(-window))
+
+	err := row.Scan(&request.CreatedAt)
+	if err == nil {
+		return request, true, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		log.Infoln(err)
+
+		return request, false, ErrUnknown
+	}
+
+	saved := userdata.IdempotentRequest{Scope: request.Scope, Key: request.Key}
+
+	row = ds.DB.QueryRowContext(ctx, `SELECT method, request_hash, response, created_at FROM idempotency_keys WHERE scope = $1 AND key = $2`, request.Scope, request.Key)
+
+	err = row.Scan(&saved.Method, &saved.RequestHash, &saved.Response, &saved.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Request was released between queries, client may retry
+		return saved, false, ErrNotFound
+	}
+	if err != nil || row.Err() != nil {
+		log.Infoln(err)
+
+		return saved, false, ErrUnknown
+	}
+
+	return saved, false, nil
+}
+
+// CompleteIdempotencyKey saves response of reserved request.
+func (ds *dbStorage) CompleteIdempotencyKey(ctx context.Context, request userdata.IdempotentRequest) error {
+	_, err := ds.DB.ExecContext(ctx, `UPDATE idempotency_keys SET response = $3 WHERE scope = $1 AND key = $2`, request.Scope, request.Key, request.Response)
+	if err != nil {
+		log.Infoln(err)
+
+		return ErrUnknown
+	}
+
+	return nil
+}
+
+// ReleaseIdempotencyKey deletes reserved request without response, so it may be done again.
+func (ds *dbStorage) ReleaseIdempotencyKey(ctx context.Context, request userdata.IdempotentRequest) error {
+	_, err := ds.DB.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE scope = $1 AND key = $2 AND response IS NULL`, request.Scope, request.Key)
+	if err != nil {
+		log.Infoln(err)
+
+		return ErrUnknown
+	}
+
+	return nil
+}
+
+// DeleteExpiredIdempotencyKeys deletes requests saved before time, returns number of deleted requests.
+func (ds *dbStorage) DeleteExpiredIdempotencyKeys(ctx context.Context, before time.Time) (int64, error) {
+	result, err := ds.DB.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE created_at < $1`, before.UTC())
+	if err != nil {
+		log.Infoln(err)
+
+		return 0, ErrUnknown
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		log.Println("Failed get deleted idempotency keys:", err)
+		return 0, ErrUnknown
+	}
+
+	return deleted, nil
+}
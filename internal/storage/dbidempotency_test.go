@@ -0,0 +1,119 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/impr0ver/gophKeeper/internal/userdata"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDBStorage_ReserveIdempotencyKey(t *testing.T) {
+	storage := newDBStorage("", "")
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.NoError(t, err)
+	storage.DB = db
+
+	const (
+		insertQuery = `INSERT INTO idempotency_keys (scope, key, method, request_hash, created_at) VALUES ($1, $2, $3, $4, $5) ON CONFLICT (scope, key) DO UPDATE SET method = EXCLUDED.method, request_hash = EXCLUDED.request_hash, response = NULL, created_at = EXCLUDED.created_at WHERE idempotency_keys.created_at < $6 RETURNING created_at`
+		selectQuery = `SELECT method, request_hash, response, created_at FROM idempotency_keys WHERE scope = $1 AND key = $2`
+	)
+
+	request := userdata.IdempotentRequest{Scope: "1", Key: "key", Method: "/rpc.Gokeeper/CreateRecord", RequestHash: "hash"}
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tc := []struct {
+		name  string
+		mock  func()
+		valid func()
+	}{
+		{
+			"New key is reserved",
+			func() {
+				mock.ExpectQuery(insertQuery).
+					WithArgs("1", "key", "/rpc.Gokeeper/CreateRecord", "hash", sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(created))
+			},
+			func() {
+				saved, reserved, err := storage.ReserveIdempotencyKey(context.Background(), request, time.Hour)
+				assert.NoError(t, err)
+				assert.True(t, reserved)
+				assert.Equal(t, created, saved.CreatedAt)
+			},
+		},
+		{
+			"Used key returns saved request",
+			func() {
+				mock.ExpectQuery(insertQuery).
+					WithArgs("1", "key", "/rpc.Gokeeper/CreateRecord", "hash", sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"created_at"}))
+				mock.ExpectQuery(selectQuery).WithArgs("1", "key").
+					WillReturnRows(sqlmock.NewRows([]string{"method", "request_hash", "response", "created_at"}).
+						AddRow("/rpc.Gokeeper/CreateRecord", "hash", []byte("response"), created))
+			},
+			func() {
+				saved, reserved, err := storage.ReserveIdempotencyKey(context.Background(), request, time.Hour)
+				assert.NoError(t, err)
+				assert.False(t, reserved)
+				assert.Equal(t, userdata.IdempotentRequest{
+					Scope:       "1",
+					Key:         "key",
+					Method:      "/rpc.Gokeeper/CreateRecord",
+					RequestHash: "hash",
+					Response:    []byte("response"),
+					CreatedAt:   created,
+				}, saved)
+			},
+		},
+		{
+			"DB error",
+			func() {
+				mock.ExpectQuery(insertQuery).
+					WithArgs("1", "key", "/rpc.Gokeeper/CreateRecord", "hash", sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnError(errors.New("connection refused"))
+			},
+			func() {
+				_, reserved, err := storage.ReserveIdempotencyKey(context.Background(), request, time.Hour)
+				assert.Equal(t, ErrUnknown, err)
+				assert.False(t, reserved)
+			},
+		},
+	}
+
+	for _, test := range tc {
+		t.Log(test.name)
+		test.mock()
+		test.valid()
+		assert.NoError(t, mock.ExpectationsWereMet())
+	}
+}
+
+func TestDBStorage_CompleteAndReleaseIdempotencyKey(t *testing.T) {
+	storage := newDBStorage("", "")
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.NoError(t, err)
+	storage.DB = db
+
+	request := userdata.IdempotentRequest{Scope: "1", Key: "key", Response: []byte("response")}
+
+	mock.ExpectExec(`UPDATE idempotency_keys SET response = $3 WHERE scope = $1 AND key = $2`).
+		WithArgs("1", "key", []byte("response")).WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, storage.CompleteIdempotencyKey(context.Background(), request))
+
+	mock.ExpectExec(`DELETE FROM idempotency_keys WHERE scope = $1 AND key = $2 AND response IS NULL`).
+		WithArgs("1", "key").WillReturnError(errors.New("connection refused"))
+	assert.Equal(t, ErrUnknown, storage.ReleaseIdempotencyKey(context.Background(), request))
+
+	before := time.Now().UTC()
+	mock.ExpectExec(`DELETE FROM idempotency_keys WHERE created_at < $1`).
+		WithArgs(before).WillReturnResult(sqlmock.NewResult(0, 3))
+	deleted, err := storage.DeleteExpiredIdempotencyKeys(context.Background(), before)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), deleted)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,39 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/impr0ver/gophKeeper/internal/userdata"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ListFileChecksums returns checksums of all file records by their IDs, files saved before checksums have empty ones.
+func (ds *dbStorage) ListFileChecksums(ctx context.Context) (map[string]string, error) {
+	rows, err := ds.DB.QueryContext(ctx, `SELECT record_id, checksum FROM data WHERE record_type = $1`, userdata.TypeFile)
+	if err != nil {
+		log.Infoln(err)
+
+		return nil, ErrUnknown
+	}
+	defer rows.Close()
+
+	checksums := make(map[string]string)
+	for rows.Next() {
+		var recordID, checksum string
+		if err := rows.Scan(&recordID, &checksum); err != nil {
+			log.Infoln(err)
+
+			return nil, ErrUnknown
+		}
+
+		checksums[recordID] = checksum
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Println("Failed get rows in listing file checksums:", err)
+		return nil, ErrUnknown
+	}
+
+	return checksums, nil
+}
@@ -0,0 +1,35 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/impr0ver/gophKeeper/internal/userdata"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDBStorage_ListFileChecksums(t *testing.T) {
+	storage := newDBStorage("", "")
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.NoError(t, err)
+	storage.DB = db
+
+	const selectQuery = `SELECT record_id, checksum FROM data WHERE record_type = $1`
+
+	mock.ExpectQuery(selectQuery).WithArgs(userdata.TypeFile).
+		WillReturnRows(sqlmock.NewRows([]string{"record_id", "checksum"}).AddRow(recordID1, "abc").AddRow(recordID2, ""))
+
+	checksums, err := storage.ListFileChecksums(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{recordID1: "abc", recordID2: ""}, checksums)
+
+	mock.ExpectQuery(selectQuery).WillReturnError(errors.New("connection refused"))
+
+	_, err = storage.ListFileChecksums(context.Background())
+	assert.Equal(t, ErrUnknown, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
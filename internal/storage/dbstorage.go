@@ -2,8 +2,8 @@ package storage
 
 import (
 	"context"
+	"crypto/rand"
 	"database/sql"
-	"encoding/hex"
 	"errors"
 	"fmt"
 	"time"
@@ -15,7 +15,6 @@ import (
 	"github.com/golang-migrate/migrate/v4/database/postgres"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 	log "github.com/sirupsen/logrus"
-	"google.golang.org/grpc/metadata"
 )
 
 // dbStorage for db storage.
@@ -68,72 +67,156 @@ func (ds *dbStorage) MigrateUP() {
 	}
 }
 
-// CreateUser saves to DB new user.
+// keySaltSize is size of random salt of user encryption key.
+const keySaltSize = 16
+
+// newKeySalt returns random salt of user encryption key.
+func newKeySalt() ([]byte, error) {
+	salt := make([]byte, keySaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	return salt, nil
+}
+
+// CreateUser saves to DB new user with random key salt.
 func (ds *dbStorage) CreateUser(credentials userdata.UserCredentials) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	row := ds.DB.QueryRowContext(ctx, `SELECT COUNT(*) FROM users WHERE login = $1`, credentials.Login)
+	salt, err := newKeySalt()
+	if err != nil {
+		log.Infoln(err)
 
-	sameLoginCounter := 0
-	err := row.Scan(&sameLoginCounter)
+		return ErrUnknown
+	}
 
-	if err != nil || row.Err() != nil {
+	// Unique index on login rejects the same login registered concurrently
+	result, err := ds.DB.ExecContext(ctx, `INSERT INTO users (login, password, key_salt) VALUES ($1, $2, $3) ON CONFLICT (login) DO NOTHING`, credentials.Login, credentials.Password, salt)
+	if err != nil {
 		log.Infoln(err)
 
 		return ErrUnknown
 	}
 
-	if sameLoginCounter > 0 {
+	if rowsAffected, err := result.RowsAffected(); err != nil {
+		log.Println("Failed get affected users:", err)
+		return ErrUnknown
+	} else if rowsAffected == 0 {
 		return ErrLoginExists
 	}
 
-	_, err = ds.DB.ExecContext(ctx, `INSERT INTO users (login, password) VALUES ($1, $2)`, credentials.Login, credentials.Password)
+	return nil
+}
+
+// LoginUser finds user by login and checks password with verify, saved hash is replaced by rehash if any.
+func (ds *dbStorage) LoginUser(login string, verify func(hash string) (string, error)) (userdata.UserID, error) {
+	var (
+		userID   userdata.UserID
+		hash     string
+		disabled bool
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	row := ds.DB.QueryRowContext(ctx, `SELECT user_id, password, disabled FROM users WHERE login = $1`, login)
+
+	err := row.Scan(&userID, &hash, &disabled)
+	if errors.Is(err, sql.ErrNoRows) {
+		log.Infoln(err)
+
+		return "", ErrWrongCredentials
+	}
+
+	if err != nil || row.Err() != nil {
+		log.Infoln(err)
+
+		return "", ErrUnknown
+	}
+
+	rehash, err := verify(hash)
 	if err != nil {
 		log.Infoln(err)
 
-		return ErrUnknown
+		return "", ErrWrongCredentials
 	}
 
-	return nil
+	if disabled {
+		return "", ErrUserDisabled
+	}
+
+	// Hash is replaced only if it is not changed concurrently, login succeeds anyway
+	if rehash != "" {
+		if _, err := ds.DB.ExecContext(ctx, `UPDATE users SET password = $1 WHERE user_id = $2 AND password = $3`, rehash, userID, hash); err != nil {
+			log.Warnf("%s :: %v", "rehash password error", err)
+		}
+	}
+
+	return userID, nil
 }
 
-// LoginUser check if credentials are valid and return userID.
-func (ds *dbStorage) LoginUser(credentials userdata.UserCredentials) (userdata.UserID, error) {
-	var userID userdata.UserID
+// GetPasswordHash gets saved password hash of user, ErrWrongCredentials is returned for unknown login.
+func (ds *dbStorage) GetPasswordHash(login string) (string, error) {
+	var hash string
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	row := ds.DB.QueryRowContext(ctx, `SELECT user_id FROM users WHERE login = $1 AND password = $2`, credentials.Login, credentials.Password)
+	row := ds.DB.QueryRowContext(ctx, `SELECT password FROM users WHERE login = $1`, login)
 
-	err := row.Scan(&userID)
+	err := row.Scan(&hash)
 	if errors.Is(err, sql.ErrNoRows) {
 		log.Infoln(err)
 
-		return userID, ErrWrongCredentials
+		return "", ErrWrongCredentials
 	}
 
 	if err != nil || row.Err() != nil {
 		log.Infoln(err)
 
-		return userID, ErrUnknown
+		return "", ErrUnknown
 	}
 
-	return userID, nil
+	return hash, nil
 }
 
-// GetRecordsInfo gets all DB record by userID.
-func (ds *dbStorage) GetRecordsInfo(ctx context.Context) ([]userdata.Record, error) {
-	md, ok := metadata.FromIncomingContext(ctx)
-	if !ok || len(md.Get("userID")) == 0 {
-		log.Println("Failed get userID from context in getting all records")
+// GetKeySalt gets salt of user encryption key.
+func (ds *dbStorage) GetKeySalt(ctx context.Context, userID userdata.UserID) ([]byte, error) {
+	if userID == "" {
+		log.Println("Empty userID in getting key salt")
+		return nil, ErrUnauthenticated
+	}
+
+	var salt []byte
+
+	row := ds.DB.QueryRowContext(ctx, `SELECT key_salt FROM users WHERE user_id = $1`, userID)
+
+	err := row.Scan(&salt)
+	if errors.Is(err, sql.ErrNoRows) {
+		log.Infoln(err)
+
 		return nil, ErrUnauthenticated
 	}
 
-	userID := userdata.UserID(md.Get("userID")[0])
+	if err != nil || row.Err() != nil {
+		log.Infoln(err)
+
+		return nil, ErrUnknown
+	}
+
+	return salt, nil
+}
+
+// GetRecordsInfo gets all DB record by userID.
+func (ds *dbStorage) GetRecordsInfo(ctx context.Context, userID userdata.UserID) ([]userdata.Record, error) {
+	if userID == "" {
+		log.Println("Empty userID in getting all records")
+		return nil, ErrUnauthenticated
+	}
 
-	rows, err := ds.DB.QueryContext(ctx, `SELECT record_id, record_type, keyhint, metadata FROM data WHERE user_id = $1`, userID)
+	rows, err := ds.DB.QueryContext(ctx, `SELECT record_id, record_type, keyhint, data_key, metadata, version, created_at, updated_at FROM data WHERE user_id = $1`, userID)
 	if err != nil {
 		log.Infoln(err)
 
@@ -146,7 +229,7 @@ func (ds *dbStorage) GetRecordsInfo(ctx context.Context) ([]userdata.Record, err
 
 	var row userdata.Record
 	for rows.Next() {
-		if err := rows.Scan(&row.ID, &row.Type, &row.KeyHint, &row.Metadata); err != nil {
+		if err := rows.Scan(&row.ID, &row.Type, &row.KeyHint, &row.DataKey, &row.Metadata, &row.Version, &row.CreatedAt, &row.UpdatedAt); err != nil {
 			log.Infoln(err)
 
 			return nil, ErrUnknown
@@ -163,54 +246,55 @@ func (ds *dbStorage) GetRecordsInfo(ctx context.Context) ([]userdata.Record, err
 	return result, nil
 }
 
-// CreateRecord saves new record to DB and return recordID.
-func (ds *dbStorage) CreateRecord(ctx context.Context, record userdata.Record) (string, error) {
-	md, ok := metadata.FromIncomingContext(ctx)
-	if !ok || len(md.Get("userID")) == 0 {
-		log.Println("Failed get userID from context in getting all records")
-		return "", ErrUnauthenticated
+// GetUserUsage gets count and size of user records and user quota.
+func (ds *dbStorage) GetUserUsage(ctx context.Context, userID userdata.UserID) (userdata.Usage, error) {
+	var usage userdata.Usage
+	if userID == "" {
+		log.Println("Empty userID in getting user usage")
+		return usage, ErrUnauthenticated
 	}
 
-	userID := userdata.UserID(md.Get("userID")[0])
-
-	hexDataString := hex.EncodeToString(record.Data)
+	row := ds.DB.QueryRowContext(ctx, `SELECT COUNT(d.record_id), COALESCE(SUM(d.data_size), 0), COALESCE(u.quota_bytes, 0) FROM users u LEFT JOIN data d ON d.user_id = u.user_id WHERE u.user_id = $1 GROUP BY u.quota_bytes`, userID)
 
-	row := ds.DB.QueryRowContext(ctx, `INSERT INTO data (user_id, record_type, keyhint, metadata, crypted_data) VALUES ($1, $2, $3, $4, $5) RETURNING record_id`,
-		userID,
-		record.Type,
-		record.KeyHint,
-		record.Metadata,
-		hexDataString,
-	)
+	err := row.Scan(&usage.Records, &usage.Bytes, &usage.Quota)
+	if errors.Is(err, sql.ErrNoRows) {
+		log.Infoln(err)
 
-	var recordID string
-	if err := row.Scan(&recordID); err != nil || row.Err() != nil {
+		return usage, ErrUnauthenticated
+	}
+	if err != nil || row.Err() != nil {
 		log.Infoln(err)
 
-		return "", ErrUnknown
+		return usage, ErrUnknown
 	}
 
-	return recordID, nil
+	return usage, nil
+}
+
+// CreateRecord saves new record to DB and return its ID, version and timestamps.
+func (ds *dbStorage) CreateRecord(ctx context.Context, userID userdata.UserID, record userdata.Record) (userdata.RecordMeta, error) {
+	if userID == "" {
+		log.Println("Empty userID in creating record")
+		return userdata.RecordMeta{}, ErrUnauthenticated
+	}
+
+	return insertRecord(ctx, ds.DB, userID, record)
 }
 
 // GetRecord gets record from DB by userID.
-func (ds *dbStorage) GetRecord(ctx context.Context, recordID string) (userdata.Record, error) {
+func (ds *dbStorage) GetRecord(ctx context.Context, userID userdata.UserID, recordID string) (userdata.Record, error) {
 	record := userdata.Record{}
-	md, ok := metadata.FromIncomingContext(ctx)
-	if !ok || len(md.Get("userID")) == 0 {
-		log.Println("Failed get userID from context in getting all records")
+	if userID == "" {
+		log.Println("Empty userID in getting record")
 		return record, ErrUnauthenticated
 	}
 
-	userID := userdata.UserID(md.Get("userID")[0])
-
-	row := ds.DB.QueryRowContext(ctx, `SELECT record_id, record_type, keyhint, metadata, crypted_data FROM data WHERE record_id = $1 AND user_id = $2`,
+	row := ds.DB.QueryRowContext(ctx, `SELECT record_id, record_type, keyhint, data_key, metadata, crypted_data, checksum, version, created_at, updated_at FROM data WHERE record_id = $1 AND user_id = $2`,
 		recordID,
 		userID,
 	)
 
-	var hexDataString string
-	err := row.Scan(&record.ID, &record.Type, &record.KeyHint, &record.Metadata, &hexDataString)
+	err := row.Scan(&record.ID, &record.Type, &record.KeyHint, &record.DataKey, &record.Metadata, &record.Data, &record.Checksum, &record.Version, &record.CreatedAt, &record.UpdatedAt)
 
 	if errors.Is(err, sql.ErrNoRows) {
 		log.Infoln(err)
@@ -223,39 +307,15 @@ func (ds *dbStorage) GetRecord(ctx context.Context, recordID string) (userdata.R
 		return record, ErrUnknown
 	}
 
-	record.Data, err = hex.DecodeString(hexDataString)
-	if err != nil {
-		log.Infoln(err)
-
-		return record, ErrUnknown
-	}
-
 	return record, nil
 }
 
 // DeleteRecord deletes record from DB by userID.
-func (ds *dbStorage) DeleteRecord(ctx context.Context, recordID string) error {
-	md, ok := metadata.FromIncomingContext(ctx)
-	if !ok || len(md.Get("userID")) == 0 {
-		log.Println("Failed get userID from context in getting all records")
+func (ds *dbStorage) DeleteRecord(ctx context.Context, userID userdata.UserID, recordID string) error {
+	if userID == "" {
+		log.Println("Empty userID in deleting record")
 		return ErrUnauthenticated
 	}
 
-	userID := userdata.UserID(md.Get("userID")[0])
-
-	result, err := ds.DB.ExecContext(ctx, `DELETE FROM data WHERE record_id = $1 AND user_id = $2`, recordID, userID)
-	if err != nil {
-		log.Infoln(err)
-
-		return ErrUnknown
-	}
-
-	if rowsAffected, err := result.RowsAffected(); err != nil {
-		log.Println("Failed get affected records:", err)
-		return ErrUnknown
-	} else if rowsAffected == 0 {
-		return ErrNotFound
-	}
-
-	return nil
+	return deleteRecord(ctx, ds.DB, userID, recordID)
 }
@@ -2,16 +2,16 @@ package storage
 
 import (
 	"context"
-	"encoding/hex"
+	"database/sql/driver"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/impr0ver/gophKeeper/internal/userdata"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	_ "github.com/jackc/pgx/v5/stdlib"
 	"github.com/stretchr/testify/assert"
-	"google.golang.org/grpc/metadata"
 )
 
 // Init the flags before start unittests
@@ -20,12 +20,27 @@ var _ = func() bool {
 	return true
 }()
 
+// recordCreated and recordUpdated are timestamps of records returned by mocked DB.
+var (
+	recordCreated = time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	recordUpdated = recordCreated.Add(time.Hour)
+)
+
 func TestNewDBStorage(t *testing.T) {
 	assert.NotPanics(t, func() {
 		NewDBStorage("", "")
 	})
 }
 
+// keySaltArg matches random key salt of new user.
+type keySaltArg struct{}
+
+func (keySaltArg) Match(v driver.Value) bool {
+	salt, ok := v.([]byte)
+
+	return ok && len(salt) == keySaltSize
+}
+
 func TestDBStorage_CreateUser(t *testing.T) {
 	storage := newDBStorage("", "")
 
@@ -41,14 +56,9 @@ func TestDBStorage_CreateUser(t *testing.T) {
 		{
 			"Create user with good credentials (doesn't exists)",
 			func() {
-				mock.ExpectQuery(
-					`SELECT COUNT(*) FROM users WHERE login = $1`,
-				).WithArgs("my_login").WillReturnRows(sqlmock.NewRows(
-					[]string{"count"}).AddRow(0),
-				)
 				mock.ExpectExec(
-					`INSERT INTO users (login, password) VALUES ($1, $2)`,
-				).WithArgs("my_login", "my_password").WillReturnResult(
+					`INSERT INTO users (login, password, key_salt) VALUES ($1, $2, $3) ON CONFLICT (login) DO NOTHING`,
+				).WithArgs("my_login", "my_password", keySaltArg{}).WillReturnResult(
 					sqlmock.NewResult(0, 1),
 				)
 			},
@@ -64,12 +74,9 @@ func TestDBStorage_CreateUser(t *testing.T) {
 		{
 			"Create user with good credentials (doesn't exists), but DB will return error",
 			func() {
-				mock.ExpectQuery(
-					`SELECT COUNT(*) FROM users WHERE login = $1`,
-				).WithArgs("my_login").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
 				mock.ExpectExec(
-					`INSERT INTO users (login, password) VALUES ($1, $2)`,
-				).WithArgs("my_login", "my_password").WillReturnError(errors.New("some DB error"))
+					`INSERT INTO users (login, password, key_salt) VALUES ($1, $2, $3) ON CONFLICT (login) DO NOTHING`,
+				).WithArgs("my_login", "my_password", keySaltArg{}).WillReturnError(errors.New("some DB error"))
 			},
 			func() {
 				err := storage.CreateUser(userdata.UserCredentials{
@@ -83,9 +90,11 @@ func TestDBStorage_CreateUser(t *testing.T) {
 		{
 			"Create user with good credentials (already exists)",
 			func() {
-				mock.ExpectQuery(
-					`SELECT COUNT(*) FROM users WHERE login = $1`,
-				).WithArgs("my_login").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+				mock.ExpectExec(
+					`INSERT INTO users (login, password, key_salt) VALUES ($1, $2, $3) ON CONFLICT (login) DO NOTHING`,
+				).WithArgs("my_login", "my_password", keySaltArg{}).WillReturnResult(
+					sqlmock.NewResult(0, 0),
+				)
 			},
 			func() {
 				err := storage.CreateUser(userdata.UserCredentials{
@@ -111,6 +120,17 @@ func TestDBStorage_LoginUser(t *testing.T) {
 	assert.NoError(t, err)
 	storage.DB = db
 
+	const selectQuery = `SELECT user_id, password, disabled FROM users WHERE login = $1`
+
+	columns := []string{"user_id", "password", "disabled"}
+
+	verify := func(rehash string, err error) func(string) (string, error) {
+		return func(hash string) (string, error) {
+			assert.Equal(t, "my_hash", hash)
+			return rehash, err
+		}
+	}
+
 	tc := []struct {
 		name  string
 		mock  func()
@@ -119,16 +139,42 @@ func TestDBStorage_LoginUser(t *testing.T) {
 		{
 			"Login user with good credentials",
 			func() {
-				mock.ExpectQuery(
-					`SELECT user_id FROM users WHERE login = $1 AND password = $2`,
-				).WithArgs("my_login", "my_password").WillReturnRows(
-					sqlmock.NewRows([]string{"user_id"}).AddRow("12345678-1234-5678-9123-123456789012"))
+				mock.ExpectQuery(selectQuery).WithArgs("my_login").WillReturnRows(
+					sqlmock.NewRows(columns).AddRow("12345678-1234-5678-9123-123456789012", "my_hash", false))
 			},
 			func() {
-				userID, err := storage.LoginUser(userdata.UserCredentials{
-					Login:    "my_login",
-					Password: "my_password",
-				})
+				userID, err := storage.LoginUser("my_login", verify("", nil))
+				assert.NoError(t, err)
+				assert.Equal(t, userdata.UserID("12345678-1234-5678-9123-123456789012"), userID)
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		},
+		{
+			"Login user with legacy hash, hash is replaced",
+			func() {
+				mock.ExpectQuery(selectQuery).WithArgs("my_login").WillReturnRows(
+					sqlmock.NewRows(columns).AddRow("12345678-1234-5678-9123-123456789012", "my_hash", false))
+				mock.ExpectExec(`UPDATE users SET password = $1 WHERE user_id = $2 AND password = $3`).
+					WithArgs("new_hash", userdata.UserID("12345678-1234-5678-9123-123456789012"), "my_hash").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			func() {
+				userID, err := storage.LoginUser("my_login", verify("new_hash", nil))
+				assert.NoError(t, err)
+				assert.Equal(t, userdata.UserID("12345678-1234-5678-9123-123456789012"), userID)
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		},
+		{
+			"Login user, but hash replacing fails",
+			func() {
+				mock.ExpectQuery(selectQuery).WithArgs("my_login").WillReturnRows(
+					sqlmock.NewRows(columns).AddRow("12345678-1234-5678-9123-123456789012", "my_hash", false))
+				mock.ExpectExec(`UPDATE users SET password = $1 WHERE user_id = $2 AND password = $3`).
+					WillReturnError(errors.New("some DB error"))
+			},
+			func() {
+				userID, err := storage.LoginUser("my_login", verify("new_hash", nil))
 				assert.NoError(t, err)
 				assert.Equal(t, userdata.UserID("12345678-1234-5678-9123-123456789012"), userID)
 				assert.NoError(t, mock.ExpectationsWereMet())
@@ -137,32 +183,48 @@ func TestDBStorage_LoginUser(t *testing.T) {
 		{
 			"Login user with good credentials, but DB will return error",
 			func() {
-				mock.ExpectQuery(
-					`SELECT user_id FROM users WHERE login = $1 AND password = $2`,
-				).WithArgs("my_login", "my_password").WillReturnError(errors.New("some DB error"))
+				mock.ExpectQuery(selectQuery).WithArgs("my_login").WillReturnError(errors.New("some DB error"))
 			},
 			func() {
-				userID, err := storage.LoginUser(userdata.UserCredentials{
-					Login:    "my_login",
-					Password: "my_password",
-				})
+				userID, err := storage.LoginUser("my_login", verify("", nil))
 				assert.Equal(t, ErrUnknown, err)
 				assert.Equal(t, userdata.UserID(""), userID)
 				assert.NoError(t, mock.ExpectationsWereMet())
 			},
 		},
 		{
-			"Login user with bad credentials",
+			"Login disabled user",
 			func() {
-				mock.ExpectQuery(
-					`SELECT user_id FROM users WHERE login = $1 AND password = $2`,
-				).WithArgs("my_login", "my_password").WillReturnRows(sqlmock.NewRows([]string{"user_id"}))
+				mock.ExpectQuery(selectQuery).WithArgs("my_login").WillReturnRows(
+					sqlmock.NewRows(columns).AddRow("12345678-1234-5678-9123-123456789012", "my_hash", true))
 			},
 			func() {
-				userID, err := storage.LoginUser(userdata.UserCredentials{
-					Login:    "my_login",
-					Password: "my_password",
-				})
+				userID, err := storage.LoginUser("my_login", verify("new_hash", nil))
+				assert.Equal(t, ErrUserDisabled, err)
+				assert.Equal(t, userdata.UserID(""), userID)
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		},
+		{
+			"Login user with wrong password",
+			func() {
+				mock.ExpectQuery(selectQuery).WithArgs("my_login").WillReturnRows(
+					sqlmock.NewRows(columns).AddRow("12345678-1234-5678-9123-123456789012", "my_hash", true))
+			},
+			func() {
+				userID, err := storage.LoginUser("my_login", verify("", errors.New("wrong password")))
+				assert.Equal(t, ErrWrongCredentials, err)
+				assert.Equal(t, userdata.UserID(""), userID)
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		},
+		{
+			"Login unknown user",
+			func() {
+				mock.ExpectQuery(selectQuery).WithArgs("my_login").WillReturnRows(sqlmock.NewRows(columns))
+			},
+			func() {
+				userID, err := storage.LoginUser("my_login", verify("", nil))
 				assert.Equal(t, ErrWrongCredentials, err)
 				assert.Equal(t, userdata.UserID(""), userID)
 				assert.NoError(t, mock.ExpectationsWereMet())
@@ -177,6 +239,57 @@ func TestDBStorage_LoginUser(t *testing.T) {
 	}
 }
 
+func TestDBStorage_GetKeySalt(t *testing.T) {
+	storage := newDBStorage("", "")
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.NoError(t, err)
+	storage.DB = db
+
+	const selectQuery = `SELECT key_salt FROM users WHERE user_id = $1`
+
+	mock.ExpectQuery(selectQuery).WithArgs(userdata.UserID("1")).WillReturnRows(sqlmock.NewRows([]string{"key_salt"}).AddRow([]byte{1, 2, 3}))
+	salt, err := storage.GetKeySalt(context.Background(), "1")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{1, 2, 3}, salt)
+
+	mock.ExpectQuery(selectQuery).WithArgs(userdata.UserID("2")).WillReturnRows(sqlmock.NewRows([]string{"key_salt"}))
+	_, err = storage.GetKeySalt(context.Background(), "2")
+	assert.Equal(t, ErrUnauthenticated, err)
+
+	mock.ExpectQuery(selectQuery).WillReturnError(errors.New("some DB error"))
+	_, err = storage.GetKeySalt(context.Background(), "1")
+	assert.Equal(t, ErrUnknown, err)
+
+	_, err = storage.GetKeySalt(context.Background(), "")
+	assert.Equal(t, ErrUnauthenticated, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDBStorage_GetPasswordHash(t *testing.T) {
+	storage := newDBStorage("", "")
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.NoError(t, err)
+	storage.DB = db
+
+	const selectQuery = `SELECT password FROM users WHERE login = $1`
+
+	mock.ExpectQuery(selectQuery).WithArgs("user").WillReturnRows(sqlmock.NewRows([]string{"password"}).AddRow("$srp6a$verifier"))
+	hash, err := storage.GetPasswordHash("user")
+	assert.NoError(t, err)
+	assert.Equal(t, "$srp6a$verifier", hash)
+
+	mock.ExpectQuery(selectQuery).WithArgs("missing").WillReturnRows(sqlmock.NewRows([]string{"password"}))
+	_, err = storage.GetPasswordHash("missing")
+	assert.Equal(t, ErrWrongCredentials, err)
+
+	mock.ExpectQuery(selectQuery).WillReturnError(errors.New("some DB error"))
+	_, err = storage.GetPasswordHash("user")
+	assert.Equal(t, ErrUnknown, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestDBStorage_GetRecordsInfo(t *testing.T) {
 	storage := newDBStorage("", "")
 	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
@@ -192,7 +305,7 @@ func TestDBStorage_GetRecordsInfo(t *testing.T) {
 			"Get all info from unauthorized user",
 			func() {},
 			func() {
-				records, err := storage.GetRecordsInfo(context.Background())
+				records, err := storage.GetRecordsInfo(context.Background(), "")
 				assert.Equal(t, ErrUnauthenticated, err)
 				assert.Empty(t, records)
 				assert.NoError(t, mock.ExpectationsWereMet())
@@ -202,29 +315,35 @@ func TestDBStorage_GetRecordsInfo(t *testing.T) {
 			"Get all info from authorized user",
 			func() {
 				mock.ExpectQuery(
-					"SELECT record_id, record_type, keyhint, metadata FROM data WHERE user_id = $1",
+					"SELECT record_id, record_type, keyhint, data_key, metadata, version, created_at, updated_at FROM data WHERE user_id = $1",
 				).WithArgs("11111111-2222-33333-4444-555555555").WillReturnRows(
-					sqlmock.NewRows([]string{"record_id", "record_type", "keyhint", "metadata"}).AddRow("1", userdata.TypeLoginAndPassword, "keyhint", "login and password").AddRow("2", userdata.TypeText, "keyhint", "custom text"))
+					sqlmock.NewRows([]string{"record_id", "record_type", "keyhint", "data_key", "metadata", "version", "created_at", "updated_at"}).AddRow("1", userdata.TypeLoginAndPassword, "keyhint", []byte("wrapped"), "login and password", 1, recordCreated, recordCreated).AddRow("2", userdata.TypeText, "keyhint", nil, "custom text", 2, recordCreated, recordUpdated))
 			},
 			func() {
-				md := metadata.Pairs("userID", string("11111111-2222-33333-4444-555555555"))
-				ctx := metadata.NewIncomingContext(context.Background(), md)
+				ctx := context.Background()
 
-				records, err := storage.GetRecordsInfo(ctx)
+				records, err := storage.GetRecordsInfo(ctx, "11111111-2222-33333-4444-555555555")
 				assert.NoError(t, err)
 
 				assert.Equal(t, []userdata.Record{
 					{
-						ID:       "1",
-						Type:     userdata.TypeLoginAndPassword,
-						KeyHint:  "keyhint",
-						Metadata: "login and password",
+						ID:        "1",
+						Type:      userdata.TypeLoginAndPassword,
+						KeyHint:   "keyhint",
+						DataKey:   []byte("wrapped"),
+						Metadata:  "login and password",
+						Version:   1,
+						CreatedAt: recordCreated,
+						UpdatedAt: recordCreated,
 					},
 					{
-						ID:       "2",
-						Type:     userdata.TypeText,
-						KeyHint:  "keyhint",
-						Metadata: "custom text",
+						ID:        "2",
+						Type:      userdata.TypeText,
+						KeyHint:   "keyhint",
+						Metadata:  "custom text",
+						Version:   2,
+						CreatedAt: recordCreated,
+						UpdatedAt: recordUpdated,
 					},
 				}, records)
 
@@ -235,16 +354,15 @@ func TestDBStorage_GetRecordsInfo(t *testing.T) {
 			"Get all info from authorized user, but DB will return error",
 			func() {
 				mock.ExpectQuery(
-					"SELECT record_id, record_type, keyhint, metadata FROM data WHERE user_id = $1",
+					"SELECT record_id, record_type, keyhint, data_key, metadata, version, created_at, updated_at FROM data WHERE user_id = $1",
 				).WithArgs(
 					"11111111-2222-33333-4444-555555555",
 				).WillReturnError(errors.New("some DB error"))
 			},
 			func() {
-				md := metadata.Pairs("userID", string("11111111-2222-33333-4444-555555555"))
-				ctx := metadata.NewIncomingContext(context.Background(), md)
+				ctx := context.Background()
 		
-				records, err := storage.GetRecordsInfo(ctx)
+				records, err := storage.GetRecordsInfo(ctx, "11111111-2222-33333-4444-555555555")
 				assert.Equal(t, ErrUnknown, err)
 				assert.Empty(t, records)
 			},
@@ -273,7 +391,7 @@ func TestDBStorage_CreateRecord(t *testing.T) {
 			"Create record with unauthorized user",
 			func() {},
 			func() {
-				recordID, err := storage.CreateRecord(context.Background(), userdata.Record{})
+				recordID, err := storage.CreateRecord(context.Background(), "", userdata.Record{})
 				assert.Equal(t, ErrUnauthenticated, err)
 				assert.Empty(t, recordID)
 				assert.NoError(t, mock.ExpectationsWereMet())
@@ -283,27 +401,34 @@ func TestDBStorage_CreateRecord(t *testing.T) {
 			"Create record with authorized user",
 			func() {
 				mock.ExpectQuery(
-					"INSERT INTO data (user_id, record_type, keyhint, metadata, crypted_data) VALUES ($1, $2, $3, $4, $5) RETURNING record_id",
+					"INSERT INTO data (record_id, user_id, record_type, keyhint, metadata, crypted_data, data_size, checksum, data_key) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) ON CONFLICT (record_id) DO NOTHING RETURNING record_id, version, created_at, updated_at",
 				).WithArgs(
+					"0f0e0d0c-0b0a-4908-8706-050403020100",
 					"11111111-2222-33333-4444-555555555",
 					userdata.TypeText,
 					"keyhint",
 					"my text",
-					hex.EncodeToString([]byte("hello!")),
-				).WillReturnRows(sqlmock.NewRows([]string{"record_id"}).AddRow("1"))
+					[]byte("hello!"),
+					int64(6),
+					"",
+					[]byte("wrapped"),
+				).WillReturnRows(sqlmock.NewRows([]string{"record_id", "version", "created_at", "updated_at"}).AddRow("1", 1, recordCreated, recordCreated))
 			},
 			func() {
-				md := metadata.Pairs("userID", string("11111111-2222-33333-4444-555555555"))
-				ctx := metadata.NewIncomingContext(context.Background(), md)
+				ctx := context.Background()
 				
-				recordID, err := storage.CreateRecord(ctx, userdata.Record{
+				// ID chosen by client is saved in lower case
+				recordID, err := storage.CreateRecord(ctx, "11111111-2222-33333-4444-555555555", userdata.Record{
+					ID:       "0F0E0D0C-0B0A-4908-8706-050403020100",
 					KeyHint:  "keyhint",
 					Metadata: "my text",
 					Type:     userdata.TypeText,
 					Data:     []byte("hello!"),
+					Size:     6,
+					DataKey:  []byte("wrapped"),
 				})
 				assert.NoError(t, err)
-				assert.Equal(t, "1", recordID)
+				assert.Equal(t, userdata.RecordMeta{ID: "1", Version: 1, CreatedAt: recordCreated, UpdatedAt: recordCreated}, recordID)
 				assert.NoError(t, mock.ExpectationsWereMet())
 			},
 		},
@@ -311,24 +436,28 @@ func TestDBStorage_CreateRecord(t *testing.T) {
 			"Create record with authorized user, but DB will return error",
 			func() {
 				mock.ExpectQuery(
-					"INSERT INTO data (user_id, record_type, keyhint, metadata, crypted_data) VALUES ($1, $2, $3, $4, $5) RETURNING record_id",
+					"INSERT INTO data (record_id, user_id, record_type, keyhint, metadata, crypted_data, data_size, checksum, data_key) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) ON CONFLICT (record_id) DO NOTHING RETURNING record_id, version, created_at, updated_at",
 				).WithArgs(
+					sqlmock.AnyArg(),
 					"11111111-2222-33333-4444-555555555",
 					userdata.TypeText,
 					"keyhint",
 					"my text",
-					hex.EncodeToString([]byte("hello!")),
+					[]byte("hello!"),
+					int64(6),
+					"",
+					[]byte(nil),
 				).WillReturnError(errors.New("some DB error"))
 			},
 			func() {
-				md := metadata.Pairs("userID", string("11111111-2222-33333-4444-555555555"))
-				ctx := metadata.NewIncomingContext(context.Background(), md)
+				ctx := context.Background()
 				
-				recordID, err := storage.CreateRecord(ctx, userdata.Record{
+				recordID, err := storage.CreateRecord(ctx, "11111111-2222-33333-4444-555555555", userdata.Record{
 					KeyHint:  "keyhint",
 					Metadata: "my text",
 					Type:     userdata.TypeText,
 					Data:     []byte("hello!"),
+					Size:     6,
 				})
 				assert.Equal(t, ErrUnknown, err)
 				assert.Empty(t, recordID)
@@ -359,7 +488,7 @@ func TestDBStorage_GetRecord(t *testing.T) {
 			"Get record with unauthorized user",
 			func() {},
 			func() {
-				record, err := storage.GetRecord(context.Background(), "1")
+				record, err := storage.GetRecord(context.Background(), "", "1")
 				assert.Equal(t, ErrUnauthenticated, err)
 				assert.Empty(t, record)
 				assert.NoError(t, mock.ExpectationsWereMet())
@@ -369,24 +498,27 @@ func TestDBStorage_GetRecord(t *testing.T) {
 			"Get record with authorized user",
 			func() {
 				mock.ExpectQuery(
-					"SELECT record_id, record_type, keyhint, metadata, crypted_data FROM data WHERE record_id = $1 AND user_id = $2",
+					"SELECT record_id, record_type, keyhint, data_key, metadata, crypted_data, checksum, version, created_at, updated_at FROM data WHERE record_id = $1 AND user_id = $2",
 				).WithArgs(
 					"1", "11111111-2222-33333-4444-555555555",
 				).WillReturnRows(
-					sqlmock.NewRows([]string{"record_id", "record_type", "keyhint", "metadata", "crypted_data"}).AddRow("1", userdata.TypeText, "keyhint", "my text", hex.EncodeToString([]byte("hello!"))))
+					sqlmock.NewRows([]string{"record_id", "record_type", "keyhint", "data_key", "metadata", "crypted_data", "checksum", "version", "created_at", "updated_at"}).AddRow("1", userdata.TypeText, "keyhint", []byte("wrapped"), "my text", []byte("hello!"), "", 3, recordCreated, recordUpdated))
 			},
 			func() {
-				md := metadata.Pairs("userID", string("11111111-2222-33333-4444-555555555"))
-				ctx := metadata.NewIncomingContext(context.Background(), md)
+				ctx := context.Background()
 				
-				record, err := storage.GetRecord(ctx, "1")
+				record, err := storage.GetRecord(ctx, "11111111-2222-33333-4444-555555555", "1")
 				assert.NoError(t, err)
 				assert.Equal(t, userdata.Record{
-					ID:       "1",
-					Metadata: "my text",
-					KeyHint:  "keyhint",
-					Type:     userdata.TypeText,
-					Data:     []byte("hello!"),
+					ID:        "1",
+					Metadata:  "my text",
+					KeyHint:   "keyhint",
+					DataKey:   []byte("wrapped"),
+					Type:      userdata.TypeText,
+					Data:      []byte("hello!"),
+					Version:   3,
+					CreatedAt: recordCreated,
+					UpdatedAt: recordUpdated,
 				}, record)
 				assert.NoError(t, mock.ExpectationsWereMet())
 			},
@@ -395,16 +527,15 @@ func TestDBStorage_GetRecord(t *testing.T) {
 			"Get non existed record with authorized user",
 			func() {
 				mock.ExpectQuery(
-					"SELECT record_id, record_type, keyhint, metadata, crypted_data FROM data WHERE record_id = $1 AND user_id = $2",
+					"SELECT record_id, record_type, keyhint, data_key, metadata, crypted_data, checksum, version, created_at, updated_at FROM data WHERE record_id = $1 AND user_id = $2",
 				).WithArgs(
 					"1", "11111111-2222-33333-4444-555555555",
-				).WillReturnRows(sqlmock.NewRows([]string{"record_id", "record_type", "keyhint", "metadata", "crypted_data"}))
+				).WillReturnRows(sqlmock.NewRows([]string{"record_id", "record_type", "keyhint", "data_key", "metadata", "crypted_data", "checksum", "version", "created_at", "updated_at"}))
 			},
 			func() {
-				md := metadata.Pairs("userID", string("11111111-2222-33333-4444-555555555"))
-				ctx := metadata.NewIncomingContext(context.Background(), md)
+				ctx := context.Background()
 				
-				record, err := storage.GetRecord(ctx, "1")
+				record, err := storage.GetRecord(ctx, "11111111-2222-33333-4444-555555555", "1")
 				assert.Equal(t, ErrNotFound, err)
 				assert.Empty(t, record)
 				assert.NoError(t, mock.ExpectationsWereMet())
@@ -414,16 +545,15 @@ func TestDBStorage_GetRecord(t *testing.T) {
 			"Get record with authorized user, but DB will return error",
 			func() {
 				mock.ExpectQuery(
-					"SELECT record_id, record_type, keyhint, metadata, crypted_data FROM data WHERE record_id = $1 AND user_id = $2",
+					"SELECT record_id, record_type, keyhint, data_key, metadata, crypted_data, checksum, version, created_at, updated_at FROM data WHERE record_id = $1 AND user_id = $2",
 				).WithArgs(
 					"1", "11111111-2222-33333-4444-555555555",
 				).WillReturnError(errors.New("some DB error"))
 			},
 			func() {
-				md := metadata.Pairs("userID", string("11111111-2222-33333-4444-555555555"))
-				ctx := metadata.NewIncomingContext(context.Background(), md)
+				ctx := context.Background()
 				
-				record, err := storage.GetRecord(ctx, "1")
+				record, err := storage.GetRecord(ctx, "11111111-2222-33333-4444-555555555", "1")
 				assert.Equal(t, ErrUnknown, err)
 				assert.Empty(t, record)
 				assert.NoError(t, mock.ExpectationsWereMet())
@@ -453,7 +583,7 @@ func TestDBStorage_DeleteRecord(t *testing.T) {
 			"Delete record with unauthorized user",
 			func() {},
 			func() {
-				err := storage.DeleteRecord(context.Background(), "1")
+				err := storage.DeleteRecord(context.Background(), "", "1")
 				assert.Equal(t, ErrUnauthenticated, err)
 				assert.NoError(t, mock.ExpectationsWereMet())
 			},
@@ -468,10 +598,9 @@ func TestDBStorage_DeleteRecord(t *testing.T) {
 				).WillReturnResult(sqlmock.NewResult(0, 1))
 			},
 			func() {
-				md := metadata.Pairs("userID", string("11111111-2222-33333-4444-555555555"))
-				ctx := metadata.NewIncomingContext(context.Background(), md)
+				ctx := context.Background()
 				
-				err := storage.DeleteRecord(ctx, "1")
+				err := storage.DeleteRecord(ctx, "11111111-2222-33333-4444-555555555", "1")
 				assert.NoError(t, err)
 				assert.NoError(t, mock.ExpectationsWereMet())
 			},
@@ -486,10 +615,9 @@ func TestDBStorage_DeleteRecord(t *testing.T) {
 				).WillReturnError(errors.New("some DB error"))
 			},
 			func() {
-				md := metadata.Pairs("userID", string("11111111-2222-33333-4444-555555555"))
-				ctx := metadata.NewIncomingContext(context.Background(), md)
+				ctx := context.Background()
 			
-				err := storage.DeleteRecord(ctx, "1")
+				err := storage.DeleteRecord(ctx, "11111111-2222-33333-4444-555555555", "1")
 				assert.Equal(t, ErrUnknown, err)
 				assert.NoError(t, mock.ExpectationsWereMet())
 			},
@@ -504,10 +632,9 @@ func TestDBStorage_DeleteRecord(t *testing.T) {
 				).WillReturnResult(sqlmock.NewResult(0, 0))
 			},
 			func() {
-				md := metadata.Pairs("userID", string("11111111-2222-33333-4444-555555555"))
-				ctx := metadata.NewIncomingContext(context.Background(), md)
+				ctx := context.Background()
 				
-				err := storage.DeleteRecord(ctx, "1")
+				err := storage.DeleteRecord(ctx, "11111111-2222-33333-4444-555555555", "1")
 				assert.Equal(t, ErrNotFound, err)
 				assert.NoError(t, mock.ExpectationsWereMet())
 			},
@@ -0,0 +1,379 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/impr0ver/gophKeeper/internal/userdata"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testMigrations = "../../migrations"
+
+// testVerifier accepts only saved hash equal to want and returns rehash.
+func testVerifier(want, rehash string) func(string) (string, error) {
+	return func(hash string) (string, error) {
+		if hash != want {
+			return "", errors.New("wrong password")
+		}
+
+		return rehash, nil
+	}
+}
+
+// testDBStorage checks DB storage backend with real DB, the same suite is run for all backends.
+func testDBStorage(t *testing.T, db DataBaseStorager) {
+	ctx := context.Background()
+
+	// Logins are unique, so suite may be run on DB with data
+	login := "suite" + strconv.FormatInt(time.Now().UnixNano(), 10)
+	credentials := userdata.UserCredentials{Login: login, Password: "password"}
+
+	// Users
+	assert.NoError(t, db.CreateUser(credentials))
+	assert.Equal(t, ErrLoginExists, db.CreateUser(credentials))
+
+	userID, err := db.LoginUser(login, testVerifier("password", "rehash"))
+	assert.NoError(t, err)
+	assert.True(t, recordIDPattern.MatchString(string(userID)))
+
+	// Saved hash is replaced by rehash
+	_, err = db.LoginUser(login, testVerifier("password", ""))
+	assert.Equal(t, ErrWrongCredentials, err)
+	_, err = db.LoginUser(login, testVerifier("rehash", ""))
+	assert.NoError(t, err)
+
+	_, err = db.LoginUser(login+"missing", testVerifier("rehash", ""))
+	assert.Equal(t, ErrWrongCredentials, err)
+
+	hash, err := db.GetPasswordHash(login)
+	assert.NoError(t, err)
+	assert.Equal(t, "rehash", hash)
+	_, err = db.GetPasswordHash(login + "missing")
+	assert.Equal(t, ErrWrongCredentials, err)
+
+	salt, err := db.GetKeySalt(ctx, userID)
+	assert.NoError(t, err)
+	assert.Len(t, salt, keySaltSize)
+
+	// Only one of concurrent registrations with the same login succeeds
+	errs := make(chan error, 5)
+	for i := 0; i < cap(errs); i++ {
+		go func() {
+			errs <- db.CreateUser(userdata.UserCredentials{Login: login + "race", Password: "password"})
+		}()
+	}
+
+	created := 0
+	for i := 0; i < cap(errs); i++ {
+		if err := <-errs; err == nil {
+			created++
+		} else {
+			assert.Equal(t, ErrLoginExists, err)
+		}
+	}
+	assert.Equal(t, 1, created)
+
+	// Records
+	record := userdata.Record{Type: userdata.TypeText, KeyHint: "hint", Metadata: "text", Data: bytes.Repeat([]byte{0, 1, 0xff}, 1000), Size: 3000}
+	meta, err := db.CreateRecord(ctx, userID, record)
+	assert.NoError(t, err)
+	assert.True(t, recordIDPattern.MatchString(meta.ID))
+	assert.Equal(t, int64(1), meta.Version)
+	assert.False(t, meta.CreatedAt.IsZero())
+
+	saved, err := db.GetRecord(ctx, userID, strings.ToUpper(meta.ID))
+	assert.NoError(t, err)
+	assert.Equal(t, meta.ID, saved.ID)
+	assert.Equal(t, record.Data, saved.Data)
+	assert.Equal(t, record.Metadata, saved.Metadata)
+
+	other, err := NewRecordID()
+	assert.NoError(t, err)
+	_, err = db.GetRecord(ctx, userdata.UserID(other), meta.ID)
+	assert.Equal(t, ErrNotFound, err)
+
+	info, err := db.GetRecordsInfo(ctx, userID)
+	assert.NoError(t, err)
+	assert.Len(t, info, 1)
+
+	usage, err := db.GetUserUsage(ctx, userID)
+	assert.NoError(t, err)
+	assert.Equal(t, userdata.Usage{Records: 1, Bytes: 3000}, usage)
+
+	// Batches
+	errCommit := errors.New("commit failed")
+	missing, err := NewRecordID()
+	assert.NoError(t, err)
+
+	batch, err := db.CreateRecords(ctx, userID, []userdata.Record{record, {Type: userdata.TypeFile, Metadata: "file", Checksum: "sum"}})
+	assert.NoError(t, err)
+	assert.Len(t, batch, 2)
+
+	results, err := db.GetRecords(ctx, userID, []string{batch[1].ID, missing, "bad", batch[0].ID})
+	assert.NoError(t, err)
+	if assert.Len(t, results, 4) {
+		assert.Equal(t, "sum", results[0].Record.Checksum)
+		assert.Equal(t, ErrNotFound, results[1].Err)
+		assert.Equal(t, ErrNotFound, results[2].Err)
+		assert.Equal(t, record.Data, results[3].Record.Data)
+	}
+
+	files, err := db.ListFileRecords(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, userID, files[batch[1].ID].UserID)
+	assert.False(t, files[batch[1].ID].UpdatedAt.IsZero())
+
+	checksums, err := db.ListFileChecksums(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "sum", checksums[batch[1].ID])
+
+	// Batch is replaced entirely or not at all
+	replaced := []userdata.Record{
+		{ID: batch[0].ID, Type: record.Type, KeyHint: "new", Data: []byte{4, 5}, Size: 2, Version: batch[0].Record.Version},
+		{ID: batch[1].ID, Type: userdata.TypeFile, KeyHint: "new", Checksum: "new", Version: batch[1].Record.Version},
+	}
+	_, err = db.ReplaceRecords(ctx, userID, append(replaced[:1:1], userdata.Record{ID: missing, Type: userdata.TypeText}))
+	assert.Equal(t, ErrNotFound, err)
+	_, err = db.ReplaceRecords(ctx, userID, []userdata.Record{replaced[0], {ID: batch[1].ID, Type: userdata.TypeText, Version: batch[1].Record.Version}})
+	assert.Equal(t, ErrVersionConflict, err)
+	_, err = db.ReplaceRecordsTx(ctx, userID, replaced, func(growth int64) error {
+		assert.Equal(t, 2-record.Size, growth)
+
+		return errCommit
+	})
+	assert.Equal(t, errCommit, err)
+
+	results, err = db.ReplaceRecords(ctx, userID, replaced)
+	assert.NoError(t, err)
+	if assert.Len(t, results, 2) {
+		assert.Equal(t, batch[0].Record.Version+1, results[0].Record.Version)
+		assert.Equal(t, batch[1].ID, results[1].Record.ID)
+	}
+
+	results, err = db.GetRecords(ctx, userID, []string{batch[0].ID, batch[1].ID})
+	assert.NoError(t, err)
+	if assert.Len(t, results, 2) {
+		assert.Equal(t, []byte{4, 5}, results[0].Record.Data)
+		assert.Equal(t, "new", results[0].Record.KeyHint)
+		assert.Equal(t, "new", results[1].Record.Checksum)
+	}
+
+	// Replaced record has new version, the old one conflicts
+	_, err = db.ReplaceRecords(ctx, userID, replaced[:1])
+	assert.Equal(t, ErrVersionConflict, err)
+
+	// Rewrap changes only key hint and data key
+	rewrapped := []userdata.Record{{ID: batch[0].ID, Type: record.Type, KeyHint: "rewrapped", DataKey: []byte{6, 7}, Version: batch[0].Record.Version + 1}}
+	_, err = db.RewrapRecords(ctx, userID, append(rewrapped[:1:1], userdata.Record{ID: missing, Type: userdata.TypeText}))
+	assert.Equal(t, ErrNotFound, err)
+	_, err = db.RewrapRecords(ctx, userID, replaced[:1])
+	assert.Equal(t, ErrVersionConflict, err)
+
+	results, err = db.RewrapRecords(ctx, userID, rewrapped)
+	assert.NoError(t, err)
+	if assert.Len(t, results, 1) {
+		assert.Equal(t, batch[0].Record.Version+2, results[0].Record.Version)
+	}
+
+	results, err = db.GetRecords(ctx, userID, []string{batch[0].ID})
+	assert.NoError(t, err)
+	if assert.Len(t, results, 1) {
+		assert.Equal(t, []byte{4, 5}, results[0].Record.Data)
+		assert.Equal(t, "rewrapped", results[0].Record.KeyHint)
+		assert.Equal(t, []byte{6, 7}, results[0].Record.DataKey)
+	}
+
+	results, err = db.DeleteRecords(ctx, userID, []string{batch[0].ID, missing, batch[1].ID})
+	assert.NoError(t, err)
+	assert.Equal(t, []userdata.RecordResult{{ID: batch[0].ID}, {ID: missing, Err: ErrNotFound}, {ID: batch[1].ID}}, results)
+
+	// ID of new record may be chosen by client, but only once
+	chosen, err := NewRecordID()
+	assert.NoError(t, err)
+	chosenMeta, err := db.CreateRecord(ctx, userID, userdata.Record{ID: strings.ToUpper(chosen), Type: userdata.TypeText})
+	assert.NoError(t, err)
+	assert.Equal(t, chosen, chosenMeta.ID)
+	_, err = db.CreateRecord(ctx, userID, userdata.Record{ID: chosen, Type: userdata.TypeText})
+	assert.Equal(t, ErrAlreadyExists, err)
+	results, err = db.CreateRecords(ctx, userID, []userdata.Record{{Type: userdata.TypeText}, {ID: chosen, Type: userdata.TypeText}})
+	assert.NoError(t, err)
+	if assert.Len(t, results, 2) {
+		assert.NoError(t, results[0].Err)
+		assert.Equal(t, ErrAlreadyExists, results[1].Err, "only record with used ID isn't created")
+		assert.NoError(t, db.DeleteRecord(ctx, userID, results[0].ID))
+	}
+	assert.NoError(t, db.DeleteRecord(ctx, userID, chosen))
+
+	// Files are named by stored lowercase IDs, records are found by IDs in any case
+	blobs := NewMemoryFileStorage()
+	storage := NewStorage(db, blobs)
+	fileID, err := NewRecordID()
+	assert.NoError(t, err)
+	upper := strings.ToUpper(fileID)
+	_, err = storage.CreateRecord(ctx, userID, userdata.Record{ID: upper, Type: userdata.TypeFile, Data: []byte("file")})
+	assert.NoError(t, err)
+	fileRecord, err := storage.GetRecord(ctx, userID, upper)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("file"), fileRecord.Data)
+	results, err = storage.GetRecords(ctx, userID, []string{upper})
+	assert.NoError(t, err)
+	if assert.Len(t, results, 1) {
+		assert.Equal(t, []byte("file"), results[0].Record.Data)
+	}
+	assert.NoError(t, storage.DeleteRecord(ctx, userID, upper))
+	stored, err := blobs.ListFiles(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, stored, "file of deleted record is not left")
+
+	_, err = storage.CreateRecord(ctx, userID, userdata.Record{ID: upper, Type: userdata.TypeFile, Data: []byte("file")})
+	assert.NoError(t, err)
+	results, err = storage.DeleteRecords(ctx, userID, []string{upper})
+	assert.NoError(t, err)
+	assert.Equal(t, []userdata.RecordResult{{ID: upper}}, results)
+	stored, err = blobs.ListFiles(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, stored, "file of deleted record is not left")
+
+	// Transactions are rolled back, if commit fails
+	_, err = db.CreateRecordTx(ctx, userID, record, func(userdata.RecordMeta) error { return errCommit })
+	assert.Equal(t, errCommit, err)
+	assert.Equal(t, errCommit, db.DeleteRecordTx(ctx, userID, meta.ID, func() error { return errCommit }))
+	_, err = db.DeleteRecordsTx(ctx, userID, []string{strings.ToUpper(meta.ID)}, func(deleted []string) error {
+		assert.Equal(t, []string{meta.ID}, deleted)
+
+		return errCommit
+	})
+	assert.Equal(t, errCommit, err)
+
+	info, err = db.GetRecordsInfo(ctx, userID)
+	assert.NoError(t, err)
+	assert.Len(t, info, 1)
+
+	assert.NoError(t, db.DeleteRecordTx(ctx, userID, meta.ID, func() error { return nil }))
+	assert.Equal(t, ErrNotFound, db.DeleteRecord(ctx, userID, meta.ID))
+
+	// Administration
+	assert.NoError(t, db.SetUserQuota(ctx, login, 100))
+	assert.NoError(t, db.SetUserAdmin(ctx, login, true))
+	assert.Equal(t, ErrUserNotFound, db.SetUserAdmin(ctx, login+"missing", true))
+
+	admin, err := db.IsAdmin(ctx, userID)
+	assert.NoError(t, err)
+	assert.True(t, admin)
+
+	users, err := db.ListUsers(ctx)
+	assert.NoError(t, err)
+	for _, user := range users {
+		if user.Login == login {
+			assert.Equal(t, userID, user.ID)
+			assert.True(t, user.Admin)
+			assert.Equal(t, userdata.Usage{Quota: 100}, user.Usage)
+		}
+	}
+
+	stats, err := db.GetStats(ctx)
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, stats.Users, int64(1))
+	assert.GreaterOrEqual(t, stats.Admins, int64(1))
+
+	issuedAt := time.Now().Add(-time.Minute)
+	assert.NoError(t, db.CheckSession(userID, issuedAt))
+	assert.NoError(t, db.RevokeTokens(ctx, login))
+	assert.Equal(t, ErrUnauthenticated, db.CheckSession(userID, issuedAt))
+	assert.NoError(t, db.CheckSession(userID, time.Now().Add(time.Second)))
+
+	assert.NoError(t, db.SetUserDisabled(ctx, login, true))
+	_, err = db.LoginUser(login, testVerifier("rehash", ""))
+	assert.Equal(t, ErrUserDisabled, err)
+
+	// Idempotency keys
+	request := userdata.IdempotentRequest{Scope: string(userID), Key: "key", Method: "/rpc.Gokeeper/CreateRecord", RequestHash: "hash"}
+	_, reserved, err := db.ReserveIdempotencyKey(ctx, request, time.Hour)
+	assert.NoError(t, err)
+	assert.True(t, reserved)
+
+	request.Response = []byte("response")
+	assert.NoError(t, db.CompleteIdempotencyKey(ctx, request))
+
+	replayed, reserved, err := db.ReserveIdempotencyKey(ctx, request, time.Hour)
+	assert.NoError(t, err)
+	assert.False(t, reserved)
+	assert.Equal(t, []byte("response"), replayed.Response)
+
+	// Expired key is reserved again
+	time.Sleep(10 * time.Millisecond)
+	_, reserved, err = db.ReserveIdempotencyKey(ctx, request, time.Millisecond)
+	assert.NoError(t, err)
+	assert.True(t, reserved)
+	assert.NoError(t, db.ReleaseIdempotencyKey(ctx, request))
+
+	_, err = db.DeleteExpiredIdempotencyKeys(ctx, time.Now().Add(time.Minute))
+	assert.NoError(t, err)
+}
+
+func TestSQLiteStorage(t *testing.T) {
+	db, err := OpenDBStorage("sqlite:"+filepath.Join(t.TempDir(), "gophkeeper.db"), "", testMigrations)
+	assert.NoError(t, err)
+
+	// Migrations may be applied again
+	db.MigrateUP()
+	db.MigrateUP()
+
+	testDBStorage(t, db)
+}
+
+func TestMemoryStorage(t *testing.T) {
+	db, err := OpenDBStorage(DBMemory, "", "")
+	assert.NoError(t, err)
+	db.MigrateUP()
+
+	testDBStorage(t, db)
+}
+
+func TestPostgresStorage(t *testing.T) {
+	dsn, ok := os.LookupEnv("TEST_DATABASE_DSN")
+	if !ok {
+		t.Skip("TEST_DATABASE_DSN is not set")
+	}
+
+	db, err := OpenDBStorage(DBPostgres, dsn, testMigrations)
+	assert.NoError(t, err)
+	db.MigrateUP()
+
+	testDBStorage(t, db)
+}
+
+func TestOpenDBStorage(t *testing.T) {
+	tc := []struct {
+		name    string
+		storage string
+		valid   bool
+	}{
+		{"Postgres by default", "", true},
+		{"Postgres", "postgres", true},
+		{"SQLite file", "sqlite:" + filepath.Join(t.TempDir(), "db"), true},
+		{"Memory", "memory", true},
+		{"SQLite without path", "sqlite:", false},
+		{"Postgres with path", "postgres:path", false},
+		{"Unknown storage", "mysql:path", false},
+	}
+
+	for _, test := range tc {
+		t.Log(test.name)
+		_, err := OpenDBStorage(test.storage, "", testMigrations)
+		if test.valid {
+			assert.NoError(t, err)
+		} else {
+			assert.Equal(t, ErrDBConfig, err)
+		}
+	}
+}
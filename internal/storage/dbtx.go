@@ -0,0 +1,138 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/impr0ver/gophKeeper/internal/userdata"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// execQuerier is DB or transaction, which executes queries.
+type execQuerier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// CreateRecordTx saves new record in transaction, which is committed only if commit succeeds.
+func (ds *dbStorage) CreateRecordTx(ctx context.Context, userID userdata.UserID, record userdata.Record, commit func(userdata.RecordMeta) error) (userdata.RecordMeta, error) {
+	if userID == "" {
+		log.Println("Empty userID in creating record")
+		return userdata.RecordMeta{}, ErrUnauthenticated
+	}
+
+	tx, err := ds.DB.BeginTx(ctx, nil)
+	if err != nil {
+		log.Infoln(err)
+
+		return userdata.RecordMeta{}, ErrUnknown
+	}
+	defer rollback(tx)
+
+	meta, err := insertRecord(ctx, tx, userID, record)
+	if err != nil {
+		return meta, err
+	}
+
+	if err := commit(meta); err != nil {
+		return userdata.RecordMeta{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Infoln(err)
+
+		return userdata.RecordMeta{}, ErrUnknown
+	}
+
+	return meta, nil
+}
+
+// DeleteRecordTx deletes record in transaction, which is committed only if commit succeeds.
+func (ds *dbStorage) DeleteRecordTx(ctx context.Context, userID userdata.UserID, recordID string, commit func() error) error {
+	if userID == "" {
+		log.Println("Empty userID in deleting record")
+		return ErrUnauthenticated
+	}
+
+	tx, err := ds.DB.BeginTx(ctx, nil)
+	if err != nil {
+		log.Infoln(err)
+
+		return ErrUnknown
+	}
+	defer rollback(tx)
+
+	if err := deleteRecord(ctx, tx, userID, recordID); err != nil {
+		return err
+	}
+
+	if err := commit(); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Infoln(err)
+
+		return ErrUnknown
+	}
+
+	return nil
+}
+
+// insertRecord inserts record and returns its ID, version and timestamps.
+func insertRecord(ctx context.Context, db execQuerier, userID userdata.UserID, record userdata.Record) (userdata.RecordMeta, error) {
+	var meta userdata.RecordMeta
+
+	id, err := newRecordID(record)
+	if err != nil {
+		log.Infoln(err)
+
+		return meta, ErrUnknown
+	}
+
+	row := db.QueryRowContext(ctx, `INSERT INTO data (record_id, user_id, record_type, keyhint, metadata, crypted_data, data_size, checksum, data_key) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) ON CONFLICT (record_id) DO NOTHING RETURNING record_id, version, created_at, updated_at`,
+		id,
+		userID,
+		record.Type,
+		record.KeyHint,
+		record.Metadata,
+		record.Data,
+		record.Size,
+		record.Checksum,
+		record.DataKey,
+	)
+
+	err = row.Scan(&meta.ID, &meta.Version, &meta.CreatedAt, &meta.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Row with used ID isn't inserted
+		return userdata.RecordMeta{}, ErrAlreadyExists
+	}
+	if err != nil {
+		log.Infoln(err)
+
+		return userdata.RecordMeta{}, ErrUnknown
+	}
+
+	return meta, nil
+}
+
+// deleteRecord deletes record of user, ErrNotFound is returned if there is no such record.
+func deleteRecord(ctx context.Context, db execQuerier, userID userdata.UserID, recordID string) error {
+	result, err := db.ExecContext(ctx, `DELETE FROM data WHERE record_id = $1 AND user_id = $2`, recordID, userID)
+	if err != nil {
+		log.Infoln(err)
+
+		return ErrUnknown
+	}
+
+	if rowsAffected, err := result.RowsAffected(); err != nil {
+		log.Println("Failed get affected records:", err)
+		return ErrUnknown
+	} else if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,203 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/impr0ver/gophKeeper/internal/userdata"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDBStorage_CreateRecordTx(t *testing.T) {
+	storage := newDBStorage("", "")
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.NoError(t, err)
+	storage.DB = db
+
+	const insertQuery = `INSERT INTO data (record_id, user_id, record_type, keyhint, metadata, crypted_data, data_size, checksum, data_key) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) ON CONFLICT (record_id) DO NOTHING RETURNING record_id, version, created_at, updated_at`
+
+	record := userdata.Record{Type: userdata.TypeFile, Metadata: "file", Size: 4, Checksum: "abc"}
+	rows := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"record_id", "version", "created_at", "updated_at"}).AddRow(recordID1, 1, recordCreated, recordCreated)
+	}
+
+	tc := []struct {
+		name  string
+		mock  func()
+		valid func()
+	}{
+		{
+			"Transaction is committed after commit func",
+			func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(insertQuery).WithArgs(sqlmock.AnyArg(), userdata.UserID("1"), userdata.TypeFile, "", "file", []byte(nil), int64(4), "abc", []byte(nil)).WillReturnRows(rows())
+				mock.ExpectCommit()
+			},
+			func() {
+				var committed string
+				meta, err := storage.CreateRecordTx(context.Background(), "1", record, func(meta userdata.RecordMeta) error {
+					committed = meta.ID
+					return nil
+				})
+				assert.NoError(t, err)
+				assert.Equal(t, recordID1, meta.ID)
+				assert.Equal(t, recordID1, committed)
+			},
+		},
+		{
+			"Commit func fails, transaction is rolled back",
+			func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(insertQuery).WillReturnRows(rows())
+				mock.ExpectRollback()
+			},
+			func() {
+				meta, err := storage.CreateRecordTx(context.Background(), "1", record, func(userdata.RecordMeta) error {
+					return ErrNotFound
+				})
+				assert.Equal(t, ErrNotFound, err)
+				assert.Empty(t, meta)
+			},
+		},
+		{
+			"Insert fails, commit func is not called",
+			func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(insertQuery).WillReturnError(errors.New("connection refused"))
+				mock.ExpectRollback()
+			},
+			func() {
+				_, err := storage.CreateRecordTx(context.Background(), "1", record, func(userdata.RecordMeta) error {
+					t.Error("commit func is called")
+					return nil
+				})
+				assert.Equal(t, ErrUnknown, err)
+			},
+		},
+		{
+			"Record with used ID is not inserted",
+			func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(insertQuery).WillReturnRows(sqlmock.NewRows([]string{"record_id", "version", "created_at", "updated_at"}))
+				mock.ExpectRollback()
+			},
+			func() {
+				_, err := storage.CreateRecordTx(context.Background(), "1", record, func(userdata.RecordMeta) error {
+					t.Error("commit func is called")
+					return nil
+				})
+				assert.Equal(t, ErrAlreadyExists, err)
+			},
+		},
+		{
+			"Commit of transaction fails",
+			func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(insertQuery).WillReturnRows(rows())
+				mock.ExpectCommit().WillReturnError(errors.New("connection reset"))
+			},
+			func() {
+				meta, err := storage.CreateRecordTx(context.Background(), "1", record, func(userdata.RecordMeta) error { return nil })
+				assert.Equal(t, ErrUnknown, err)
+				assert.Empty(t, meta)
+			},
+		},
+		{
+			"Without user",
+			func() {},
+			func() {
+				_, err := storage.CreateRecordTx(context.Background(), "", record, func(userdata.RecordMeta) error { return nil })
+				assert.Equal(t, ErrUnauthenticated, err)
+			},
+		},
+	}
+
+	for _, test := range tc {
+		t.Log(test.name)
+		test.mock()
+		test.valid()
+		assert.NoError(t, mock.ExpectationsWereMet())
+	}
+}
+
+func TestDBStorage_DeleteRecordTx(t *testing.T) {
+	storage := newDBStorage("", "")
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.NoError(t, err)
+	storage.DB = db
+
+	const deleteQuery = `DELETE FROM data WHERE record_id = $1 AND user_id = $2`
+
+	tc := []struct {
+		name  string
+		mock  func()
+		valid func()
+	}{
+		{
+			"Transaction is committed after commit func",
+			func() {
+				mock.ExpectBegin()
+				mock.ExpectExec(deleteQuery).WithArgs(recordID1, userdata.UserID("1")).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			func() {
+				called := false
+				err := storage.DeleteRecordTx(context.Background(), "1", recordID1, func() error {
+					called = true
+					return nil
+				})
+				assert.NoError(t, err)
+				assert.True(t, called)
+			},
+		},
+		{
+			"Record is not found, commit func is not called",
+			func() {
+				mock.ExpectBegin()
+				mock.ExpectExec(deleteQuery).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+			},
+			func() {
+				err := storage.DeleteRecordTx(context.Background(), "1", recordID1, func() error {
+					t.Error("commit func is called")
+					return nil
+				})
+				assert.Equal(t, ErrNotFound, err)
+			},
+		},
+		{
+			"Commit func fails, transaction is rolled back",
+			func() {
+				mock.ExpectBegin()
+				mock.ExpectExec(deleteQuery).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectRollback()
+			},
+			func() {
+				err := storage.DeleteRecordTx(context.Background(), "1", recordID1, func() error { return ErrUnknown })
+				assert.Equal(t, ErrUnknown, err)
+			},
+		},
+		{
+			"Commit of transaction fails",
+			func() {
+				mock.ExpectBegin()
+				mock.ExpectExec(deleteQuery).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit().WillReturnError(errors.New("connection reset"))
+			},
+			func() {
+				err := storage.DeleteRecordTx(context.Background(), "1", recordID1, func() error { return nil })
+				assert.Equal(t, ErrUnknown, err)
+			},
+		},
+	}
+
+	for _, test := range tc {
+		t.Log(test.name)
+		test.mock()
+		test.valid()
+		assert.NoError(t, mock.ExpectationsWereMet())
+	}
+}
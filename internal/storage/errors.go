@@ -9,4 +9,13 @@ var (
 	ErrUnauthenticated  = errors.New("user is unauthorized")
 	ErrNotFound         = errors.New("not found record with id")
 	ErrUnknown          = errors.New("internal server error")
+	ErrUserNotFound     = errors.New("user not found")
+	ErrUserDisabled     = errors.New("user is disabled")
+	ErrQuotaExceeded    = errors.New("storage quota exceeded")
+	ErrFsckAction       = errors.New("unknown storage check action, use report, quarantine or delete")
+	ErrBlobConfig       = errors.New("wrong blob store settings")
+	ErrDBConfig         = errors.New("unknown DB storage, use postgres, sqlite:path or memory")
+	ErrDataLoss         = errors.New("record data is corrupted")
+	ErrVersionConflict  = errors.New("record was changed by other request")
+	ErrAlreadyExists    = errors.New("record with id already exists")
 )
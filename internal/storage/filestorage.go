@@ -1,10 +1,15 @@
 package storage
 
 import (
+	"bytes"
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"io"
-	"os"
+	"path"
+	"strings"
 
 	"github.com/impr0ver/gophKeeper/internal/userdata"
 
@@ -12,33 +17,47 @@ import (
 	"google.golang.org/grpc/metadata"
 )
 
-// fileStorage store records on disk as file.
+// quarantineDirectory is directory in file storage, where orphan files are moved.
+const quarantineDirectory = ".quarantine"
+
+// stagedPrefix is prefix of temporary files, which are not records yet or are records being deleted.
+const stagedPrefix = ".staged-"
+
+// fileStorage store records as blobs, by default as files on local disk.
 type fileStorage struct {
-	directory string
+	blobs BlobStore
 }
 
-// newFileStorage returns new file storage.
+// newFileStorage returns new file storage in local directory.
 func newFileStorage(directory string) *fileStorage {
-	if err := os.Mkdir(directory, os.ModePerm); err != nil && !os.IsExist(err) {
+	blobs, err := newLocalBlobStore(directory)
+	if err != nil {
 		log.Fatalln("Failed open directory for file storage")
 
 		return nil
 	}
 
-	return &fileStorage{directory: directory}
+	return newBlobFileStorage(blobs)
 }
 
-// CreateRecord creates new file with record data.
-func (storage *fileStorage) CreateRecord(_ context.Context, record userdata.Record) (string, error) {
-	file, err := os.Create(storage.directory + "/" + record.ID)
-	if err != nil {
-		log.Infoln(err)
+// newBlobFileStorage returns new file storage in blob store.
+func newBlobFileStorage(blobs BlobStore) *fileStorage {
+	return &fileStorage{blobs: blobs}
+}
 
-		return "", ErrUnknown
+// CreateRecord creates new file with record data, file appears only when it's fully written.
+func (storage *fileStorage) CreateRecord(ctx context.Context, record userdata.Record) (string, error) {
+	staged, err := storage.StageRecord(ctx, record.Data)
+	if err != nil {
+		return "", err
 	}
 
-	if _, err := file.Write(record.Data); err != nil {
-		return "", ErrUnknown
+	if err := storage.CommitRecord(ctx, staged, record.ID); err != nil {
+		if err := storage.DiscardRecord(ctx, staged); err != nil {
+			log.Infoln(err)
+		}
+
+		return "", err
 	}
 
 	return record.ID, nil
@@ -54,20 +73,14 @@ func (storage *fileStorage) GetRecord(ctx context.Context, recordID string) (use
 
 	metadata := string(md.Get("recordMetadata")[0])
 
-	file, err := os.Open(storage.directory + "/" + recordID)
-	if errors.Is(err, os.ErrNotExist) {
-		log.Infoln(err)
-
-		return userdata.Record{}, ErrNotFound
-	}
+	file, err := storage.blobs.Get(ctx, recordID)
 	if err != nil {
-		log.Infoln(err)
-
-		return userdata.Record{}, ErrUnknown
+		return userdata.Record{}, err
 	}
+	defer file.Close()
 
-	data, errReadAll := io.ReadAll(file)
-	if errReadAll != nil {
+	data, err := io.ReadAll(file)
+	if err != nil {
 		log.Infoln(err)
 
 		return userdata.Record{}, ErrUnknown
@@ -82,17 +95,117 @@ func (storage *fileStorage) GetRecord(ctx context.Context, recordID string) (use
 }
 
 // DeleteRecord deletes file with record data.
-func (storage *fileStorage) DeleteRecord(_ context.Context, recordID string) error {
-	filename := storage.directory + "/" + recordID
-	if _, err := os.Stat(filename); errors.Is(err, os.ErrNotExist) {
-		return ErrNotFound
-	}
+func (storage *fileStorage) DeleteRecord(ctx context.Context, recordID string) error {
+	return storage.blobs.Delete(ctx, recordID)
+}
 
-	if err := os.RemoveAll(filename); err != nil {
+// StageRecord writes data to temporary file, it becomes record file only after CommitRecord.
+func (storage *fileStorage) StageRecord(ctx context.Context, data []byte) (string, error) {
+	suffix := make([]byte, 16)
+	if _, err := rand.Read(suffix); err != nil {
 		log.Infoln(err)
 
+		return "", ErrUnknown
+	}
+
+	staged := stagedPrefix + hex.EncodeToString(suffix)
+	if err := storage.blobs.Put(ctx, staged, bytes.NewReader(data), int64(len(data))); err != nil {
+		return "", err
+	}
+
+	return staged, nil
+}
+
+// CommitRecord renames staged file to record file.
+func (storage *fileStorage) CommitRecord(ctx context.Context, staged string, recordID string) error {
+	if !isStaged(staged) {
+		log.Println("Wrong staged file name in committing file record:", staged)
+		return ErrUnknown
+	}
+
+	if err := storage.blobs.Rename(ctx, staged, recordID); err != nil {
+		log.Println("Failed commit file record:", err)
 		return ErrUnknown
 	}
 
 	return nil
 }
+
+// DiscardRecord removes staged file.
+func (storage *fileStorage) DiscardRecord(ctx context.Context, staged string) error {
+	if !isStaged(staged) {
+		log.Println("Wrong staged file name in discarding file record:", staged)
+		return ErrUnknown
+	}
+
+	if err := storage.blobs.Delete(ctx, staged); err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+
+	return nil
+}
+
+// StageDelete renames record file to staged file, record may be restored with CommitRecord until it's discarded.
+func (storage *fileStorage) StageDelete(ctx context.Context, recordID string) (string, error) {
+	staged := stagedPrefix + "deleted-" + recordID
+
+	if err := storage.blobs.Rename(ctx, recordID, staged); err != nil {
+		return "", err
+	}
+
+	return staged, nil
+}
+
+// ListFiles lists record files and staged files, quarantined files are skipped.
+func (storage *fileStorage) ListFiles(ctx context.Context) ([]userdata.StoredFile, error) {
+	files, err := storage.blobs.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range files {
+		files[i].Staged = isStaged(files[i].Name)
+	}
+
+	return files, nil
+}
+
+// QuarantineFile moves file to quarantine directory, where it's kept for manual check.
+func (storage *fileStorage) QuarantineFile(ctx context.Context, name string) error {
+	if path.Base(name) != name || !validKey(name) {
+		log.Println("Wrong file name in quarantine:", name)
+		return ErrUnknown
+	}
+
+	return storage.blobs.Rename(ctx, name, quarantineDirectory+"/"+name)
+}
+
+// Checksum reads file with record data and returns its hex SHA-256, file isn't loaded to memory.
+func (storage *fileStorage) Checksum(ctx context.Context, recordID string) (string, error) {
+	file, err := storage.blobs.Get(ctx, recordID)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		log.Infoln(err)
+
+		return "", ErrUnknown
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// checksum returns hex SHA-256 of record data.
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+
+	return hex.EncodeToString(sum[:])
+}
+
+// isStaged checks that name is staged file in storage directory.
+func isStaged(name string) bool {
+	return strings.HasPrefix(name, stagedPrefix) && path.Base(name) == name
+}
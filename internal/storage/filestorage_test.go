@@ -189,3 +189,86 @@ func TestFileStorage_DeleteRecord(t *testing.T) {
 
 	assert.NoError(t, os.RemoveAll(filesPath))
 }
+
+func TestFileStorage_StageRecord(t *testing.T) {
+	directory := t.TempDir()
+	storage := newFileStorage(directory)
+	ctx := context.Background()
+
+	staged, err := storage.StageRecord(ctx, []byte("text"))
+	assert.NoError(t, err)
+	assert.True(t, isStaged(staged))
+	assert.FileExists(t, directory+"/"+staged)
+
+	// Staged file is not a record until it's committed
+	_, err = storage.GetRecord(metadata.NewIncomingContext(ctx, metadata.Pairs("recordMetadata", "file.txt")), "1")
+	assert.Equal(t, ErrNotFound, err)
+
+	assert.NoError(t, storage.CommitRecord(ctx, staged, "1"))
+	assert.NoFileExists(t, directory+"/"+staged)
+	data, err := os.ReadFile(directory + "/1")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("text"), data)
+
+	assert.Equal(t, ErrUnknown, storage.CommitRecord(ctx, staged, "2"), "staged file is renamed already")
+	assert.Equal(t, ErrUnknown, storage.CommitRecord(ctx, "../1", "2"), "only staged files are committed")
+
+	staged, err = storage.StageRecord(ctx, []byte("other"))
+	assert.NoError(t, err)
+	assert.NoError(t, storage.DiscardRecord(ctx, staged))
+	assert.NoFileExists(t, directory+"/"+staged)
+	assert.NoError(t, storage.DiscardRecord(ctx, staged), "discarded file may be discarded again")
+	assert.Equal(t, ErrUnknown, storage.DiscardRecord(ctx, "1"))
+}
+
+func TestFileStorage_StageDelete(t *testing.T) {
+	directory := t.TempDir()
+	storage := newFileStorage(directory)
+	ctx := context.Background()
+
+	_, err := storage.CreateRecord(ctx, userdata.Record{ID: "1", Type: userdata.TypeFile, Data: []byte("text")})
+	assert.NoError(t, err)
+
+	staged, err := storage.StageDelete(ctx, "1")
+	assert.NoError(t, err)
+	assert.NoFileExists(t, directory+"/1")
+
+	// Not committed deletion is restored
+	assert.NoError(t, storage.CommitRecord(ctx, staged, "1"))
+	assert.FileExists(t, directory+"/1")
+
+	staged, err = storage.StageDelete(ctx, "1")
+	assert.NoError(t, err)
+	assert.NoError(t, storage.DiscardRecord(ctx, staged))
+	assert.NoFileExists(t, directory+"/"+staged)
+
+	_, err = storage.StageDelete(ctx, "1")
+	assert.Equal(t, ErrNotFound, err)
+}
+
+func TestFileStorage_ListFiles(t *testing.T) {
+	directory := t.TempDir()
+	storage := newFileStorage(directory)
+	ctx := context.Background()
+
+	_, err := storage.CreateRecord(ctx, userdata.Record{ID: "1", Type: userdata.TypeFile, Data: []byte("text")})
+	assert.NoError(t, err)
+	staged, err := storage.StageRecord(ctx, []byte("staged"))
+	assert.NoError(t, err)
+	assert.NoError(t, os.Mkdir(directory+"/dir", 0700))
+
+	files, err := storage.ListFiles(ctx)
+	assert.NoError(t, err)
+	if assert.Len(t, files, 2, "directories are skipped") {
+		byName := map[string]userdata.StoredFile{files[0].Name: files[0], files[1].Name: files[1]}
+		assert.Equal(t, int64(4), byName["1"].Size)
+		assert.False(t, byName["1"].Staged)
+		assert.True(t, byName[staged].Staged)
+	}
+
+	assert.NoError(t, storage.QuarantineFile(ctx, "1"))
+	assert.NoFileExists(t, directory+"/1")
+	assert.FileExists(t, directory+"/"+quarantineDirectory+"/1")
+	assert.Equal(t, ErrNotFound, storage.QuarantineFile(ctx, "1"))
+	assert.Equal(t, ErrUnknown, storage.QuarantineFile(ctx, "../1"))
+}
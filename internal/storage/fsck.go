@@ -0,0 +1,249 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/impr0ver/gophKeeper/internal/userdata"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// FsckAction is action done with orphans found by storage check.
+type FsckAction string
+
+// Storage check actions.
+const (
+	// FsckReport only reports orphans.
+	FsckReport FsckAction = "report"
+	// FsckQuarantine moves orphan files to quarantine directory of file storage.
+	FsckQuarantine FsckAction = "quarantine"
+	// FsckDelete deletes orphan files and file records without files.
+	FsckDelete FsckAction = "delete"
+)
+
+// ParseFsckAction checks storage check action.
+func ParseFsckAction(action string) (FsckAction, error) {
+	switch FsckAction(action) {
+	case FsckReport, FsckQuarantine, FsckDelete:
+		return FsckAction(action), nil
+	default:
+		return "", ErrFsckAction
+	}
+}
+
+// FsckResult is machine-readable report of storage consistency check.
+type FsckResult struct {
+	Action    FsckAction `json:"action"`
+	CheckedAt time.Time  `json:"checked_at"`
+	// Files is number of record files, FileRecords is number of file records in DB.
+	Files       int `json:"files"`
+	FileRecords int `json:"file_records"`
+	// OrphanFiles are files without records.
+	OrphanFiles []string `json:"orphan_files"`
+	// StaleStaged are staged files left by failed saving or deleting.
+	StaleStaged []string `json:"stale_staged_files"`
+	// MissingFiles are IDs of file records without files.
+	MissingFiles []string `json:"missing_files"`
+	// Fixed are orphans quarantined or deleted by action.
+	Fixed  []string `json:"fixed"`
+	Errors []string `json:"errors"`
+}
+
+// Consistent reports whether nothing is found.
+func (r FsckResult) Consistent() bool {
+	return len(r.OrphanFiles) == 0 && len(r.StaleStaged) == 0 && len(r.MissingFiles) == 0
+}
+
+// Checker cross-checks files of file storage with file records in DB.
+type Checker struct {
+	DB    FsckStorager
+	Files FileStorager
+	// Grace is age of files, which may belong to records being saved or deleted right now, they are not checked.
+	Grace time.Duration
+}
+
+// NewChecker returns storage consistency checker.
+func NewChecker(db FsckStorager, files FileStorager, grace time.Duration) *Checker {
+	return &Checker{
+		DB:    db,
+		Files: files,
+		Grace: grace,
+	}
+}
+
+// Check finds files without records, stale staged files and file records without files and does action with them.
+func (c *Checker) Check(ctx context.Context, action FsckAction) (FsckResult, error) {
+	if _, err := ParseFsckAction(string(action)); err != nil {
+		return FsckResult{}, err
+	}
+
+	result := FsckResult{
+		Action:       action,
+		CheckedAt:    time.Now(),
+		OrphanFiles:  []string{},
+		StaleStaged:  []string{},
+		MissingFiles: []string{},
+		Fixed:        []string{},
+		Errors:       []string{},
+	}
+
+	files, err := c.Files.ListFiles(ctx)
+	if err != nil {
+		return result, err
+	}
+
+	records, err := c.DB.ListFileRecords(ctx)
+	if err != nil {
+		return result, err
+	}
+
+	result.FileRecords = len(records)
+	before := result.CheckedAt.Add(-c.Grace)
+
+	for _, file := range files {
+		if file.Staged {
+			if file.ModTime.Before(before) {
+				result.StaleStaged = append(result.StaleStaged, file.Name)
+			}
+
+			continue
+		}
+
+		result.Files++
+
+		if _, ok := records[file.Name]; !ok && file.ModTime.Before(before) {
+			result.OrphanFiles = append(result.OrphanFiles, file.Name)
+		}
+	}
+
+	result.MissingFiles = missingFiles(files, records, before)
+
+	sort.Strings(result.OrphanFiles)
+	sort.Strings(result.StaleStaged)
+
+	c.fix(ctx, &result, before)
+
+	return result, nil
+}
+
+// missingFiles returns sorted IDs of file records without files. Record may be committed after files are listed,
+// its file is renamed in transaction, so records changed in grace period are not checked, like new files.
+func missingFiles(files []userdata.StoredFile, records map[string]userdata.FileRecordInfo, before time.Time) []string {
+	found := make(map[string]bool, len(files))
+	for _, file := range files {
+		// Record file being deleted is still there
+		found[strings.TrimPrefix(file.Name, stagedPrefix+"deleted-")] = true
+	}
+
+	missing := make([]string, 0)
+	for recordID, info := range records {
+		if !found[recordID] && info.UpdatedAt.Before(before) {
+			missing = append(missing, recordID)
+		}
+	}
+
+	sort.Strings(missing)
+
+	return missing
+}
+
+// fix quarantines or deletes orphans found by check, errors are put into result.
+func (c *Checker) fix(ctx context.Context, result *FsckResult, before time.Time) {
+	if result.Action == FsckReport {
+		return
+	}
+
+	fixed := func(name string, err error) {
+		if err != nil {
+			result.Errors = append(result.Errors, name+": "+err.Error())
+			return
+		}
+
+		result.Fixed = append(result.Fixed, name)
+	}
+
+	for _, name := range append(append([]string{}, result.OrphanFiles...), result.StaleStaged...) {
+		if result.Action == FsckQuarantine {
+			fixed(name, c.Files.QuarantineFile(ctx, name))
+			continue
+		}
+
+		if isStaged(name) {
+			fixed(name, c.Files.DiscardRecord(ctx, name))
+		} else {
+			fixed(name, c.Files.DeleteRecord(ctx, name))
+		}
+	}
+
+	// Records without files can't be read, but they can't be quarantined too
+	if result.Action == FsckDelete && len(result.MissingFiles) > 0 {
+		c.deleteMissing(ctx, result, before, fixed)
+	}
+}
+
+// deleteMissing deletes records without files. Files and records are listed again right before deleting,
+// so records, which got files or were changed since check, are kept.
+func (c *Checker) deleteMissing(ctx context.Context, result *FsckResult, before time.Time, fixed func(name string, err error)) {
+	files, err := c.Files.ListFiles(ctx)
+	if err != nil {
+		result.Errors = append(result.Errors, "list files: "+err.Error())
+		return
+	}
+
+	records, err := c.DB.ListFileRecords(ctx)
+	if err != nil {
+		result.Errors = append(result.Errors, "list file records: "+err.Error())
+		return
+	}
+
+	missing := make(map[string]bool, len(result.MissingFiles))
+	for _, recordID := range missingFiles(files, records, before) {
+		missing[recordID] = true
+	}
+
+	for _, recordID := range result.MissingFiles {
+		if missing[recordID] {
+			fixed(recordID, c.DB.DeleteRecord(ctx, records[recordID].UserID, recordID))
+		}
+	}
+}
+
+// Run checks storage every interval until ctx is done, results are logged as JSON.
+func (c *Checker) Run(ctx context.Context, interval time.Duration, action FsckAction) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// Both channels may be ready, stopped checker doesn't start new check
+			if ctx.Err() != nil {
+				return
+			}
+
+			result, err := c.Check(ctx, action)
+			if err != nil {
+				log.Warnf("storage check error: %v", err)
+				continue
+			}
+
+			report, err := json.Marshal(result)
+			if err != nil {
+				log.Warnf("storage check report error: %v", err)
+				continue
+			}
+
+			if result.Consistent() {
+				log.Infof("storage check: %s", report)
+			} else {
+				log.Warnf("storage check found inconsistencies: %s", report)
+			}
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/impr0ver/gophKeeper/internal/storage/mocks"
+	"github.com/impr0ver/gophKeeper/internal/userdata"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// prepareFsckFiles creates files of storage check: record file, orphan file, new orphan file and stale staged file.
+func prepareFsckFiles(t *testing.T, directory string) {
+	old := time.Now().Add(-2 * time.Hour)
+
+	for name, modTime := range map[string]time.Time{
+		"record":                               old,
+		"orphan":                               old,
+		"new":                                  time.Now(),
+		stagedPrefix + "1":                     old,
+		stagedPrefix + "deleted-" + "deleting": time.Now(),
+	} {
+		assert.NoError(t, os.WriteFile(directory+"/"+name, []byte("data"), 0600))
+		assert.NoError(t, os.Chtimes(directory+"/"+name, modTime, modTime))
+	}
+}
+
+func TestChecker_Check(t *testing.T) {
+	old := time.Now().Add(-2 * time.Hour)
+	records := map[string]userdata.FileRecordInfo{
+		"record":   {UserID: "1", UpdatedAt: old},
+		"missing":  {UserID: "2", UpdatedAt: old},
+		"deleting": {UserID: "3", UpdatedAt: old},
+		"creating": {UserID: "4", UpdatedAt: time.Now()},
+	}
+
+	tc := []struct {
+		name   string
+		action FsckAction
+		mock   func(db *mocks.FsckStorager)
+		valid  func(result FsckResult, directory string)
+	}{
+		{
+			"Report only",
+			FsckReport,
+			func(*mocks.FsckStorager) {},
+			func(result FsckResult, directory string) {
+				assert.False(t, result.Consistent())
+				assert.Equal(t, 3, result.Files)
+				assert.Equal(t, 4, result.FileRecords)
+				assert.Equal(t, []string{"orphan"}, result.OrphanFiles, "new file may be saved right now")
+				assert.Equal(t, []string{stagedPrefix + "1"}, result.StaleStaged)
+				assert.Equal(t, []string{"missing"}, result.MissingFiles, "file being deleted or saved is not missing")
+				assert.Empty(t, result.Fixed)
+				assert.FileExists(t, directory+"/orphan")
+			},
+		},
+		{
+			"Quarantine orphans",
+			FsckQuarantine,
+			func(*mocks.FsckStorager) {},
+			func(result FsckResult, directory string) {
+				assert.Equal(t, []string{"orphan", stagedPrefix + "1"}, result.Fixed)
+				assert.Empty(t, result.Errors)
+				assert.NoFileExists(t, directory+"/orphan")
+				assert.FileExists(t, directory+"/"+quarantineDirectory+"/orphan")
+				assert.FileExists(t, directory+"/"+quarantineDirectory+"/"+stagedPrefix+"1")
+				assert.FileExists(t, directory+"/record")
+			},
+		},
+		{
+			"Delete orphans and records without files",
+			FsckDelete,
+			func(db *mocks.FsckStorager) {
+				db.On("ListFileRecords", context.Background()).Return(records, nil).Once()
+				db.On("DeleteRecord", context.Background(), userdata.UserID("2"), "missing").Return(nil).Once()
+			},
+			func(result FsckResult, directory string) {
+				assert.Equal(t, []string{"orphan", stagedPrefix + "1", "missing"}, result.Fixed)
+				assert.NoFileExists(t, directory+"/orphan")
+				assert.NoFileExists(t, directory+"/"+stagedPrefix+"1")
+				assert.FileExists(t, directory+"/new")
+			},
+		},
+		{
+			"Failed deletion is reported",
+			FsckDelete,
+			func(db *mocks.FsckStorager) {
+				db.On("ListFileRecords", context.Background()).Return(records, nil).Once()
+				db.On("DeleteRecord", context.Background(), userdata.UserID("2"), "missing").Return(ErrUnknown).Once()
+			},
+			func(result FsckResult, _ string) {
+				assert.Equal(t, []string{"orphan", stagedPrefix + "1"}, result.Fixed)
+				assert.Equal(t, []string{"missing: " + ErrUnknown.Error()}, result.Errors)
+			},
+		},
+	}
+
+	for _, test := range tc {
+		t.Log(test.name)
+
+		directory := t.TempDir()
+		prepareFsckFiles(t, directory)
+
+		db := mocks.NewFsckStorager(t)
+		test.mock(db)
+		db.On("ListFileRecords", context.Background()).Return(records, nil).Once()
+
+		result, err := NewChecker(db, newFileStorage(directory), time.Hour).Check(context.Background(), test.action)
+		assert.NoError(t, err)
+		assert.Equal(t, test.action, result.Action)
+		test.valid(result, directory)
+	}
+}
+
+func TestChecker_CheckRecordCreatedWhileListing(t *testing.T) {
+	directory := t.TempDir()
+	db := mocks.NewFsckStorager(t)
+	checker := NewChecker(db, newFileStorage(directory), time.Hour)
+
+	old := time.Now().Add(-2 * time.Hour)
+	listed := map[string]userdata.FileRecordInfo{
+		"created":  {UserID: "1", UpdatedAt: time.Now()},
+		"restored": {UserID: "2", UpdatedAt: old},
+		"missing":  {UserID: "3", UpdatedAt: old},
+	}
+
+	// Record is committed and file of other record is restored after files are listed
+	db.On("ListFileRecords", context.Background()).Return(listed, nil).Run(func(mock.Arguments) {
+		assert.NoError(t, os.WriteFile(directory+"/created", []byte("data"), 0600))
+		assert.NoError(t, os.WriteFile(directory+"/restored", []byte("data"), 0600))
+	}).Once()
+	db.On("ListFileRecords", context.Background()).Return(listed, nil).Once()
+	db.On("DeleteRecord", context.Background(), userdata.UserID("3"), "missing").Return(nil).Once()
+
+	result, err := checker.Check(context.Background(), FsckDelete)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"missing", "restored"}, result.MissingFiles, "new record is not missing")
+	assert.Equal(t, []string{"missing"}, result.Fixed, "restored file is checked again before deleting")
+	assert.Empty(t, result.Errors)
+}
+
+func TestChecker_Errors(t *testing.T) {
+	db := mocks.NewFsckStorager(t)
+	checker := NewChecker(db, newFileStorage(t.TempDir()), time.Hour)
+
+	_, err := checker.Check(context.Background(), "repair")
+	assert.Equal(t, ErrFsckAction, err)
+
+	db.On("ListFileRecords", context.Background()).Return(nil, ErrUnknown).Once()
+	_, err = checker.Check(context.Background(), FsckReport)
+	assert.Equal(t, ErrUnknown, err)
+
+	db.On("ListFileRecords", context.Background()).Return(map[string]userdata.FileRecordInfo{}, nil).Once()
+	result, err := checker.Check(context.Background(), FsckReport)
+	assert.NoError(t, err)
+	assert.True(t, result.Consistent(), "empty storage")
+}
+
+func TestChecker_Run(t *testing.T) {
+	db := mocks.NewFsckStorager(t)
+	checker := NewChecker(db, newFileStorage(t.TempDir()), time.Hour)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	db.On("ListFileRecords", ctx).Return(map[string]userdata.FileRecordInfo{}, nil).Run(func(_ mock.Arguments) { cancel() }).Once()
+
+	checker.Run(ctx, time.Millisecond, FsckReport)
+}
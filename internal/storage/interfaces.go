@@ -2,6 +2,8 @@ package storage
 
 import (
 	"context"
+	"strings"
+	"time"
 
 	"github.com/impr0ver/gophKeeper/internal/userdata"
 )
@@ -9,11 +11,12 @@ import (
 type DataBaseStorager interface {
 	MigrateUP()
 	CreateUser(credentials userdata.UserCredentials) error
-	LoginUser(credentials userdata.UserCredentials) (userdata.UserID, error)
-	GetRecordsInfo(ctx context.Context) ([]userdata.Record, error)
-	CreateRecord(ctx context.Context, record userdata.Record) (string, error)
-	GetRecord(ctx context.Context, recordID string) (userdata.Record, error)
-	DeleteRecord(ctx context.Context, recordID string) error
+	LoginUser(login string, verify func(hash string) (string, error)) (userdata.UserID, error)
+	RecordStorager
+	FsckStorager
+	ScrubStorager
+	AdminStorager
+	IdempotencyStorager
 }
 
 // NewDBStorage connects to DB (interface).
@@ -21,6 +24,32 @@ func NewDBStorage(connectionURL string, migrateURL string) DataBaseStorager {
 	return newDBStorage(connectionURL, migrateURL)
 }
 
+// NewMemoryDBStorage returns empty DB storage in memory (interface).
+func NewMemoryDBStorage() DataBaseStorager {
+	return newMemStorage()
+}
+
+// OpenDBStorage returns DB storage selected by storage setting: postgres with connectionURL, sqlite:path or memory.
+func OpenDBStorage(storage string, connectionURL string, migrateURL string) (DataBaseStorager, error) {
+	kind, path, _ := strings.Cut(storage, ":")
+
+	switch {
+	case (kind == "" || kind == DBPostgres) && path == "":
+		return newDBStorage(connectionURL, migrateURL), nil
+	case kind == DBMemory && path == "":
+		return newMemStorage(), nil
+	case kind == DBSQLite:
+		db, err := newSQLiteStorage(path, migrateURL)
+		if err != nil {
+			return nil, err
+		}
+
+		return db, nil
+	}
+
+	return nil, ErrDBConfig
+}
+
 // FileStorager interface for storage, which can storage files.
 //
 //go:generate mockery --name FileStorager
@@ -28,6 +57,13 @@ type FileStorager interface {
 	GetRecord(ctx context.Context, recordID string) (userdata.Record, error)
 	CreateRecord(ctx context.Context, record userdata.Record) (string, error)
 	DeleteRecord(ctx context.Context, recordID string) error
+	StageRecord(ctx context.Context, data []byte) (string, error)
+	CommitRecord(ctx context.Context, staged string, recordID string) error
+	DiscardRecord(ctx context.Context, staged string) error
+	StageDelete(ctx context.Context, recordID string) (string, error)
+	ListFiles(ctx context.Context) ([]userdata.StoredFile, error)
+	QuarantineFile(ctx context.Context, name string) error
+	Checksum(ctx context.Context, recordID string) (string, error)
 }
 
 // NewFileStorage returns new file storage (interface).
@@ -35,12 +71,85 @@ func NewFileStorage(directory string) FileStorager {
 	return newFileStorage(directory)
 }
 
-// Storager interface for storage, which can storage only text data.
+// NewMemoryFileStorage returns file storage in memory (interface).
+func NewMemoryFileStorage() FileStorager {
+	return newBlobFileStorage(NewMemoryBlobStore())
+}
+
+// NewBlobFileStorage returns new file storage in blob store (interface).
+func NewBlobFileStorage(blobs BlobStore) FileStorager {
+	return newBlobFileStorage(blobs)
+}
+
+// Storager interface for storage of users and their records, records are accessed only by owner.
+// Users are created with password hash and logged in by login, saved hash is checked by verify,
+// which returns non-empty rehash to replace saved one. Saved hash may be got to start SRP login.
 //
 //go:generate mockery --name Storager
 type Storager interface {
 	CreateUser(credentials userdata.UserCredentials) error
-	LoginUser(credentials userdata.UserCredentials) (userdata.UserID, error)
-	GetRecordsInfo(ctx context.Context) ([]userdata.Record, error)
-	FileStorager
+	LoginUser(login string, verify func(hash string) (string, error)) (userdata.UserID, error)
+	GetPasswordHash(login string) (string, error)
+	GetKeySalt(ctx context.Context, userID userdata.UserID) ([]byte, error)
+	GetRecordsInfo(ctx context.Context, userID userdata.UserID) ([]userdata.Record, error)
+	GetUserUsage(ctx context.Context, userID userdata.UserID) (userdata.Usage, error)
+	CreateRecord(ctx context.Context, userID userdata.UserID, record userdata.Record) (userdata.RecordMeta, error)
+	GetRecord(ctx context.Context, userID userdata.UserID, recordID string) (userdata.Record, error)
+	DeleteRecord(ctx context.Context, userID userdata.UserID, recordID string) error
+	CreateRecords(ctx context.Context, userID userdata.UserID, records []userdata.Record) ([]userdata.RecordResult, error)
+	GetRecords(ctx context.Context, userID userdata.UserID, recordIDs []string) ([]userdata.RecordResult, error)
+	DeleteRecords(ctx context.Context, userID userdata.UserID, recordIDs []string) ([]userdata.RecordResult, error)
+	ReplaceRecords(ctx context.Context, userID userdata.UserID, records []userdata.Record) ([]userdata.RecordResult, error)
+	RewrapRecords(ctx context.Context, userID userdata.UserID, records []userdata.Record) ([]userdata.RecordResult, error)
+}
+
+// RecordStorager interface for DB storage of records, which may be created, deleted and replaced in transaction with files.
+// Transaction is committed only if commit func succeeds.
+//
+//go:generate mockery --name RecordStorager
+type RecordStorager interface {
+	Storager
+	CreateRecordTx(ctx context.Context, userID userdata.UserID, record userdata.Record, commit func(userdata.RecordMeta) error) (userdata.RecordMeta, error)
+	DeleteRecordTx(ctx context.Context, userID userdata.UserID, recordID string, commit func() error) error
+	DeleteRecordsTx(ctx context.Context, userID userdata.UserID, recordIDs []string, commit func(deleted []string) error) ([]userdata.RecordResult, error)
+	ReplaceRecordsTx(ctx context.Context, userID userdata.UserID, records []userdata.Record, commit func(growth int64) error) ([]userdata.RecordResult, error)
+}
+
+// FsckStorager interface for DB storage, which lists file records for storage consistency check.
+//
+//go:generate mockery --name FsckStorager
+type FsckStorager interface {
+	ListFileRecords(ctx context.Context) (map[string]userdata.FileRecordInfo, error)
+	DeleteRecord(ctx context.Context, userID userdata.UserID, recordID string) error
+}
+
+// ScrubStorager interface for DB storage, which lists checksums of file records for their verification.
+//
+//go:generate mockery --name ScrubStorager
+type ScrubStorager interface {
+	ListFileChecksums(ctx context.Context) (map[string]string, error)
+}
+
+// AdminStorager interface for storage of user accounts administration.
+//
+//go:generate mockery --name AdminStorager
+type AdminStorager interface {
+	ListUsers(ctx context.Context) ([]userdata.UserInfo, error)
+	GetStats(ctx context.Context) (userdata.ServerStats, error)
+	IsAdmin(ctx context.Context, userID userdata.UserID) (bool, error)
+	SetUserAdmin(ctx context.Context, login string, admin bool) error
+	SetUserDisabled(ctx context.Context, login string, disabled bool) error
+	SetUserQuota(ctx context.Context, login string, quota int64) error
+	RevokeTokens(ctx context.Context, login string) error
+	CheckSession(userID userdata.UserID, issuedAt time.Time) error
+}
+
+// IdempotencyStorager interface for storage of mutating requests by idempotency keys.
+//
+//go:generate mockery --name IdempotencyStorager
+type IdempotencyStorager interface {
+	ReserveIdempotencyKey(ctx context.Context, request userdata.IdempotentRequest, window time.Duration) (userdata.IdempotentRequest, bool, error)
+	CompleteIdempotencyKey(ctx context.Context, request userdata.IdempotentRequest) error
+	ReleaseIdempotencyKey(ctx context.Context, request userdata.IdempotentRequest) error
+	DeleteExpiredIdempotencyKeys(ctx context.Context, before time.Time) (int64, error)
 }
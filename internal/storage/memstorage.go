@@ -0,0 +1,760 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/impr0ver/gophKeeper/internal/userdata"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// memUser is user account in memory storage.
+type memUser struct {
+	id         userdata.UserID
+	login      string
+	password   string
+	keySalt    []byte
+	admin      bool
+	disabled   bool
+	quota      int64
+	validAfter time.Time
+}
+
+// memRecord is record in memory storage.
+type memRecord struct {
+	userID userdata.UserID
+	record userdata.Record
+}
+
+// memStorage is DB storage in memory for tests and development, it has the same semantics and errors as dbStorage.
+// Transactions hold lock of storage until commit, so they are serializable.
+type memStorage struct {
+	mu          sync.Mutex
+	users       map[userdata.UserID]*memUser
+	records     map[string]memRecord
+	idempotency map[[2]string]userdata.IdempotentRequest
+}
+
+// newMemStorage returns empty memory storage.
+func newMemStorage() *memStorage {
+	return &memStorage{
+		users:       make(map[userdata.UserID]*memUser),
+		records:     make(map[string]memRecord),
+		idempotency: make(map[[2]string]userdata.IdempotentRequest),
+	}
+}
+
+// MigrateUP does nothing, memory storage has no schema.
+func (ms *memStorage) MigrateUP() {}
+
+// userByLogin returns user by login, storage must be locked.
+func (ms *memStorage) userByLogin(login string) *memUser {
+	for _, user := range ms.users {
+		if user.login == login {
+			return user
+		}
+	}
+
+	return nil
+}
+
+// usage returns records count and size of user, storage must be locked.
+func (ms *memStorage) usage(user *memUser) userdata.Usage {
+	usage := userdata.Usage{Quota: user.quota}
+	for _, saved := range ms.records {
+		if saved.userID == user.id {
+			usage.Records++
+			usage.Bytes += saved.record.Size
+		}
+	}
+
+	return usage
+}
+
+// CreateUser saves new user.
+func (ms *memStorage) CreateUser(credentials userdata.UserCredentials) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if ms.userByLogin(credentials.Login) != nil {
+		return ErrLoginExists
+	}
+
+	id, err := NewRecordID()
+	if err != nil {
+		log.Infoln(err)
+
+		return ErrUnknown
+	}
+
+	salt, err := newKeySalt()
+	if err != nil {
+		log.Infoln(err)
+
+		return ErrUnknown
+	}
+
+	ms.users[userdata.UserID(id)] = &memUser{id: userdata.UserID(id), login: credentials.Login, password: credentials.Password, keySalt: salt}
+
+	return nil
+}
+
+// LoginUser finds user by login and checks password with verify, saved hash is replaced by rehash if any.
+func (ms *memStorage) LoginUser(login string, verify func(hash string) (string, error)) (userdata.UserID, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	user := ms.userByLogin(login)
+	if user == nil {
+		return "", ErrWrongCredentials
+	}
+
+	rehash, err := verify(user.password)
+	if err != nil {
+		log.Infoln(err)
+
+		return "", ErrWrongCredentials
+	}
+
+	if user.disabled {
+		return "", ErrUserDisabled
+	}
+
+	if rehash != "" {
+		user.password = rehash
+	}
+
+	return user.id, nil
+}
+
+// GetPasswordHash gets saved password hash of user.
+func (ms *memStorage) GetPasswordHash(login string) (string, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	user := ms.userByLogin(login)
+	if user == nil {
+		return "", ErrWrongCredentials
+	}
+
+	return user.password, nil
+}
+
+// GetKeySalt gets salt of user encryption key.
+func (ms *memStorage) GetKeySalt(_ context.Context, userID userdata.UserID) ([]byte, error) {
+	if userID == "" {
+		return nil, ErrUnauthenticated
+	}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	user, ok := ms.users[userID]
+	if !ok {
+		return nil, ErrUnauthenticated
+	}
+
+	return bytes.Clone(user.keySalt), nil
+}
+
+// GetRecordsInfo gets all records of user without data in order of creation.
+func (ms *memStorage) GetRecordsInfo(_ context.Context, userID userdata.UserID) ([]userdata.Record, error) {
+	if userID == "" {
+		return nil, ErrUnauthenticated
+	}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	result := make([]userdata.Record, 0, 10)
+	for _, saved := range ms.records {
+		if saved.userID != userID {
+			continue
+		}
+
+		result = append(result, userdata.Record{
+			ID:        saved.record.ID,
+			Type:      saved.record.Type,
+			KeyHint:   saved.record.KeyHint,
+			DataKey:   bytes.Clone(saved.record.DataKey),
+			Metadata:  saved.record.Metadata,
+			Version:   saved.record.Version,
+			CreatedAt: saved.record.CreatedAt,
+			UpdatedAt: saved.record.UpdatedAt,
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].CreatedAt.Equal(result[j].CreatedAt) {
+			return result[i].CreatedAt.Before(result[j].CreatedAt)
+		}
+
+		return result[i].ID < result[j].ID
+	})
+
+	return result, nil
+}
+
+// GetUserUsage gets count and size of user records and user quota.
+func (ms *memStorage) GetUserUsage(_ context.Context, userID userdata.UserID) (userdata.Usage, error) {
+	if userID == "" {
+		return userdata.Usage{}, ErrUnauthenticated
+	}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	user, ok := ms.users[userID]
+	if !ok {
+		return userdata.Usage{}, ErrUnauthenticated
+	}
+
+	return ms.usage(user), nil
+}
+
+// insert saves new record with ID, storage must be locked.
+func (ms *memStorage) insert(userID userdata.UserID, id string, record userdata.Record) userdata.RecordMeta {
+	now := time.Now().UTC()
+
+	record.ID = id
+	record.Data = bytes.Clone(record.Data)
+	record.DataKey = bytes.Clone(record.DataKey)
+	record.Version = 1
+	record.CreatedAt = now
+	record.UpdatedAt = now
+
+	ms.records[id] = memRecord{userID: userID, record: record}
+
+	return record.Meta()
+}
+
+// CreateRecord saves new record and return its ID, version and timestamps.
+func (ms *memStorage) CreateRecord(ctx context.Context, userID userdata.UserID, record userdata.Record) (userdata.RecordMeta, error) {
+	return ms.CreateRecordTx(ctx, userID, record, func(userdata.RecordMeta) error { return nil })
+}
+
+// CreateRecordTx saves new record, which is deleted if commit fails.
+func (ms *memStorage) CreateRecordTx(_ context.Context, userID userdata.UserID, record userdata.Record, commit func(userdata.RecordMeta) error) (userdata.RecordMeta, error) {
+	if userID == "" {
+		return userdata.RecordMeta{}, ErrUnauthenticated
+	}
+
+	id, err := newRecordID(record)
+	if err != nil {
+		log.Infoln(err)
+
+		return userdata.RecordMeta{}, ErrUnknown
+	}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	// Record ID is primary key in DB
+	if _, ok := ms.records[id]; ok {
+		return userdata.RecordMeta{}, ErrAlreadyExists
+	}
+
+	meta := ms.insert(userID, id, record)
+
+	if err := commit(meta); err != nil {
+		delete(ms.records, id)
+
+		return userdata.RecordMeta{}, err
+	}
+
+	return meta, nil
+}
+
+// get returns record of user with data, storage must be locked.
+func (ms *memStorage) get(userID userdata.UserID, recordID string) (userdata.Record, bool) {
+	saved, ok := ms.records[strings.ToLower(recordID)]
+	if !ok || saved.userID != userID {
+		return userdata.Record{}, false
+	}
+
+	// Size isn't returned with record like in DB
+	record := saved.record
+	record.Data = bytes.Clone(record.Data)
+	record.DataKey = bytes.Clone(record.DataKey)
+	record.Size = 0
+
+	return record, true
+}
+
+// GetRecord gets record of user.
+func (ms *memStorage) GetRecord(_ context.Context, userID userdata.UserID, recordID string) (userdata.Record, error) {
+	if userID == "" {
+		return userdata.Record{}, ErrUnauthenticated
+	}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	record, ok := ms.get(userID, recordID)
+	if !ok {
+		return userdata.Record{}, ErrNotFound
+	}
+
+	return record, nil
+}
+
+// DeleteRecord deletes record of user.
+func (ms *memStorage) DeleteRecord(ctx context.Context, userID userdata.UserID, recordID string) error {
+	return ms.DeleteRecordTx(ctx, userID, recordID, func() error { return nil })
+}
+
+// DeleteRecordTx deletes record of user, it's restored if commit fails.
+func (ms *memStorage) DeleteRecordTx(_ context.Context, userID userdata.UserID, recordID string, commit func() error) error {
+	if userID == "" {
+		return ErrUnauthenticated
+	}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	id := strings.ToLower(recordID)
+	saved, ok := ms.records[id]
+	if !ok || saved.userID != userID {
+		return ErrNotFound
+	}
+
+	delete(ms.records, id)
+
+	if err := commit(); err != nil {
+		ms.records[id] = saved
+
+		return err
+	}
+
+	return nil
+}
+
+// CreateRecords saves records and returns their meta in the same order, records with used IDs get ErrAlreadyExists.
+func (ms *memStorage) CreateRecords(_ context.Context, userID userdata.UserID, records []userdata.Record) ([]userdata.RecordResult, error) {
+	if userID == "" {
+		return nil, ErrUnauthenticated
+	}
+
+	results := make([]userdata.RecordResult, len(records))
+	for i, record := range records {
+		id, err := newRecordID(record)
+		if err != nil {
+			log.Infoln(err)
+
+			return nil, ErrUnknown
+		}
+
+		results[i].ID = id
+	}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	for i, record := range records {
+		// Record ID is primary key in DB, record with the same ID in batch is inserted first
+		if _, ok := ms.records[results[i].ID]; ok {
+			results[i].Err = ErrAlreadyExists
+			continue
+		}
+
+		meta := ms.insert(userID, results[i].ID, record)
+		results[i].Record = userdata.Record{ID: meta.ID, Version: meta.Version, CreatedAt: meta.CreatedAt, UpdatedAt: meta.UpdatedAt}
+	}
+
+	return results, nil
+}
+
+// GetRecords gets records of user, results are in order of IDs, missing records have ErrNotFound.
+func (ms *memStorage) GetRecords(_ context.Context, userID userdata.UserID, recordIDs []string) ([]userdata.RecordResult, error) {
+	if userID == "" {
+		return nil, ErrUnauthenticated
+	}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	results := make([]userdata.RecordResult, len(recordIDs))
+	for i, id := range recordIDs {
+		results[i].ID = id
+
+		record, ok := ms.get(userID, id)
+		if !ok {
+			results[i].Err = ErrNotFound
+			continue
+		}
+
+		results[i].Record = record
+	}
+
+	return results, nil
+}
+
+// DeleteRecords deletes records of user, results are in order of IDs, missing records have ErrNotFound.
+func (ms *memStorage) DeleteRecords(ctx context.Context, userID userdata.UserID, recordIDs []string) ([]userdata.RecordResult, error) {
+	return ms.DeleteRecordsTx(ctx, userID, recordIDs, func([]string) error { return nil })
+}
+
+// DeleteRecordsTx deletes records of user, they are restored if commit fails.
+func (ms *memStorage) DeleteRecordsTx(_ context.Context, userID userdata.UserID, recordIDs []string, commit func(deleted []string) error) ([]userdata.RecordResult, error) {
+	if userID == "" {
+		return nil, ErrUnauthenticated
+	}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	saved := make(map[string]memRecord)
+	results := make([]userdata.RecordResult, len(recordIDs))
+	for i, id := range recordIDs {
+		results[i].ID = id
+
+		id = strings.ToLower(id)
+		record, ok := ms.records[id]
+		if !ok || record.userID != userID {
+			results[i].Err = ErrNotFound
+			continue
+		}
+
+		saved[id] = record
+		delete(ms.records, id)
+	}
+
+	deleted := make([]string, 0, len(saved))
+	for id := range saved {
+		deleted = append(deleted, id)
+	}
+
+	if err := commit(deleted); err != nil {
+		for id, record := range saved {
+			ms.records[id] = record
+		}
+
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// ReplaceRecords replaces data and key hints of records.
+func (ms *memStorage) ReplaceRecords(ctx context.Context, userID userdata.UserID, records []userdata.Record) ([]userdata.RecordResult, error) {
+	return ms.ReplaceRecordsTx(ctx, userID, records, func(int64) error { return nil })
+}
+
+// ReplaceRecordsTx replaces data and key hints of records, they are restored if commit fails.
+func (ms *memStorage) ReplaceRecordsTx(_ context.Context, userID userdata.UserID, records []userdata.Record, commit func(growth int64) error) ([]userdata.RecordResult, error) {
+	if userID == "" {
+		return nil, ErrUnauthenticated
+	}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	saved, growth, err := ms.replaced(userID, records)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	results := make([]userdata.RecordResult, len(records))
+	for i, record := range records {
+		id := strings.ToLower(record.ID)
+		replaced := saved[id].record
+		replaced.KeyHint = record.KeyHint
+		replaced.DataKey = bytes.Clone(record.DataKey)
+		replaced.Data = bytes.Clone(record.Data)
+		replaced.Size = record.Size
+		replaced.Checksum = record.Checksum
+		replaced.Version++
+		replaced.UpdatedAt = now
+
+		ms.records[id] = memRecord{userID: userID, record: replaced}
+		results[i] = userdata.RecordResult{ID: record.ID, Record: userdata.Record{ID: id, Version: replaced.Version, CreatedAt: replaced.CreatedAt, UpdatedAt: now}}
+	}
+
+	if err := commit(growth); err != nil {
+		for id, old := range saved {
+			ms.records[id] = old
+		}
+
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// RewrapRecords replaces key hints and wrapped data keys of records, data of records isn't changed.
+func (ms *memStorage) RewrapRecords(_ context.Context, userID userdata.UserID, records []userdata.Record) ([]userdata.RecordResult, error) {
+	if userID == "" {
+		return nil, ErrUnauthenticated
+	}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	saved, _, err := ms.replaced(userID, records)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	results := make([]userdata.RecordResult, len(records))
+	for i, record := range records {
+		id := strings.ToLower(record.ID)
+		rewrapped := saved[id].record
+		rewrapped.KeyHint = record.KeyHint
+		rewrapped.DataKey = bytes.Clone(record.DataKey)
+		rewrapped.Version++
+		rewrapped.UpdatedAt = now
+
+		ms.records[id] = memRecord{userID: userID, record: rewrapped}
+		results[i] = userdata.RecordResult{ID: record.ID, Record: userdata.Record{ID: id, Version: rewrapped.Version, CreatedAt: rewrapped.CreatedAt, UpdatedAt: now}}
+	}
+
+	return results, nil
+}
+
+// replaced returns saved records of batch by IDs and growth of data size.
+// Batch is replaced entirely or not at all, as in DB.
+func (ms *memStorage) replaced(userID userdata.UserID, records []userdata.Record) (map[string]memRecord, int64, error) {
+	saved := make(map[string]memRecord, len(records))
+	var growth int64
+	for _, record := range records {
+		id := strings.ToLower(record.ID)
+		old, ok := ms.records[id]
+		if !ok || old.userID != userID {
+			return nil, 0, ErrNotFound
+		}
+
+		if _, ok := saved[id]; ok || old.record.Type != record.Type || old.record.Version != record.Version {
+			return nil, 0, ErrVersionConflict
+		}
+
+		saved[id] = old
+		growth += record.Size - old.record.Size
+	}
+
+	return saved, growth, nil
+}
+
+// ListFileRecords returns owners and update times of all file records by record IDs.
+func (ms *memStorage) ListFileRecords(_ context.Context) (map[string]userdata.FileRecordInfo, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	records := make(map[string]userdata.FileRecordInfo)
+	for id, saved := range ms.records {
+		if saved.record.Type == userdata.TypeFile {
+			records[id] = userdata.FileRecordInfo{UserID: saved.userID, UpdatedAt: saved.record.UpdatedAt}
+		}
+	}
+
+	return records, nil
+}
+
+// ListFileChecksums returns checksums of all file records by record IDs.
+func (ms *memStorage) ListFileChecksums(_ context.Context) (map[string]string, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	checksums := make(map[string]string)
+	for id, saved := range ms.records {
+		if saved.record.Type == userdata.TypeFile {
+			checksums[id] = saved.record.Checksum
+		}
+	}
+
+	return checksums, nil
+}
+
+// ListUsers gets all users with their records count and usage ordered by login.
+func (ms *memStorage) ListUsers(_ context.Context) ([]userdata.UserInfo, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	result := make([]userdata.UserInfo, 0, len(ms.users))
+	for _, user := range ms.users {
+		result = append(result, userdata.UserInfo{
+			ID:       user.id,
+			Login:    user.login,
+			Admin:    user.admin,
+			Disabled: user.disabled,
+			Usage:    ms.usage(user),
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Login < result[j].Login })
+
+	return result, nil
+}
+
+// GetStats gets users and records counters.
+func (ms *memStorage) GetStats(_ context.Context) (userdata.ServerStats, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	stats := userdata.ServerStats{RecordsByType: make(map[userdata.RecordType]int64)}
+
+	for _, user := range ms.users {
+		stats.Users++
+		if user.disabled {
+			stats.DisabledUsers++
+		}
+		if user.admin {
+			stats.Admins++
+		}
+	}
+
+	for _, saved := range ms.records {
+		stats.RecordsByType[saved.record.Type]++
+		stats.Records++
+		stats.StoredBytes += saved.record.Size
+	}
+
+	return stats, nil
+}
+
+// IsAdmin checks if user has admin role.
+func (ms *memStorage) IsAdmin(_ context.Context, userID userdata.UserID) (bool, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	user, ok := ms.users[userID]
+	if !ok {
+		return false, ErrUserNotFound
+	}
+
+	return user.admin && !user.disabled, nil
+}
+
+// updateUser changes user by login.
+func (ms *memStorage) updateUser(login string, update func(user *memUser)) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	user := ms.userByLogin(login)
+	if user == nil {
+		return ErrUserNotFound
+	}
+
+	update(user)
+
+	return nil
+}
+
+// SetUserAdmin grants or revokes admin role of user.
+func (ms *memStorage) SetUserAdmin(_ context.Context, login string, admin bool) error {
+	return ms.updateUser(login, func(user *memUser) { user.admin = admin })
+}
+
+// SetUserDisabled disables or enables user account.
+func (ms *memStorage) SetUserDisabled(_ context.Context, login string, disabled bool) error {
+	return ms.updateUser(login, func(user *memUser) { user.disabled = disabled })
+}
+
+// SetUserQuota sets user storage quota in bytes, zero quota resets it to server default.
+func (ms *memStorage) SetUserQuota(_ context.Context, login string, quota int64) error {
+	if quota < 0 {
+		quota = 0
+	}
+
+	return ms.updateUser(login, func(user *memUser) { user.quota = quota })
+}
+
+// RevokeTokens makes all tokens of user issued before now invalid.
+func (ms *memStorage) RevokeTokens(_ context.Context, login string) error {
+	return ms.updateUser(login, func(user *memUser) { user.validAfter = time.Now().UTC() })
+}
+
+// CheckSession checks that user is enabled and token was issued after last revoke.
+func (ms *memStorage) CheckSession(userID userdata.UserID, issuedAt time.Time) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	user, ok := ms.users[userID]
+	if !ok {
+		return ErrUnauthenticated
+	}
+
+	if user.disabled {
+		return ErrUserDisabled
+	}
+
+	// JWT issue time has seconds precision
+	if !user.validAfter.IsZero() && issuedAt.Before(user.validAfter.Truncate(time.Second)) {
+		return ErrUnauthenticated
+	}
+
+	return nil
+}
+
+// ReserveIdempotencyKey saves new request by key, expired request with the same key is replaced.
+// If key is already used in window, saved request is returned and reserved is false.
+func (ms *memStorage) ReserveIdempotencyKey(_ context.Context, request userdata.IdempotentRequest, window time.Duration) (userdata.IdempotentRequest, bool, error) {
+	now := time.Now().UTC()
+	key := [2]string{request.Scope, request.Key}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if saved, ok := ms.idempotency[key]; ok && !saved.CreatedAt.Before(now.Add(-window)) {
+		saved.Response = bytes.Clone(saved.Response)
+
+		return saved, false, nil
+	}
+
+	request.Response = nil
+	request.CreatedAt = now
+	ms.idempotency[key] = request
+
+	return request, true, nil
+}
+
+// CompleteIdempotencyKey saves response of reserved request.
+func (ms *memStorage) CompleteIdempotencyKey(_ context.Context, request userdata.IdempotentRequest) error {
+	key := [2]string{request.Scope, request.Key}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if saved, ok := ms.idempotency[key]; ok {
+		saved.Response = bytes.Clone(request.Response)
+		ms.idempotency[key] = saved
+	}
+
+	return nil
+}
+
+// ReleaseIdempotencyKey deletes reserved request without response, so it may be done again.
+func (ms *memStorage) ReleaseIdempotencyKey(_ context.Context, request userdata.IdempotentRequest) error {
+	key := [2]string{request.Scope, request.Key}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if saved, ok := ms.idempotency[key]; ok && saved.Response == nil {
+		delete(ms.idempotency, key)
+	}
+
+	return nil
+}
+
+// DeleteExpiredIdempotencyKeys deletes requests saved before time, returns number of deleted requests.
+func (ms *memStorage) DeleteExpiredIdempotencyKeys(_ context.Context, before time.Time) (int64, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	var deleted int64
+	for key, saved := range ms.idempotency {
+		if saved.CreatedAt.Before(before) {
+			delete(ms.idempotency, key)
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
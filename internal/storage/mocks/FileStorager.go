@@ -15,6 +15,52 @@ type FileStorager struct {
 	mock.Mock
 }
 
+// Checksum provides a mock function with given fields: ctx, recordID
+func (_m *FileStorager) Checksum(ctx context.Context, recordID string) (string, error) {
+	ret := _m.Called(ctx, recordID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Checksum")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (string, error)); ok {
+		return rf(ctx, recordID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) string); ok {
+		r0 = rf(ctx, recordID)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, recordID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CommitRecord provides a mock function with given fields: ctx, staged, recordID
+func (_m *FileStorager) CommitRecord(ctx context.Context, staged string, recordID string) error {
+	ret := _m.Called(ctx, staged, recordID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CommitRecord")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, staged, recordID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // CreateRecord provides a mock function with given fields: ctx, record
 func (_m *FileStorager) CreateRecord(ctx context.Context, record userdata.Record) (string, error) {
 	ret := _m.Called(ctx, record)
@@ -61,6 +107,24 @@ func (_m *FileStorager) DeleteRecord(ctx context.Context, recordID string) error
 	return r0
 }
 
+// DiscardRecord provides a mock function with given fields: ctx, staged
+func (_m *FileStorager) DiscardRecord(ctx context.Context, staged string) error {
+	ret := _m.Called(ctx, staged)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DiscardRecord")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, staged)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // GetRecord provides a mock function with given fields: ctx, recordID
 func (_m *FileStorager) GetRecord(ctx context.Context, recordID string) (userdata.Record, error) {
 	ret := _m.Called(ctx, recordID)
@@ -89,6 +153,110 @@ func (_m *FileStorager) GetRecord(ctx context.Context, recordID string) (userdat
 	return r0, r1
 }
 
+// ListFiles provides a mock function with given fields: ctx
+func (_m *FileStorager) ListFiles(ctx context.Context) ([]userdata.StoredFile, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListFiles")
+	}
+
+	var r0 []userdata.StoredFile
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]userdata.StoredFile, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []userdata.StoredFile); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]userdata.StoredFile)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// QuarantineFile provides a mock function with given fields: ctx, name
+func (_m *FileStorager) QuarantineFile(ctx context.Context, name string) error {
+	ret := _m.Called(ctx, name)
+
+	if len(ret) == 0 {
+		panic("no return value specified for QuarantineFile")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, name)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// StageDelete provides a mock function with given fields: ctx, recordID
+func (_m *FileStorager) StageDelete(ctx context.Context, recordID string) (string, error) {
+	ret := _m.Called(ctx, recordID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for StageDelete")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (string, error)); ok {
+		return rf(ctx, recordID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) string); ok {
+		r0 = rf(ctx, recordID)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, recordID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// StageRecord provides a mock function with given fields: ctx, data
+func (_m *FileStorager) StageRecord(ctx context.Context, data []byte) (string, error) {
+	ret := _m.Called(ctx, data)
+
+	if len(ret) == 0 {
+		panic("no return value specified for StageRecord")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []byte) (string, error)); ok {
+		return rf(ctx, data)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []byte) string); ok {
+		r0 = rf(ctx, data)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []byte) error); ok {
+		r1 = rf(ctx, data)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // NewFileStorager creates a new instance of FileStorager. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewFileStorager(t interface {
@@ -15,27 +15,57 @@ type Storager struct {
 	mock.Mock
 }
 
-// CreateRecord provides a mock function with given fields: ctx, record
-func (_m *Storager) CreateRecord(ctx context.Context, record userdata.Record) (string, error) {
-	ret := _m.Called(ctx, record)
+// CreateRecord provides a mock function with given fields: ctx, userID, record
+func (_m *Storager) CreateRecord(ctx context.Context, userID userdata.UserID, record userdata.Record) (userdata.RecordMeta, error) {
+	ret := _m.Called(ctx, userID, record)
 
 	if len(ret) == 0 {
 		panic("no return value specified for CreateRecord")
 	}
 
-	var r0 string
+	var r0 userdata.RecordMeta
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, userdata.Record) (string, error)); ok {
-		return rf(ctx, record)
+	if rf, ok := ret.Get(0).(func(context.Context, userdata.UserID, userdata.Record) (userdata.RecordMeta, error)); ok {
+		return rf(ctx, userID, record)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, userdata.Record) string); ok {
-		r0 = rf(ctx, record)
+	if rf, ok := ret.Get(0).(func(context.Context, userdata.UserID, userdata.Record) userdata.RecordMeta); ok {
+		r0 = rf(ctx, userID, record)
 	} else {
-		r0 = ret.Get(0).(string)
+		r0 = ret.Get(0).(userdata.RecordMeta)
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, userdata.Record) error); ok {
-		r1 = rf(ctx, record)
+	if rf, ok := ret.Get(1).(func(context.Context, userdata.UserID, userdata.Record) error); ok {
+		r1 = rf(ctx, userID, record)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateRecords provides a mock function with given fields: ctx, userID, records
+func (_m *Storager) CreateRecords(ctx context.Context, userID userdata.UserID, records []userdata.Record) ([]userdata.RecordResult, error) {
+	ret := _m.Called(ctx, userID, records)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateRecords")
+	}
+
+	var r0 []userdata.RecordResult
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, userdata.UserID, []userdata.Record) ([]userdata.RecordResult, error)); ok {
+		return rf(ctx, userID, records)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, userdata.UserID, []userdata.Record) []userdata.RecordResult); ok {
+		r0 = rf(ctx, userID, records)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]userdata.RecordResult)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, userdata.UserID, []userdata.Record) error); ok {
+		r1 = rf(ctx, userID, records)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -61,17 +91,17 @@ func (_m *Storager) CreateUser(credentials userdata.UserCredentials) error {
 	return r0
 }
 
-// DeleteRecord provides a mock function with given fields: ctx, recordID
-func (_m *Storager) DeleteRecord(ctx context.Context, recordID string) error {
-	ret := _m.Called(ctx, recordID)
+// DeleteRecord provides a mock function with given fields: ctx, userID, recordID
+func (_m *Storager) DeleteRecord(ctx context.Context, userID userdata.UserID, recordID string) error {
+	ret := _m.Called(ctx, userID, recordID)
 
 	if len(ret) == 0 {
 		panic("no return value specified for DeleteRecord")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
-		r0 = rf(ctx, recordID)
+	if rf, ok := ret.Get(0).(func(context.Context, userdata.UserID, string) error); ok {
+		r0 = rf(ctx, userID, recordID)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -79,9 +109,97 @@ func (_m *Storager) DeleteRecord(ctx context.Context, recordID string) error {
 	return r0
 }
 
-// GetRecord provides a mock function with given fields: ctx, recordID
-func (_m *Storager) GetRecord(ctx context.Context, recordID string) (userdata.Record, error) {
-	ret := _m.Called(ctx, recordID)
+// DeleteRecords provides a mock function with given fields: ctx, userID, recordIDs
+func (_m *Storager) DeleteRecords(ctx context.Context, userID userdata.UserID, recordIDs []string) ([]userdata.RecordResult, error) {
+	ret := _m.Called(ctx, userID, recordIDs)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteRecords")
+	}
+
+	var r0 []userdata.RecordResult
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, userdata.UserID, []string) ([]userdata.RecordResult, error)); ok {
+		return rf(ctx, userID, recordIDs)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, userdata.UserID, []string) []userdata.RecordResult); ok {
+		r0 = rf(ctx, userID, recordIDs)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]userdata.RecordResult)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, userdata.UserID, []string) error); ok {
+		r1 = rf(ctx, userID, recordIDs)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetKeySalt provides a mock function with given fields: ctx, userID
+func (_m *Storager) GetKeySalt(ctx context.Context, userID userdata.UserID) ([]byte, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetKeySalt")
+	}
+
+	var r0 []byte
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, userdata.UserID) ([]byte, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, userdata.UserID) []byte); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]byte)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, userdata.UserID) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPasswordHash provides a mock function with given fields: login
+func (_m *Storager) GetPasswordHash(login string) (string, error) {
+	ret := _m.Called(login)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetPasswordHash")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (string, error)); ok {
+		return rf(login)
+	}
+	if rf, ok := ret.Get(0).(func(string) string); ok {
+		r0 = rf(login)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(login)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetRecord provides a mock function with given fields: ctx, userID, recordID
+func (_m *Storager) GetRecord(ctx context.Context, userID userdata.UserID, recordID string) (userdata.Record, error) {
+	ret := _m.Called(ctx, userID, recordID)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetRecord")
@@ -89,17 +207,47 @@ func (_m *Storager) GetRecord(ctx context.Context, recordID string) (userdata.Re
 
 	var r0 userdata.Record
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) (userdata.Record, error)); ok {
-		return rf(ctx, recordID)
+	if rf, ok := ret.Get(0).(func(context.Context, userdata.UserID, string) (userdata.Record, error)); ok {
+		return rf(ctx, userID, recordID)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string) userdata.Record); ok {
-		r0 = rf(ctx, recordID)
+	if rf, ok := ret.Get(0).(func(context.Context, userdata.UserID, string) userdata.Record); ok {
+		r0 = rf(ctx, userID, recordID)
 	} else {
 		r0 = ret.Get(0).(userdata.Record)
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = rf(ctx, recordID)
+	if rf, ok := ret.Get(1).(func(context.Context, userdata.UserID, string) error); ok {
+		r1 = rf(ctx, userID, recordID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetRecords provides a mock function with given fields: ctx, userID, recordIDs
+func (_m *Storager) GetRecords(ctx context.Context, userID userdata.UserID, recordIDs []string) ([]userdata.RecordResult, error) {
+	ret := _m.Called(ctx, userID, recordIDs)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetRecords")
+	}
+
+	var r0 []userdata.RecordResult
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, userdata.UserID, []string) ([]userdata.RecordResult, error)); ok {
+		return rf(ctx, userID, recordIDs)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, userdata.UserID, []string) []userdata.RecordResult); ok {
+		r0 = rf(ctx, userID, recordIDs)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]userdata.RecordResult)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, userdata.UserID, []string) error); ok {
+		r1 = rf(ctx, userID, recordIDs)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -107,9 +255,9 @@ func (_m *Storager) GetRecord(ctx context.Context, recordID string) (userdata.Re
 	return r0, r1
 }
 
-// GetRecordsInfo provides a mock function with given fields: ctx
-func (_m *Storager) GetRecordsInfo(ctx context.Context) ([]userdata.Record, error) {
-	ret := _m.Called(ctx)
+// GetRecordsInfo provides a mock function with given fields: ctx, userID
+func (_m *Storager) GetRecordsInfo(ctx context.Context, userID userdata.UserID) ([]userdata.Record, error) {
+	ret := _m.Called(ctx, userID)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetRecordsInfo")
@@ -117,19 +265,19 @@ func (_m *Storager) GetRecordsInfo(ctx context.Context) ([]userdata.Record, erro
 
 	var r0 []userdata.Record
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context) ([]userdata.Record, error)); ok {
-		return rf(ctx)
+	if rf, ok := ret.Get(0).(func(context.Context, userdata.UserID) ([]userdata.Record, error)); ok {
+		return rf(ctx, userID)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context) []userdata.Record); ok {
-		r0 = rf(ctx)
+	if rf, ok := ret.Get(0).(func(context.Context, userdata.UserID) []userdata.Record); ok {
+		r0 = rf(ctx, userID)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]userdata.Record)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
-		r1 = rf(ctx)
+	if rf, ok := ret.Get(1).(func(context.Context, userdata.UserID) error); ok {
+		r1 = rf(ctx, userID)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -137,9 +285,37 @@ func (_m *Storager) GetRecordsInfo(ctx context.Context) ([]userdata.Record, erro
 	return r0, r1
 }
 
-// LoginUser provides a mock function with given fields: credentials
-func (_m *Storager) LoginUser(credentials userdata.UserCredentials) (userdata.UserID, error) {
-	ret := _m.Called(credentials)
+// GetUserUsage provides a mock function with given fields: ctx, userID
+func (_m *Storager) GetUserUsage(ctx context.Context, userID userdata.UserID) (userdata.Usage, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUserUsage")
+	}
+
+	var r0 userdata.Usage
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, userdata.UserID) (userdata.Usage, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, userdata.UserID) userdata.Usage); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		r0 = ret.Get(0).(userdata.Usage)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, userdata.UserID) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// LoginUser provides a mock function with given fields: login, verify
+func (_m *Storager) LoginUser(login string, verify func(hash string) (string, error)) (userdata.UserID, error) {
+	ret := _m.Called(login, verify)
 
 	if len(ret) == 0 {
 		panic("no return value specified for LoginUser")
@@ -147,17 +323,77 @@ func (_m *Storager) LoginUser(credentials userdata.UserCredentials) (userdata.Us
 
 	var r0 userdata.UserID
 	var r1 error
-	if rf, ok := ret.Get(0).(func(userdata.UserCredentials) (userdata.UserID, error)); ok {
-		return rf(credentials)
+	if rf, ok := ret.Get(0).(func(string, func(hash string) (string, error)) (userdata.UserID, error)); ok {
+		return rf(login, verify)
 	}
-	if rf, ok := ret.Get(0).(func(userdata.UserCredentials) userdata.UserID); ok {
-		r0 = rf(credentials)
+	if rf, ok := ret.Get(0).(func(string, func(hash string) (string, error)) userdata.UserID); ok {
+		r0 = rf(login, verify)
 	} else {
 		r0 = ret.Get(0).(userdata.UserID)
 	}
 
-	if rf, ok := ret.Get(1).(func(userdata.UserCredentials) error); ok {
-		r1 = rf(credentials)
+	if rf, ok := ret.Get(1).(func(string, func(hash string) (string, error)) error); ok {
+		r1 = rf(login, verify)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ReplaceRecords provides a mock function with given fields: ctx, userID, records
+func (_m *Storager) ReplaceRecords(ctx context.Context, userID userdata.UserID, records []userdata.Record) ([]userdata.RecordResult, error) {
+	ret := _m.Called(ctx, userID, records)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReplaceRecords")
+	}
+
+	var r0 []userdata.RecordResult
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, userdata.UserID, []userdata.Record) ([]userdata.RecordResult, error)); ok {
+		return rf(ctx, userID, records)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, userdata.UserID, []userdata.Record) []userdata.RecordResult); ok {
+		r0 = rf(ctx, userID, records)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]userdata.RecordResult)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, userdata.UserID, []userdata.Record) error); ok {
+		r1 = rf(ctx, userID, records)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RewrapRecords provides a mock function with given fields: ctx, userID, records
+func (_m *Storager) RewrapRecords(ctx context.Context, userID userdata.UserID, records []userdata.Record) ([]userdata.RecordResult, error) {
+	ret := _m.Called(ctx, userID, records)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RewrapRecords")
+	}
+
+	var r0 []userdata.RecordResult
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, userdata.UserID, []userdata.Record) ([]userdata.RecordResult, error)); ok {
+		return rf(ctx, userID, records)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, userdata.UserID, []userdata.Record) []userdata.RecordResult); ok {
+		r0 = rf(ctx, userID, records)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]userdata.RecordResult)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, userdata.UserID, []userdata.Record) error); ok {
+		r1 = rf(ctx, userID, records)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -0,0 +1,405 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/impr0ver/gophKeeper/internal/userdata"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// s3Algorithm is algorithm of AWS Signature Version 4.
+	s3Algorithm = "AWS4-HMAC-SHA256"
+	// s3DateFormat is format of X-Amz-Date header.
+	s3DateFormat = "20060102T150405Z"
+	// s3UnsignedPayload is used instead of hash of streamed body.
+	s3UnsignedPayload = "UNSIGNED-PAYLOAD"
+	// s3EmptyPayload is hash of empty body.
+	s3EmptyPayload = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+)
+
+// S3Config settings of S3-compatible object storage.
+type S3Config struct {
+	// Endpoint is URL of storage, like https://s3.eu-central-1.amazonaws.com or http://127.0.0.1:9000 for MinIO.
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	// Prefix is prepended to keys, so one bucket may be shared.
+	Prefix string
+}
+
+// s3BlobStore stores blobs as objects in S3-compatible storage, path-style URLs are used.
+type s3BlobStore struct {
+	client   *http.Client
+	endpoint *url.URL
+	config   S3Config
+	now      func() time.Time
+}
+
+// NewS3BlobStore returns blob store in bucket of S3-compatible storage.
+func NewS3BlobStore(config S3Config) (BlobStore, error) {
+	return newS3BlobStore(config, http.DefaultClient)
+}
+
+// newS3BlobStore returns blob store in bucket of S3-compatible storage, requests are sent by client.
+func newS3BlobStore(config S3Config, client *http.Client) (*s3BlobStore, error) {
+	endpoint, err := url.Parse(strings.TrimSuffix(config.Endpoint, "/"))
+	if err != nil || endpoint.Host == "" || (endpoint.Scheme != "http" && endpoint.Scheme != "https") {
+		log.Println("Wrong S3 endpoint:", config.Endpoint)
+		return nil, ErrBlobConfig
+	}
+
+	if config.Bucket == "" || config.Region == "" || config.AccessKey == "" || config.SecretKey == "" {
+		log.Println("S3 bucket, region and credentials must be set")
+		return nil, ErrBlobConfig
+	}
+
+	return &s3BlobStore{
+		client:   client,
+		endpoint: endpoint,
+		config:   config,
+		now:      time.Now,
+	}, nil
+}
+
+// objectPath returns escaped path of object with key.
+func (store *s3BlobStore) objectPath(key string) string {
+	return "/" + s3Escape(store.config.Bucket, false) + "/" + s3Escape(store.config.Prefix+key, true)
+}
+
+// do signs and sends request, body is streamed with unsigned payload.
+func (store *s3BlobStore) do(ctx context.Context, method string, escapedPath string, query url.Values, header http.Header, body io.Reader, size int64) (*http.Response, error) {
+	rawQuery := s3CanonicalQuery(query)
+	target := store.endpoint.String() + escapedPath
+	if rawQuery != "" {
+		target += "?" + rawQuery
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return nil, err
+	}
+
+	for name, values := range header {
+		req.Header[name] = values
+	}
+
+	payloadHash := s3EmptyPayload
+	if body != nil {
+		req.ContentLength = size
+		payloadHash = s3UnsignedPayload
+		if size == 0 {
+			req.Body = http.NoBody
+		}
+	}
+
+	store.sign(req, payloadHash, store.now())
+
+	return store.client.Do(req)
+}
+
+// sign adds AWS Signature Version 4 to request, all headers set before are signed.
+func (store *s3BlobStore) sign(req *http.Request, payloadHash string, t time.Time) {
+	amzDate := t.UTC().Format(s3DateFormat)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signedHeaders := []string{"host"}
+	for name := range req.Header {
+		signedHeaders = append(signedHeaders, strings.ToLower(name))
+	}
+	sort.Strings(signedHeaders)
+
+	header := req.Header.Clone()
+	header.Set("Host", req.URL.Host)
+
+	scope := amzDate[:8] + "/" + store.config.Region + "/s3/aws4_request"
+	signature := s3Signature(store.config.SecretKey, store.config.Region, amzDate, req.Method, req.URL.EscapedPath(), req.URL.RawQuery, header, signedHeaders, payloadHash)
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s3Algorithm, store.config.AccessKey, scope, strings.Join(signedHeaders, ";"), signature))
+}
+
+// s3Signature returns signature of request by AWS Signature Version 4.
+func s3Signature(secretKey string, region string, amzDate string, method string, escapedPath string, rawQuery string, header http.Header, signedHeaders []string, payloadHash string) string {
+	var canonical strings.Builder
+	canonical.WriteString(method + "\n" + escapedPath + "\n" + rawQuery + "\n")
+	for _, name := range signedHeaders {
+		canonical.WriteString(name + ":" + strings.TrimSpace(strings.Join(header.Values(name), ",")) + "\n")
+	}
+	canonical.WriteString("\n" + strings.Join(signedHeaders, ";") + "\n" + payloadHash)
+
+	canonicalHash := sha256.Sum256([]byte(canonical.String()))
+	scope := amzDate[:8] + "/" + region + "/s3/aws4_request"
+	stringToSign := s3Algorithm + "\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(canonicalHash[:])
+
+	key := []byte("AWS4" + secretKey)
+	for _, part := range []string{amzDate[:8], region, "s3", "aws4_request", stringToSign} {
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(part))
+		key = mac.Sum(nil)
+	}
+
+	return hex.EncodeToString(key)
+}
+
+// s3Escape escapes string like AWS does, only unreserved characters are kept.
+func s3Escape(s string, keepSlash bool) string {
+	var escaped strings.Builder
+	for _, c := range []byte(s) {
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-' || c == '_' || c == '.' || c == '~' || (keepSlash && c == '/') {
+			escaped.WriteByte(c)
+		} else {
+			fmt.Fprintf(&escaped, "%%%02X", c)
+		}
+	}
+
+	return escaped.String()
+}
+
+// s3CanonicalQuery returns query sorted by names with escaped names and values.
+func s3CanonicalQuery(query url.Values) string {
+	params := make([]string, 0, len(query))
+	for name, values := range query {
+		for _, value := range values {
+			params = append(params, s3Escape(name, false)+"="+s3Escape(value, false))
+		}
+	}
+	sort.Strings(params)
+
+	return strings.Join(params, "&")
+}
+
+// s3Error reads response with unexpected status and logs it.
+func s3Error(operation string, resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	log.Printf("S3 %s error: %s %s", operation, resp.Status, body)
+
+	return ErrUnknown
+}
+
+// Put uploads object, data of unknown size is read to memory first.
+func (store *s3BlobStore) Put(ctx context.Context, key string, data io.Reader, size int64) error {
+	if !validKey(key) {
+		log.Println("Wrong blob key in putting blob:", key)
+		return ErrUnknown
+	}
+
+	if size < 0 {
+		blob, err := io.ReadAll(data)
+		if err != nil {
+			log.Infoln(err)
+
+			return ErrUnknown
+		}
+
+		data, size = bytes.NewReader(blob), int64(len(blob))
+	}
+
+	resp, err := store.do(ctx, http.MethodPut, store.objectPath(key), nil, nil, io.NopCloser(data), size)
+	if err != nil {
+		log.Infoln(err)
+
+		return ErrUnknown
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return s3Error("put", resp)
+	}
+
+	return nil
+}
+
+// Get downloads object, body is streamed.
+func (store *s3BlobStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	if !validKey(key) {
+		log.Println("Wrong blob key in getting blob:", key)
+		return nil, ErrUnknown
+	}
+
+	resp, err := store.do(ctx, http.MethodGet, store.objectPath(key), nil, nil, nil, 0)
+	if err != nil {
+		log.Infoln(err)
+
+		return nil, ErrUnknown
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp.Body, nil
+	case http.StatusNotFound:
+		resp.Body.Close()
+
+		return nil, ErrNotFound
+	default:
+		defer resp.Body.Close()
+
+		return nil, s3Error("get", resp)
+	}
+}
+
+// exists checks that object exists.
+func (store *s3BlobStore) exists(ctx context.Context, key string) error {
+	resp, err := store.do(ctx, http.MethodHead, store.objectPath(key), nil, nil, nil, 0)
+	if err != nil {
+		log.Infoln(err)
+
+		return ErrUnknown
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusNotFound:
+		return ErrNotFound
+	default:
+		return s3Error("head", resp)
+	}
+}
+
+// Delete deletes object, S3 deletes missing objects silently, so existence is checked first.
+func (store *s3BlobStore) Delete(ctx context.Context, key string) error {
+	if !validKey(key) {
+		log.Println("Wrong blob key in deleting blob:", key)
+		return ErrUnknown
+	}
+
+	if err := store.exists(ctx, key); err != nil {
+		return err
+	}
+
+	return store.delete(ctx, key)
+}
+
+// delete deletes object without checks.
+func (store *s3BlobStore) delete(ctx context.Context, key string) error {
+	resp, err := store.do(ctx, http.MethodDelete, store.objectPath(key), nil, nil, nil, 0)
+	if err != nil {
+		log.Infoln(err)
+
+		return ErrUnknown
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return s3Error("delete", resp)
+	}
+
+	return nil
+}
+
+// Rename copies object to other key and deletes source, S3 has no atomic rename.
+func (store *s3BlobStore) Rename(ctx context.Context, from string, to string) error {
+	if !validKey(from) || !validKey(to) {
+		log.Println("Wrong blob key in renaming blob:", from, to)
+		return ErrUnknown
+	}
+
+	header := http.Header{"X-Amz-Copy-Source": {store.objectPath(from)}}
+
+	resp, err := store.do(ctx, http.MethodPut, store.objectPath(to), nil, header, nil, 0)
+	if err != nil {
+		log.Infoln(err)
+
+		return ErrUnknown
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return s3Error("copy", resp)
+	}
+
+	// Copy may fail after status is sent, then body is error
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		log.Infoln(err)
+
+		return ErrUnknown
+	}
+	if bytes.Contains(body, []byte("<Error>")) {
+		log.Printf("S3 copy error: %s", body)
+		return ErrUnknown
+	}
+
+	return store.delete(ctx, from)
+}
+
+// s3ListResult is response of ListObjectsV2.
+type s3ListResult struct {
+	Contents []struct {
+		Key          string    `xml:"Key"`
+		Size         int64     `xml:"Size"`
+		LastModified time.Time `xml:"LastModified"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// List lists objects with prefix page by page, nested keys are grouped by delimiter and skipped.
+func (store *s3BlobStore) List(ctx context.Context) ([]userdata.StoredFile, error) {
+	files := []userdata.StoredFile{}
+	query := url.Values{
+		"list-type": {"2"},
+		"delimiter": {"/"},
+		"prefix":    {store.config.Prefix},
+	}
+
+	for {
+		resp, err := store.do(ctx, http.MethodGet, "/"+s3Escape(store.config.Bucket, false), query, nil, nil, 0)
+		if err != nil {
+			log.Infoln(err)
+
+			return nil, ErrUnknown
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			err := s3Error("list", resp)
+			resp.Body.Close()
+
+			return nil, err
+		}
+
+		var result s3ListResult
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			log.Infoln(err)
+
+			return nil, ErrUnknown
+		}
+
+		for _, object := range result.Contents {
+			files = append(files, userdata.StoredFile{
+				Name:    strings.TrimPrefix(object.Key, store.config.Prefix),
+				Size:    object.Size,
+				ModTime: object.LastModified,
+			})
+		}
+
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return files, nil
+		}
+
+		query.Set("continuation-token", result.NextContinuationToken)
+	}
+}
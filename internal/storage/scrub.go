@@ -0,0 +1,137 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/impr0ver/gophKeeper/internal/userdata"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ScrubResult is machine-readable report of file checksums verification.
+type ScrubResult struct {
+	CheckedAt time.Time `json:"checked_at"`
+	// Verified is number of files with matching checksums, Unchecked is number of files saved before checksums.
+	Verified  int `json:"verified"`
+	Unchecked int `json:"unchecked"`
+	// Corrupted are IDs of records with checksum mismatch.
+	Corrupted []string `json:"corrupted"`
+	// Missing are IDs of records without files, they are deleted after listing or are found by fsck.
+	Missing []string `json:"missing"`
+	Errors  []string `json:"errors"`
+}
+
+// Scrubber re-verifies checksums of record files, so corruption is found before records are read.
+type Scrubber struct {
+	DB    ScrubStorager
+	Files FileStorager
+
+	mu    sync.Mutex
+	stats userdata.ScrubStats
+}
+
+// NewScrubber returns file checksums verifier.
+func NewScrubber(db ScrubStorager, files FileStorager) *Scrubber {
+	return &Scrubber{
+		DB:    db,
+		Files: files,
+	}
+}
+
+// Scrub reads all record files and compares their checksums with saved ones.
+func (s *Scrubber) Scrub(ctx context.Context) (ScrubResult, error) {
+	result := ScrubResult{
+		CheckedAt: time.Now(),
+		Corrupted: []string{},
+		Missing:   []string{},
+		Errors:    []string{},
+	}
+
+	checksums, err := s.DB.ListFileChecksums(ctx)
+	if err != nil {
+		return result, err
+	}
+
+	recordIDs := make([]string, 0, len(checksums))
+	for recordID := range checksums {
+		recordIDs = append(recordIDs, recordID)
+	}
+	sort.Strings(recordIDs)
+
+	for _, recordID := range recordIDs {
+		if checksums[recordID] == "" {
+			result.Unchecked++
+			continue
+		}
+
+		sum, err := s.Files.Checksum(ctx, recordID)
+		switch {
+		case errors.Is(err, ErrNotFound):
+			result.Missing = append(result.Missing, recordID)
+		case err != nil:
+			result.Errors = append(result.Errors, recordID+": "+err.Error())
+		case sum != checksums[recordID]:
+			log.Warnf("%s :: %s", "file record checksum mismatch", recordID)
+			result.Corrupted = append(result.Corrupted, recordID)
+		default:
+			result.Verified++
+		}
+
+		if ctx.Err() != nil {
+			return result, ctx.Err()
+		}
+	}
+
+	s.mu.Lock()
+	s.stats.Runs++
+	s.stats.LastRun = result.CheckedAt
+	s.stats.Verified = int64(result.Verified)
+	s.stats.Corrupted = int64(len(result.Corrupted))
+	s.mu.Unlock()
+
+	return result, nil
+}
+
+// Stats returns metrics of last scrub.
+func (s *Scrubber) Stats() userdata.ScrubStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.stats
+}
+
+// Run scrubs files every interval until ctx is done, results are logged as JSON.
+func (s *Scrubber) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			result, err := s.Scrub(ctx)
+			if err != nil {
+				log.Warnf("storage scrub error: %v", err)
+				continue
+			}
+
+			report, err := json.Marshal(result)
+			if err != nil {
+				log.Warnf("storage scrub report error: %v", err)
+				continue
+			}
+
+			if len(result.Corrupted) == 0 {
+				log.Infof("storage scrub: %s", report)
+			} else {
+				log.Errorf("storage scrub found corrupted records: %s", report)
+			}
+		}
+	}
+}
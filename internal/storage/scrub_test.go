@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/impr0ver/gophKeeper/internal/storage/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestScrubber_Scrub(t *testing.T) {
+	ctx := context.Background()
+	blobs := NewMemoryBlobStore()
+	for key, data := range map[string]string{"good": "data", "corrupted": "dat4", "legacy": "data"} {
+		assert.NoError(t, blobs.Put(ctx, key, strings.NewReader(data), -1))
+	}
+
+	db := mocks.NewScrubStorager(t)
+	db.On("ListFileChecksums", ctx).Return(map[string]string{
+		"good":      checksum([]byte("data")),
+		"corrupted": checksum([]byte("data")),
+		"legacy":    "",
+		"missing":   checksum([]byte("data")),
+	}, nil).Once()
+
+	scrubber := NewScrubber(db, newBlobFileStorage(blobs))
+	assert.Equal(t, int64(0), scrubber.Stats().Runs)
+
+	result, err := scrubber.Scrub(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, result.Verified)
+	assert.Equal(t, 1, result.Unchecked)
+	assert.Equal(t, []string{"corrupted"}, result.Corrupted)
+	assert.Equal(t, []string{"missing"}, result.Missing)
+	assert.Empty(t, result.Errors)
+
+	stats := scrubber.Stats()
+	assert.Equal(t, int64(1), stats.Runs)
+	assert.Equal(t, int64(1), stats.Verified)
+	assert.Equal(t, int64(1), stats.Corrupted)
+	assert.Equal(t, result.CheckedAt, stats.LastRun)
+
+	db.On("ListFileChecksums", ctx).Return(nil, ErrUnknown).Once()
+
+	_, err = scrubber.Scrub(ctx)
+	assert.Equal(t, ErrUnknown, err)
+	assert.Equal(t, int64(1), scrubber.Stats().Runs, "failed scrub is not counted")
+}
+
+func TestScrubber_Run(t *testing.T) {
+	db := mocks.NewScrubStorager(t)
+	db.On("ListFileChecksums", mock.Anything).Return(map[string]string{}, nil)
+
+	scrubber := NewScrubber(db, newBlobFileStorage(NewMemoryBlobStore()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		scrubber.Run(ctx, time.Millisecond)
+		close(done)
+	}()
+
+	assert.Eventually(t, func() bool { return scrubber.Stats().Runs > 0 }, time.Second, time.Millisecond)
+
+	cancel()
+	<-done
+}
@@ -0,0 +1,82 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"path/filepath"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/sqlite"
+	log "github.com/sirupsen/logrus"
+	_ "modernc.org/sqlite"
+)
+
+// Kinds of DB storage.
+const (
+	DBPostgres = "postgres"
+	DBSQLite   = "sqlite"
+	DBMemory   = "memory"
+)
+
+// sqliteMigrations is directory of SQLite migrations in migrations directory.
+const sqliteMigrations = "sqlite"
+
+// sqliteStorage is DB storage in embedded SQLite file, queries are shared with postgres storage.
+type sqliteStorage struct {
+	*dbStorage
+}
+
+// newSQLiteStorage opens SQLite DB file, it's created if not exists.
+func newSQLiteStorage(path string, migrateURL string) (*sqliteStorage, error) {
+	if path == "" {
+		return nil, ErrDBConfig
+	}
+
+	// Times are saved in format, which SQLite date functions understand
+	params := url.Values{}
+	params.Add("_pragma", "foreign_keys(1)")
+	params.Add("_pragma", "busy_timeout(5000)")
+	params.Add("_pragma", "journal_mode(WAL)")
+	params.Set("_time_format", "sqlite")
+
+	db, err := sql.Open("sqlite", "file:"+path+"?"+params.Encode())
+	if err != nil {
+		log.Infoln(err)
+
+		return nil, ErrUnknown
+	}
+
+	// SQLite has one writer, so transactions wait for each other in pool instead of failing with busy DB
+	db.SetMaxOpenConns(1)
+
+	return &sqliteStorage{
+		dbStorage: &dbStorage{
+			DB:     db,
+			MigURL: filepath.Join(migrateURL, sqliteMigrations),
+		},
+	}, nil
+}
+
+// MigrateUP migrates SQLite DB with its own migrations.
+func (ss *sqliteStorage) MigrateUP() {
+	driver, err := sqlite.WithInstance(ss.DB, &sqlite.Config{})
+	if err != nil {
+		log.Fatalln("Failed create sqlite instance:", err)
+
+		return
+	}
+
+	mig, err := migrate.NewWithDatabaseInstance(fmt.Sprintf("file://%s", ss.MigURL), DBSQLite, driver)
+	if err != nil {
+		log.Fatalln("Failed create migration instance:", err)
+
+		return
+	}
+
+	if err := mig.Up(); err != nil && err != migrate.ErrNoChange {
+		log.Fatalln("Failed migrate: ", err)
+
+		return
+	}
+}
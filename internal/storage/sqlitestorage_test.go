@@ -0,0 +1,51 @@
+package storage
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/impr0ver/gophKeeper/internal/userdata"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/sqlite"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSQLiteStorage_MigrateHexData(t *testing.T) {
+	db, err := newSQLiteStorage(filepath.Join(t.TempDir(), "gophkeeper.db"), testMigrations)
+	assert.NoError(t, err)
+
+	// Schema before bytea migration with hex data
+	driver, err := sqlite.WithInstance(db.DB, &sqlite.Config{})
+	assert.NoError(t, err)
+	mig, err := migrate.NewWithDatabaseInstance("file://"+db.MigURL, DBSQLite, driver)
+	assert.NoError(t, err)
+	assert.NoError(t, mig.Steps(1))
+
+	_, err = db.DB.Exec(`INSERT INTO users (user_id, login, password) VALUES ($1, 'user', 'password')`, recordID1)
+	assert.NoError(t, err)
+	_, err = db.DB.Exec(`INSERT INTO data (record_id, user_id, record_type, keyhint, metadata, crypted_data, data_size) VALUES ($1, $2, $3, '', '', '0102ff', 3), ($4, 'deleted user', $3, '', '', '03', 1)`,
+		recordID2, recordID1, userdata.TypeText, recordID1)
+	assert.NoError(t, err)
+
+	db.MigrateUP()
+
+	record, err := db.GetRecord(context.Background(), recordID1, recordID2)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{1, 2, 0xff}, record.Data)
+
+	// Existing users get key salt
+	salt, err := db.GetKeySalt(context.Background(), recordID1)
+	assert.NoError(t, err)
+	assert.Len(t, salt, keySaltSize)
+
+	// Records of missing users are deleted
+	stats, err := db.GetStats(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), stats.Records)
+
+	// Logins are unique
+	assert.NoError(t, db.CreateUser(userdata.UserCredentials{Login: "other", Password: "password"}))
+	assert.Equal(t, ErrLoginExists, db.CreateUser(userdata.UserCredentials{Login: "user", Password: "password"}))
+}
@@ -3,6 +3,8 @@ package storage
 import (
 	"context"
 	"errors"
+	"math"
+	"strings"
 
 	"github.com/impr0ver/gophKeeper/internal/userdata"
 
@@ -12,12 +14,14 @@ import (
 
 // Storage struct which saves to DB and file storage.
 type Storage struct {
-	DBStorage   Storager
+	DBStorage   RecordStorager
 	FileStorage FileStorager
+	// Quota is default user storage quota in bytes, zero means unlimited.
+	Quota int64
 }
 
 // NewStorage returns new storage.
-func NewStorage(DBStorage Storager, fileStorage FileStorager) *Storage {
+func NewStorage(DBStorage RecordStorager, fileStorage FileStorager) *Storage {
 	return &Storage{
 		DBStorage:   DBStorage,
 		FileStorage: fileStorage,
@@ -25,8 +29,13 @@ func NewStorage(DBStorage Storager, fileStorage FileStorager) *Storage {
 }
 
 // LoginUser check user login using DB storage.
-func (s *Storage) LoginUser(credentials userdata.UserCredentials) (userdata.UserID, error) {
-	return s.DBStorage.LoginUser(credentials)
+func (s *Storage) LoginUser(login string, verify func(hash string) (string, error)) (userdata.UserID, error) {
+	return s.DBStorage.LoginUser(login, verify)
+}
+
+// GetPasswordHash gets saved password hash of user from DB storage.
+func (s *Storage) GetPasswordHash(login string) (string, error) {
+	return s.DBStorage.GetPasswordHash(login)
 }
 
 // CreateUser creates new user and saves to DB storage.
@@ -34,57 +43,163 @@ func (s *Storage) CreateUser(credentials userdata.UserCredentials) error {
 	return s.DBStorage.CreateUser(credentials)
 }
 
+// GetKeySalt gets salt of user encryption key from DB storage.
+func (s *Storage) GetKeySalt(ctx context.Context, userID userdata.UserID) ([]byte, error) {
+	return s.DBStorage.GetKeySalt(ctx, userID)
+}
+
 // GetRecordsInfo gets all records from user from DB storage.
-func (s *Storage) GetRecordsInfo(ctx context.Context) ([]userdata.Record, error) {
-	return s.DBStorage.GetRecordsInfo(ctx)
+func (s *Storage) GetRecordsInfo(ctx context.Context, userID userdata.UserID) ([]userdata.Record, error) {
+	return s.DBStorage.GetRecordsInfo(ctx, userID)
+}
+
+// GetUserUsage gets user usage from DB storage.
+func (s *Storage) GetUserUsage(ctx context.Context, userID userdata.UserID) (userdata.Usage, error) {
+	return s.DBStorage.GetUserUsage(ctx, userID)
+}
+
+// checkQuota checks that user has space for new record, if quotas are enabled.
+func (s *Storage) checkQuota(ctx context.Context, userID userdata.UserID, size int64) error {
+	left, err := s.quotaLeft(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if size > left {
+		return ErrQuotaExceeded
+	}
+
+	return nil
+}
+
+// quotaLeft returns free space of user in bytes. Quota of user overrides server default,
+// space is unlimited only if both quotas are disabled.
+func (s *Storage) quotaLeft(ctx context.Context, userID userdata.UserID) (int64, error) {
+	usage, err := s.DBStorage.GetUserUsage(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	quota := s.Quota
+	if usage.Quota > 0 {
+		quota = usage.Quota
+	}
+	if quota <= 0 {
+		return math.MaxInt64, nil
+	}
+
+	return quota - usage.Bytes, nil
 }
 
 // CreateRecord creates record, saves to DB and saves to file storage if record type is file.
-func (s *Storage) CreateRecord(ctx context.Context, record userdata.Record) (string, error) {
-	data := record.Data
+func (s *Storage) CreateRecord(ctx context.Context, userID userdata.UserID, record userdata.Record) (userdata.RecordMeta, error) {
+	record.Size = int64(len(record.Data))
+
+	if err := s.checkQuota(ctx, userID, record.Size); err != nil {
+		log.Infoln(err)
+
+		return userdata.RecordMeta{}, err
+	}
 
 	if record.Type == userdata.TypeFile {
-		record.Data = nil
+		return s.createFileRecord(ctx, userID, record)
 	}
 
-	id, err := s.DBStorage.CreateRecord(ctx, record)
+	meta, err := s.DBStorage.CreateRecord(ctx, userID, record)
 	if err != nil {
 		log.Infoln(err)
 
-		return "", err
+		return meta, err
 	}
 
-	// Check is Type is file, then work with file
-	if record.Type == userdata.TypeFile {
-		record.ID = id
-		record.Data = data
-		_, err = s.FileStorage.CreateRecord(ctx, record)
-		return "", err
+	return meta, nil
+}
+
+// createFileRecord saves file record in two phases: data is staged to temporary file, row is inserted in transaction
+// and staged file is renamed to record file before commit. Failed steps are compensated, so row never points to nothing.
+func (s *Storage) createFileRecord(ctx context.Context, userID userdata.UserID, record userdata.Record) (userdata.RecordMeta, error) {
+	staged, err := s.FileStorage.StageRecord(ctx, record.Data)
+	if err != nil {
+		log.Infoln(err)
+
+		return userdata.RecordMeta{}, err
 	}
 
-	return id, nil
+	record.Checksum = checksum(record.Data)
+	record.Data = nil
+
+	var committed string
+	meta, err := s.DBStorage.CreateRecordTx(ctx, userID, record, func(meta userdata.RecordMeta) error {
+		if err := s.FileStorage.CommitRecord(ctx, staged, meta.ID); err != nil {
+			return err
+		}
+
+		committed = meta.ID
+
+		return nil
+	})
+	if err == nil {
+		return meta, nil
+	}
+
+	log.Infoln(err)
+
+	// Client may be gone, cleanup is done anyway
+	if committed == "" {
+		s.discard(context.Background(), staged)
+	} else if err := s.FileStorage.DeleteRecord(context.Background(), committed); err != nil {
+		log.Warnf("%s :: %v", "delete file of not committed record error", err)
+	}
+
+	return userdata.RecordMeta{}, err
+}
+
+// discard removes staged file, error is only logged, because left file is garbage and doesn't break records.
+func (s *Storage) discard(ctx context.Context, staged string) {
+	if err := s.FileStorage.DiscardRecord(ctx, staged); err != nil {
+		log.Warnf("%s :: %v", "discard staged file error", err)
+	}
 }
 
 // DeleteRecord deletes record from DB storage and, delete file from storage if record type is file.
-func (s *Storage) DeleteRecord(ctx context.Context, recordID string) error {
-	err := s.DBStorage.DeleteRecord(ctx, recordID)
+// File is moved aside before commit and restored if commit fails, so row never points to nothing.
+func (s *Storage) DeleteRecord(ctx context.Context, userID userdata.UserID, recordID string) error {
+	// Files are named by ID saved in DB, ID of request may differ in case
+	fileID := strings.ToLower(recordID)
+
+	var staged string
+	err := s.DBStorage.DeleteRecordTx(ctx, userID, recordID, func() error {
+		var err error
+		staged, err = s.FileStorage.StageDelete(ctx, fileID)
+		if errors.Is(err, ErrNotFound) {
+			// Only file records have files
+			return nil
+		}
+
+		return err
+	})
 	if err != nil {
 		log.Infoln(err)
 
+		if staged != "" {
+			if err := s.FileStorage.CommitRecord(context.Background(), staged, fileID); err != nil {
+				log.Warnf("%s :: %v", "restore file of not deleted record error", err)
+			}
+		}
+
 		return err
 	}
 
-	err = s.FileStorage.DeleteRecord(ctx, recordID)
-	if !errors.Is(err, ErrNotFound) && err != nil {
-		return ErrUnknown
+	if staged != "" {
+		s.discard(ctx, staged)
 	}
 
 	return nil
 }
 
 // GetRecord gets record from DB or file storage if record type is file.
-func (s *Storage) GetRecord(ctx context.Context, recordID string) (userdata.Record, error) {
-	record, err := s.DBStorage.GetRecord(ctx, recordID)
+func (s *Storage) GetRecord(ctx context.Context, userID userdata.UserID, recordID string) (userdata.Record, error) {
+	record, err := s.DBStorage.GetRecord(ctx, userID, recordID)
 	if err != nil {
 		log.Infoln(err)
 
@@ -97,8 +212,277 @@ func (s *Storage) GetRecord(ctx context.Context, recordID string) (userdata.Reco
 			"recordMetadata", record.Metadata,
 		)
 		ctx := metadata.NewIncomingContext(ctx, md)
-		return s.FileStorage.GetRecord(ctx, recordID)
+		fileRecord, err := s.FileStorage.GetRecord(ctx, record.ID)
+		if err != nil {
+			return fileRecord, err
+		}
+
+		if err := verify(record, fileRecord.Data); err != nil {
+			return userdata.Record{}, err
+		}
+
+		record.Data = fileRecord.Data
 	}
 
 	return record, nil
 }
+
+// verify checks data of file record by checksum saved with record, files saved before checksums are not checked.
+func verify(record userdata.Record, data []byte) error {
+	if record.Checksum == "" || checksum(data) == record.Checksum {
+		return nil
+	}
+
+	log.Warnf("%s :: %s", "file record checksum mismatch", record.ID)
+
+	return ErrDataLoss
+}
+
+// CreateRecords creates records with one DB batch, records over quota get ErrQuotaExceeded and are not saved.
+func (s *Storage) CreateRecords(ctx context.Context, userID userdata.UserID, records []userdata.Record) ([]userdata.RecordResult, error) {
+	left, err := s.quotaLeft(ctx, userID)
+	if err != nil {
+		log.Infoln(err)
+
+		return nil, err
+	}
+
+	results := make([]userdata.RecordResult, len(records))
+	batch := make([]userdata.Record, 0, len(records))
+	indexes := make([]int, 0, len(records))
+	staged := make(map[int]string)
+
+	for i, record := range records {
+		record.Size = int64(len(record.Data))
+		if record.Size > left {
+			results[i].Err = ErrQuotaExceeded
+			continue
+		}
+
+		if record.Type == userdata.TypeFile {
+			name, err := s.FileStorage.StageRecord(ctx, record.Data)
+			if err != nil {
+				results[i].Err = err
+				continue
+			}
+
+			staged[i] = name
+			record.Checksum = checksum(record.Data)
+			record.Data = nil
+		}
+
+		left -= record.Size
+		batch = append(batch, record)
+		indexes = append(indexes, i)
+	}
+
+	created, err := s.DBStorage.CreateRecords(ctx, userID, batch)
+	if err != nil {
+		log.Infoln(err)
+
+		for _, name := range staged {
+			s.discard(context.Background(), name)
+		}
+
+		return nil, err
+	}
+
+	var failedFiles []string
+	for j, result := range created {
+		i := indexes[j]
+		results[i] = result
+
+		name, ok := staged[i]
+		if !ok {
+			continue
+		}
+
+		if result.Err != nil {
+			s.discard(ctx, name)
+			continue
+		}
+
+		if err := s.FileStorage.CommitRecord(ctx, name, result.ID); err != nil {
+			results[i].Err = err
+			failedFiles = append(failedFiles, result.ID)
+			s.discard(ctx, name)
+		}
+	}
+
+	// Records without saved files can't be read, they are removed from DB
+	if len(failedFiles) > 0 {
+		if _, err := s.DBStorage.DeleteRecords(context.Background(), userID, failedFiles); err != nil {
+			log.Warnf("%s :: %v", "delete records without files error", err)
+		}
+	}
+
+	return results, nil
+}
+
+// GetRecords gets records with one DB batch, data of files is read from file storage.
+func (s *Storage) GetRecords(ctx context.Context, userID userdata.UserID, recordIDs []string) ([]userdata.RecordResult, error) {
+	results, err := s.DBStorage.GetRecords(ctx, userID, recordIDs)
+	if err != nil {
+		log.Infoln(err)
+
+		return nil, err
+	}
+
+	for i, result := range results {
+		if result.Err != nil || result.Record.Type != userdata.TypeFile {
+			continue
+		}
+
+		md := metadata.Pairs(
+			"recordMetadata", result.Record.Metadata,
+		)
+		// Files are named by ID saved in DB, ID of request may differ in case
+		fileRecord, err := s.FileStorage.GetRecord(metadata.NewIncomingContext(ctx, md), result.Record.ID)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+
+		if err := verify(result.Record, fileRecord.Data); err != nil {
+			results[i].Err = err
+			continue
+		}
+
+		results[i].Record.Data = fileRecord.Data
+	}
+
+	return results, nil
+}
+
+// DeleteRecords deletes records with one DB batch and their files.
+// Files are moved aside before commit and restored if commit fails, so rows never point to nothing.
+func (s *Storage) DeleteRecords(ctx context.Context, userID userdata.UserID, recordIDs []string) ([]userdata.RecordResult, error) {
+	staged := make(map[string]string)
+	results, err := s.DBStorage.DeleteRecordsTx(ctx, userID, recordIDs, func(deleted []string) error {
+		for _, id := range deleted {
+			name, err := s.FileStorage.StageDelete(ctx, id)
+			if errors.Is(err, ErrNotFound) {
+				// Only file records have files
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			staged[id] = name
+		}
+
+		return nil
+	})
+	if err != nil {
+		log.Infoln(err)
+
+		// Client may be gone, staged files are restored anyway
+		for id, name := range staged {
+			if err := s.FileStorage.CommitRecord(context.Background(), name, id); err != nil {
+				log.Warnf("%s :: %v", "restore file of not deleted record error", err)
+			}
+		}
+
+		return nil, err
+	}
+
+	for _, name := range staged {
+		s.discard(ctx, name)
+	}
+
+	return results, nil
+}
+
+// ReplaceRecords replaces data of records with one DB transaction, batch is replaced entirely or not at all.
+// New files are staged before transaction and swapped with saved ones before commit, saved files are restored
+// if commit fails, so rows never point to files of other version.
+func (s *Storage) ReplaceRecords(ctx context.Context, userID userdata.UserID, records []userdata.Record) ([]userdata.RecordResult, error) {
+	batch := make([]userdata.Record, len(records))
+	staged := make(map[string]string)
+
+	discardStaged := func() {
+		for _, name := range staged {
+			s.discard(context.Background(), name)
+		}
+	}
+
+	for i, record := range records {
+		record.Size = int64(len(record.Data))
+
+		if record.Type == userdata.TypeFile {
+			name, err := s.FileStorage.StageRecord(ctx, record.Data)
+			if err != nil {
+				log.Infoln(err)
+				discardStaged()
+
+				return nil, err
+			}
+
+			// Files are named by ID saved in DB, ID of request may differ in case
+			staged[strings.ToLower(record.ID)] = name
+			record.Checksum = checksum(record.Data)
+			record.Data = nil
+		}
+
+		batch[i] = record
+	}
+
+	saved := make(map[string]string)
+	results, err := s.DBStorage.ReplaceRecordsTx(ctx, userID, batch, func(growth int64) error {
+		if growth > 0 {
+			left, err := s.quotaLeft(ctx, userID)
+			if err != nil {
+				return err
+			}
+
+			if growth > left {
+				return ErrQuotaExceeded
+			}
+		}
+
+		for id, name := range staged {
+			old, err := s.FileStorage.StageDelete(ctx, id)
+			if err != nil && !errors.Is(err, ErrNotFound) {
+				return err
+			}
+			saved[id] = old
+
+			if err := s.FileStorage.CommitRecord(ctx, name, id); err != nil {
+				return err
+			}
+			delete(staged, id)
+		}
+
+		return nil
+	})
+	if err != nil {
+		log.Infoln(err)
+
+		// Client may be gone, saved files are restored anyway
+		for id, old := range saved {
+			if old == "" {
+				continue
+			}
+
+			if err := s.FileStorage.CommitRecord(context.Background(), old, id); err != nil {
+				log.Warnf("%s :: %v", "restore file of not replaced record error", err)
+			}
+		}
+		discardStaged()
+
+		return nil, err
+	}
+
+	for _, old := range saved {
+		if old != "" {
+			s.discard(ctx, old)
+		}
+	}
+
+	return results, nil
+}
+
+// RewrapRecords replaces wrapped data keys of records, data and files of records aren't changed.
+func (s *Storage) RewrapRecords(ctx context.Context, userID userdata.UserID, records []userdata.Record) ([]userdata.RecordResult, error) {
+	return s.DBStorage.RewrapRecords(ctx, userID, records)
+}
@@ -11,15 +11,54 @@ import (
 	"github.com/stretchr/testify/mock"
 )
 
+// createTx returns result of mocked CreateRecordTx, which calls commit func and then fails with dbErr, if it's set.
+func createTx(meta userdata.RecordMeta, dbErr error) func(context.Context, userdata.UserID, userdata.Record, func(userdata.RecordMeta) error) (userdata.RecordMeta, error) {
+	return func(_ context.Context, _ userdata.UserID, _ userdata.Record, commit func(userdata.RecordMeta) error) (userdata.RecordMeta, error) {
+		if err := commit(meta); err != nil {
+			return userdata.RecordMeta{}, err
+		}
+		if dbErr != nil {
+			return userdata.RecordMeta{}, dbErr
+		}
+
+		return meta, nil
+	}
+}
+
+// deleteTx returns result of mocked DeleteRecordTx, which calls commit func and then fails with dbErr, if it's set.
+func deleteTx(dbErr error) func(context.Context, userdata.UserID, string, func() error) error {
+	return func(_ context.Context, _ userdata.UserID, _ string, commit func() error) error {
+		if err := commit(); err != nil {
+			return err
+		}
+
+		return dbErr
+	}
+}
+
+// deleteRecordsTx returns result of mocked DeleteRecordsTx, which calls commit func with deleted IDs and then fails with dbErr, if it's set.
+func deleteRecordsTx(deleted []string, results []userdata.RecordResult, dbErr error) func(context.Context, userdata.UserID, []string, func([]string) error) ([]userdata.RecordResult, error) {
+	return func(_ context.Context, _ userdata.UserID, _ []string, commit func([]string) error) ([]userdata.RecordResult, error) {
+		if err := commit(deleted); err != nil {
+			return nil, err
+		}
+		if dbErr != nil {
+			return nil, dbErr
+		}
+
+		return results, nil
+	}
+}
+
 func TestNewStorage(t *testing.T) {
-	db, file := mocks.NewStorager(t), mocks.NewFileStorager(t)
+	db, file := mocks.NewRecordStorager(t), mocks.NewFileStorager(t)
 	storage := NewStorage(db, file)
 
 	assert.NotEmpty(t, storage)
 }
 
 func TestStorage_CreateUser(t *testing.T) {
-	db, file := mocks.NewStorager(t), mocks.NewFileStorager(t)
+	db, file := mocks.NewRecordStorager(t), mocks.NewFileStorager(t)
 	storage := NewStorage(db, file)
 
 	tc := []struct {
@@ -52,7 +91,7 @@ func TestStorage_CreateUser(t *testing.T) {
 }
 
 func TestStorage_GetRecordsInfo(t *testing.T) {
-	db, file := mocks.NewStorager(t), mocks.NewFileStorager(t)
+	db, file := mocks.NewRecordStorager(t), mocks.NewFileStorager(t)
 	storage := NewStorage(db, file)
 
 	tc := []struct {
@@ -63,10 +102,10 @@ func TestStorage_GetRecordsInfo(t *testing.T) {
 		{
 			"Get all records info",
 			func() {
-				db.On("GetRecordsInfo", context.Background()).Return([]userdata.Record{}, nil)
+				db.On("GetRecordsInfo", context.Background(), userdata.UserID("1")).Return([]userdata.Record{}, nil)
 			},
 			func() {
-				_, _ = storage.GetRecordsInfo(context.Background())
+				_, _ = storage.GetRecordsInfo(context.Background(), "1")
 				db.AssertExpectations(t)
 			},
 		},
@@ -79,7 +118,7 @@ func TestStorage_GetRecordsInfo(t *testing.T) {
 }
 
 func TestStorage_LoginUser(t *testing.T) {
-	db, file := mocks.NewStorager(t), mocks.NewFileStorager(t)
+	db, file := mocks.NewRecordStorager(t), mocks.NewFileStorager(t)
 	storage := NewStorage(db, file)
 
 	tc := []struct {
@@ -92,14 +131,12 @@ func TestStorage_LoginUser(t *testing.T) {
 			func() {
 				db.On(
 					"LoginUser",
-					mock.AnythingOfType("userdata.UserCredentials"),
+					"login",
+					mock.AnythingOfType("func(string) (string, error)"),
 				).Return(userdata.UserID(""), nil)
 			},
 			func() {
-				_, _ = storage.LoginUser(userdata.UserCredentials{
-					Login:    "login",
-					Password: "password",
-				})
+				_, _ = storage.LoginUser("login", func(string) (string, error) { return "", nil })
 				db.AssertExpectations(t)
 				file.AssertExpectations(t)
 			},
@@ -113,8 +150,9 @@ func TestStorage_LoginUser(t *testing.T) {
 }
 
 func TestStorage_CreateRecord(t *testing.T) {
-	db, file := mocks.NewStorager(t), mocks.NewFileStorager(t)
+	db, file := mocks.NewRecordStorager(t), mocks.NewFileStorager(t)
 	storage := NewStorage(db, file)
+	db.On("GetUserUsage", context.Background(), userdata.UserID("1")).Return(userdata.Usage{}, nil)
 
 	tc := []struct {
 		name  string
@@ -127,11 +165,12 @@ func TestStorage_CreateRecord(t *testing.T) {
 				db.On(
 					"CreateRecord",
 					context.Background(),
+					userdata.UserID("1"),
 					mock.AnythingOfType("userdata.Record"),
-				).Return("", nil)
+				).Return(userdata.RecordMeta{}, nil)
 			},
 			func() {
-				_, _ = storage.CreateRecord(context.Background(), userdata.Record{
+				_, _ = storage.CreateRecord(context.Background(), "1", userdata.Record{
 					ID:       "",
 					Metadata: "",
 					Type:     userdata.TypeText,
@@ -144,24 +183,18 @@ func TestStorage_CreateRecord(t *testing.T) {
 		{
 			"Create file record",
 			func() {
-				db.On(
-					"CreateRecord",
-					context.Background(),
-					mock.AnythingOfType("userdata.Record"),
-				).Return("", nil)
-				file.On(
-					"CreateRecord",
-					context.Background(),
-					mock.AnythingOfType("userdata.Record"),
-				).Return("", nil)
+				file.On("StageRecord", context.Background(), []byte("file")).Return(".staged-1", nil).Once()
+				db.On("CreateRecordTx", context.Background(), userdata.UserID("1"), userdata.Record{Type: userdata.TypeFile, Size: 4, Checksum: checksum([]byte("file"))}, mock.Anything).
+					Return(createTx(userdata.RecordMeta{ID: "1"}, nil)).Once()
+				file.On("CommitRecord", context.Background(), ".staged-1", "1").Return(nil).Once()
 			},
 			func() {
-				_, _ = storage.CreateRecord(context.Background(), userdata.Record{
-					ID:       "",
-					Metadata: "",
-					Type:     userdata.TypeFile,
-					Data:     nil,
+				meta, err := storage.CreateRecord(context.Background(), "1", userdata.Record{
+					Type: userdata.TypeFile,
+					Data: []byte("file"),
 				})
+				assert.NoError(t, err)
+				assert.Equal(t, "1", meta.ID)
 				db.AssertExpectations(t)
 				file.AssertExpectations(t)
 			},
@@ -175,7 +208,7 @@ func TestStorage_CreateRecord(t *testing.T) {
 }
 
 func TestStorage_GetRecord(t *testing.T) {
-	db, file := mocks.NewStorager(t), mocks.NewFileStorager(t)
+	db, file := mocks.NewRecordStorager(t), mocks.NewFileStorager(t)
 	storage := NewStorage(db, file)
 
 	tc := []struct {
@@ -189,6 +222,7 @@ func TestStorage_GetRecord(t *testing.T) {
 				db.On(
 					"GetRecord",
 					context.Background(),
+					userdata.UserID("1"),
 					"",
 				).Return(userdata.Record{Type: userdata.TypeFile}, nil)
 				file.On(
@@ -198,18 +232,44 @@ func TestStorage_GetRecord(t *testing.T) {
 				).Return(userdata.Record{}, nil)
 			},
 			func() {
-				_, _ = storage.GetRecord(context.Background(), "")
+				_, _ = storage.GetRecord(context.Background(), "1", "")
 				db.AssertExpectations(t)
 				file.AssertExpectations(t)
 			},
 		},
+		{
+			"Get corrupted file record",
+			func() {
+				db.On("GetRecord", context.Background(), userdata.UserID("1"), "2").
+					Return(userdata.Record{ID: "2", Type: userdata.TypeFile, Checksum: checksum([]byte("file"))}, nil).Once()
+				file.On("GetRecord", mock.Anything, "2").Return(userdata.Record{Data: []byte("fil3")}, nil).Once()
+			},
+			func() {
+				record, err := storage.GetRecord(context.Background(), "1", "2")
+				assert.Equal(t, ErrDataLoss, err)
+				assert.Empty(t, record)
+			},
+		},
+		{
+			"Get file record with valid checksum",
+			func() {
+				db.On("GetRecord", context.Background(), userdata.UserID("1"), "3").
+					Return(userdata.Record{ID: "3", Type: userdata.TypeFile, Checksum: checksum([]byte("file"))}, nil).Once()
+				file.On("GetRecord", mock.Anything, "3").Return(userdata.Record{Data: []byte("file")}, nil).Once()
+			},
+			func() {
+				record, err := storage.GetRecord(context.Background(), "1", "3")
+				assert.NoError(t, err)
+				assert.Equal(t, []byte("file"), record.Data)
+			},
+		},
 		{
 			"Get text record",
 			func() {
-				db.On("GetRecord", context.Background(), "").Return(userdata.Record{}, nil)
+				db.On("GetRecord", context.Background(), userdata.UserID("1"), "").Return(userdata.Record{}, nil)
 			},
 			func() {
-				_, _ = storage.GetRecord(context.Background(), "")
+				_, _ = storage.GetRecord(context.Background(), "1", "")
 			},
 		},
 	}
@@ -221,7 +281,7 @@ func TestStorage_GetRecord(t *testing.T) {
 }
 
 func TestStorage_DeleteRecord(t *testing.T) {
-	db, file := mocks.NewStorager(t), mocks.NewFileStorager(t)
+	db, file := mocks.NewRecordStorager(t), mocks.NewFileStorager(t)
 	storage := NewStorage(db, file)
 
 	tc := []struct {
@@ -232,22 +292,452 @@ func TestStorage_DeleteRecord(t *testing.T) {
 		{
 			"Delete file record",
 			func() {
-				db.On("DeleteRecord", context.Background(), "").Return(nil)
-				file.On("DeleteRecord", context.Background(), "").Return(nil)
+				db.On("DeleteRecordTx", context.Background(), userdata.UserID("1"), "1", mock.Anything).Return(deleteTx(nil)).Once()
+				file.On("StageDelete", context.Background(), "1").Return(".staged-deleted-1", nil).Once()
+				file.On("DiscardRecord", context.Background(), ".staged-deleted-1").Return(nil).Once()
 			},
 			func() {
-				_ = storage.DeleteRecord(context.Background(), "")
-				db.AssertExpectations(t)
-				file.AssertExpectations(t)
+				err := storage.DeleteRecord(context.Background(), "1", "1")
+				assert.NoError(t, err)
 			},
 		},
 		{
 			"Delete text record",
 			func() {
-				db.On("DeleteRecord", context.Background(), "").Return(userdata.Record{}, nil)
+				db.On("DeleteRecordTx", context.Background(), userdata.UserID("1"), "2", mock.Anything).Return(deleteTx(nil)).Once()
+				file.On("StageDelete", context.Background(), "2").Return("", ErrNotFound).Once()
+			},
+			func() {
+				err := storage.DeleteRecord(context.Background(), "1", "2")
+				assert.NoError(t, err)
+			},
+		},
+	}
+	for _, test := range tc {
+		t.Log(test.name)
+		test.mock()
+		test.valid()
+		db.AssertExpectations(t)
+		file.AssertExpectations(t)
+	}
+}
+
+func TestStorage_FileRecordFailures(t *testing.T) {
+	db, file := mocks.NewRecordStorager(t), mocks.NewFileStorager(t)
+	storage := NewStorage(db, file)
+	record := userdata.Record{Type: userdata.TypeFile, Data: []byte("file")}
+	inserted := userdata.Record{Type: userdata.TypeFile, Size: 4, Checksum: checksum([]byte("file"))}
+	db.On("GetUserUsage", context.Background(), userdata.UserID("1")).Return(userdata.Usage{}, nil)
+
+	tc := []struct {
+		name  string
+		mock  func()
+		valid func()
+	}{
+		{
+			"Staging fails, nothing is saved",
+			func() {
+				file.On("StageRecord", context.Background(), []byte("file")).Return("", ErrUnknown).Once()
+			},
+			func() {
+				_, err := storage.CreateRecord(context.Background(), "1", record)
+				assert.Equal(t, ErrUnknown, err)
+			},
+		},
+		{
+			"Insert fails, staged file is discarded",
+			func() {
+				file.On("StageRecord", context.Background(), []byte("file")).Return(".staged-1", nil).Once()
+				db.On("CreateRecordTx", context.Background(), userdata.UserID("1"), inserted, mock.Anything).Return(userdata.RecordMeta{}, ErrUnknown).Once()
+				file.On("DiscardRecord", context.Background(), ".staged-1").Return(nil).Once()
+			},
+			func() {
+				_, err := storage.CreateRecord(context.Background(), "1", record)
+				assert.Equal(t, ErrUnknown, err)
+			},
+		},
+		{
+			"Rename fails, row is rolled back and staged file is discarded",
+			func() {
+				file.On("StageRecord", context.Background(), []byte("file")).Return(".staged-1", nil).Once()
+				db.On("CreateRecordTx", context.Background(), userdata.UserID("1"), inserted, mock.Anything).
+					Return(createTx(userdata.RecordMeta{ID: "1"}, nil)).Once()
+				file.On("CommitRecord", context.Background(), ".staged-1", "1").Return(ErrUnknown).Once()
+				file.On("DiscardRecord", context.Background(), ".staged-1").Return(nil).Once()
+			},
+			func() {
+				meta, err := storage.CreateRecord(context.Background(), "1", record)
+				assert.Equal(t, ErrUnknown, err)
+				assert.Empty(t, meta)
+			},
+		},
+		{
+			"Commit of transaction fails, renamed file is removed",
+			func() {
+				file.On("StageRecord", context.Background(), []byte("file")).Return(".staged-1", nil).Once()
+				db.On("CreateRecordTx", context.Background(), userdata.UserID("1"), inserted, mock.Anything).
+					Return(createTx(userdata.RecordMeta{ID: "1"}, ErrUnknown)).Once()
+				file.On("CommitRecord", context.Background(), ".staged-1", "1").Return(nil).Once()
+				file.On("DeleteRecord", context.Background(), "1").Return(nil).Once()
+			},
+			func() {
+				meta, err := storage.CreateRecord(context.Background(), "1", record)
+				assert.Equal(t, ErrUnknown, err)
+				assert.Empty(t, meta)
+			},
+		},
+		{
+			"Delete of row fails, file is not touched",
+			func() {
+				db.On("DeleteRecordTx", context.Background(), userdata.UserID("1"), "1", mock.Anything).Return(ErrNotFound).Once()
+			},
+			func() {
+				err := storage.DeleteRecord(context.Background(), "1", "1")
+				assert.Equal(t, ErrNotFound, err)
+			},
+		},
+		{
+			"Moving file aside fails, row is rolled back",
+			func() {
+				db.On("DeleteRecordTx", context.Background(), userdata.UserID("1"), "1", mock.Anything).Return(deleteTx(nil)).Once()
+				file.On("StageDelete", context.Background(), "1").Return("", ErrUnknown).Once()
+			},
+			func() {
+				err := storage.DeleteRecord(context.Background(), "1", "1")
+				assert.Equal(t, ErrUnknown, err)
+			},
+		},
+		{
+			"Commit of deletion fails, file is restored",
+			func() {
+				db.On("DeleteRecordTx", context.Background(), userdata.UserID("1"), "1", mock.Anything).Return(deleteTx(ErrUnknown)).Once()
+				file.On("StageDelete", context.Background(), "1").Return(".staged-deleted-1", nil).Once()
+				file.On("CommitRecord", context.Background(), ".staged-deleted-1", "1").Return(nil).Once()
+			},
+			func() {
+				err := storage.DeleteRecord(context.Background(), "1", "1")
+				assert.Equal(t, ErrUnknown, err)
+			},
+		},
+	}
+	for _, test := range tc {
+		t.Log(test.name)
+		test.mock()
+		test.valid()
+		db.AssertExpectations(t)
+		file.AssertExpectations(t)
+	}
+}
+
+func TestStorage_CreateRecordQuota(t *testing.T) {
+	db, file := mocks.NewRecordStorager(t), mocks.NewFileStorager(t)
+	storage := NewStorage(db, file)
+	storage.Quota = 10
+
+	tc := []struct {
+		name  string
+		mock  func()
+		valid func()
+	}{
+		{
+			"Create record within server quota",
+			func() {
+				db.On("GetUserUsage", context.Background(), userdata.UserID("1")).Return(userdata.Usage{Bytes: 4}, nil).Once()
+				db.On(
+					"CreateRecord",
+					context.Background(),
+					userdata.UserID("1"),
+					mock.AnythingOfType("userdata.Record"),
+				).Return(userdata.RecordMeta{ID: "1"}, nil).Once()
+			},
+			func() {
+				meta, err := storage.CreateRecord(context.Background(), "1", userdata.Record{
+					Type: userdata.TypeText,
+					Data: []byte("123456"),
+				})
+				assert.NoError(t, err)
+				assert.Equal(t, "1", meta.ID)
+			},
+		},
+		{
+			"Create record over server quota",
+			func() {
+				db.On("GetUserUsage", context.Background(), userdata.UserID("1")).Return(userdata.Usage{Bytes: 5}, nil).Once()
+			},
+			func() {
+				_, err := storage.CreateRecord(context.Background(), "1", userdata.Record{
+					Type: userdata.TypeText,
+					Data: []byte("123456"),
+				})
+				assert.Equal(t, ErrQuotaExceeded, err)
+			},
+		},
+		{
+			"Create record within user quota",
+			func() {
+				db.On("GetUserUsage", context.Background(), userdata.UserID("1")).Return(userdata.Usage{Bytes: 5, Quota: 100}, nil).Once()
+				db.On(
+					"CreateRecord",
+					context.Background(),
+					userdata.UserID("1"),
+					mock.AnythingOfType("userdata.Record"),
+				).Return(userdata.RecordMeta{ID: "2"}, nil).Once()
+			},
+			func() {
+				_, err := storage.CreateRecord(context.Background(), "1", userdata.Record{
+					Type: userdata.TypeText,
+					Data: []byte("123456"),
+				})
+				assert.NoError(t, err)
+			},
+		},
+	}
+	for _, test := range tc {
+		t.Log(test.name)
+		test.mock()
+		test.valid()
+	}
+
+	// Quota of user is checked, even if server has no default quota
+	storage.Quota = 0
+	db.On("GetUserUsage", context.Background(), userdata.UserID("1")).Return(userdata.Usage{Bytes: 5, Quota: 10}, nil).Once()
+
+	_, err := storage.CreateRecord(context.Background(), "1", userdata.Record{
+		Type: userdata.TypeText,
+		Data: []byte("123456"),
+	})
+	assert.Equal(t, ErrQuotaExceeded, err)
+
+	db.On("GetUserUsage", context.Background(), userdata.UserID("1")).Return(userdata.Usage{Bytes: 5}, nil).Once()
+	db.On(
+		"CreateRecord",
+		context.Background(),
+		userdata.UserID("1"),
+		mock.AnythingOfType("userdata.Record"),
+	).Return(userdata.RecordMeta{ID: "3"}, nil).Once()
+
+	_, err = storage.CreateRecord(context.Background(), "1", userdata.Record{
+		Type: userdata.TypeText,
+		Data: []byte("123456"),
+	})
+	assert.NoError(t, err, "no quotas")
+	db.AssertExpectations(t)
+}
+
+// replaceTx returns result of mocked ReplaceRecordsTx, which calls commit func with growth and then fails with dbErr, if it's set.
+func replaceTx(growth int64, results []userdata.RecordResult, dbErr error) func(context.Context, userdata.UserID, []userdata.Record, func(int64) error) ([]userdata.RecordResult, error) {
+	return func(_ context.Context, _ userdata.UserID, _ []userdata.Record, commit func(int64) error) ([]userdata.RecordResult, error) {
+		if err := commit(growth); err != nil {
+			return nil, err
+		}
+		if dbErr != nil {
+			return nil, dbErr
+		}
+
+		return results, nil
+	}
+}
+
+func TestStorage_CreateRecords(t *testing.T) {
+	db, file := mocks.NewRecordStorager(t), mocks.NewFileStorager(t)
+	storage := NewStorage(db, file)
+	storage.Quota = 10
+
+	db.On("GetUserUsage", context.Background(), userdata.UserID("1")).Return(userdata.Usage{Bytes: 4}, nil).Once()
+	db.On("CreateRecords", context.Background(), userdata.UserID("1"), []userdata.Record{
+		{Type: userdata.TypeText, Data: []byte("123"), Size: 3},
+		{Type: userdata.TypeFile, Metadata: "file", Size: 2, Checksum: checksum([]byte("12"))},
+		{Type: userdata.TypeFile, Metadata: "broken", Size: 1, Checksum: checksum([]byte("1"))},
+	}).Return([]userdata.RecordResult{{ID: "1"}, {ID: "2"}, {ID: "3"}}, nil).Once()
+	file.On("StageRecord", context.Background(), []byte("12")).Return(".staged-2", nil).Once()
+	file.On("StageRecord", context.Background(), []byte("1")).Return(".staged-3", nil).Once()
+	file.On("CommitRecord", context.Background(), ".staged-2", "2").Return(nil).Once()
+	file.On("CommitRecord", context.Background(), ".staged-3", "3").Return(ErrUnknown).Once()
+	file.On("DiscardRecord", context.Background(), ".staged-3").Return(nil).Once()
+	db.On("DeleteRecords", context.Background(), userdata.UserID("1"), []string{"3"}).
+		Return([]userdata.RecordResult{{ID: "3"}}, nil).Once()
+
+	results, err := storage.CreateRecords(context.Background(), "1", []userdata.Record{
+		{Type: userdata.TypeText, Data: []byte("123")},
+		{Type: userdata.TypeText, Data: []byte("1234")},
+		{Type: userdata.TypeFile, Metadata: "file", Data: []byte("12")},
+		{Type: userdata.TypeFile, Metadata: "broken", Data: []byte("1")},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []userdata.RecordResult{
+		{ID: "1"},
+		{Err: ErrQuotaExceeded},
+		{ID: "2"},
+		{ID: "3", Err: ErrUnknown},
+	}, results)
+
+	// Staged files are discarded, if batch is not saved
+	storage.Quota = 0
+	db.On("GetUserUsage", context.Background(), userdata.UserID("1")).Return(userdata.Usage{}, nil).Once()
+	file.On("StageRecord", context.Background(), []byte("12")).Return(".staged-4", nil).Once()
+	db.On("CreateRecords", context.Background(), userdata.UserID("1"), []userdata.Record{{Type: userdata.TypeFile, Size: 2, Checksum: checksum([]byte("12"))}}).
+		Return(nil, ErrUnknown).Once()
+	file.On("DiscardRecord", context.Background(), ".staged-4").Return(nil).Once()
+
+	_, err = storage.CreateRecords(context.Background(), "1", []userdata.Record{{Type: userdata.TypeFile, Data: []byte("12")}})
+	assert.Equal(t, ErrUnknown, err)
+}
+
+func TestStorage_GetAndDeleteRecords(t *testing.T) {
+	db, file := mocks.NewRecordStorager(t), mocks.NewFileStorager(t)
+	storage := NewStorage(db, file)
+
+	db.On("GetRecords", context.Background(), userdata.UserID("1"), []string{"1", "2", "3", "4"}).Return([]userdata.RecordResult{
+		{ID: "1", Record: userdata.Record{ID: "1", Type: userdata.TypeText, Data: []byte("text")}},
+		{ID: "2", Record: userdata.Record{ID: "2", Type: userdata.TypeFile, Metadata: "file", Checksum: checksum([]byte("file"))}},
+		{ID: "3", Err: ErrNotFound},
+		{ID: "4", Record: userdata.Record{ID: "4", Type: userdata.TypeFile, Metadata: "file", Checksum: checksum([]byte("file"))}},
+	}, nil).Once()
+	file.On("GetRecord", mock.Anything, "2").Return(userdata.Record{ID: "2", Type: userdata.TypeFile, Metadata: "file", Data: []byte("file")}, nil).Once()
+	file.On("GetRecord", mock.Anything, "4").Return(userdata.Record{ID: "4", Type: userdata.TypeFile, Metadata: "file", Data: []byte("fil3")}, nil).Once()
+
+	results, err := storage.GetRecords(context.Background(), "1", []string{"1", "2", "3", "4"})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("text"), results[0].Record.Data)
+	assert.Equal(t, []byte("file"), results[1].Record.Data)
+	assert.Equal(t, ErrNotFound, results[2].Err)
+	assert.Equal(t, ErrDataLoss, results[3].Err)
+	assert.Nil(t, results[3].Record.Data)
+
+	deleted := []userdata.RecordResult{{ID: "1"}, {ID: "2", Err: ErrNotFound}}
+	db.On("DeleteRecordsTx", context.Background(), userdata.UserID("1"), []string{"1", "2"}, mock.Anything).
+		Return(deleteRecordsTx([]string{"1"}, deleted, nil)).Once()
+	file.On("StageDelete", context.Background(), "1").Return("", ErrNotFound).Once()
+
+	results, err = storage.DeleteRecords(context.Background(), "1", []string{"1", "2"})
+	assert.NoError(t, err)
+	assert.Equal(t, deleted, results)
+
+	// Staged files are restored if commit fails
+	db.On("DeleteRecordsTx", context.Background(), userdata.UserID("1"), []string{"3", "4"}, mock.Anything).
+		Return(deleteRecordsTx([]string{"3", "4"}, nil, nil)).Once()
+	file.On("StageDelete", context.Background(), "3").Return(".staged-deleted-3", nil).Once()
+	file.On("StageDelete", context.Background(), "4").Return("", ErrUnknown).Once()
+	file.On("CommitRecord", context.Background(), ".staged-deleted-3", "3").Return(nil).Once()
+
+	_, err = storage.DeleteRecords(context.Background(), "1", []string{"3", "4"})
+	assert.Equal(t, ErrUnknown, err)
+
+	db.On("DeleteRecordsTx", context.Background(), userdata.UserID("1"), []string{"3"}, mock.Anything).
+		Return(deleteRecordsTx([]string{"3"}, nil, ErrUnknown)).Once()
+	file.On("StageDelete", context.Background(), "3").Return(".staged-deleted-3", nil).Once()
+	file.On("CommitRecord", context.Background(), ".staged-deleted-3", "3").Return(nil).Once()
+
+	_, err = storage.DeleteRecords(context.Background(), "1", []string{"3"})
+	assert.Equal(t, ErrUnknown, err)
+
+	// Files are named by lowercase IDs saved in DB
+	db.On("GetRecords", context.Background(), userdata.UserID("1"), []string{"ABC"}).Return([]userdata.RecordResult{
+		{ID: "ABC", Record: userdata.Record{ID: "abc", Type: userdata.TypeFile, Metadata: "file", Checksum: checksum([]byte("file"))}},
+	}, nil).Once()
+	file.On("GetRecord", mock.Anything, "abc").Return(userdata.Record{ID: "abc", Type: userdata.TypeFile, Metadata: "file", Data: []byte("file")}, nil).Once()
+
+	results, err = storage.GetRecords(context.Background(), "1", []string{"ABC"})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("file"), results[0].Record.Data)
+
+	db.On("DeleteRecordsTx", context.Background(), userdata.UserID("1"), []string{"ABC"}, mock.Anything).
+		Return(deleteRecordsTx([]string{"abc"}, []userdata.RecordResult{{ID: "ABC"}}, nil)).Once()
+	file.On("StageDelete", context.Background(), "abc").Return(".staged-deleted-abc", nil).Once()
+	file.On("DiscardRecord", context.Background(), ".staged-deleted-abc").Return(nil).Once()
+
+	results, err = storage.DeleteRecords(context.Background(), "1", []string{"ABC"})
+	assert.NoError(t, err)
+	assert.Equal(t, []userdata.RecordResult{{ID: "ABC"}}, results)
+}
+
+func TestStorage_ReplaceRecords(t *testing.T) {
+	db, file := mocks.NewRecordStorager(t), mocks.NewFileStorager(t)
+	storage := NewStorage(db, file)
+	storage.Quota = 10
+
+	records := []userdata.Record{
+		{ID: "1", Type: userdata.TypeText, KeyHint: "new", Data: []byte("123"), Version: 1},
+		{ID: "2", Type: userdata.TypeFile, KeyHint: "new", Data: []byte("12"), Version: 2},
+	}
+	replaced := []userdata.Record{
+		{ID: "1", Type: userdata.TypeText, KeyHint: "new", Data: []byte("123"), Size: 3, Version: 1},
+		{ID: "2", Type: userdata.TypeFile, KeyHint: "new", Size: 2, Checksum: checksum([]byte("12")), Version: 2},
+	}
+	results := []userdata.RecordResult{{ID: "1", Record: userdata.Record{ID: "1", Version: 2}}, {ID: "2", Record: userdata.Record{ID: "2", Version: 3}}}
+
+	tc := []struct {
+		name  string
+		mock  func()
+		valid func()
+	}{
+		{
+			"Replace records, files are swapped and old ones are discarded",
+			func() {
+				file.On("StageRecord", context.Background(), []byte("12")).Return(".staged-2", nil).Once()
+				db.On("ReplaceRecordsTx", context.Background(), userdata.UserID("1"), replaced, mock.Anything).Return(replaceTx(0, results, nil)).Once()
+				file.On("StageDelete", context.Background(), "2").Return(".staged-deleted-2", nil).Once()
+				file.On("CommitRecord", context.Background(), ".staged-2", "2").Return(nil).Once()
+				file.On("DiscardRecord", context.Background(), ".staged-deleted-2").Return(nil).Once()
+			},
+			func() {
+				got, err := storage.ReplaceRecords(context.Background(), "1", records)
+				assert.NoError(t, err)
+				assert.Equal(t, results, got)
+			},
+		},
+		{
+			"Growth over quota, staged file is discarded",
+			func() {
+				file.On("StageRecord", context.Background(), []byte("12")).Return(".staged-2", nil).Once()
+				db.On("ReplaceRecordsTx", context.Background(), userdata.UserID("1"), replaced, mock.Anything).Return(replaceTx(5, nil, nil)).Once()
+				db.On("GetUserUsage", context.Background(), userdata.UserID("1")).Return(userdata.Usage{Bytes: 6}, nil).Once()
+				file.On("DiscardRecord", context.Background(), ".staged-2").Return(nil).Once()
+			},
+			func() {
+				_, err := storage.ReplaceRecords(context.Background(), "1", records)
+				assert.Equal(t, ErrQuotaExceeded, err)
+			},
+		},
+		{
+			"Commit of transaction fails, old file is restored",
+			func() {
+				file.On("StageRecord", context.Background(), []byte("12")).Return(".staged-2", nil).Once()
+				db.On("ReplaceRecordsTx", context.Background(), userdata.UserID("1"), replaced, mock.Anything).Return(replaceTx(0, nil, ErrUnknown)).Once()
+				file.On("StageDelete", context.Background(), "2").Return(".staged-deleted-2", nil).Once()
+				file.On("CommitRecord", context.Background(), ".staged-2", "2").Return(nil).Once()
+				file.On("CommitRecord", context.Background(), ".staged-deleted-2", "2").Return(nil).Once()
+			},
+			func() {
+				_, err := storage.ReplaceRecords(context.Background(), "1", records)
+				assert.Equal(t, ErrUnknown, err)
+			},
+		},
+		{
+			"Files are named by lowercase IDs saved in DB",
+			func() {
+				file.On("StageRecord", context.Background(), []byte("12")).Return(".staged-abc", nil).Once()
+				db.On("ReplaceRecordsTx", context.Background(), userdata.UserID("1"), mock.Anything, mock.Anything).Return(replaceTx(0, nil, nil)).Once()
+				file.On("StageDelete", context.Background(), "abc").Return(".staged-deleted-abc", nil).Once()
+				file.On("CommitRecord", context.Background(), ".staged-abc", "abc").Return(nil).Once()
+				file.On("DiscardRecord", context.Background(), ".staged-deleted-abc").Return(nil).Once()
+			},
+			func() {
+				upper := []userdata.Record{{ID: "ABC", Type: userdata.TypeFile, KeyHint: "new", Data: []byte("12"), Version: 2}}
+				_, err := storage.ReplaceRecords(context.Background(), "1", upper)
+				assert.NoError(t, err)
+			},
+		},
+		{
+			"Version conflict, staged file is discarded",
+			func() {
+				file.On("StageRecord", context.Background(), []byte("12")).Return(".staged-2", nil).Once()
+				db.On("ReplaceRecordsTx", context.Background(), userdata.UserID("1"), replaced, mock.Anything).Return(nil, ErrVersionConflict).Once()
+				file.On("DiscardRecord", context.Background(), ".staged-2").Return(nil).Once()
 			},
 			func() {
-				_ = storage.DeleteRecord(context.Background(), "")
+				_, err := storage.ReplaceRecords(context.Background(), "1", records)
+				assert.Equal(t, ErrVersionConflict, err)
 			},
 		},
 	}
@@ -255,5 +745,7 @@ func TestStorage_DeleteRecord(t *testing.T) {
 		t.Log(test.name)
 		test.mock()
 		test.valid()
+		db.AssertExpectations(t)
+		file.AssertExpectations(t)
 	}
 }
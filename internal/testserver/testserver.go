@@ -0,0 +1,121 @@
+// Package testserver runs gophKeeper server in process on bufconn listener with memory storage,
+// so end-to-end tests of client handlers, gRPC and storage don't need network, certificates and DB.
+package testserver
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/impr0ver/gophKeeper/internal/crypt"
+	"github.com/impr0ver/gophKeeper/internal/handlers"
+	pb "github.com/impr0ver/gophKeeper/internal/rpc"
+	"github.com/impr0ver/gophKeeper/internal/storage"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const (
+	bufferSize        = 1024 * 1024
+	jwtSecret         = "testServerSecret"
+	jwtExpiration     = time.Hour
+	idempotencyWindow = time.Hour
+)
+
+// passwords are cheap hash parameters, tests don't need protection of passwords.
+var passwords = crypt.PasswordParams{Memory: 64, Iterations: 1, Parallelism: 1}
+
+// Config is settings of test server.
+type Config struct {
+	// AdminToken gives access to admin service, empty token allows only admin-role users.
+	AdminToken string
+	// UserQuota is default user storage quota in bytes, zero is unlimited.
+	UserQuota int64
+	// LegacyLogin allows login with password sent to server.
+	LegacyLogin bool
+}
+
+// Server is in-process server with memory storage.
+type Server struct {
+	DB       storage.DataBaseStorager
+	Files    storage.FileStorager
+	Conn     *handlers.ServerConn
+	listener *bufconn.Listener
+	cancel   context.CancelFunc
+}
+
+// Start starts server with empty memory storage.
+func Start(config Config) *Server {
+	db := storage.NewMemoryDBStorage()
+	files := storage.NewMemoryFileStorage()
+
+	stor := storage.NewStorage(db, files)
+	stor.Quota = config.UserQuota
+
+	authenticator := handlers.NewAuthenticatorJWT([]byte(jwtSecret), jwtExpiration, db)
+	conn := handlers.NewServerConn(handlers.NewServerHandlers(stor, authenticator, passwords, config.LegacyLogin), authenticator, "", "", false)
+	conn.Admin = handlers.NewAdminConn(handlers.NewAdminHandlers(db, storage.NewScrubber(db, files)), authenticator, config.AdminToken)
+	conn.Idempotency = handlers.NewIdempotency(db, idempotencyWindow)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	listener := bufconn.Listen(bufferSize)
+	conn.Serve(ctx, listener, insecure.NewCredentials())
+
+	return &Server{
+		DB:       db,
+		Files:    files,
+		Conn:     conn,
+		listener: listener,
+		cancel:   cancel,
+	}
+}
+
+// Dial returns gRPC connection to server.
+func (s *Server) Dial() (*grpc.ClientConn, error) {
+	return grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return s.listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+}
+
+// ClientConnection returns client connection to server.
+func (s *Server) ClientConnection() (handlers.ClientConnection, error) {
+	conn, err := s.Dial()
+	if err != nil {
+		return nil, err
+	}
+
+	return &handlers.ClientConnGPRC{
+		GokeeperClient: pb.NewGokeeperClient(conn),
+		Passwords:      passwords,
+	}, nil
+}
+
+// Client returns client handlers connected to server.
+func (s *Server) Client() (handlers.ClientHandlers, error) {
+	conn, err := s.ClientConnection()
+	if err != nil {
+		return nil, err
+	}
+
+	return handlers.NewClientHandlers(conn), nil
+}
+
+// AdminConnection returns admin client connection to server with admin token.
+func (s *Server) AdminConnection(adminToken string) (handlers.AdminConnection, error) {
+	conn, err := s.Dial()
+	if err != nil {
+		return nil, err
+	}
+
+	return handlers.NewAdminConnectionFromConn(conn, adminToken), nil
+}
+
+// Stop stops server, background routines are cancelled before it.
+func (s *Server) Stop() {
+	s.cancel()
+	s.Conn.Stop()
+}
@@ -0,0 +1,328 @@
+package testserver
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/impr0ver/gophKeeper/internal/crypt"
+	"github.com/impr0ver/gophKeeper/internal/handlers"
+	"github.com/impr0ver/gophKeeper/internal/storage"
+	"github.com/impr0ver/gophKeeper/internal/userdata"
+	"github.com/impr0ver/gophKeeper/internal/vault"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestServer_Client(t *testing.T) {
+	server := Start(Config{})
+	defer server.Stop()
+
+	client, err := server.Client()
+	assert.NoError(t, err)
+
+	credentials := userdata.UserCredentials{Login: "user", Password: "password", AESKey: "aesKey"}
+	assert.NoError(t, client.Register(credentials))
+	assert.ErrorIs(t, client.Register(credentials), storage.ErrLoginExists)
+
+	// Records are encrypted by client and saved by server
+	meta, err := client.CreateRecord(userdata.Record{Type: userdata.TypeText, Metadata: "note", Data: []byte("secret text")})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), meta.Version)
+
+	record, err := client.GetRecord(meta.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("secret text"), record.Data)
+	assert.Equal(t, meta.CreatedAt.UTC(), record.CreatedAt.UTC())
+
+	saved, err := server.DB.GetRecord(context.Background(), userID(t, server, "user"), meta.ID)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(saved.Data), "secret text")
+	_, _, ok := userdata.ParseKeyHint(saved.KeyHint)
+	assert.True(t, ok)
+	assert.NotContains(t, saved.KeyHint, "aes")
+
+	// Files are saved to file storage and written to disk by client
+	path := filepath.Join(t.TempDir(), "file.txt")
+	fileMeta, err := client.CreateRecord(userdata.Record{Type: userdata.TypeFile, Metadata: path, Data: []byte("file data")})
+	assert.NoError(t, err)
+
+	files, err := server.Files.ListFiles(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, files, 1)
+
+	_, err = client.GetRecord(fileMeta.ID)
+	assert.NoError(t, err)
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("file data"), data)
+
+	// Batches
+	results, err := client.CreateRecords([]userdata.Record{
+		{Type: userdata.TypeText, Metadata: "first", Data: []byte("1")},
+		{Type: userdata.TypeText, Metadata: "second", Data: []byte("2")},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, results, 2)
+
+	info, err := client.GetRecordsInfo()
+	assert.NoError(t, err)
+	assert.Len(t, info, 4)
+
+	results, err = client.GetRecords([]string{results[1].ID, "missing", results[0].ID})
+	assert.NoError(t, err)
+	if assert.Len(t, results, 3) {
+		assert.Equal(t, []byte("2"), results[0].Record.Data)
+		assert.ErrorIs(t, results[1].Err, storage.ErrNotFound)
+		assert.Equal(t, []byte("1"), results[2].Record.Data)
+	}
+
+	results, err = client.DeleteRecords([]string{results[0].ID, results[2].ID})
+	assert.NoError(t, err)
+	assert.NoError(t, results[0].Err)
+	assert.NoError(t, results[1].Err)
+
+	assert.NoError(t, client.DeleteRecord(fileMeta.ID))
+	assert.ErrorIs(t, client.DeleteRecord(fileMeta.ID), storage.ErrNotFound)
+
+	files, err = server.Files.ListFiles(context.Background())
+	assert.NoError(t, err)
+	assert.Empty(t, files)
+
+	// Another client
+	other, err := server.Client()
+	assert.NoError(t, err)
+	assert.ErrorIs(t, other.Login(userdata.UserCredentials{Login: "user", Password: "wrong", AESKey: "aesKey"}), storage.ErrWrongCredentials)
+	assert.NoError(t, other.Login(credentials))
+
+	info, err = other.GetRecordsInfo()
+	assert.NoError(t, err)
+	assert.Len(t, info, 1)
+}
+
+func TestServer_RotateKey(t *testing.T) {
+	server := Start(Config{})
+	defer server.Stop()
+
+	client, err := server.Client()
+	assert.NoError(t, err)
+
+	credentials := userdata.UserCredentials{Login: "user", Password: "password", AESKey: "oldKey"}
+	assert.NoError(t, client.Register(credentials))
+
+	note, err := client.CreateRecord(userdata.Record{Type: userdata.TypeText, Metadata: "note", Data: []byte("secret text")})
+	assert.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "file.txt")
+	file, err := client.CreateRecord(userdata.Record{Type: userdata.TypeFile, Metadata: path, Data: []byte("file data")})
+	assert.NoError(t, err)
+	fileCtx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("recordMetadata", path))
+	stored, err := server.Files.GetRecord(fileCtx, file.ID)
+	assert.NoError(t, err)
+
+	var done int
+	count, err := client.RotateKey("newKey", "new", func(processed int, total int) {
+		assert.Equal(t, 2, total)
+		done = processed
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.Equal(t, 2, done)
+
+	// Records keep IDs, only data keys are rewrapped and file isn't changed
+	info, err := client.GetRecordsInfo()
+	assert.NoError(t, err)
+	for _, record := range info {
+		assert.Contains(t, []string{note.ID, file.ID}, record.ID)
+		assert.Equal(t, int64(2), record.Version)
+		assert.NotEmpty(t, record.DataKey)
+	}
+
+	files, err := server.Files.ListFiles(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, files, 1)
+
+	rotated, err := server.Files.GetRecord(fileCtx, file.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, stored.Data, rotated.Data)
+
+	// Rotation is done, nothing is left for second run
+	count, err = client.RotateKey("newKey", "new", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, count)
+
+	// Records are read with new key only
+	credentials.AESKey = "newKey"
+	other, err := server.Client()
+	assert.NoError(t, err)
+	assert.NoError(t, other.Login(credentials))
+
+	record, err := other.GetRecord(note.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("secret text"), record.Data)
+
+	_, err = other.GetRecord(file.ID)
+	assert.NoError(t, err)
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("file data"), data)
+}
+
+func TestServer_Vault(t *testing.T) {
+	server := Start(Config{})
+	defer server.Stop()
+
+	client, err := server.Client()
+	assert.NoError(t, err)
+	assert.NoError(t, client.Register(userdata.UserCredentials{Login: "user", Password: "password", AESKey: "aesKey"}))
+
+	note, err := client.CreateRecord(userdata.Record{Type: userdata.TypeText, Metadata: "note", Data: []byte("secret text")})
+	assert.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "file.txt")
+	file, err := client.CreateRecord(userdata.Record{Type: userdata.TypeFile, Metadata: path, Data: []byte("file data")})
+	assert.NoError(t, err)
+
+	var archive bytes.Buffer
+	count, err := client.ExportVault(&archive, "vaultPassword", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	// Archive is imported to other account of the same server, record IDs are unique on server
+	other, err := server.Client()
+	assert.NoError(t, err)
+	assert.NoError(t, other.Register(userdata.UserCredentials{Login: "other", Password: "password", AESKey: "otherKey"}))
+
+	imported, skipped, err := other.ImportVault(bytes.NewReader(archive.Bytes()), "vaultPassword", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, imported)
+	assert.Equal(t, 0, skipped)
+
+	imported, skipped, err = other.ImportVault(bytes.NewReader(archive.Bytes()), "vaultPassword", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, imported)
+	assert.Equal(t, 2, skipped)
+
+	info, err := other.GetRecordsInfo()
+	assert.NoError(t, err)
+	assert.Len(t, info, 2)
+
+	record, err := other.GetRecord(storage.DeriveRecordID("other", note.ID))
+	assert.NoError(t, err)
+	assert.Equal(t, "note", record.Metadata)
+	assert.Equal(t, []byte("secret text"), record.Data)
+
+	// Archive is restored to the same account of new server with the same IDs
+	restored := Start(Config{})
+	defer restored.Stop()
+
+	client, err = restored.Client()
+	assert.NoError(t, err)
+	assert.NoError(t, client.Register(userdata.UserCredentials{Login: "user", Password: "newPassword", AESKey: "newKey"}))
+
+	imported, skipped, err = client.ImportVault(bytes.NewReader(archive.Bytes()), "vaultPassword", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, imported)
+	assert.Equal(t, 0, skipped)
+
+	record, err = client.GetRecord(note.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("secret text"), record.Data)
+
+	// File data is in archive too
+	_, err = client.GetRecord(file.ID)
+	assert.NoError(t, err)
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("file data"), data)
+
+	_, _, err = client.ImportVault(bytes.NewReader(archive.Bytes()), "wrongPassword", nil)
+	assert.ErrorIs(t, err, vault.ErrPassword)
+}
+
+func TestServer_Admin(t *testing.T) {
+	server := Start(Config{AdminToken: "adminToken", UserQuota: 1024})
+	defer server.Stop()
+
+	client, err := server.Client()
+	assert.NoError(t, err)
+
+	credentials := userdata.UserCredentials{Login: "user", Password: "password", AESKey: "aesKey"}
+	assert.NoError(t, client.Register(credentials))
+
+	admin, err := server.AdminConnection("adminToken")
+	assert.NoError(t, err)
+
+	users, err := admin.ListUsers()
+	assert.NoError(t, err)
+	if assert.Len(t, users, 1) {
+		assert.Equal(t, "user", users[0].Login)
+	}
+
+	// User quota replaces default quota
+	assert.NoError(t, admin.SetUserQuota("user", 10))
+	_, err = client.CreateRecord(userdata.Record{Type: userdata.TypeText, Metadata: "note", Data: []byte("text longer than quota")})
+	assert.ErrorIs(t, err, storage.ErrQuotaExceeded)
+
+	stats, err := admin.GetStats()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), stats.Users)
+	assert.Equal(t, int64(0), stats.Records)
+
+	// Disabled user can't login
+	assert.NoError(t, admin.SetUserDisabled("user", true))
+	assert.ErrorIs(t, client.Login(credentials), storage.ErrUserDisabled)
+
+	wrong, err := server.AdminConnection("wrong")
+	assert.NoError(t, err)
+	_, err = wrong.ListUsers()
+	assert.Error(t, err)
+}
+
+func TestServer_LegacyLogin(t *testing.T) {
+	server := Start(Config{LegacyLogin: true})
+	defer server.Stop()
+
+	// User registered before SRP login has SHA-256 password hash
+	credentials := userdata.UserCredentials{Login: "user", Password: "password", AESKey: "aesKey"}
+	assert.NoError(t, server.DB.CreateUser(userdata.UserCredentials{
+		Login:    "user",
+		Password: crypt.PasswordHash(credentials),
+	}))
+
+	conn, err := server.ClientConnection()
+	assert.NoError(t, err)
+	_, err = conn.Login(credentials)
+	assert.ErrorIs(t, err, storage.ErrWrongCredentials)
+
+	// Password is sent once, server replaces hash by SRP verifier
+	conn.(*handlers.ClientConnGPRC).LegacyLogin = true
+	_, err = conn.Login(credentials)
+	assert.NoError(t, err)
+
+	hash, err := server.DB.GetPasswordHash("user")
+	assert.NoError(t, err)
+	assert.True(t, crypt.IsSRPVerifier(hash))
+
+	conn.(*handlers.ClientConnGPRC).LegacyLogin = false
+	_, err = conn.Login(credentials)
+	assert.NoError(t, err)
+
+	_, err = conn.Login(userdata.UserCredentials{Login: "user", Password: "wrong"})
+	assert.ErrorIs(t, err, storage.ErrWrongCredentials)
+}
+
+// userID returns ID of user by login, passwords are saved by server as hashes.
+func userID(t *testing.T, server *Server, login string) userdata.UserID {
+	users, err := server.DB.ListUsers(context.Background())
+	assert.NoError(t, err)
+
+	for _, user := range users {
+		if user.Login == login {
+			return user.ID
+		}
+	}
+
+	return ""
+}
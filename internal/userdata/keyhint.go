@@ -0,0 +1,45 @@
+package userdata
+
+import (
+	"encoding/hex"
+	"strings"
+	"unicode"
+)
+
+// Key hint of record is "fp:" | fingerprint | ":" | label. Fingerprint is HMAC of encryption key,
+// so key can't be got from it, and label is chosen by user. Hints of older clients are masked AES keys.
+const (
+	keyHintPrefix = "fp:"
+	// FingerprintSize is length of hex fingerprint of encryption key.
+	FingerprintSize = 16
+	// MaxKeyLabel is max length of label of encryption key.
+	MaxKeyLabel = 64
+)
+
+// NewKeyHint returns key hint with fingerprint and label of encryption key.
+func NewKeyHint(fingerprint, label string) string {
+	return keyHintPrefix + fingerprint + ":" + label
+}
+
+// ParseKeyHint returns fingerprint and label of encryption key, ok is false for hints of older clients.
+func ParseKeyHint(hint string) (fingerprint string, label string, ok bool) {
+	fingerprint, label, found := strings.Cut(strings.TrimPrefix(hint, keyHintPrefix), ":")
+	if !strings.HasPrefix(hint, keyHintPrefix) || !found || len(fingerprint) != FingerprintSize || !ValidKeyLabel(label) {
+		return "", "", false
+	}
+
+	if _, err := hex.DecodeString(fingerprint); err != nil || strings.ToLower(fingerprint) != fingerprint {
+		return "", "", false
+	}
+
+	return fingerprint, label, true
+}
+
+// ValidKeyLabel checks label of encryption key: it's short and has no control characters.
+func ValidKeyLabel(label string) bool {
+	if len(label) > MaxKeyLabel {
+		return false
+	}
+
+	return strings.IndexFunc(label, unicode.IsControl) < 0
+}
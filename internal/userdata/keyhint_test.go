@@ -0,0 +1,36 @@
+package userdata
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyHint(t *testing.T) {
+	hint := NewKeyHint("0123456789abcdef", "work: laptop")
+	assert.Equal(t, "fp:0123456789abcdef:work: laptop", hint)
+
+	fingerprint, label, ok := ParseKeyHint(hint)
+	assert.True(t, ok)
+	assert.Equal(t, "0123456789abcdef", fingerprint)
+	assert.Equal(t, "work: laptop", label)
+
+	_, label, ok = ParseKeyHint(NewKeyHint("0123456789abcdef", ""))
+	assert.True(t, ok)
+	assert.Empty(t, label)
+
+	for _, hint := range []string{
+		"",
+		"my***",
+		"fp:0123456789abcdef",
+		"fp:0123456789ABCDEF:label",
+		"fp:0123456789abcdeg:label",
+		"fp:0123456789abcde:label",
+		"fp:0123456789abcdef:" + strings.Repeat("a", MaxKeyLabel+1),
+		"fp:0123456789abcdef:new\nline",
+	} {
+		_, _, ok := ParseKeyHint(hint)
+		assert.False(t, ok, hint)
+	}
+}
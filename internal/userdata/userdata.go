@@ -3,6 +3,7 @@ package userdata
 import (
 	"io"
 	"os"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -34,6 +35,100 @@ type Record struct {
 	KeyHint  string
 	Type     RecordType
 	Data     []byte
+	Size     int64
+	// Checksum is hex SHA-256 of file data, it's empty for other records and files saved before checksums.
+	Checksum string
+	// DataKey is random key of record data wrapped with key of user, it's empty if data is encrypted with key of user.
+	DataKey   []byte
+	Version   int64
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// RecordMeta is ID, version and server timestamps of record.
+type RecordMeta struct {
+	ID        string
+	Version   int64
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Meta returns meta of record.
+func (r Record) Meta() RecordMeta {
+	return RecordMeta{
+		ID:        r.ID,
+		Version:   r.Version,
+		CreatedAt: r.CreatedAt,
+		UpdatedAt: r.UpdatedAt,
+	}
+}
+
+// RecordResult is result of batch operation for one record, operation for other records is done even if Err is set.
+type RecordResult struct {
+	ID     string
+	Record Record
+	Err    error
+}
+
+// Usage is storage usage of user.
+type Usage struct {
+	Records int64
+	Bytes   int64
+	Quota   int64
+}
+
+// UserInfo is user account summary for administration.
+type UserInfo struct {
+	ID       UserID
+	Login    string
+	Admin    bool
+	Disabled bool
+	Usage    Usage
+}
+
+// ServerStats is summary of server state for administration.
+type ServerStats struct {
+	Users         int64
+	DisabledUsers int64
+	Admins        int64
+	Records       int64
+	StoredBytes   int64
+	RecordsByType map[RecordType]int64
+	Uptime        time.Duration
+	Scrub         ScrubStats
+}
+
+// ScrubStats is result of last verification of file checksums.
+type ScrubStats struct {
+	Runs      int64
+	LastRun   time.Time
+	Verified  int64
+	Corrupted int64
+}
+
+// FileRecordInfo is owner of file record and time of its last change, file of recently changed record may be saved right now.
+type FileRecordInfo struct {
+	UserID    UserID
+	UpdatedAt time.Time
+}
+
+// StoredFile is file in file storage of server.
+type StoredFile struct {
+	Name    string
+	Size    int64
+	ModTime time.Time
+	// Staged file is not record file yet or is record file being deleted.
+	Staged bool
+}
+
+// IdempotentRequest is mutating request saved by idempotency key, response is empty until request is done.
+type IdempotentRequest struct {
+	Scope       string
+	Key         string
+	Method      string
+	RequestHash string
+	Response    []byte
+	CreatedAt   time.Time
 }
 
 type RecordType int32
@@ -98,7 +193,7 @@ func (fdata *BinaryFile) Size() (int64, error) {
 	fi, err := os.Stat(fdata.FilePath)
 	if err != nil {
 		log.Infoln(err)
-		
+
 		return 0, err
 	}
 
@@ -0,0 +1,252 @@
+package vault
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/impr0ver/gophKeeper/internal/crypt"
+	"github.com/impr0ver/gophKeeper/internal/userdata"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// Vault archive is backup of records with plain data encrypted with password:
+// magic "GKVAULT" | version | memory uint32 | iterations uint32 | parallelism uint8 | salt | sections | MAC.
+// Each section is length uint32 and nonce with AES-256-GCM sealed text, the first section is manifest
+// and the others are data of records in order of manifest. Header and index of section are authenticated
+// with section, MAC is HMAC-SHA256 of all previous bytes. Keys of encryption and MAC are derived from
+// password with Argon2id.
+const (
+	magic = "GKVAULT"
+	// Version is version of archive format.
+	Version    byte = 1
+	headerSize      = len(magic) + 1 + 4 + 4 + 1 + saltSize
+	saltSize        = 16
+	keySize         = 32
+	macSize         = sha256.Size
+)
+
+// Archive errors.
+var (
+	ErrFormat   = errors.New("file isn't vault archive")
+	ErrVersion  = errors.New("unsupported version of vault archive")
+	ErrPassword = errors.New("wrong password or vault archive is damaged")
+	ErrDamaged  = errors.New("vault archive is damaged")
+)
+
+// Manifest describes archive and its records.
+type Manifest struct {
+	Version   byte      `json:"version"`
+	Login     string    `json:"login"`
+	CreatedAt time.Time `json:"created_at"`
+	Records   []Entry   `json:"records"`
+}
+
+// Entry describes record of archive, its data follows manifest.
+type Entry struct {
+	ID       string              `json:"id"`
+	Type     userdata.RecordType `json:"type"`
+	Metadata string              `json:"metadata"`
+	Size     int64               `json:"size"`
+	// Checksum is hex SHA-256 of plain data of record.
+	Checksum string `json:"checksum"`
+}
+
+// keys derives keys of encryption and MAC from password.
+func keys(password string, salt []byte, params crypt.PasswordParams) (cipher.AEAD, []byte, error) {
+	key := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, 2*keySize)
+
+	block, err := aes.NewCipher(key[:keySize])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return aead, key[keySize:], nil
+}
+
+// sectionData returns associated data of section: header of archive and index of section.
+func sectionData(header []byte, index int) []byte {
+	return binary.BigEndian.AppendUint32(bytes.Clone(header), uint32(index))
+}
+
+// checksum returns hex SHA-256 of data.
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+
+	return hex.EncodeToString(sum[:])
+}
+
+// Write writes archive of records with plain data, which is encrypted with password.
+func Write(w io.Writer, password string, params crypt.PasswordParams, login string, records []userdata.Record) error {
+	salt, err := crypt.GenerateRand(saltSize)
+	if err != nil {
+		return err
+	}
+
+	header := make([]byte, 0, headerSize)
+	header = append(header, magic...)
+	header = append(header, Version)
+	header = binary.BigEndian.AppendUint32(header, params.Memory)
+	header = binary.BigEndian.AppendUint32(header, params.Iterations)
+	header = append(header, params.Parallelism)
+	header = append(header, salt...)
+
+	aead, macKey, err := keys(password, salt, params)
+	if err != nil {
+		return err
+	}
+
+	manifest := Manifest{
+		Version:   Version,
+		Login:     login,
+		CreatedAt: time.Now().UTC(),
+		Records:   make([]Entry, 0, len(records)),
+	}
+	for _, record := range records {
+		manifest.Records = append(manifest.Records, Entry{
+			ID:       record.ID,
+			Type:     record.Type,
+			Metadata: record.Metadata,
+			Size:     int64(len(record.Data)),
+			Checksum: checksum(record.Data),
+		})
+	}
+
+	manifestData, err := json.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+
+	mac := hmac.New(sha256.New, macKey)
+	out := io.MultiWriter(w, mac)
+
+	if _, err := out.Write(header); err != nil {
+		return err
+	}
+
+	sections := make([][]byte, 0, len(records)+1)
+	sections = append(sections, manifestData)
+	for _, record := range records {
+		sections = append(sections, record.Data)
+	}
+
+	for i, section := range sections {
+		nonce := make([]byte, aead.NonceSize())
+		if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+			return err
+		}
+
+		sealed := aead.Seal(nonce, nonce, section, sectionData(header, i))
+		if _, err := out.Write(binary.BigEndian.AppendUint32(nil, uint32(len(sealed)))); err != nil {
+			return err
+		}
+		if _, err := out.Write(sealed); err != nil {
+			return err
+		}
+	}
+
+	_, err = w.Write(mac.Sum(nil))
+
+	return err
+}
+
+// Read reads archive encrypted with password and returns its manifest and records with plain data.
+// ErrPassword is returned if password is wrong or archive is changed.
+func Read(r io.Reader, password string) (Manifest, []userdata.Record, error) {
+	var manifest Manifest
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return manifest, nil, err
+	}
+
+	if len(data) < headerSize+macSize || string(data[:len(magic)]) != magic {
+		return manifest, nil, ErrFormat
+	}
+
+	header := data[:headerSize]
+	if header[len(magic)] != Version {
+		return manifest, nil, ErrVersion
+	}
+
+	params := crypt.PasswordParams{
+		Memory:      binary.BigEndian.Uint32(header[len(magic)+1:]),
+		Iterations:  binary.BigEndian.Uint32(header[len(magic)+5:]),
+		Parallelism: header[len(magic)+9],
+	}
+	// Parameters are bounded as in cipher header, so crafted archive can't hang key derivation before MAC check
+	if !params.Bounded() {
+		return manifest, nil, ErrFormat
+	}
+
+	aead, macKey, err := keys(password, header[len(magic)+10:], params)
+	if err != nil {
+		return manifest, nil, err
+	}
+
+	body, sum := data[:len(data)-macSize], data[len(data)-macSize:]
+	mac := hmac.New(sha256.New, macKey)
+	mac.Write(body)
+	if !hmac.Equal(mac.Sum(nil), sum) {
+		return manifest, nil, ErrPassword
+	}
+
+	// Sections are authenticated by MAC, so errors below mean broken writer, not wrong password
+	sections := make([][]byte, 0)
+	for rest := body[headerSize:]; len(rest) > 0; {
+		if len(rest) < 4 || uint64(len(rest)-4) < uint64(binary.BigEndian.Uint32(rest)) {
+			return manifest, nil, ErrDamaged
+		}
+
+		size := binary.BigEndian.Uint32(rest)
+		sealed := rest[4 : 4+size]
+		rest = rest[4+size:]
+
+		if len(sealed) < aead.NonceSize() {
+			return manifest, nil, ErrDamaged
+		}
+
+		section, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], sectionData(header, len(sections)))
+		if err != nil {
+			return manifest, nil, ErrDamaged
+		}
+
+		sections = append(sections, section)
+	}
+
+	if len(sections) == 0 || json.Unmarshal(sections[0], &manifest) != nil || len(manifest.Records) != len(sections)-1 {
+		return manifest, nil, ErrDamaged
+	}
+
+	records := make([]userdata.Record, 0, len(manifest.Records))
+	for i, entry := range manifest.Records {
+		recordData := sections[i+1]
+		if int64(len(recordData)) != entry.Size || checksum(recordData) != entry.Checksum {
+			return manifest, nil, ErrDamaged
+		}
+
+		records = append(records, userdata.Record{
+			ID:       entry.ID,
+			Type:     entry.Type,
+			Metadata: entry.Metadata,
+			Data:     recordData,
+		})
+	}
+
+	return manifest, records, nil
+}
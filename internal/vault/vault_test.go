@@ -0,0 +1,83 @@
+package vault
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/impr0ver/gophKeeper/internal/crypt"
+	"github.com/impr0ver/gophKeeper/internal/userdata"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVault(t *testing.T) {
+	params := crypt.PasswordParams{Memory: 64, Iterations: 1, Parallelism: 1}
+	records := []userdata.Record{
+		{ID: "1", Type: userdata.TypeText, Metadata: "note", Data: []byte("secret text")},
+		{ID: "2", Type: userdata.TypeFile, Metadata: "file.txt", Data: []byte("file data")},
+		{ID: "3", Type: userdata.TypeText, Metadata: "empty"},
+	}
+
+	var buf bytes.Buffer
+	assert.NoError(t, Write(&buf, "password", params, "user", records))
+	archive := buf.Bytes()
+	assert.NotContains(t, string(archive), "secret text")
+	assert.NotContains(t, string(archive), "note")
+
+	manifest, got, err := Read(bytes.NewReader(archive), "password")
+	assert.NoError(t, err)
+	assert.Equal(t, Version, manifest.Version)
+	assert.Equal(t, "user", manifest.Login)
+	if assert.Len(t, manifest.Records, 3) {
+		assert.Equal(t, int64(9), manifest.Records[1].Size)
+	}
+	if assert.Len(t, got, 3) {
+		for i, record := range records {
+			assert.Equal(t, record.ID, got[i].ID)
+			assert.Equal(t, record.Type, got[i].Type)
+			assert.Equal(t, record.Metadata, got[i].Metadata)
+			assert.Equal(t, string(record.Data), string(got[i].Data))
+		}
+	}
+
+	tc := []struct {
+		name     string
+		archive  func() []byte
+		password string
+		err      error
+	}{
+		{"Wrong password", func() []byte { return archive }, "other", ErrPassword},
+		{"Changed data", func() []byte {
+			changed := bytes.Clone(archive)
+			changed[headerSize+10] ^= 1
+
+			return changed
+		}, "password", ErrPassword},
+		{"Truncated archive", func() []byte { return archive[:len(archive)-10] }, "password", ErrPassword},
+		{"Not archive", func() []byte { return []byte("hello!") }, "password", ErrFormat},
+		{"Other version", func() []byte {
+			changed := bytes.Clone(archive)
+			changed[len(magic)] = Version + 1
+
+			return changed
+		}, "password", ErrVersion},
+		{"Too much memory", func() []byte {
+			changed := bytes.Clone(archive)
+			changed[len(magic)+1] = 0xff
+
+			return changed
+		}, "password", ErrFormat},
+		{"Too many iterations", func() []byte {
+			changed := bytes.Clone(archive)
+			changed[len(magic)+5] = 0xff
+
+			return changed
+		}, "password", ErrFormat},
+	}
+
+	for _, test := range tc {
+		t.Log(test.name)
+		_, _, err := Read(bytes.NewReader(test.archive()), test.password)
+		assert.Equal(t, test.err, err)
+	}
+}
@@ -0,0 +1,8 @@
+ALTER TABLE data
+    DROP COLUMN IF EXISTS data_size;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS quota_bytes,
+    DROP COLUMN IF EXISTS tokens_valid_after,
+    DROP COLUMN IF EXISTS disabled,
+    DROP COLUMN IF EXISTS is_admin;
@@ -0,0 +1,10 @@
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS is_admin BOOLEAN NOT NULL DEFAULT false,
+    ADD COLUMN IF NOT EXISTS disabled BOOLEAN NOT NULL DEFAULT false,
+    ADD COLUMN IF NOT EXISTS tokens_valid_after TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS quota_bytes BIGINT;
+
+ALTER TABLE data
+    ADD COLUMN IF NOT EXISTS data_size BIGINT NOT NULL DEFAULT 0;
+
+UPDATE data SET data_size = length(crypted_data) / 2;
@@ -0,0 +1,2 @@
+ALTER TABLE data
+    DROP COLUMN IF EXISTS checksum;
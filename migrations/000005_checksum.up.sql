@@ -0,0 +1,2 @@
+ALTER TABLE data
+    ADD COLUMN IF NOT EXISTS checksum TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS users_login_idx;
+DROP INDEX IF EXISTS data_user_id_idx;
+
+ALTER TABLE data
+    DROP CONSTRAINT IF EXISTS data_user_id_fkey,
+    ALTER COLUMN user_id DROP NOT NULL,
+    ALTER COLUMN user_id TYPE VARCHAR(256) USING user_id::text,
+    ALTER COLUMN crypted_data TYPE TEXT USING encode(crypted_data, 'hex');
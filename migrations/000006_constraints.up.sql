@@ -0,0 +1,13 @@
+-- Records of missing users can't be read by anyone and would break foreign key
+DELETE FROM data WHERE user_id IS NULL OR user_id NOT IN (SELECT user_id::text FROM users);
+
+ALTER TABLE data
+    ALTER COLUMN crypted_data TYPE BYTEA USING decode(crypted_data, 'hex'),
+    ALTER COLUMN user_id TYPE UUID USING user_id::uuid,
+    ALTER COLUMN user_id SET NOT NULL,
+    ADD CONSTRAINT data_user_id_fkey FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS data_user_id_idx ON data (user_id);
+
+-- Fails if logins are already duplicated, they must be renamed by administrator before migration
+CREATE UNIQUE INDEX IF NOT EXISTS users_login_idx ON users (login);
@@ -0,0 +1,6 @@
+-- Salt of derivation of user encryption key, it's generated by server for new users
+ALTER TABLE users ADD COLUMN IF NOT EXISTS key_salt BYTEA;
+
+UPDATE users SET key_salt = decode(replace(gen_random_uuid()::text, '-', ''), 'hex') WHERE key_salt IS NULL;
+
+ALTER TABLE users ALTER COLUMN key_salt SET NOT NULL;
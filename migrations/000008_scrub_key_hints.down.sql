@@ -0,0 +1,2 @@
+-- Scrubbed key hints can't be restored
+SELECT 1;
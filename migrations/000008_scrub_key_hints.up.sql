@@ -0,0 +1,2 @@
+-- Older clients saved masked AES key as key hint, only hints with key fingerprint are kept
+UPDATE data SET keyhint = '' WHERE keyhint IS NOT NULL AND keyhint NOT LIKE 'fp:%';
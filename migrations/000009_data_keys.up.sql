@@ -0,0 +1,2 @@
+-- Data key of record wrapped with key of user, records encrypted with key of user directly have no data key
+ALTER TABLE data ADD COLUMN IF NOT EXISTS data_key BYTEA;
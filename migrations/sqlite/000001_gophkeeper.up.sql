@@ -0,0 +1,37 @@
+CREATE TABLE users (
+    user_id TEXT PRIMARY KEY COLLATE NOCASE DEFAULT (lower(hex(randomblob(4)) || '-' || hex(randomblob(2)) || '-4' || substr(hex(randomblob(2)), 2) || '-' || substr('89ab', 1 + abs(random()) % 4, 1) || substr(hex(randomblob(2)), 2) || '-' || hex(randomblob(6)))),
+    login TEXT,
+    password TEXT,
+    is_admin BOOLEAN NOT NULL DEFAULT false,
+    disabled BOOLEAN NOT NULL DEFAULT false,
+    tokens_valid_after TIMESTAMP,
+    quota_bytes INTEGER
+);
+
+CREATE TABLE data (
+    record_id TEXT PRIMARY KEY COLLATE NOCASE DEFAULT (lower(hex(randomblob(4)) || '-' || hex(randomblob(2)) || '-4' || substr(hex(randomblob(2)), 2) || '-' || substr('89ab', 1 + abs(random()) % 4, 1) || substr(hex(randomblob(2)), 2) || '-' || hex(randomblob(6)))),
+    user_id TEXT,
+    record_type INTEGER,
+    keyhint TEXT,
+    metadata TEXT,
+    crypted_data TEXT,
+    data_size INTEGER NOT NULL DEFAULT 0,
+    version INTEGER NOT NULL DEFAULT 1,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    checksum TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX data_user_id_idx ON data (user_id);
+
+CREATE TABLE idempotency_keys (
+    scope TEXT NOT NULL,
+    key TEXT NOT NULL,
+    method TEXT NOT NULL,
+    request_hash TEXT NOT NULL,
+    response BLOB,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (scope, key)
+);
+
+CREATE INDEX idempotency_keys_created_at_idx ON idempotency_keys (created_at);
@@ -0,0 +1,23 @@
+DROP INDEX IF EXISTS users_login_idx;
+
+CREATE TABLE data_old (
+    record_id TEXT PRIMARY KEY COLLATE NOCASE DEFAULT (lower(hex(randomblob(4)) || '-' || hex(randomblob(2)) || '-4' || substr(hex(randomblob(2)), 2) || '-' || substr('89ab', 1 + abs(random()) % 4, 1) || substr(hex(randomblob(2)), 2) || '-' || hex(randomblob(6)))),
+    user_id TEXT,
+    record_type INTEGER,
+    keyhint TEXT,
+    metadata TEXT,
+    crypted_data TEXT,
+    data_size INTEGER NOT NULL DEFAULT 0,
+    version INTEGER NOT NULL DEFAULT 1,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    checksum TEXT NOT NULL DEFAULT ''
+);
+
+INSERT INTO data_old (record_id, user_id, record_type, keyhint, metadata, crypted_data, data_size, version, created_at, updated_at, checksum)
+    SELECT record_id, user_id, record_type, keyhint, metadata, lower(hex(crypted_data)), data_size, version, created_at, updated_at, checksum FROM data;
+
+DROP TABLE data;
+ALTER TABLE data_old RENAME TO data;
+
+CREATE INDEX data_user_id_idx ON data (user_id);
@@ -0,0 +1,25 @@
+-- SQLite can't change type of column, so table is rebuilt with hex data decoded
+DELETE FROM data WHERE user_id IS NULL OR user_id NOT IN (SELECT user_id FROM users);
+
+CREATE TABLE data_new (
+    record_id TEXT PRIMARY KEY COLLATE NOCASE DEFAULT (lower(hex(randomblob(4)) || '-' || hex(randomblob(2)) || '-4' || substr(hex(randomblob(2)), 2) || '-' || substr('89ab', 1 + abs(random()) % 4, 1) || substr(hex(randomblob(2)), 2) || '-' || hex(randomblob(6)))),
+    user_id TEXT NOT NULL COLLATE NOCASE REFERENCES users (user_id) ON DELETE CASCADE,
+    record_type INTEGER,
+    keyhint TEXT,
+    metadata TEXT,
+    crypted_data BLOB,
+    data_size INTEGER NOT NULL DEFAULT 0,
+    version INTEGER NOT NULL DEFAULT 1,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    checksum TEXT NOT NULL DEFAULT ''
+);
+
+INSERT INTO data_new (record_id, user_id, record_type, keyhint, metadata, crypted_data, data_size, version, created_at, updated_at, checksum)
+    SELECT record_id, user_id, record_type, keyhint, metadata, unhex(crypted_data), data_size, version, created_at, updated_at, checksum FROM data;
+
+DROP TABLE data;
+ALTER TABLE data_new RENAME TO data;
+
+CREATE INDEX data_user_id_idx ON data (user_id);
+CREATE UNIQUE INDEX users_login_idx ON users (login);
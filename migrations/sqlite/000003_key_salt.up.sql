@@ -0,0 +1,4 @@
+-- Salt of derivation of user encryption key, it's generated by server for new users
+ALTER TABLE users ADD COLUMN key_salt BLOB;
+
+UPDATE users SET key_salt = randomblob(16) WHERE key_salt IS NULL;